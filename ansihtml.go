@@ -0,0 +1,99 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// ANSIToHTML converts text colorized with ANSI escape sequences, such as the
+// captured output of Format, to HTML written to `dst`, so that pipelines
+// capturing terminal output can publish it to web pages as is. Unlike with
+// FormatHTML, the kinds of the tokens are unknown, so each run of text is
+// wrapped in a <span> with a style attribute giving its colors and
+// attributes, as set by the SGR sequences before it.
+//
+// As with FormatHTML, the output is wrapped in a <pre> element, with the
+// class "json" unless HTMLOptions.ClassPrefix says otherwise, or not at all
+// with HTMLOptions.NoPre; its other options don't apply. Escape sequences
+// other than SGR ones, such as cursor movements, are dropped.
+func ANSIToHTML(dst io.Writer, src []byte, opts HTMLOptions) error {
+	prefix := opts.ClassPrefix
+	if prefix == "" {
+		prefix = "json-"
+	}
+
+	sb := &strings.Builder{}
+	if !opts.NoPre {
+		fmt.Fprintf(sb, `<pre class="%s">`, html.EscapeString(strings.TrimSuffix(prefix, "-")))
+	}
+	var style cssStyle
+	open := "" // The style of the open <span>, if any.
+	for len(src) > 0 {
+		i := bytes.IndexByte(src, '\x1b')
+		if i < 0 {
+			i = len(src)
+		}
+		if i > 0 {
+			if decls := strings.Join(style.declarations(), ";"); decls != open {
+				if open != "" {
+					sb.WriteString("</span>")
+				}
+				if decls != "" {
+					fmt.Fprintf(sb, `<span style="%s">`, decls)
+				}
+				open = decls
+			}
+			sb.WriteString(html.EscapeString(string(src[:i])))
+			src = src[i:]
+			continue
+		}
+
+		params, final, n := escapeSequence(src)
+		if final == 'm' {
+			style.apply(params)
+		}
+		src = src[n:]
+	}
+	if open != "" {
+		sb.WriteString("</span>")
+	}
+	if !opts.NoPre {
+		sb.WriteString("</pre>")
+	}
+	_, err := io.WriteString(dst, sb.String())
+	return err
+}
+
+// escapeSequence returns the parameters and final byte of the escape sequence
+// at the start of `b`, and its length. The final byte is 0 if it isn't a CSI
+// sequence, such as an OSC sequence, which are terminated by BEL or ST.
+func escapeSequence(b []byte) (params string, final byte, n int) {
+	if len(b) < 2 {
+		return "", 0, len(b)
+	}
+	switch b[1] {
+	case '[':
+		// A CSI sequence: parameter and intermediate bytes, then a final byte.
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return string(b[2:i]), b[i], i + 1
+			}
+		}
+		return "", 0, len(b)
+	case ']', 'P', '_', '^':
+		// A string sequence, such as OSC, terminated by BEL or ESC \.
+		for i := 2; i < len(b); i++ {
+			if b[i] == '\a' {
+				return "", 0, i + 1
+			}
+			if b[i] == '\x1b' && i+1 < len(b) && b[i+1] == '\\' {
+				return "", 0, i + 2
+			}
+		}
+		return "", 0, len(b)
+	}
+	return "", 0, 2
+}
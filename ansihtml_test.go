@@ -0,0 +1,79 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestANSIToHTML(t *testing.T) {
+	tests := []struct {
+		name, src string
+		opts      HTMLOptions
+		want      string
+	}{
+		{"attributes", "a\x1b[1mb\x1b[22;31mc\x1b[39m<d>\x1b[0m", HTMLOptions{},
+			`<pre class="json">a<span style="font-weight:bold">b</span><span style="color:#cd0000">c</span>&lt;d&gt;</pre>`},
+		{"class prefix", "\x1b[4mx", HTMLOptions{ClassPrefix: "out-"},
+			`<pre class="out"><span style="text-decoration:underline">x</span></pre>`},
+		{"no pre", "\x1b[38;5;21mx\x1b[m", HTMLOptions{NoPre: true},
+			`<span style="color:#0000ff">x</span>`},
+		// Consecutive runs of text in the same style share a <span>.
+		{"same style", "\x1b[32ma\x1b[0m\x1b[32mb\x1b[0m", HTMLOptions{NoPre: true},
+			`<span style="color:#00cd00">ab</span>`},
+		// Other escape sequences, such as hyperlinks and erasing the line, are dropped.
+		{"other sequences", "\x1b]8;;http://x\x07link\x1b]8;;\x1b\\ \x1b[2Kx", HTMLOptions{NoPre: true}, `link x`},
+		{"only escapes", "\x1b[31m\x1b[0m", HTMLOptions{NoPre: true}, ``},
+		{"truncated", "x\x1b[31", HTMLOptions{NoPre: true}, `x`},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := ANSIToHTML(&sb, []byte(tt.src), tt.opts); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestANSIToHTMLMatchesFormatHTML(t *testing.T) {
+	withColor(t, true)
+	// Converting the output of Format gives what FormatHTML writes with inline styles.
+	f := styledFormatter()
+	var colorized, converted, direct strings.Builder
+	if err := f.Format(&colorized, []byte(htmlInput)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ANSIToHTML(&converted, []byte(colorized.String()), HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.FormatHTML(&direct, []byte(htmlInput), HTMLOptions{InlineStyles: true}); err != nil {
+		t.Fatal(err)
+	}
+	if converted.String() != direct.String() {
+		t.Errorf("got:\n%s\nwant:\n%s", converted.String(), direct.String())
+	}
+}
+
+func TestEscapeSequence(t *testing.T) {
+	tests := []struct {
+		src    string
+		params string
+		final  byte
+		n      int
+	}{
+		{"\x1b[1;31mx", "1;31", 'm', 7},
+		{"\x1b[2Kx", "2", 'K', 4},
+		{"\x1b]0;title\x07x", "", 0, 10},
+		{"\x1b]0;title\x1b\\x", "", 0, 11},
+		{"\x1b[31", "", 0, 4},
+		{"\x1b7x", "", 0, 2},
+		{"\x1b", "", 0, 1},
+	}
+	for _, tt := range tests {
+		params, final, n := escapeSequence([]byte(tt.src))
+		if params != tt.params || final != tt.final || n != tt.n {
+			t.Errorf("%q: got %q, %q, %d, want %q, %q, %d", tt.src, params, final, n, tt.params, tt.final, tt.n)
+		}
+	}
+}
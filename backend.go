@@ -0,0 +1,71 @@
+package jsoncolor
+
+import "strings"
+
+// RendererBackend renders the pieces of formatted output into the bytes
+// written. See Formatter.Backend.
+type RendererBackend interface {
+	// Render appends the rendering of `text`, of the kind `kind`, to `dst`
+	// and returns the extended slice.
+	Render(dst []byte, kind TokenKind, text string) []byte
+}
+
+// RendererFunc adapts a function to a RendererBackend.
+type RendererFunc func(dst []byte, kind TokenKind, text string) []byte
+
+// Render calls fn(dst, kind, text).
+func (fn RendererFunc) Render(dst []byte, kind TokenKind, text string) []byte {
+	return fn(dst, kind, text)
+}
+
+// ANSIRenderer returns a RendererBackend applying the colors of the Formatter
+// as escape sequences, as the Formatter does without a Backend. It's meant to
+// be wrapped by backends altering the output of only some kinds of tokens.
+// Since the kinds of text don't tell them apart, strings are rendered in the
+// colors of strings even where RawColor would apply, and the Prefix in the
+// color of whitespace.
+func (f *Formatter) ANSIRenderer() RendererBackend {
+	return ansiRenderer{f}
+}
+
+// ansiRenderer is the RendererBackend returned by Formatter.ANSIRenderer.
+type ansiRenderer struct {
+	f *Formatter
+}
+
+func (r ansiRenderer) Render(dst []byte, kind TokenKind, text string) []byte {
+	f := r.f
+	switch {
+	case kind == TokenKey || kind == TokenString:
+		quote, color := f.fieldQuoteColor(), f.fieldColor()
+		if kind == TokenString {
+			quote, color = f.stringQuoteColor(), f.stringColor()
+		}
+		if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+			q := text[:1]
+			dst = append(dst, quote.SprintfFunc()(q)...)
+			dst = append(dst, color.SprintfFunc()("%s", text[1:len(text)-1])...)
+			return append(dst, quote.SprintfFunc()(q)...)
+		}
+		// Unquoted keys of JSON5, or placeholders of Skeleton output.
+		if text == skeletonPlaceholder {
+			return append(dst, f.skeletonColor().SprintfFunc()("%s", text)...)
+		}
+		return append(dst, color.SprintfFunc()("%s", text)...)
+	case text == skeletonPlaceholder:
+		return append(dst, f.skeletonColor().SprintfFunc()("%s", text)...)
+	case kind == TokenWhitespace && strings.Contains(text, indentGuide):
+		sprintfGuide := f.indentGuideColor().SprintfFunc()
+		sprintfSpace := f.spaceColor().SprintfFunc()
+		for i, part := range strings.Split(text, indentGuide) {
+			if i > 0 {
+				dst = append(dst, sprintfGuide(indentGuide)...)
+			}
+			if part != "" {
+				dst = append(dst, sprintfSpace("%s", part)...)
+			}
+		}
+		return dst
+	}
+	return append(dst, f.segmentColor(memberSegment{Segment: Segment{Kind: kind, Text: text}}).SprintfFunc()("%s", text)...)
+}
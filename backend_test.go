@@ -0,0 +1,53 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// tagBackend renders each piece of text tagged with its kind.
+var tagBackend = RendererFunc(func(dst []byte, kind TokenKind, text string) []byte {
+	if kind == TokenWhitespace {
+		return append(dst, text...)
+	}
+	return fmt.Appendf(dst, "<%s:%s>", kind, text)
+})
+
+func TestFormatterBackend(t *testing.T) {
+	f := newPlainFormatter()
+	f.Backend = tagBackend
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`{"a": [1, "b", null]}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := `<object-delim:{>
+  <key:"a"><colon::> <array-delim:[>
+    <number:1><comma:,>
+    <string:"b"><comma:,>
+    <null:null>
+  <array-delim:]>
+<object-delim:}>`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestANSIRenderer(t *testing.T) {
+	withColor(t, true)
+	src := []byte(`{"a": [1, "b", true, null], "c": {}}`)
+	f := styledFormatter()
+	var want strings.Builder
+	if err := f.Format(&want, src); err != nil {
+		t.Fatal(err)
+	}
+	f.Backend = f.ANSIRenderer()
+	var got strings.Builder
+	if err := f.Format(&got, src); err != nil {
+		t.Fatal(err)
+	}
+	// Without a Backend, the output is the same.
+	if got.String() != want.String() {
+		t.Errorf("got %q, want %q", got.String(), want.String())
+	}
+}
@@ -0,0 +1,124 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Background is the brightness of the background of the terminal the output
+// is shown on. See Formatter.Background.
+type Background int
+
+const (
+	// BackgroundAuto detects the background of the terminal. See
+	// DetectBackground.
+	BackgroundAuto Background = iota
+	// BackgroundDark is a dark background, such as black.
+	BackgroundDark
+	// BackgroundLight is a light background, such as white.
+	BackgroundLight
+)
+
+// String returns the name of the background, e.g. "dark".
+func (b Background) String() string {
+	switch b {
+	case BackgroundAuto:
+		return "auto"
+	case BackgroundDark:
+		return "dark"
+	case BackgroundLight:
+		return "light"
+	}
+	return "unknown"
+}
+
+// detectedBackground caches the result of DetectBackground for the whole
+// process, since querying the terminal takes a round trip to it.
+var detectedBackground = sync.OnceValue(detectBackground)
+
+// DetectBackground returns the background of the terminal: when standard input
+// and output are both a terminal, on Unix systems, it's queried for its
+// background color with the OSC 11 escape sequence, which most terminal
+// emulators answer, and otherwise, or if it doesn't answer in time, the
+// COLORFGBG environment variable set by some terminals, e.g. "15;0" for white
+// on black, is used. The background is assumed to be dark if unknown. The
+// terminal is only queried once, and the result reused.
+func DetectBackground() Background {
+	return detectedBackground()
+}
+
+func detectBackground() Background {
+	// The terminal isn't queried when input is piped or output redirected,
+	// since the program is then unlikely to be interactive, and its answer
+	// would be of no use.
+	if isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+		if b, ok := oscBackground(queryBackground()); ok {
+			return b
+		}
+	}
+	colorFGBG := os.Getenv("COLORFGBG")
+	if i := strings.LastIndexByte(colorFGBG, ';'); i >= 0 {
+		// The ANSI colors 7 (light gray) and 9 to 15 are light.
+		if bg, err := strconv.Atoi(colorFGBG[i+1:]); err == nil && (bg == 7 || bg >= 9 && bg <= 15) {
+			return BackgroundLight
+		}
+	}
+	return BackgroundDark
+}
+
+// oscBackground returns the background whose color is given by `answer`, the
+// answer of a terminal to the OSC 11 query, e.g.
+// "\x1b]11;rgb:ffff/ffff/ffff\x07", and false if there's no color in it.
+func oscBackground(answer []byte) (Background, bool) {
+	_, color, ok := bytes.Cut(answer, []byte("\x1b]11;rgb:"))
+	if !ok {
+		return BackgroundAuto, false
+	}
+	// The answer is terminated by BEL or ST (ESC \).
+	end := bytes.IndexAny(color, "\x07\x1b")
+	if end < 0 {
+		return BackgroundAuto, false
+	}
+	components := strings.Split(string(color[:end]), "/")
+	if len(components) != 3 {
+		return BackgroundAuto, false
+	}
+	// Each component has 1 to 4 hexadecimal digits, e.g. "ff" or "ffff" for the maximum.
+	var rgb [3]float64
+	for i, c := range components {
+		v, err := strconv.ParseUint(c, 16, 16)
+		if err != nil || len(c) == 0 || len(c) > 4 {
+			return BackgroundAuto, false
+		}
+		rgb[i] = float64(v) / float64(uint64(1)<<(4*len(c))-1)
+	}
+	// The background is light if its HSL lightness is at least one half.
+	if max(rgb[0], rgb[1], rgb[2])+min(rgb[0], rgb[1], rgb[2]) >= 1 {
+		return BackgroundLight, true
+	}
+	return BackgroundDark, true
+}
+
+// resolve returns the detected background for BackgroundAuto, and the
+// background itself otherwise.
+func (b Background) resolve() Background {
+	if b == BackgroundAuto {
+		return DetectBackground()
+	}
+	return b
+}
+
+// variant returns the Formatter holding the colors for the background of the
+// terminal, among LightColors and DarkColors, or nil if there's none.
+func (f *Formatter) variant() *Formatter {
+	if f.LightColors == nil && f.DarkColors == nil {
+		return nil
+	}
+	if f.Background.resolve() == BackgroundLight {
+		return f.LightColors
+	}
+	return f.DarkColors
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package jsoncolor
+
+// queryBackground returns nil, since the terminal is only queried for its
+// background color on Unix systems, leaving COLORFGBG to DetectBackground.
+func queryBackground() []byte {
+	return nil
+}
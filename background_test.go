@@ -0,0 +1,96 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOSCBackground(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   Background
+		ok     bool
+	}{
+		{"\x1b]11;rgb:ffff/ffff/ffff\x07\x1b[?62;22c", BackgroundLight, true},
+		{"\x1b]11;rgb:0000/0000/0000\x1b\\\x1b[?62;22c", BackgroundDark, true},
+		{"\x1b]11;rgb:fd/f6/e3\x07", BackgroundLight, true},
+		{"\x1b]11;rgb:1e1e/1e1e/2e2e\x07", BackgroundDark, true},
+		{"\x1b]11;rgb:8/8/8\x07", BackgroundLight, true},
+		{"\x1b[?62;22c", BackgroundAuto, false},
+		{"\x1b]11;rgb:ffff/ffff\x07", BackgroundAuto, false},
+		{"\x1b]11;rgb:fffff/ffff/ffff\x07", BackgroundAuto, false},
+		{"\x1b]11;rgb:ffff/ffff/ffff", BackgroundAuto, false},
+		{"", BackgroundAuto, false},
+	}
+	for _, tt := range tests {
+		got, ok := oscBackground([]byte(tt.answer))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("oscBackground(%q) = %v, %v, want %v, %v", tt.answer, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDetectBackgroundColorFGBG(t *testing.T) {
+	tests := []struct {
+		colorFGBG string
+		want      Background
+	}{
+		{"15;0", BackgroundDark},
+		{"0;15", BackgroundLight},
+		{"0;default;7", BackgroundLight},
+		{"", BackgroundDark},
+	}
+	for _, tt := range tests {
+		t.Setenv("COLORFGBG", tt.colorFGBG)
+		if got := detectBackground(); got != tt.want {
+			t.Errorf("COLORFGBG=%q: got %v, want %v", tt.colorFGBG, got, tt.want)
+		}
+	}
+}
+
+func TestFormatterBackgroundVariants(t *testing.T) {
+	withColor(t, true)
+	f := plainCompactFormatter()
+	f.NumberColor = Style{FgRed}
+	f.StringColor = Style{FgGreen}
+	f.LightColors = &Formatter{NumberColor: Style{FgBlue}}
+	f.DarkColors = &Formatter{NumberColor: Style{FgHiBlue}, StringColor: Style{FgHiGreen}}
+	tests := []struct {
+		background Background
+		want       string
+	}{
+		// Colors the variant doesn't set are the Formatter's own.
+		{BackgroundLight, "[\x1b[34m1\x1b[0m,\"\x1b[32ma\x1b[0m\"]"},
+		{BackgroundDark, "[\x1b[94m1\x1b[0m,\"\x1b[92ma\x1b[0m\"]"},
+	}
+	for _, tt := range tests {
+		f.Background = tt.background
+		var sb strings.Builder
+		if err := f.Format(&sb, []byte(`[1, "a"]`)); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.background, got, tt.want)
+		}
+	}
+
+	// With only one variant, the Formatter's own colors apply on the other
+	// background.
+	f.DarkColors = nil
+	f.Background = BackgroundDark
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "[\x1b[31m1\x1b[0m]"; got != want {
+		t.Errorf("no dark variant: got %q, want %q", got, want)
+	}
+}
+
+func TestBackgroundString(t *testing.T) {
+	for b, want := range map[Background]string{BackgroundAuto: "auto", BackgroundDark: "dark", BackgroundLight: "light", Background(9): "unknown"} {
+		if got := b.String(); got != want {
+			t.Errorf("%d: got %q, want %q", b, got, want)
+		}
+	}
+}
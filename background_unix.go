@@ -0,0 +1,65 @@
+//go:build unix
+
+package jsoncolor
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// backgroundQueryTimeout is how long the terminal is given to answer the
+// query of its background color. Local terminals answer within milliseconds,
+// and the few which answer neither OSC 11 nor DA1 (see queryBackground) only
+// delay the start of the program by as much. A slower answer, e.g. over a
+// distant SSH connection, leaves the background to COLORFGBG.
+const backgroundQueryTimeout = 100 * time.Millisecond
+
+// queryBackground queries the controlling terminal for its background color
+// with the OSC 11 escape sequence, and returns its answer (see oscBackground),
+// or nil if it can't be queried.
+func queryBackground() []byte {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil
+	}
+	defer tty.Close()
+	// File.Fd would make reads blocking, and so ignore the deadline below.
+	conn, err := tty.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	var fd uintptr
+	conn.Control(func(f uintptr) { fd = f })
+	// The terminal must not echo its answer, nor wait for a newline to pass it on.
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil
+	}
+	defer term.Restore(fd, state)
+
+	// Terminals answer the primary device attributes query (DA1), which
+	// follows, in order, so that one not supporting OSC 11 is known not to as
+	// soon as it answers, rather than after the timeout.
+	if _, err := tty.WriteString("\x1b]11;?\x07\x1b[c"); err != nil {
+		return nil
+	}
+	if err := tty.SetReadDeadline(time.Now().Add(backgroundQueryTimeout)); err != nil {
+		return nil
+	}
+	var answer []byte
+	buf := make([]byte, 64)
+	for {
+		n, err := tty.Read(buf)
+		answer = append(answer, buf[:n]...)
+		// The answer to DA1 ends with 'c', e.g. "\x1b[?62;22c".
+		if i := bytes.LastIndex(answer, []byte("\x1b[?")); i >= 0 && bytes.IndexByte(answer[i:], 'c') >= 0 {
+			return answer
+		}
+		if err != nil {
+			return answer
+		}
+	}
+}
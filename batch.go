@@ -0,0 +1,248 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/amterp/color"
+)
+
+// BatchOptions configures FormatFiles.
+type BatchOptions struct {
+	// Formatter is used to format each file. If nil, files are indented with
+	// two spaces. Files are always written without colors, whatever the
+	// Formatter's colors, so that they remain valid JSON.
+	// Output ends with a newline unless Formatter.TrailingNewline is NewlineNever.
+	Formatter *Formatter
+
+	// OutputDir is the directory results are written to. If empty, each file is
+	// rewritten in place. Relative input paths keep their directory structure
+	// below OutputDir (e.g. "conf/a.json" is written to "<OutputDir>/conf/a.json"),
+	// while other paths are written directly into it under their base name.
+	OutputDir string
+
+	// FS, if non-nil, is the file system the input paths are read from, such as
+	// an embed.FS, instead of the operating system's. Paths must then be valid
+	// fs.FS paths (slash-separated and unrooted), and OutputDir must be set
+	// since the inputs can't be rewritten in place.
+	FS fs.FS
+
+	// Concurrency is the maximum number of files processed at the same time.
+	// If zero or negative, runtime.GOMAXPROCS(0) is used.
+	Concurrency int
+
+	// Progress, if non-nil, receives a colorized line for each file as soon as
+	// it's done, reporting its success or failure, e.g. for os.Stderr.
+	Progress io.Writer
+}
+
+// FileResult reports the outcome of formatting a single file with FormatFiles.
+type FileResult struct {
+	Path       string // The input path, as passed to FormatFiles.
+	OutputPath string // The path the result was written to, or would have been if Err is set.
+	Changed    bool   // True if the output differs from the input.
+	Err        error  // Non-nil if the file could not be read, formatted or written.
+}
+
+// FormatFiles formats many JSON files concurrently, rewriting them in place or
+// writing the results to a separate directory (see BatchOptions.OutputDir).
+// Files rewritten in place are replaced atomically and keep their permissions,
+// and are left untouched if already formatted.
+//
+// A failure to process one file doesn't stop the others. The returned results
+// are in the order of `paths`, each carrying its own error, and the returned
+// error is non-nil if any file failed.
+func FormatFiles(paths []string, opts BatchOptions) ([]FileResult, error) {
+	f := opts.Formatter
+	if f == nil {
+		f = newPlainFormatter()
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	if opts.FS != nil && opts.OutputDir == "" {
+		return nil, errors.New("jsoncolor: BatchOptions.OutputDir must be set when reading from BatchOptions.FS")
+	}
+
+	results := make([]FileResult, len(paths))
+	for i, path := range paths {
+		results[i] = FileResult{Path: path, OutputPath: batchOutputPath(path, opts.OutputDir)}
+		if opts.FS != nil && !fs.ValidPath(path) {
+			results[i].Err = &fs.PathError{Op: "open", Path: path, Err: fs.ErrInvalid}
+		}
+	}
+	// Refuse to write two inputs to the same output, e.g. "a/x.json" and "/b/x.json"
+	// when both end up under their base name, rather than letting one overwrite the other.
+	outputs := map[string]int{}
+	for i := range results {
+		result := &results[i]
+		if result.Err != nil {
+			continue
+		}
+		output := filepath.Clean(result.OutputPath)
+		if j, ok := outputs[output]; ok {
+			result.Err = fmt.Errorf("jsoncolor: output path %q is also used for %q", result.OutputPath, results[j].Path)
+			continue
+		}
+		outputs[output] = i
+	}
+
+	progress := &batchProgress{w: opts.Progress, total: len(paths)}
+
+	// Feed the indices of the files to a fixed number of workers.
+	indices := make(chan int)
+	wg := sync.WaitGroup{}
+	for n := 0; n < concurrency; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				result := &results[i]
+				if result.Err == nil {
+					result.Changed, result.Err = formatFile(f, opts.FS, result.Path, result.OutputPath)
+				}
+				progress.report(result)
+			}
+		}()
+	}
+	for i := range paths {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	if progress.failed > 0 {
+		return results, fmt.Errorf("jsoncolor: failed to format %d of %d files", progress.failed, len(paths))
+	}
+	return results, nil
+}
+
+// batchOutputPath returns the path the result for the input `path` is written to.
+func batchOutputPath(path, outputDir string) string {
+	if outputDir == "" {
+		return path
+	}
+	if filepath.IsLocal(filepath.FromSlash(path)) {
+		return filepath.Join(outputDir, filepath.FromSlash(path))
+	}
+	return filepath.Join(outputDir, filepath.Base(path))
+}
+
+// formatFile formats the JSON file at `path` using `f`, without colors, and
+// writes the result to `outputPath`, which may be the same file. The file is
+// read from `fsys`, or the operating system's file system if nil, and decoded
+// like the input of Format. It reports whether the formatted output differs
+// from the input.
+func formatFile(f *Formatter, fsys fs.FS, path, outputPath string) (bool, error) {
+	var src []byte
+	var err error
+	if fsys != nil {
+		src, err = fs.ReadFile(fsys, path)
+	} else {
+		src, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return false, err
+	}
+	// A byte order mark or UTF-16 input is a change, since the output is
+	// UTF-8 without one.
+	decoded, err := transcodeInput(src)
+	if err != nil {
+		return false, err
+	}
+	buf := &bytes.Buffer{}
+	fs := newFormatterStateWithOptions(f, buf, formatterOptions.with(WithColorMode(ColorNever)))
+	if err := fs.format(buf, decoded, f.TrailingNewline.resolve(true)); err != nil {
+		return false, err
+	}
+	changed := !bytes.Equal(src, buf.Bytes())
+
+	if fsys == nil && outputPath == path {
+		if !changed {
+			return false, nil
+		}
+		return true, replaceFile(path, buf.Bytes())
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return changed, err
+	}
+	return changed, os.WriteFile(outputPath, buf.Bytes(), 0o644)
+}
+
+// replaceFile atomically replaces the contents of the existing file at `path`
+// with `data`, by writing to a temporary file in the same directory and
+// renaming it over the original. The file's permissions are preserved.
+func replaceFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Clean up the temporary file on failure. After a successful rename, this is a no-op.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var (
+	// batchDoneColor is used for the progress lines of files formatted successfully.
+	batchDoneColor = color.New(color.FgGreen)
+	// batchCountColor is used for the "[n/total]" counter in progress lines.
+	batchCountColor = color.New(color.Faint)
+)
+
+// batchProgress counts completed files and writes progress lines for FormatFiles.
+// It's shared by all workers, so access is serialized.
+type batchProgress struct {
+	mu     sync.Mutex
+	w      io.Writer // Destination of progress lines; nil to disable them.
+	total  int       // Number of files being processed.
+	done   int       // Number of files completed so far.
+	failed int       // Number of files which failed so far.
+}
+
+// report records the completion of a file and prints its progress line.
+func (p *batchProgress) report(result *FileResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	if result.Err != nil {
+		p.failed++
+	}
+	if p.w == nil {
+		return
+	}
+
+	counter := batchCountColor.Sprintf("[%d/%d]", p.done, p.total)
+	switch {
+	case result.Err != nil:
+		fmt.Fprintf(p.w, "%s %s %s: %v\n", counter, DefaultErrorColor.Sprint("✗"), result.Path, result.Err)
+	case result.Changed:
+		fmt.Fprintf(p.w, "%s %s %s\n", counter, batchDoneColor.Sprint("✓"), result.Path)
+	default:
+		fmt.Fprintf(p.w, "%s %s %s %s\n", counter, batchDoneColor.Sprint("✓"), result.Path, batchCountColor.Sprint("(unchanged)"))
+	}
+}
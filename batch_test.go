@@ -0,0 +1,234 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// writeFiles creates the files in `files`, keyed by path relative to `dir`.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns the contents of the file at `path`.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFormatFilesInPlace(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.json": `{"a":[1,2]}`,
+		"b.json": "{\n  \"b\": true\n}\n",
+	})
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+
+	var progress bytes.Buffer
+	results, err := FormatFiles([]string{a, b}, BatchOptions{Progress: &progress, Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := readFile(t, a), "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n"; got != want {
+		t.Errorf("a.json: got %q, want %q", got, want)
+	}
+	info, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("a.json: got mode %v, want permissions to be kept", info.Mode())
+	}
+
+	if !results[0].Changed || results[1].Changed {
+		t.Errorf("got changed %v, %v, want true, false", results[0].Changed, results[1].Changed)
+	}
+	if results[0].OutputPath != a {
+		t.Errorf("got output path %q, want %q", results[0].OutputPath, a)
+	}
+
+	wantProgress := "[1/2] ✓ " + a + "\n[2/2] ✓ " + b + " (unchanged)\n"
+	if got := stripANSI(progress.String()); got != wantProgress {
+		t.Errorf("got progress:\n%s\nwant:\n%s", got, wantProgress)
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d files in the directory, want 2", len(entries))
+	}
+}
+
+func TestFormatFilesOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"conf/a.json": `[1]`})
+	t.Chdir(dir)
+
+	out := filepath.Join(dir, "out")
+	other := filepath.Join(t.TempDir(), "b.json")
+	writeFiles(t, filepath.Dir(other), map[string]string{"b.json": `{}`})
+
+	f := &Formatter{Indent: "\t", TrailingNewline: NewlineNever}
+	results, err := FormatFiles([]string{"conf/a.json", other}, BatchOptions{OutputDir: out, Formatter: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Relative paths keep their directory, others are written under their base name.
+	if got, want := results[0].OutputPath, filepath.Join(out, "conf", "a.json"); got != want {
+		t.Errorf("got output path %q, want %q", got, want)
+	}
+	if got, want := results[1].OutputPath, filepath.Join(out, "b.json"); got != want {
+		t.Errorf("got output path %q, want %q", got, want)
+	}
+	if got, want := readFile(t, results[0].OutputPath), "[\n\t1\n]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// The input is left untouched.
+	if got := readFile(t, "conf/a.json"); got != `[1]` {
+		t.Errorf("input was modified: %q", got)
+	}
+}
+
+func TestFormatFilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"ok.json":      `1`,
+		"invalid.json": `{"a":`,
+		"sub/ok.json":  `2`,
+	})
+	paths := []string{
+		filepath.Join(dir, "ok.json"),
+		filepath.Join(dir, "invalid.json"),
+		filepath.Join(dir, "missing.json"),
+		filepath.Join(dir, "sub", "ok.json"),
+	}
+
+	var progress bytes.Buffer
+	results, err := FormatFiles(paths, BatchOptions{OutputDir: filepath.Join(dir, "out"), Progress: &progress})
+	if err == nil || !strings.Contains(err.Error(), "failed to format 3 of 4 files") {
+		t.Errorf("got %v, want an error for 3 of 4 files", err)
+	}
+
+	if results[0].Err != nil {
+		t.Errorf("ok.json: got %v", results[0].Err)
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "unexpected EOF") {
+		t.Errorf("invalid.json: got %v, want an unexpected EOF error", results[1].Err)
+	}
+	if !os.IsNotExist(results[2].Err) {
+		t.Errorf("missing.json: got %v, want a not-exist error", results[2].Err)
+	}
+	// Both ok.json files map to the same output path.
+	if results[3].Err == nil || !strings.Contains(results[3].Err.Error(), "is also used for") {
+		t.Errorf("sub/ok.json: got %v, want a conflicting output error", results[3].Err)
+	}
+	if got := strings.Count(stripANSI(progress.String()), "✗"); got != 3 {
+		t.Errorf("got %d failures in the progress:\n%s", got, progress.String())
+	}
+}
+
+func TestFormatFilesNoColors(t *testing.T) {
+	withColor(t, true)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.json": `{"a":[1,"x",null]}`})
+	path := filepath.Join(dir, "a.json")
+
+	// The colors of the Formatter, including StyleFuncs, are left out of files.
+	f := styledFormatter()
+	f.NullColor = StyleFunc(func(s string) string { return "<" + s + ">" })
+	if _, err := FormatFiles([]string{path}, BatchOptions{Formatter: f}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "{\n  \"a\": [\n    1,\n    \"x\",\n    null\n  ]\n}\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatFilesEncodings(t *testing.T) {
+	dir := t.TempDir()
+	const formatted = "{\n  \"é\": 1\n}\n"
+	writeFiles(t, dir, map[string]string{
+		"bom.json":   "\xef\xbb\xbf" + formatted,
+		"utf16.json": string(utf16Data(formatted, binary.LittleEndian, true)),
+	})
+	paths := []string{filepath.Join(dir, "bom.json"), filepath.Join(dir, "utf16.json")}
+	results, err := FormatFiles(paths, BatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The files are rewritten as UTF-8 without a byte order mark.
+	for i, path := range paths {
+		if !results[i].Changed {
+			t.Errorf("%s: got unchanged", path)
+		}
+		if got := readFile(t, path); got != formatted {
+			t.Errorf("%s: got %q, want %q", path, got, formatted)
+		}
+	}
+}
+
+func TestFormatFilesFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/a.json":  {Data: []byte(`{"a":1}`)},
+		"conf/b.json":  {Data: []byte("{\n  \"b\": 2\n}\n")},
+		"invalid.json": {Data: []byte(`[`)},
+	}
+	out := t.TempDir()
+	paths := []string{"conf/a.json", "conf/b.json", "invalid.json", "missing.json", "../up.json"}
+	results, err := FormatFiles(paths, BatchOptions{FS: fsys, OutputDir: out})
+	if err == nil {
+		t.Error("got no error")
+	}
+
+	if got, want := readFile(t, filepath.Join(out, "conf", "a.json")), "{\n  \"a\": 1\n}\n"; got != want {
+		t.Errorf("a.json: got %q, want %q", got, want)
+	}
+	// Unchanged files are still written to the output directory.
+	if got, want := readFile(t, filepath.Join(out, "conf", "b.json")), "{\n  \"b\": 2\n}\n"; got != want {
+		t.Errorf("b.json: got %q, want %q", got, want)
+	}
+	if !results[0].Changed || results[1].Changed {
+		t.Errorf("got changed %v, %v, want true, false", results[0].Changed, results[1].Changed)
+	}
+
+	for i, wantErr := range []bool{false, false, true, true, true} {
+		if gotErr := results[i].Err != nil; gotErr != wantErr {
+			t.Errorf("%s: got error %v", paths[i], results[i].Err)
+		}
+	}
+	if !errors.Is(results[4].Err, fs.ErrInvalid) {
+		t.Errorf("../up.json: got %v, want an invalid path error", results[4].Err)
+	}
+}
+
+func TestFormatFilesFSRequiresOutputDir(t *testing.T) {
+	_, err := FormatFiles([]string{"a.json"}, BatchOptions{FS: fstest.MapFS{}})
+	if err == nil {
+		t.Error("got no error")
+	}
+}
@@ -0,0 +1,81 @@
+package jsoncolor
+
+import (
+	"io"
+	"strings"
+)
+
+// FormatBBCode formats `src` as BBCode using the DefaultFormatter. See
+// Formatter.FormatBBCode.
+func FormatBBCode(dst io.Writer, src []byte) error {
+	return DefaultFormatter.FormatBBCode(dst, src)
+}
+
+// FormatBBCode formats `src` like Format, but writes BBCode tags instead of
+// ANSI escape sequences, for posting colorized JSON to forums and other tools
+// which accept BBCode: each token is wrapped in a [color=#rrggbb] tag, and in
+// [b], [i], [u] and [s] tags for its attributes. Backgrounds have no BBCode
+// equivalent and are left out, and faint text is written in a lighter color.
+//
+// As with FormatHTML, the colors are read from the escape sequences the
+// Formatter's colors produce, so no tags are written if colors are disabled.
+// BBCode has no way to escape brackets, so strings looking like tags, such as
+// "[b]", are interpreted as such by most forums. The output isn't wrapped in a
+// [code] tag, which many forums show without colors; the whitespace of the
+// indentation is only kept by forums showing the text as is. Nothing is
+// written if `src` is invalid.
+func (f *Formatter) FormatBBCode(dst io.Writer, src []byte) error {
+	tags := make(map[string][2]string)
+	sb := &strings.Builder{}
+	err := f.formatMemberSegments(src, func(s memberSegment) {
+		if s.Kind == TokenWhitespace {
+			sb.WriteString(s.Text)
+			return
+		}
+		key := f.segmentColorKey(s)
+		tag, ok := tags[key]
+		if !ok {
+			tag = bbcodeTags(sgrStyle(f.segmentColor(s).SprintfFunc()("%s", "x")))
+			tags[key] = tag
+		}
+		sb.WriteString(tag[0])
+		sb.WriteString(s.Text)
+		sb.WriteString(tag[1])
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(dst, sb.String())
+	return err
+}
+
+// bbcodeTags returns the opening and closing BBCode tags of the style `c`.
+func bbcodeTags(c cssStyle) [2]string {
+	color := c.color
+	if c.reverse {
+		color = c.background
+	}
+	if c.faint {
+		color = fadedColor(color)
+	}
+	var open, close string
+	wrap := func(start, end string) {
+		open, close = open+start, end+close
+	}
+	if color != "" {
+		wrap("[color="+color+"]", "[/color]")
+	}
+	if c.bold {
+		wrap("[b]", "[/b]")
+	}
+	if c.italic {
+		wrap("[i]", "[/i]")
+	}
+	if c.underline {
+		wrap("[u]", "[/u]")
+	}
+	if c.crossed {
+		wrap("[s]", "[/s]")
+	}
+	return [2]string{open, close}
+}
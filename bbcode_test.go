@@ -0,0 +1,67 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatBBCode(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	f.CommaColor = Style{FgRed, BgWhite, Underline, CrossedOut}
+	var sb strings.Builder
+	if err := f.FormatBBCode(&sb, []byte(`{"a": ["x", 1, true, false, null]}`)); err != nil {
+		t.Fatal(err)
+	}
+	// Faint null is written in a lighter color, and the background of commas
+	// has no BBCode equivalent.
+	want := `{
+  [color=#0000ee][b]"a"[/b][/color]: [
+    [color=#00cd00]"x"[/color][color=#cd0000][u][s],[/s][/u][/color]
+    [color=#00ffff]1[/color][color=#cd0000][u][s],[/s][/u][/color]
+    [i]true[/i][color=#cd0000][u][s],[/s][/u][/color]
+    [color=#cd0000]false[/color][color=#cd0000][u][s],[/s][/u][/color]
+    [color=#666666]null[/color]
+  ]
+}`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	withColor(t, false)
+	sb.Reset()
+	if err := f.FormatBBCode(&sb, []byte(`{"a": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": 1\n}"; sb.String() != want {
+		t.Errorf("colors disabled: got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestFormatBBCodeInvalid(t *testing.T) {
+	var sb strings.Builder
+	if err := FormatBBCode(&sb, []byte(`{"a"}`)); err == nil {
+		t.Error("got no error")
+	}
+	if sb.Len() != 0 {
+		t.Errorf("got output %q", sb.String())
+	}
+}
+
+func TestBBCodeTags(t *testing.T) {
+	tests := []struct {
+		sgr, open, close string
+	}{
+		{"\x1b[1;3;34m", "[color=#0000ee][b][i]", "[/i][/b][/color]"},
+		// Reversed text is shown in the color of its background.
+		{"\x1b[7;31;42m", "[color=#00cd00]", "[/color]"},
+		{"\x1b[2;44m", "[color=#666666]", "[/color]"},
+		{"\x1b[2;31m", "[color=#e16666]", "[/color]"},
+	}
+	for _, tt := range tests {
+		tags := bbcodeTags(sgrStyle(tt.sgr + "x"))
+		if tags[0] != tt.open || tags[1] != tt.close {
+			t.Errorf("%q: got %q, want %q, %q", tt.sgr, tags, tt.open, tt.close)
+		}
+	}
+}
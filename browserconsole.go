@@ -0,0 +1,59 @@
+package jsoncolor
+
+import "strings"
+
+// FormatBrowserConsole formats `src` with the DefaultFormatter for the
+// console of a web browser. See Formatter.FormatBrowserConsole.
+func FormatBrowserConsole(src []byte) (format string, args []string, err error) {
+	return DefaultFormatter.FormatBrowserConsole(src)
+}
+
+// FormatBrowserConsole formats `src` like Format, but for the console of the
+// developer tools of web browsers, which styles text with CSS given through
+// %c directives rather than escape sequences. It returns the arguments of
+// console.log showing the output, for web-based playgrounds compiled to
+// WebAssembly or serving the arguments to a page:
+//
+//	console.log(format, ...args)
+//
+// Each piece of text is passed as an argument too, after its style, so that
+// the % directives it may contain are not interpreted. The colors of the
+// Formatter are applied even where github.com/amterp/color disables them,
+// since they're not written to standard output. See FormatHTML for output
+// to insert into the DOM.
+func (f *Formatter) FormatBrowserConsole(src []byte) (format string, args []string, err error) {
+	var sb, text strings.Builder
+	style := ""
+	styles := make(map[string]string)
+	flush := func() {
+		if text.Len() > 0 {
+			sb.WriteString("%c%s")
+			args = append(args, style, text.String())
+			text.Reset()
+		}
+	}
+	err = f.formatMemberSegments(src, func(s memberSegment) {
+		next := style
+		if s.Kind != TokenWhitespace {
+			// Booleans are the only kind whose color depends on the text.
+			key := f.segmentColorKey(s)
+			var ok bool
+			next, ok = styles[key]
+			if !ok {
+				next = strings.Join(sgrCSS(forceColor(f.segmentColor(s)).SprintfFunc()("%s", "x")), ";")
+				styles[key] = next
+			}
+		}
+		// Consecutive pieces of text with the same style are passed together.
+		if next != style {
+			flush()
+			style = next
+		}
+		text.WriteString(s.Text)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	flush()
+	return sb.String(), args, nil
+}
@@ -0,0 +1,41 @@
+package jsoncolor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatBrowserConsole(t *testing.T) {
+	// Colors are applied even where the color package disables them.
+	withColor(t, false)
+	format, args, err := styledFormatter().FormatBrowserConsole([]byte(`{"a": ["%s", true, false]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "%c%s%c%s%c%s%c%s%c%s%c%s%c%s%c%s%c%s"; format != want {
+		t.Errorf("format: got %q, want %q", format, want)
+	}
+	// Text is passed as arguments, so that "%s" isn't interpreted, and
+	// whitespace keeps the style of the text it follows.
+	want := []string{
+		"", "{\n  ",
+		"color:#0000ee;font-weight:bold", `"a"`,
+		"", ": [\n    ",
+		"color:#00cd00", `"%s"`,
+		"", ",\n    ",
+		"font-style:italic", "true",
+		"", ",\n    ",
+		"color:#cd0000", "false\n  ",
+		"", "]\n}",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("args: got %q, want %q", args, want)
+	}
+}
+
+func TestFormatBrowserConsoleInvalid(t *testing.T) {
+	format, args, err := styledFormatter().FormatBrowserConsole([]byte(`[1`))
+	if err == nil || format != "" || args != nil {
+		t.Errorf("got %q, %q, %v", format, args, err)
+	}
+}
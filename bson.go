@@ -0,0 +1,401 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatBSON renders the BSON documents in `src` as colorized JSON using the
+// DefaultFormatter. See Formatter.FormatBSON.
+func FormatBSON(dst io.Writer, src []byte) error {
+	return DefaultFormatter.FormatBSON(dst, src)
+}
+
+// FormatBSON renders the BSON documents in `src`, such as the contents of a
+// file written by mongodump, as colorized JSON written to `dst` according to
+// the Formatter's settings, one document per line.
+//
+// Values without a JSON equivalent are written in MongoDB Extended JSON v2,
+// relaxed format, so the output is standard JSON: for instance, an ObjectId is
+// written as {"$oid": "..."}, a datetime as {"$date": "2006-01-02T15:04:05Z"}
+// (or {"$date": {"$numberLong": "..."}} outside of years 1970 to 9999), and
+// binary data as {"$binary": {"base64": "...", "subType": "00"}}. Integers and
+// finite doubles are written as plain numbers.
+//
+// The input is decoded entirely before anything is written, so no output is
+// written for invalid BSON.
+func (f *Formatter) FormatBSON(dst io.Writer, src []byte) error {
+	buf := &bytes.Buffer{}
+	d := &bsonDecoder{fs: newFormatterState(f, buf), src: src}
+	for n := 0; d.pos < len(src); n++ {
+		if n > 0 {
+			d.fs.printSpace("\n", true)
+		}
+		if err := d.document(false); err != nil {
+			return err
+		}
+	}
+	if f.TrailingNewline.resolve(false) {
+		d.fs.printSpace("\n", true)
+	}
+	_, err := dst.Write(buf.Bytes())
+	return err
+}
+
+// bsonDecoder decodes BSON documents, writing them as JSON through a formatterState.
+type bsonDecoder struct {
+	fs    *formatterState
+	src   []byte
+	pos   int // Position of the next byte to read.
+	depth int // Number of enclosing documents.
+}
+
+// errorf returns an error for the data at the current position.
+func (d *bsonDecoder) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("jsoncolor: error decoding input BSON: %s at offset %d", fmt.Sprintf(format, a...), d.pos)
+}
+
+// read returns the next `n` bytes.
+func (d *bsonDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.src)-d.pos {
+		return nil, d.errorf("unexpected end of input")
+	}
+	b := d.src[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// int32 reads a little-endian 32-bit integer.
+func (d *bsonDecoder) int32() (int32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+// uint64 reads a little-endian 64-bit integer.
+func (d *bsonDecoder) uint64() (uint64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// cstring reads a NUL-terminated string.
+func (d *bsonDecoder) cstring() (string, error) {
+	i := bytes.IndexByte(d.src[d.pos:], 0)
+	if i < 0 {
+		return "", d.errorf("unterminated cstring")
+	}
+	s := string(d.src[d.pos : d.pos+i])
+	d.pos += i + 1
+	return s, nil
+}
+
+// string reads a string prefixed by its length, including its terminating NUL.
+func (d *bsonDecoder) string() (string, error) {
+	n, err := d.int32()
+	if err != nil {
+		return "", err
+	}
+	if n < 1 {
+		return "", d.errorf("invalid string length %d", n)
+	}
+	b, err := d.read(int(n))
+	if err != nil {
+		return "", err
+	}
+	if b[n-1] != 0 {
+		return "", d.errorf("string not terminated by NUL")
+	}
+	return string(b[:n-1]), nil
+}
+
+// tokens writes a sequence of JSON tokens.
+func (d *bsonDecoder) tokens(tokens ...json.Token) error {
+	for _, t := range tokens {
+		if err := d.fs.writeToken(t, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// document decodes a document, written as a JSON array if `isArray` is true,
+// whose elements are then keyed by their index.
+func (d *bsonDecoder) document(isArray bool) error {
+	if d.depth >= maxBinaryDepth {
+		return d.errorf("exceeded max depth")
+	}
+	start := d.pos
+	n, err := d.int32()
+	if err != nil {
+		return err
+	}
+	if n < 5 || int(n) > len(d.src)-start {
+		d.pos = start
+		return d.errorf("invalid document length %d", n)
+	}
+	end := start + int(n) - 1 // Position of the terminating NUL.
+	if d.src[end] != 0 {
+		return d.errorf("document not terminated by NUL")
+	}
+
+	openDelim, closeDelim := json.Delim('{'), json.Delim('}')
+	if isArray {
+		openDelim, closeDelim = json.Delim('['), json.Delim(']')
+	}
+	if err := d.tokens(openDelim); err != nil {
+		return err
+	}
+	d.depth++
+	// Bound the elements to the document, so that they can't read past it.
+	src := d.src
+	d.src = src[:end]
+	for d.pos < end {
+		typ := d.src[d.pos]
+		d.pos++
+		name, err := d.cstring()
+		if err != nil {
+			return err
+		}
+		if !isArray {
+			if err := d.tokens(name); err != nil {
+				return err
+			}
+		}
+		if err := d.value(typ); err != nil {
+			return err
+		}
+	}
+	d.src = src
+	d.depth--
+	d.pos = end + 1
+	return d.tokens(closeDelim)
+}
+
+// value decodes a value of the BSON type `typ`.
+func (d *bsonDecoder) value(typ byte) error {
+	switch typ {
+	case 0x01: // double
+		n, err := d.uint64()
+		if err != nil {
+			return err
+		}
+		v := math.Float64frombits(n)
+		token, special, err := floatToken(v)
+		if err != nil {
+			return err
+		}
+		if special != "" {
+			return d.tokens(json.Delim('{'), "$numberDouble", special, json.Delim('}'))
+		}
+		return d.tokens(token)
+	case 0x02: // string
+		s, err := d.string()
+		if err != nil {
+			return err
+		}
+		return d.tokens(s)
+	case 0x03: // embedded document
+		return d.document(false)
+	case 0x04: // array
+		return d.document(true)
+	case 0x05: // binary
+		n, err := d.int32()
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return d.errorf("invalid binary length %d", n)
+		}
+		b, err := d.read(int(n) + 1)
+		if err != nil {
+			return err
+		}
+		subtype, data := b[0], b[1:]
+		if subtype == 0x02 && len(data) >= 4 {
+			// The old binary subtype repeats the length of the data.
+			data = data[4:]
+		}
+		return d.tokens(json.Delim('{'), "$binary", json.Delim('{'),
+			"base64", base64.StdEncoding.EncodeToString(data),
+			"subType", fmt.Sprintf("%02x", subtype),
+			json.Delim('}'), json.Delim('}'))
+	case 0x06: // undefined
+		return d.tokens(json.Delim('{'), "$undefined", true, json.Delim('}'))
+	case 0x07: // ObjectId
+		b, err := d.read(12)
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('{'), "$oid", hex.EncodeToString(b), json.Delim('}'))
+	case 0x08: // boolean
+		b, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		return d.tokens(b[0] != 0)
+	case 0x09: // UTC datetime
+		n, err := d.uint64()
+		if err != nil {
+			return err
+		}
+		ms := int64(n)
+		if t := time.UnixMilli(ms).UTC(); ms >= 0 && t.Year() <= 9999 {
+			return d.tokens(json.Delim('{'), "$date", t.Format("2006-01-02T15:04:05.999Z07:00"), json.Delim('}'))
+		}
+		return d.tokens(json.Delim('{'), "$date", json.Delim('{'), "$numberLong", strconv.FormatInt(ms, 10), json.Delim('}'), json.Delim('}'))
+	case 0x0A: // null
+		return d.tokens(nil)
+	case 0x0B: // regular expression
+		pattern, err := d.cstring()
+		if err != nil {
+			return err
+		}
+		options, err := d.cstring()
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('{'), "$regularExpression", json.Delim('{'),
+			"pattern", pattern, "options", options, json.Delim('}'), json.Delim('}'))
+	case 0x0C: // DBPointer
+		ns, err := d.string()
+		if err != nil {
+			return err
+		}
+		b, err := d.read(12)
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('{'), "$dbPointer", json.Delim('{'), "$ref", ns,
+			"$id", json.Delim('{'), "$oid", hex.EncodeToString(b), json.Delim('}'),
+			json.Delim('}'), json.Delim('}'))
+	case 0x0D: // JavaScript code
+		code, err := d.string()
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('{'), "$code", code, json.Delim('}'))
+	case 0x0E: // symbol
+		symbol, err := d.string()
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('{'), "$symbol", symbol, json.Delim('}'))
+	case 0x0F: // JavaScript code with scope
+		if _, err := d.int32(); err != nil {
+			return err
+		}
+		code, err := d.string()
+		if err != nil {
+			return err
+		}
+		if err := d.tokens(json.Delim('{'), "$code", code, "$scope"); err != nil {
+			return err
+		}
+		if err := d.document(false); err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('}'))
+	case 0x10: // int32
+		n, err := d.int32()
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Number(strconv.Itoa(int(n))))
+	case 0x11: // timestamp
+		n, err := d.uint64()
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('{'), "$timestamp", json.Delim('{'),
+			"t", json.Number(strconv.FormatUint(n>>32, 10)),
+			"i", json.Number(strconv.FormatUint(n&math.MaxUint32, 10)),
+			json.Delim('}'), json.Delim('}'))
+	case 0x12: // int64
+		n, err := d.uint64()
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Number(strconv.FormatInt(int64(n), 10)))
+	case 0x13: // decimal128
+		b, err := d.read(16)
+		if err != nil {
+			return err
+		}
+		return d.tokens(json.Delim('{'), "$numberDecimal", decimal128String(b), json.Delim('}'))
+	case 0xFF: // min key
+		return d.tokens(json.Delim('{'), "$minKey", json.Number("1"), json.Delim('}'))
+	case 0x7F: // max key
+		return d.tokens(json.Delim('{'), "$maxKey", json.Number("1"), json.Delim('}'))
+	}
+	return d.errorf("invalid element type 0x%02x", typ)
+}
+
+// decimal128String returns the string representation of the IEEE 754-2008
+// decimal128 number in binary integer decimal encoding `b`, in little-endian
+// byte order, as specified for MongoDB Extended JSON.
+func decimal128String(b []byte) string {
+	low, high := binary.LittleEndian.Uint64(b[:8]), binary.LittleEndian.Uint64(b[8:])
+	sign := ""
+	if high>>63 != 0 {
+		sign = "-"
+	}
+	switch (high >> 58) & 0x1f {
+	case 0x1f:
+		return "NaN"
+	case 0x1e:
+		return sign + "Infinity"
+	}
+
+	coefficient := new(big.Int)
+	var exponent int
+	if (high>>61)&3 == 3 {
+		// The coefficient would exceed the maximum of 10^34-1, so it's non-canonical and taken as zero.
+		exponent = int((high >> 47) & 0x3fff)
+	} else {
+		exponent = int((high >> 49) & 0x3fff)
+		coefficient.SetUint64(high & (1<<49 - 1))
+		coefficient.Lsh(coefficient, 64)
+		coefficient.Or(coefficient, new(big.Int).SetUint64(low))
+		if coefficient.Cmp(new(big.Int).Exp(big.NewInt(10), big.NewInt(34), nil)) >= 0 {
+			coefficient.SetInt64(0)
+		}
+	}
+	exponent -= 6176
+
+	digits := coefficient.String()
+	adjusted := exponent + len(digits) - 1
+	switch {
+	case exponent > 0 || adjusted < -6:
+		// Scientific notation.
+		s := digits[:1]
+		if len(digits) > 1 {
+			s += "." + digits[1:]
+		}
+		if adjusted >= 0 {
+			return sign + s + "E+" + strconv.Itoa(adjusted)
+		}
+		return sign + s + "E" + strconv.Itoa(adjusted)
+	case exponent == 0:
+		return sign + digits
+	}
+	point := len(digits) + exponent
+	if point <= 0 {
+		return sign + "0." + strings.Repeat("0", -point) + digits
+	}
+	return sign + digits[:point] + "." + digits[point:]
+}
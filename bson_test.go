@@ -0,0 +1,195 @@
+package jsoncolor
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// bsonDoc builds a BSON document from its encoded elements.
+func bsonDoc(elems ...[]byte) []byte {
+	n := 5
+	for _, e := range elems {
+		n += len(e)
+	}
+	doc := binary.LittleEndian.AppendUint32(nil, uint32(n))
+	for _, e := range elems {
+		doc = append(doc, e...)
+	}
+	return append(doc, 0)
+}
+
+// bsonElem encodes an element of type `typ` named `name`, whose value is
+// encoded as `value`.
+func bsonElem(typ byte, name string, value ...byte) []byte {
+	e := append([]byte{typ}, name...)
+	return append(append(e, 0), value...)
+}
+
+// bsonHex decodes `h`, ignoring spaces.
+func bsonHex(h string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(h, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// formatBSONPlain formats `src` with `f`, and strips the colors.
+func formatBSONPlain(t *testing.T, f *Formatter, src []byte) (string, error) {
+	t.Helper()
+	var sb strings.Builder
+	err := f.FormatBSON(&sb, src)
+	return stripANSI(sb.String()), err
+}
+
+func TestFormatBSON(t *testing.T) {
+	src := bsonDoc(
+		bsonElem(0x02, "s", bsonHex("03000000 616200")...),
+		bsonElem(0x04, "a", bsonDoc(bsonElem(0x10, "0", 1, 0, 0, 0), bsonElem(0x0A, "1"))...),
+		bsonElem(0x01, "x", bsonHex("000000000000f83f")...),
+		bsonElem(0x01, "inf", bsonHex("000000000000f07f")...),
+		bsonElem(0x12, "l", bsonHex("ffffffffffffffff")...),
+		bsonElem(0x08, "t", 1),
+		bsonElem(0x09, "d", bsonHex("e803000000000000")...),
+		bsonElem(0x09, "before", bsonHex("ffffffffffffffff")...),
+		bsonElem(0x11, "ts", bsonHex("0200000001000000")...),
+		bsonElem(0x0B, "re", bsonHex("5e6100 6900")...),
+		bsonElem(0x7F, "max"),
+	)
+	got, err := formatBSONPlain(t, newPlainFormatter(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "s": "ab",
+  "a": [
+    1,
+    null
+  ],
+  "x": 1.5,
+  "inf": {
+    "$numberDouble": "Infinity"
+  },
+  "l": -1,
+  "t": true,
+  "d": {
+    "$date": "1970-01-01T00:00:01Z"
+  },
+  "before": {
+    "$date": {
+      "$numberLong": "-1"
+    }
+  },
+  "ts": {
+    "$timestamp": {
+      "t": 1,
+      "i": 2
+    }
+  },
+  "re": {
+    "$regularExpression": {
+      "pattern": "^a",
+      "options": "i"
+    }
+  },
+  "max": {
+    "$maxKey": 1
+  }
+}`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Several documents in a row, as in a mongodump file, are written one per line.
+	got, err = formatBSONPlain(t, plainCompactFormatter(), append(bsonDoc(), bsonDoc(bsonElem(0x08, "t", 1))...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{}\n{\"t\":true}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatBSONBinary(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"generic", "03000000 00 010203", `{"$binary":{"base64":"AQID","subType":"00"}}`},
+		{"uuid", "10000000 04 00112233445566778899aabbccddeeff", `{"$binary":{"base64":"ABEiM0RVZneImaq7zN3u/w==","subType":"04"}}`},
+		// The old binary subtype repeats the length, which isn't part of the data.
+		{"old", "06000000 02 02000000 abcd", `{"$binary":{"base64":"q80=","subType":"02"}}`},
+		{"empty user defined", "00000000 80", `{"$binary":{"base64":"","subType":"80"}}`},
+	}
+	for _, tt := range tests {
+		got, err := formatBSONPlain(t, plainCompactFormatter(), bsonDoc(bsonElem(0x05, "b", bsonHex(tt.value)...)))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if want := `{"b":` + tt.want + `}`; got != want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, want)
+		}
+	}
+}
+
+func TestFormatBSONObjectID(t *testing.T) {
+	oid := bsonHex("507f1f77bcf86cd799439011")
+	src := bsonDoc(
+		bsonElem(0x07, "_id", oid...),
+		bsonElem(0x0C, "ref", append(bsonHex("02000000 6100"), oid...)...),
+	)
+	got, err := formatBSONPlain(t, plainCompactFormatter(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"_id":{"$oid":"507f1f77bcf86cd799439011"},"ref":{"$dbPointer":{"$ref":"a","$id":{"$oid":"507f1f77bcf86cd799439011"}}}}`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDecimal128String(t *testing.T) {
+	tests := []struct {
+		hex, want string
+	}{
+		{"0100000000000000 0000000000004030", "1"},
+		{"7b00000000000000 0000000000003c30", "1.23"},
+		{"0100000000000000 0000000000003a30", "0.001"},
+		{"0100000000000000 0000000000004230", "1E+1"},
+		{"0100000000000000 0000000000002030", "1E-16"},
+		{"0000000000000000 00000000000000f8", "-Infinity"},
+		{"0000000000000000 000000000000007c", "NaN"},
+	}
+	for _, tt := range tests {
+		if got := decimal128String(bsonHex(tt.hex)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want string
+	}{
+		{"truncated binary", bsonDoc(bsonElem(0x05, "b", bsonHex("05000000 00 0102")...)), "unexpected end of input at offset 11"},
+		{"negative binary length", bsonDoc(bsonElem(0x05, "b", bsonHex("ffffffff 00")...)), "invalid binary length -1 at offset 11"},
+		{"truncated ObjectID", bsonDoc(bsonElem(0x07, "_id", bsonHex("507f1f77")...)), "unexpected end of input at offset 9"},
+		{"unterminated string", bsonDoc(bsonElem(0x02, "s", bsonHex("02000000 6161")...)), "string not terminated by NUL at offset 13"},
+		{"invalid type", bsonDoc(bsonElem(0x20, "x")), "invalid element type 0x20 at offset 7"},
+		{"unterminated document", bsonHex("05000000 01"), "document not terminated by NUL at offset 4"},
+		{"document length", bsonHex("06000000 00"), "invalid document length 6 at offset 0"},
+	}
+	for _, tt := range tests {
+		got, err := formatBSONPlain(t, &Formatter{}, tt.src)
+		if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+		// Nothing is written for invalid input.
+		if got != "" {
+			t.Errorf("%s: got output %q", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// maxBinaryDepth is the maximum nesting depth of containers accepted in
+// binary input, such as CBOR, protecting against stack exhaustion.
+const maxBinaryDepth = 10000
+
+// maxBytesAnnotation is the maximum length of a byte string whose contents
+// are shown in hexadecimal in its annotation. Only the length of longer ones is.
+const maxBytesAnnotation = 32
+
+// FormatCBOR renders the CBOR data in `src` as colorized JSON using the
+// DefaultFormatter. See Formatter.FormatCBOR.
+func FormatCBOR(dst io.Writer, src []byte) error {
+	return DefaultFormatter.FormatCBOR(dst, src)
+}
+
+// FormatCBOR renders the CBOR (RFC 8949) data in `src` as colorized JSON
+// written to `dst`, according to the Formatter's settings. This is useful to
+// debug IoT and COSE payloads, whose wire format is CBOR.
+//
+// Data items without a JSON equivalent are converted following RFC 8949
+// section 6.1, and annotated with comments colorized with CommentColor:
+//   - Byte strings are written as base64url strings, annotated with their
+//     contents in hexadecimal, e.g. `"AQI" /* h'0102' */`, or their length if long.
+//   - Tags are written as their content, preceded by a comment naming the tag,
+//     e.g. `/* tag 1: epoch date/time */ 1700000000`. Bignums (tags 2 and 3)
+//     are written as numbers.
+//   - Map keys other than text strings are written as strings, e.g. "1",
+//     annotated with their type.
+//   - undefined, NaN and infinities are written as null, and other simple
+//     values as numbers, annotated with what they were.
+//
+// If the Formatter's StrictOutput is set, annotations are left out, so that
+// the output is standard JSON. A CBOR sequence (RFC 8742) of several data
+// items is written as one JSON value per line. The input is decoded entirely
+// before anything is written, so no output is written for invalid CBOR.
+func (f *Formatter) FormatCBOR(dst io.Writer, src []byte) error {
+	buf := &bytes.Buffer{}
+	d := &cborDecoder{fs: newFormatterState(f, buf), src: src}
+	for n := 0; d.pos < len(src); n++ {
+		// Separate the values of a sequence, unless a comment already ended the line.
+		// Tag comments start a line of their own.
+		if n > 0 && !d.fs.newlinePending && (d.fs.strictOutput || src[d.pos]>>5 != cborTag) {
+			d.fs.printSpace("\n", true)
+		}
+		if err := d.value(); err != nil {
+			return err
+		}
+	}
+	d.fs.newlinePending = false
+	if f.TrailingNewline.resolve(false) {
+		d.fs.printSpace("\n", true)
+	}
+	_, err := dst.Write(buf.Bytes())
+	return err
+}
+
+// CBOR major types.
+const (
+	cborUint = iota
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// cborTagNames holds the names of common CBOR tags, shown in annotations.
+var cborTagNames = map[uint64]string{
+	0:     "date/time string",
+	1:     "epoch date/time",
+	4:     "decimal fraction",
+	5:     "bigfloat",
+	16:    "COSE_Encrypt0",
+	17:    "COSE_Mac0",
+	18:    "COSE_Sign1",
+	21:    "base64url expected",
+	22:    "base64 expected",
+	23:    "base16 expected",
+	24:    "encoded CBOR",
+	32:    "URI",
+	33:    "base64url",
+	34:    "base64",
+	36:    "MIME message",
+	37:    "UUID",
+	61:    "CWT",
+	96:    "COSE_Encrypt",
+	97:    "COSE_Mac",
+	98:    "COSE_Sign",
+	55799: "self-described CBOR",
+}
+
+// cborDecoder decodes CBOR data items, writing them as JSON through a formatterState.
+type cborDecoder struct {
+	fs  *formatterState
+	src []byte
+	pos int // Position of the next byte to read.
+
+	// remaining holds the number of elements (or members) left to read in each
+	// enclosing array (or map), or -1 for those of indefinite length.
+	remaining []int64
+}
+
+// errorf returns an error for the data at the current position.
+func (d *cborDecoder) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("jsoncolor: error decoding input CBOR: %s at offset %d", fmt.Sprintf(format, a...), d.pos)
+}
+
+// head reads the initial byte of a data item and its argument. For items of
+// indefinite length, `indefinite` is true and `arg` is 0.
+func (d *cborDecoder) head() (major byte, info byte, arg uint64, indefinite bool, err error) {
+	if d.pos >= len(d.src) {
+		return 0, 0, 0, false, d.errorf("unexpected end of input")
+	}
+	major, info = d.src[d.pos]>>5, d.src[d.pos]&0x1f
+	d.pos++
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info == 31:
+		return major, info, 0, true, nil
+	case info > 27:
+		return 0, 0, 0, false, d.errorf("invalid additional information %d", info)
+	}
+	size := 1 << (info - 24)
+	if d.pos+size > len(d.src) {
+		return 0, 0, 0, false, d.errorf("unexpected end of input")
+	}
+	b := d.src[d.pos : d.pos+size]
+	d.pos += size
+	switch size {
+	case 1:
+		arg = uint64(b[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(b))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(b))
+	default:
+		arg = binary.BigEndian.Uint64(b)
+	}
+	return major, info, arg, false, nil
+}
+
+// more reports whether another element or member follows in the current container.
+func (d *cborDecoder) more() bool {
+	if len(d.remaining) == 0 {
+		return false
+	}
+	if n := d.remaining[len(d.remaining)-1]; n >= 0 {
+		return n > 0
+	}
+	return d.pos < len(d.src) && d.src[d.pos] != 0xff
+}
+
+// next reports whether another element or member follows in the current
+// container, consuming the break code ending a container of indefinite length.
+func (d *cborDecoder) next() bool {
+	n := &d.remaining[len(d.remaining)-1]
+	if *n >= 0 {
+		if *n == 0 {
+			return false
+		}
+		*n--
+		return true
+	}
+	if d.pos < len(d.src) && d.src[d.pos] == 0xff {
+		d.pos++
+		return false
+	}
+	return true
+}
+
+// value decodes the next data item and writes it as a JSON value.
+func (d *cborDecoder) value() error {
+	major, info, arg, indefinite, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborArray, cborMap:
+		return d.container(major, arg, indefinite)
+	case cborTag:
+		return d.tag(arg)
+	}
+	token, annotation, err := d.scalar(major, info, arg, indefinite)
+	if err != nil {
+		return err
+	}
+	if err := d.fs.writeToken(token, false); err != nil {
+		return err
+	}
+	if annotation != "" {
+		d.fs.writeAnnotation(annotation, true, d.more())
+	}
+	return nil
+}
+
+// container decodes the contents of an array or map, whose head was just read.
+func (d *cborDecoder) container(major byte, count uint64, indefinite bool) error {
+	if len(d.remaining) >= maxBinaryDepth {
+		return d.errorf("exceeded max depth")
+	}
+	n := int64(count)
+	if indefinite {
+		n = -1
+	} else if count > math.MaxInt64 {
+		return d.errorf("invalid length %d", count)
+	}
+	delim := json.Delim('[')
+	if major == cborMap {
+		delim = json.Delim('{')
+	}
+	if err := d.fs.writeToken(delim, false); err != nil {
+		return err
+	}
+
+	d.remaining = append(d.remaining, n)
+	for d.next() {
+		if major == cborMap {
+			if err := d.key(); err != nil {
+				return err
+			}
+		}
+		if err := d.value(); err != nil {
+			return err
+		}
+	}
+	d.remaining = d.remaining[:len(d.remaining)-1]
+
+	if delim == json.Delim('[') {
+		return d.fs.writeToken(json.Delim(']'), false)
+	}
+	return d.fs.writeToken(json.Delim('}'), false)
+}
+
+// key decodes the key of a map member and writes it as an object key.
+func (d *cborDecoder) key() error {
+	major, info, arg, indefinite, err := d.head()
+	if err != nil {
+		return err
+	}
+	if major == cborArray || major == cborMap || major == cborTag {
+		d.pos--
+		return d.errorf("unsupported map key of major type %d", major)
+	}
+	token, annotation, err := d.scalar(major, info, arg, indefinite)
+	if err != nil {
+		return err
+	}
+
+	var key string
+	switch value := token.(type) {
+	case string:
+		key = value
+	case json.Number:
+		key = value.String()
+	case bool:
+		key = strconv.FormatBool(value)
+	default:
+		key = "null"
+	}
+	if err := d.fs.writeToken(key, false); err != nil {
+		return err
+	}
+	if major != cborText {
+		annotation = cborKeyAnnotation(major, annotation)
+	}
+	if annotation != "" {
+		d.fs.writeAnnotation(annotation, true, true)
+	}
+	return nil
+}
+
+// cborKeyAnnotation returns the annotation of a map key of the given major
+// type other than text, given the annotation of its value, if any.
+func cborKeyAnnotation(major byte, annotation string) string {
+	kind := "simple"
+	switch major {
+	case cborUint, cborNegInt:
+		kind = "int"
+	case cborBytes:
+		kind = "bytes"
+	}
+	if annotation == "" {
+		return kind + " key"
+	}
+	return kind + " key: " + annotation
+}
+
+// tag decodes the content of a tag, whose head was just read.
+func (d *cborDecoder) tag(number uint64) error {
+	if (number == 2 || number == 3) && d.pos < len(d.src) && d.src[d.pos]>>5 == cborBytes {
+		// Bignums are written as the numbers they stand for.
+		_, _, arg, indefinite, err := d.head()
+		if err != nil {
+			return err
+		}
+		b, err := d.bytes(cborBytes, arg, indefinite)
+		if err != nil {
+			return err
+		}
+		n := new(big.Int).SetBytes(b)
+		if number == 3 {
+			n.Neg(n.Add(n, big.NewInt(1)))
+		}
+		return d.fs.writeToken(json.Number(n.String()), false)
+	}
+
+	annotation := "tag " + strconv.FormatUint(number, 10)
+	if name, ok := cborTagNames[number]; ok {
+		annotation += ": " + name
+	}
+	d.fs.writeAnnotation(annotation, false, true)
+	return d.value()
+}
+
+// scalar decodes a data item other than an array, map or tag, whose head was
+// just read, into a JSON token and the annotation to write after it, if any.
+func (d *cborDecoder) scalar(major, info byte, arg uint64, indefinite bool) (json.Token, string, error) {
+	switch major {
+	case cborUint:
+		return json.Number(strconv.FormatUint(arg, 10)), "", nil
+	case cborNegInt:
+		if arg < math.MaxInt64 {
+			return json.Number(strconv.FormatInt(-1-int64(arg), 10)), "", nil
+		}
+		n := new(big.Int).SetUint64(arg)
+		return json.Number(n.Neg(n.Add(n, big.NewInt(1))).String()), "", nil
+	case cborBytes:
+		b, err := d.bytes(major, arg, indefinite)
+		if err != nil {
+			return nil, "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(b), bytesAnnotation(b), nil
+	case cborText:
+		b, err := d.bytes(major, arg, indefinite)
+		if err != nil {
+			return nil, "", err
+		}
+		return string(b), "", nil
+	}
+
+	// Major type 7: simple values and floating-point numbers.
+	switch {
+	case info == 20:
+		return false, "", nil
+	case info == 21:
+		return true, "", nil
+	case info == 22:
+		return nil, "", nil
+	case info == 23:
+		return nil, "undefined", nil
+	case info == 25:
+		return floatToken(halfToFloat(uint16(arg)))
+	case info == 26:
+		return floatToken(float64(math.Float32frombits(uint32(arg))))
+	case info == 27:
+		return floatToken(math.Float64frombits(arg))
+	case indefinite:
+		d.pos--
+		return nil, "", d.errorf("unexpected break code")
+	}
+	return json.Number(strconv.FormatUint(arg, 10)), "simple value", nil
+}
+
+// bytes reads the contents of a byte or text string, whose head was just
+// read, concatenating the chunks of strings of indefinite length.
+func (d *cborDecoder) bytes(major byte, length uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		if length > uint64(len(d.src)-d.pos) {
+			return nil, d.errorf("unexpected end of input")
+		}
+		b := d.src[d.pos : d.pos+int(length)]
+		d.pos += int(length)
+		return b, nil
+	}
+	var b []byte
+	for {
+		if d.pos < len(d.src) && d.src[d.pos] == 0xff {
+			d.pos++
+			return b, nil
+		}
+		chunkMajor, _, chunkLength, chunkIndefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, d.errorf("invalid chunk in string of indefinite length")
+		}
+		chunk, err := d.bytes(major, chunkLength, false)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+}
+
+// halfToFloat converts an IEEE 754 half-precision number to a float64.
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var v float64
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		v = -v
+	}
+	return v
+}
+
+// floatToken returns the JSON token for the floating-point number `v`, along
+// with an annotation for those which JSON can't represent, written as null.
+func floatToken(v float64) (json.Token, string, error) {
+	switch {
+	case math.IsNaN(v):
+		return nil, "NaN", nil
+	case math.IsInf(v, 1):
+		return nil, "Infinity", nil
+	case math.IsInf(v, -1):
+		return nil, "-Infinity", nil
+	}
+	// Let encoding/json format the number, so that it matches the output of Marshal.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", err
+	}
+	return json.Number(b), "", nil
+}
+
+// bytesAnnotation returns the annotation of the byte string `b`.
+func bytesAnnotation(b []byte) string {
+	if len(b) > maxBytesAnnotation {
+		return strconv.Itoa(len(b)) + " bytes"
+	}
+	return "h'" + hex.EncodeToString(b) + "'"
+}
+
+// writeAnnotation writes `text` as a block comment, annotating the input
+// converted to JSON, unless the output is strictly JSON (f.StrictOutput).
+// `trailing` and `more` are as for writeComment.
+func (fs *formatterState) writeAnnotation(text string, trailing, more bool) {
+	if fs.strictOutput {
+		return
+	}
+	fs.writeComment(TokenAnnotation, "/* "+text+" */", trailing, more)
+}
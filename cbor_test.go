@@ -0,0 +1,146 @@
+package jsoncolor
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// formatCBORHex formats the CBOR data given in hexadecimal with `f`, and
+// strips the colors.
+func formatCBORHex(t *testing.T, f *Formatter, h string) (string, error) {
+	t.Helper()
+	src, err := hex.DecodeString(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	err = f.FormatCBOR(&sb, src)
+	return stripANSI(sb.String()), err
+}
+
+func TestFormatCBOR(t *testing.T) {
+	// Examples from RFC 8949 appendix A, with the output annotated and in strict mode.
+	tests := []struct {
+		name, hex, want, strict string
+	}{
+		{"map", "a26161016162820203", `{"a":1,"b":[2,3]}`, `{"a":1,"b":[2,3]}`},
+		{"bytes", "4401020304", `"AQIDBA" /* h'01020304' */`, `"AQIDBA"`},
+		{"int keys", "a201020304", `{"1":/* int key */ 2,"3":/* int key */ 4}`, `{"1":2,"3":4}`},
+		{"negative", "3bffffffffffffffff", `-18446744073709551616`, `-18446744073709551616`},
+		{"half float", "f93c00", `1`, `1`},
+		{"double", "fb3ff199999999999a", `1.1`, `1.1`},
+		{"undefined", "f7", `null /* undefined */`, `null`},
+		{"infinity", "f97c00", `null /* Infinity */`, `null`},
+		{"negative infinity", "f9fc00", `null /* -Infinity */`, `null`},
+		{"NaN", "f97e00", `null /* NaN */`, `null`},
+		{"simple", "f0", `16 /* simple value */`, `16`},
+		// A CBOR sequence is written one value per line.
+		{"sequence", "0102", "1\n2", "1\n2"},
+	}
+	for _, tt := range tests {
+		got, err := formatCBORHex(t, &Formatter{}, tt.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		got, err = formatCBORHex(t, &Formatter{StrictOutput: true}, tt.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.strict {
+			t.Errorf("%s: strict: got %s, want %s", tt.name, got, tt.strict)
+		}
+	}
+}
+
+func TestFormatCBORTags(t *testing.T) {
+	tests := []struct {
+		name, hex, want string
+	}{
+		{"epoch", "c11a514b67b0", "/* tag 1: epoch date/time */\n1363896240"},
+		{"URI", "d82076687474703a2f2f7777772e6578616d706c652e636f6d", "/* tag 32: URI */\n\"http://www.example.com\""},
+		{"self-described", "d9d9f7a0", "/* tag 55799: self-described CBOR */\n{}"},
+		{"unknown", "d90100f5", "/* tag 256 */\ntrue"},
+		// Bignums are written as numbers, without annotation.
+		{"bignum", "c249010000000000000000", "18446744073709551616"},
+		{"negative bignum", "c349010000000000000000", "-18446744073709551617"},
+	}
+	for _, tt := range tests {
+		got, err := formatCBORHex(t, &Formatter{}, tt.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Within a container, the annotation precedes the value on its line.
+	got, err := formatCBORHex(t, &Formatter{Indent: "  "}, "a26161c10101420102")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": /* tag 1: epoch date/time */ 1,\n  \"1\": /* int key */ \"AQI\" /* h'0102' */\n}"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatCBORIndefinite(t *testing.T) {
+	tests := []struct {
+		name, hex, want string
+	}{
+		{"arrays", "9f018202039f0405ffff", `[1,[2,3],[4,5]]`},
+		{"map", "bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"text", "7f657374726561646d696e67ff", `"streaming"`},
+		{"bytes", "5f42010243030405ff", `"AQIDBAU" /* h'0102030405' */`},
+		{"empty", "9fff", `[]`},
+	}
+	for _, tt := range tests {
+		got, err := formatCBORHex(t, &Formatter{}, tt.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCBORErrors(t *testing.T) {
+	tests := []struct {
+		hex, wantErr string
+	}{
+		{"18", "unexpected end of input at offset 1"},
+		{"82", "unexpected end of input at offset 1"},
+		{"9f01", "unexpected end of input at offset 2"},
+		{"61", "unexpected end of input at offset 1"},
+		{"a1820102", "unsupported map key of major type 4 at offset 1"},
+		{"fc", "invalid additional information 28 at offset 1"},
+		{"ff", "unexpected break code at offset 0"},
+		{"5f6161ff", "invalid chunk in string of indefinite length at offset 2"},
+		{strings.Repeat("81", maxBinaryDepth+1) + "01", "exceeded max depth"},
+	}
+	for _, tt := range tests {
+		got, err := formatCBORHex(t, &Formatter{}, tt.hex)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%.20s: got error %v, want one containing %q", tt.hex, err, tt.wantErr)
+		}
+		// The input is decoded entirely before anything is written.
+		if got != "" {
+			t.Errorf("%.20s: wrote %q", tt.hex, got)
+		}
+	}
+}
+
+func TestHalfToFloat(t *testing.T) {
+	tests := map[uint16]float64{0x0000: 0, 0x3c00: 1, 0xc000: -2, 0x7bff: 65504, 0x0001: 5.960464477539063e-8, 0x0400: 6.103515625e-5}
+	for h, want := range tests {
+		if got := halfToFloat(h); got != want {
+			t.Errorf("%#04x: got %v, want %v", h, got, want)
+		}
+	}
+}
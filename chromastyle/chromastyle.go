@@ -0,0 +1,159 @@
+// Package chromastyle builds jsoncolor Formatters and Themes from Chroma
+// styles, so that JSON colorized with jsoncolor matches what tools built on
+// Chroma, such as bat and glamour, show for the same theme, e.g. with a
+// --theme=monokai flag.
+package chromastyle
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/amterp/jsoncolor"
+	"github.com/amterp/jsoncolor/termenvstyle"
+	"github.com/muesli/termenv"
+)
+
+// TokenType returns the Chroma token type which Chroma's JSON lexer gives to
+// the tokens of the kind `kind`.
+func TokenType(kind jsoncolor.TokenKind) chroma.TokenType {
+	switch kind {
+	case jsoncolor.TokenKey:
+		return chroma.NameTag
+	case jsoncolor.TokenString:
+		return chroma.LiteralStringDouble
+	case jsoncolor.TokenNumber:
+		return chroma.LiteralNumberInteger
+	case jsoncolor.TokenBool, jsoncolor.TokenNull:
+		return chroma.KeywordConstant
+	case jsoncolor.TokenObjectDelim, jsoncolor.TokenArrayDelim, jsoncolor.TokenColon, jsoncolor.TokenComma:
+		return chroma.Punctuation
+	case jsoncolor.TokenComment, jsoncolor.TokenAnnotation:
+		return chroma.CommentSingle
+	}
+	return chroma.Text
+}
+
+// Formatter returns a Formatter colorizing each kind of token with the entry
+// of the Chroma style `style` for its TokenType, with colors converted to the
+// termenv profile `p`. Whitespace is left unstyled, and so are backgrounds
+// matching the background of the style, which terminals show as their own.
+func Formatter(style *chroma.Style, p termenv.Profile) *jsoncolor.Formatter {
+	background := style.Get(chroma.Background).Background
+	color := func(tokenType chroma.TokenType) jsoncolor.SprintfFuncer {
+		entry := style.Get(tokenType)
+		t := p.String()
+		if entry.Colour.IsSet() {
+			t = t.Foreground(p.Color(entry.Colour.String()))
+		}
+		if entry.Background.IsSet() && entry.Background != background {
+			t = t.Background(p.Color(entry.Background.String()))
+		}
+		if entry.Bold == chroma.Yes {
+			t = t.Bold()
+		}
+		if entry.Italic == chroma.Yes {
+			t = t.Italic()
+		}
+		if entry.Underline == chroma.Yes {
+			t = t.Underline()
+		}
+		return termenvstyle.From(t)
+	}
+	return &jsoncolor.Formatter{
+		SpaceColor:       jsoncolor.Style{},
+		CommaColor:       color(TokenType(jsoncolor.TokenComma)),
+		ColonColor:       color(TokenType(jsoncolor.TokenColon)),
+		ObjectColor:      color(TokenType(jsoncolor.TokenObjectDelim)),
+		ArrayColor:       color(TokenType(jsoncolor.TokenArrayDelim)),
+		FieldQuoteColor:  color(TokenType(jsoncolor.TokenKey)),
+		FieldColor:       color(TokenType(jsoncolor.TokenKey)),
+		StringQuoteColor: color(TokenType(jsoncolor.TokenString)),
+		StringColor:      color(TokenType(jsoncolor.TokenString)),
+		TrueColor:        color(TokenType(jsoncolor.TokenBool)),
+		FalseColor:       color(TokenType(jsoncolor.TokenBool)),
+		NumberColor:      color(TokenType(jsoncolor.TokenNumber)),
+		NullColor:        color(TokenType(jsoncolor.TokenNull)),
+		CommentColor:     color(TokenType(jsoncolor.TokenComment)),
+		ErrorColor:       color(chroma.Error),
+		// The colors are already converted to `p`.
+		ColorProfile: jsoncolor.ProfileTrueColor,
+	}
+}
+
+// Theme returns a jsoncolor Theme named after the Chroma style `style`,
+// colorizing each kind of token with the entry of the style for its
+// TokenType, like Formatter. Its colors are jsoncolor Styles in 24-bit color,
+// which the Formatter applying the theme converts to the color profile of the
+// terminal (see jsoncolor.Formatter.ColorProfile).
+func Theme(style *chroma.Style) jsoncolor.Theme {
+	background := style.Get(chroma.Background).Background
+	color := func(tokenType chroma.TokenType) jsoncolor.Style {
+		entry := style.Get(tokenType)
+		s := jsoncolor.Style{}
+		if entry.Bold == chroma.Yes {
+			s = append(s, jsoncolor.Bold)
+		}
+		if entry.Italic == chroma.Yes {
+			s = append(s, jsoncolor.Italic)
+		}
+		if entry.Underline == chroma.Yes {
+			s = append(s, jsoncolor.Underline)
+		}
+		if c := entry.Colour; c.IsSet() {
+			s = jsoncolor.RGB(c.Red(), c.Green(), c.Blue(), s...)
+		}
+		if c := entry.Background; c.IsSet() && c != background {
+			s = append(s, 48, 2, jsoncolor.Attribute(c.Red()), jsoncolor.Attribute(c.Green()), jsoncolor.Attribute(c.Blue()))
+		}
+		return s
+	}
+	return jsoncolor.Theme{
+		Name:             style.Name,
+		SpaceColor:       jsoncolor.Style{},
+		CommaColor:       color(TokenType(jsoncolor.TokenComma)),
+		ColonColor:       color(TokenType(jsoncolor.TokenColon)),
+		ObjectColor:      color(TokenType(jsoncolor.TokenObjectDelim)),
+		ArrayColor:       color(TokenType(jsoncolor.TokenArrayDelim)),
+		FieldQuoteColor:  color(TokenType(jsoncolor.TokenKey)),
+		FieldColor:       color(TokenType(jsoncolor.TokenKey)),
+		StringQuoteColor: color(TokenType(jsoncolor.TokenString)),
+		StringColor:      color(TokenType(jsoncolor.TokenString)),
+		TrueColor:        color(TokenType(jsoncolor.TokenBool)),
+		FalseColor:       color(TokenType(jsoncolor.TokenBool)),
+		NumberColor:      color(TokenType(jsoncolor.TokenNumber)),
+		NullColor:        color(TokenType(jsoncolor.TokenNull)),
+		CommentColor:     color(TokenType(jsoncolor.TokenComment)),
+		ErrorColor:       color(chroma.Error),
+	}
+}
+
+// RegisterThemes registers the Theme of each registered Chroma style with
+// jsoncolor.RegisterTheme, so that they can be looked up with
+// jsoncolor.LookupTheme and selected with JSONCOLOR_THEME, except for those
+// whose names are taken by themes already registered, such as the built-in
+// "monokai".
+func RegisterThemes() {
+	for _, name := range styles.Names() {
+		if _, ok := jsoncolor.LookupTheme(name); !ok {
+			jsoncolor.RegisterTheme(Theme(styles.Get(name)))
+		}
+	}
+}
+
+// Get returns the Formatter for the Chroma style registered under `name`,
+// case-insensitively, such as "monokai" or "dracula", with colors converted to
+// the color profile of the terminal as detected from the environment. It
+// returns false if no style is registered under the name.
+func Get(name string) (*jsoncolor.Formatter, bool) {
+	style, ok := styles.Registry[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return Formatter(style, termenv.EnvColorProfile()), true
+}
+
+// Names returns the names of the registered Chroma styles, sorted.
+func Names() []string {
+	return styles.Names()
+}
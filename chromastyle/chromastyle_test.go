@@ -0,0 +1,129 @@
+package chromastyle
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/amterp/jsoncolor"
+	"github.com/muesli/termenv"
+)
+
+var testStyle = chroma.MustNewStyle("test", chroma.StyleEntries{
+	chroma.Background:    "bg:#000000",
+	chroma.NameTag:       "bold #ff0000 bg:#000000",
+	chroma.LiteralString: "italic #00ff00",
+	chroma.LiteralNumber: "#0000ff bg:#ffffff",
+	chroma.Keyword:       "underline #ffff00",
+	chroma.Punctuation:   "#888888",
+})
+
+func TestFormatter(t *testing.T) {
+	tests := []struct {
+		profile termenv.Profile
+		src     string
+		want    string
+	}{
+		// Keys keep no background, since theirs is the background of the style.
+		{termenv.ANSI, `{"a": 1}`, "\x1b[90m{\x1b[0m\x1b[91;1m\"\x1b[0m\x1b[91;1ma\x1b[0m\x1b[91;1m\"\x1b[0m\x1b[90m:\x1b[0m\x1b[94;107m1\x1b[0m\x1b[90m}\x1b[0m"},
+		{termenv.TrueColor, `["b", true]`, "\x1b[38;2;136;136;136m[\x1b[0m\x1b[38;2;0;255;0;3m\"\x1b[0m\x1b[38;2;0;255;0;3mb\x1b[0m\x1b[38;2;0;255;0;3m\"\x1b[0m\x1b[38;2;136;136;136m,\x1b[0m\x1b[38;2;255;255;0;4mtrue\x1b[0m\x1b[38;2;136;136;136m]\x1b[0m"},
+		{termenv.Ascii, `{"a": [null]}`, `{"a":[null]}`},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := Formatter(testStyle, tt.profile).Format(&sb, []byte(tt.src)); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("profile %v: got %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestTokenType(t *testing.T) {
+	tests := []struct {
+		kind jsoncolor.TokenKind
+		want chroma.TokenType
+	}{
+		{jsoncolor.TokenKey, chroma.NameTag},
+		{jsoncolor.TokenString, chroma.LiteralStringDouble},
+		{jsoncolor.TokenNumber, chroma.LiteralNumberInteger},
+		{jsoncolor.TokenNull, chroma.KeywordConstant},
+		{jsoncolor.TokenColon, chroma.Punctuation},
+		{jsoncolor.TokenComment, chroma.CommentSingle},
+	}
+	for _, tt := range tests {
+		if got := TokenType(tt.kind); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	if f, ok := Get("Monokai"); !ok || f == nil {
+		t.Error("monokai not found")
+	}
+	if _, ok := Get("no such style"); ok {
+		t.Error("found a style that isn't registered")
+	}
+	names := Names()
+	if !sort.StringsAreSorted(names) {
+		t.Error("names aren't sorted")
+	}
+	for _, name := range names {
+		if _, ok := Get(name); !ok {
+			t.Errorf("%s: not found", name)
+		}
+	}
+}
+
+func TestTheme(t *testing.T) {
+	theme := Theme(testStyle)
+	if theme.Name != "test" {
+		t.Errorf("got name %q", theme.Name)
+	}
+	tests := []struct {
+		name  string
+		color jsoncolor.SprintfFuncer
+		want  jsoncolor.Style
+	}{
+		// Backgrounds matching that of the style are left out.
+		{"field", theme.FieldColor, jsoncolor.Style{jsoncolor.Bold, 38, 2, 255, 0, 0}},
+		{"string", theme.StringColor, jsoncolor.Style{jsoncolor.Italic, 38, 2, 0, 255, 0}},
+		{"number", theme.NumberColor, jsoncolor.Style{38, 2, 0, 0, 255, 48, 2, 255, 255, 255}},
+		{"true", theme.TrueColor, jsoncolor.Style{jsoncolor.Underline, 38, 2, 255, 255, 0}},
+		{"comma", theme.CommaColor, jsoncolor.Style{38, 2, 136, 136, 136}},
+		{"space", theme.SpaceColor, jsoncolor.Style{}},
+	}
+	for _, tt := range tests {
+		if got, ok := tt.color.(jsoncolor.Style); !ok || !equalStyles(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.color, tt.want)
+		}
+	}
+}
+
+// equalStyles reports whether `a` and `b` have the same attributes.
+func equalStyles(a, b jsoncolor.Style) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRegisterThemes(t *testing.T) {
+	builtin, _ := jsoncolor.LookupTheme("monokai")
+	RegisterThemes()
+	// The built-in themes are kept.
+	if got, _ := jsoncolor.LookupTheme("monokai"); !equalStyles(got.FieldColor.(jsoncolor.Style), builtin.FieldColor.(jsoncolor.Style)) {
+		t.Errorf("monokai was replaced")
+	}
+	if theme, ok := jsoncolor.LookupTheme("github"); !ok || theme.Name != "github" {
+		t.Errorf("github: got %q, %v", theme.Name, ok)
+	}
+}
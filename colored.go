@@ -0,0 +1,300 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SpanMeta describes a token of the output parsed by ParseColored.
+type SpanMeta struct {
+	// Start and End are the offsets of the token in the plain JSON.
+	Start, End int
+	// Kind is the kind of the token. Whitespace isn't described by spans, and
+	// annotations, which look like any other comment, are TokenComment.
+	Kind TokenKind
+	// Path is the JSON Pointer (RFC 6901) of the value the token belongs to,
+	// as in Segment.Path.
+	Path string
+	// Style holds the SGR parameters in effect at the start of the token, so
+	// that Style.Sprint renders it as it was. It's empty for unstyled text.
+	Style Style
+}
+
+// ParseColored reverses the output of Format and the Encoders, as read by a
+// tool downstream of a colorizing process: it returns the JSON in `colored`
+// without escape sequences, and a span for each token of it giving its kind,
+// the path of its value and its style, recovering the structure of the output
+// without access to the original. A header written with Formatter.Header is
+// skipped, and indent guides are replaced with spaces, so that `plain` is JSON again, or
+// JSONC or JSON5 if the output kept the syntax of such input.
+//
+// Escape sequences other than SGR ones are dropped. The placeholders of
+// Skeleton output, whose kind is lost, are given the kind TokenNull. It
+// returns an error if `colored` doesn't lex as JSON once the escape sequences
+// are removed.
+func ParseColored(colored []byte) (plain []byte, spans []SpanMeta, err error) {
+	_, colored, _ = CutHeader(colored)
+
+	// Remove the escape sequences, recording where the style changes.
+	type styleChange struct {
+		offset int
+		style  Style
+	}
+	var text []byte
+	var changes []styleChange
+	var state sgrState
+	var style Style
+	for len(colored) > 0 {
+		i := bytes.IndexByte(colored, '\x1b')
+		if i < 0 {
+			i = len(colored)
+		}
+		text = append(text, colored[:i]...)
+		colored = colored[i:]
+		if len(colored) == 0 {
+			break
+		}
+		params, final, n := escapeSequence(colored)
+		colored = colored[n:]
+		if final != 'm' {
+			continue
+		}
+		state.apply(params)
+		style = state.style()
+		if len(changes) > 0 && changes[len(changes)-1].offset == len(text) {
+			changes[len(changes)-1].style = style
+		} else {
+			changes = append(changes, styleChange{len(text), style})
+		}
+	}
+	// styleAt returns the style at `offset`, for increasing offsets.
+	style = nil
+	styleAt := func(offset int) Style {
+		for len(changes) > 0 && changes[0].offset <= offset {
+			style, changes = changes[0].style, changes[1:]
+		}
+		return style
+	}
+
+	// Lex the text, tracking the path of each value.
+	type container struct {
+		path, key string
+		object    bool
+		count     int
+	}
+	var stack []container
+	childPath := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+		c := stack[len(stack)-1]
+		if c.object {
+			return c.path + "/" + escapePointerToken(c.key)
+		}
+		return c.path + "/" + strconv.Itoa(c.count)
+	}
+	// expectKey is true where an object key may follow.
+	expectKey := false
+	plain = make([]byte, 0, len(text))
+	for i := 0; i < len(text); {
+		start := i
+		c := text[i]
+		var kind TokenKind
+		path := ""
+		switch {
+		case isSpace(c):
+			plain = append(plain, c)
+			i++
+			continue
+		case bytes.HasPrefix(text[i:], []byte(indentGuide)):
+			// A guide takes the place of a space of the indentation.
+			plain = append(plain, ' ')
+			i += len(indentGuide)
+			continue
+		case c == '{' || c == '[':
+			kind, path = TokenArrayDelim, childPath()
+			if c == '{' {
+				kind = TokenObjectDelim
+			}
+			stack = append(stack, container{path: path, object: c == '{'})
+			expectKey = c == '{'
+			i++
+		case c == '}' || c == ']':
+			if len(stack) == 0 || stack[len(stack)-1].object != (c == '}') {
+				return nil, nil, fmt.Errorf("jsoncolor: error parsing colored output: unexpected %q at offset %d", c, len(plain))
+			}
+			kind, path = TokenArrayDelim, stack[len(stack)-1].path
+			if c == '}' {
+				kind = TokenObjectDelim
+			}
+			stack = stack[:len(stack)-1]
+			expectKey = false
+			i++
+		case c == ':' || c == ',':
+			kind = TokenColon
+			if c == ',' {
+				kind = TokenComma
+				if len(stack) > 0 {
+					stack[len(stack)-1].count++
+					expectKey = stack[len(stack)-1].object
+				}
+			}
+			if len(stack) > 0 {
+				path = stack[len(stack)-1].path
+			}
+			i++
+		case c == '/' && i+1 < len(text) && (text[i+1] == '/' || text[i+1] == '*'):
+			kind = TokenComment
+			end := []byte("\n")
+			if text[i+1] == '*' {
+				end = []byte("*/")
+			}
+			n := bytes.Index(text[i+2:], end)
+			if n < 0 {
+				i = len(text)
+			} else {
+				i += 2 + n
+				if end[0] == '*' {
+					i += len(end)
+				}
+			}
+			if len(stack) > 0 {
+				path = stack[len(stack)-1].path
+			}
+		default:
+			i += colorScanValue(text[i:])
+			if i == start {
+				r, _ := utf8.DecodeRune(text[i:])
+				return nil, nil, fmt.Errorf("jsoncolor: error parsing colored output: unexpected %q at offset %d", r, len(plain))
+			}
+			value := string(text[start:i])
+			switch {
+			case expectKey:
+				kind = TokenKey
+				key := value
+				if c == '"' || c == '\'' {
+					if unquoted, err := strconv.Unquote(value); err == nil {
+						key = unquoted
+					} else {
+						key = value[1 : len(value)-1]
+					}
+				}
+				stack[len(stack)-1].key = key
+				expectKey = false
+				path = childPath()
+			case c == '"' || c == '\'':
+				kind, path = TokenString, childPath()
+			case value == "true" || value == "false":
+				kind, path = TokenBool, childPath()
+			case value == "null" || value == skeletonPlaceholder:
+				kind, path = TokenNull, childPath()
+			default:
+				kind, path = TokenNumber, childPath()
+			}
+		}
+		spans = append(spans, SpanMeta{
+			Start: len(plain),
+			End:   len(plain) + i - start,
+			Kind:  kind,
+			Path:  path,
+			Style: styleAt(start),
+		})
+		plain = append(plain, text[start:i]...)
+	}
+	if len(stack) > 0 {
+		return nil, nil, fmt.Errorf("jsoncolor: error parsing colored output: unexpected end of input")
+	}
+	return plain, spans, nil
+}
+
+// colorScanValue returns the length of the string, number, literal or
+// placeholder at the start of `b`, or 0 if there is none.
+func colorScanValue(b []byte) int {
+	if b[0] == '"' || b[0] == '\'' {
+		for i := 1; i < len(b); i++ {
+			switch b[i] {
+			case '\\':
+				i++
+			case b[0]:
+				return i + 1
+			}
+		}
+		return 0
+	}
+	if bytes.HasPrefix(b, []byte(skeletonPlaceholder)) {
+		return len(skeletonPlaceholder)
+	}
+	// Numbers, literals, and the identifiers of JSON5.
+	i := 0
+	for i < len(b) && !isSpace(b[i]) && !strings.ContainsRune(`{}[]:,"'/`, rune(b[i])) && b[i] != '\x1b' {
+		i++
+	}
+	return i
+}
+
+// sgrState holds the attributes set by SGR escape sequences, like cssStyle,
+// as the Style setting them.
+type sgrState struct {
+	attrs  [CrossedOut + 1]bool // Indexed by text attribute.
+	fg, bg Style                // Including the parameters of extended colors.
+}
+
+// apply applies the SGR parameters `params`, e.g. "1;31".
+func (s *sgrState) apply(params string) {
+	var codes []int
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p) // An empty parameter means 0.
+		codes = append(codes, n)
+	}
+	for i := 0; i < len(codes); i++ {
+		switch n := codes[i]; {
+		case n == 0:
+			*s = sgrState{}
+		case n >= int(Bold) && n <= int(CrossedOut):
+			s.attrs[n] = true
+		case n == 22:
+			s.attrs[Bold], s.attrs[Faint] = false, false
+		case n >= 23 && n <= 29:
+			s.attrs[n-20] = false
+			if n == 25 {
+				s.attrs[BlinkRapid] = false
+			}
+		case n >= 30 && n <= 37, n >= 90 && n <= 97:
+			s.fg = Style{Attribute(n)}
+		case n == 39:
+			s.fg = nil
+		case n >= 40 && n <= 47, n >= 100 && n <= 107:
+			s.bg = Style{Attribute(n)}
+		case n == 49:
+			s.bg = nil
+		case n == 38 || n == 48:
+			_, used := extendedColor(codes[i+1:])
+			color := Style{}
+			for _, code := range codes[i : i+1+used] {
+				color = append(color, Attribute(code))
+			}
+			i += used
+			if n == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// style returns the Style setting the attributes of the state, or nil if
+// none are set.
+func (s *sgrState) style() Style {
+	var style Style
+	for attr, on := range s.attrs {
+		if on {
+			style = append(style, Attribute(attr))
+		}
+	}
+	style = append(style, s.fg...)
+	return append(style, s.bg...)
+}
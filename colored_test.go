@@ -0,0 +1,115 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const coloredInput = `{"a/b": [1, "x\"y", true, {"c": null}], "d~": []}`
+
+func TestParseColoredRoundTrip(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	var colored bytes.Buffer
+	if err := f.Format(&colored, []byte(coloredInput)); err != nil {
+		t.Fatal(err)
+	}
+	plain, spans, err := ParseColored(colored.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := stripANSI(colored.String()); string(plain) != want {
+		t.Errorf("got plain:\n%s\nwant:\n%s", plain, want)
+	}
+
+	// The spans are the segments of the output other than whitespace.
+	segments, err := f.Segments([]byte(coloredInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []Segment
+	for _, s := range segments {
+		if s.Kind != TokenWhitespace {
+			want = append(want, s)
+		}
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("got %d spans, want %d", len(spans), len(want))
+	}
+	for i, span := range spans {
+		got := Segment{Text: string(plain[span.Start:span.End]), Kind: span.Kind, Path: span.Path}
+		if got != want[i] {
+			t.Errorf("span %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// Rendering each span in its style gives output parsed the same way.
+	var rendered strings.Builder
+	end := 0
+	for _, span := range spans {
+		rendered.Write(plain[end:span.Start])
+		rendered.WriteString(span.Style.Sprint(string(plain[span.Start:span.End])))
+		end = span.End
+	}
+	rendered.Write(plain[end:])
+	replain, respans, err := ParseColored([]byte(rendered.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replain, plain) || !reflect.DeepEqual(respans, spans) {
+		t.Errorf("rendered output parsed differently:\n%q", rendered.String())
+	}
+}
+
+func TestParseColoredStyles(t *testing.T) {
+	colored := "\x1b[1;34m\"k\"\x1b[0m: \x1b[38;5;208;4m1\x1b[24m\x1b[2K\x1b[0m"
+	plain, spans, err := ParseColored([]byte("{" + colored + "}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"k": 1}`; string(plain) != want {
+		t.Errorf("got %q, want %q", plain, want)
+	}
+	want := []SpanMeta{
+		{0, 1, TokenObjectDelim, "", nil},
+		{1, 4, TokenKey, "/k", Style{Bold, FgBlue}},
+		{4, 5, TokenColon, "", nil},
+		{6, 7, TokenNumber, "/k", Style{Underline, 38, 5, 208}},
+		{7, 8, TokenObjectDelim, "", nil},
+	}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("got %+v\nwant %+v", spans, want)
+	}
+}
+
+func TestParseColoredSyntax(t *testing.T) {
+	// Headers are skipped, indent guides become spaces, and JSON5 is lexed.
+	src := `// jsoncolor {"version":1,"sha256":"ab"}` + "\n" +
+		"{\n" + indentGuide + " a: 'x', // c\n" + indentGuide + " b: [" + skeletonPlaceholder + "]\n}"
+	plain, spans, err := ParseColored([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  a: 'x', // c\n  b: [" + skeletonPlaceholder + "]\n}"; string(plain) != want {
+		t.Errorf("got %q, want %q", plain, want)
+	}
+	var kinds []string
+	for _, span := range spans {
+		kinds = append(kinds, span.Kind.String()+" "+span.Path)
+	}
+	want := []string{"object-delim ", "key /a", "colon ", "string /a", "comma ", "comment ",
+		"key /b", "colon ", "array-delim /b", "null /b/0", "array-delim /b", "object-delim "}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("got %q\nwant %q", kinds, want)
+	}
+}
+
+func TestParseColoredErrors(t *testing.T) {
+	for _, src := range []string{`[1}`, `{"a": 1`, `]`, `{"a": "b}`, "\x1b[31m[\"a]\x1b[0m"} {
+		if _, _, err := ParseColored([]byte(src)); err == nil {
+			t.Errorf("%q: got no error", src)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatterColorize(t *testing.T) {
+	withColor(t, true)
+	tests := []struct {
+		name     string
+		colorize map[TokenKind]bool
+		want     string
+	}{
+		{"all", nil, "{\x1b[34;1m\"\x1b[0m\x1b[34;1ma\x1b[0m\x1b[34;1m\"\x1b[0m:[\x1b[96m1\x1b[0m,\x1b[32m\"\x1b[0m\x1b[32mb\x1b[0m\x1b[32m\"\x1b[0m,\x1b[3mtrue\x1b[0m]}"},
+		// The quotes of keys are colorized with them.
+		{"keys", map[TokenKind]bool{TokenKey: true}, "{\x1b[34;1m\"\x1b[0m\x1b[34;1ma\x1b[0m\x1b[34;1m\"\x1b[0m:[1,\"b\",true]}"},
+		{"values", map[TokenKind]bool{TokenNumber: true, TokenBool: true, TokenKey: false}, "{\"a\":[\x1b[96m1\x1b[0m,\"b\",\x1b[3mtrue\x1b[0m]}"},
+		{"none", map[TokenKind]bool{}, `{"a":[1,"b",true]}`},
+	}
+	for _, tt := range tests {
+		f := styledFormatter()
+		f.Indent = ""
+		f.Colorize = tt.colorize
+		var sb strings.Builder
+		if err := f.Format(&sb, []byte(`{"a": [1, "b", true]}`)); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatterColorizeAnnotations(t *testing.T) {
+	withColor(t, true)
+	// Annotations are colorized apart from comments, in the comment color.
+	f := plainCompactFormatter()
+	f.CommentColor = Style{Faint}
+	f.Colorize = map[TokenKind]bool{TokenAnnotation: true}
+	var sb strings.Builder
+	if err := f.FormatMsgPack(&sb, []byte{0xd4, 0x05, 0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "\"AQ\" \x1b[2m/* ext 5: h'01' */\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	sb.Reset()
+	f.Syntax = SyntaxJSONC
+	if err := f.Format(&sb, []byte(`[1 /* c */]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "[1 /* c */]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
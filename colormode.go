@@ -0,0 +1,119 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/amterp/color"
+)
+
+// ColorMode controls whether an Encoder writes colors. See
+// Encoder.SetColorMode.
+type ColorMode int
+
+const (
+	// ColorDefault leaves the decision to the colors of the Formatter. Like
+	// github.com/amterp/color, the default colors and Styles are disabled
+	// when standard output is not a terminal, or NO_COLOR is set.
+	ColorDefault ColorMode = iota
+	// ColorAuto writes colors if the destination writer is a terminal, like
+	// os.Stdout in an interactive shell. The NO_COLOR environment variable,
+	// if set to a non-empty value, disables colors regardless, as does
+	// TERM=dumb, and CLICOLOR_FORCE, if set to a value other than "0",
+	// enables them regardless of the destination (see https://no-color.org
+	// and https://bixense.com/clicolors).
+	ColorAuto
+	// ColorAlways always writes colors, even where the color package would
+	// disable them, such as when writing to a file or a pipe.
+	ColorAlways
+	// ColorNever never writes colors.
+	ColorNever
+)
+
+// WithColorMode sets whether colors are written, like Encoder.SetColorMode.
+func WithColorMode(m ColorMode) EncodeOption {
+	return func(o *encodeOptions) {
+		o.colorMode = m
+	}
+}
+
+// SetColorMode sets whether the Encoder writes colors, such as ColorAuto to
+// only write colors to terminals, as decided for its writer at each call to
+// Encode. The default, ColorDefault, leaves the decision to the colors of the
+// Formatter.
+//
+// ColorAlways forces the colors of the Formatter which are *color.Color or
+// Style values, including the default ones, and other SprintfFuncers decide
+// on their own. ColorNever writes no colors at all.
+func (enc *Encoder) SetColorMode(m ColorMode) {
+	enc.opts = enc.opts.with(WithColorMode(m))
+}
+
+// SetColors specifies whether the Encoder writes colors, regardless of its
+// writer, e.g. enc.SetColors(isTTY), so that the same code can write colored
+// output to a terminal and plain output to a log file. It's a shorthand for
+// SetColorMode with ColorAlways or ColorNever.
+func (enc *Encoder) SetColors(enabled bool) {
+	if enabled {
+		enc.SetColorMode(ColorAlways)
+	} else {
+		enc.SetColorMode(ColorNever)
+	}
+}
+
+// resolve returns ColorAlways or ColorNever for ColorAuto, as decided for the
+// writer `w`, and the mode itself otherwise.
+func (m ColorMode) resolve(w io.Writer) ColorMode {
+	if m != ColorAuto {
+		return m
+	}
+	switch {
+	case os.Getenv("NO_COLOR") != "":
+		return ColorNever
+	case os.Getenv("CLICOLOR_FORCE") != "" && os.Getenv("CLICOLOR_FORCE") != "0":
+		return ColorAlways
+	case os.Getenv("TERM") == "dumb" || !isTerminal(w):
+		return ColorNever
+	}
+	return ColorAlways
+}
+
+// colorFunc returns the function resolving the colorizing function of a color
+// for output to `dst`, according to the color mode, with colors converted to
+// the profile `profile`.
+func (o encodeOptions) colorFunc(dst io.Writer, profile ColorProfile) func(SprintfFuncer) func(format string, a ...interface{}) string {
+	switch o.colorMode.resolve(dst) {
+	case ColorNever:
+		return func(SprintfFuncer) func(format string, a ...interface{}) string {
+			return fmt.Sprintf
+		}
+	case ColorAlways:
+		return func(c SprintfFuncer) func(format string, a ...interface{}) string {
+			return profile.degrade(forceColor(c).SprintfFunc())
+		}
+	}
+	return func(c SprintfFuncer) func(format string, a ...interface{}) string {
+		return profile.degrade(c.SprintfFunc())
+	}
+}
+
+// forceColor returns the color `c` enabled regardless of the color package
+// disabling colors, if it's a *color.Color or a Style.
+func forceColor(c SprintfFuncer) SprintfFuncer {
+	switch c := c.(type) {
+	case *color.Color:
+		// The copy is enabled on its own, leaving `c` as it is.
+		forced := *c
+		forced.EnableColor()
+		return &forced
+	case Style:
+		if len(c) == 0 {
+			return c
+		}
+		return StyleFunc(func(s string) string {
+			return c.sequence() + s + "\x1b[0m"
+		})
+	}
+	return c
+}
@@ -0,0 +1,107 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+// encodeColorMode encodes `v` with the color mode `m`, using a Formatter
+// coloring numbers only.
+func encodeColorMode(t *testing.T, m ColorMode, v any) string {
+	t.Helper()
+	f := plainCompactFormatter()
+	f.NumberColor = Style{FgHiCyan}
+	var sb strings.Builder
+	enc := NewEncoderWithFormatter(&sb, f, WithColorMode(m))
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestColorMode(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	const colored, plain = "[\x1b[96m1\x1b[0m]\n", "[1]\n"
+	tests := []struct {
+		name    string
+		enabled bool
+		mode    ColorMode
+		want    string
+	}{
+		{"default enabled", true, ColorDefault, colored},
+		{"default disabled", false, ColorDefault, plain},
+		{"always", false, ColorAlways, colored},
+		{"never", true, ColorNever, plain},
+		// A strings.Builder isn't a terminal.
+		{"auto", true, ColorAuto, plain},
+	}
+	for _, tt := range tests {
+		withColor(t, tt.enabled)
+		if got := encodeColorMode(t, tt.mode, []int{1}); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestColorModeEnvironment(t *testing.T) {
+	withColor(t, false)
+	tests := []struct {
+		noColor, force, term string
+		want                 ColorMode
+	}{
+		{"", "", "", ColorNever},
+		{"", "1", "", ColorAlways},
+		{"", "0", "", ColorNever},
+		{"", "1", "dumb", ColorAlways},
+		// NO_COLOR takes precedence over CLICOLOR_FORCE.
+		{"1", "1", "", ColorNever},
+	}
+	for _, tt := range tests {
+		t.Setenv("NO_COLOR", tt.noColor)
+		t.Setenv("CLICOLOR_FORCE", tt.force)
+		t.Setenv("TERM", tt.term)
+		if got := ColorAuto.resolve(&strings.Builder{}); got != tt.want {
+			t.Errorf("NO_COLOR=%q CLICOLOR_FORCE=%q TERM=%q: got %d, want %d", tt.noColor, tt.force, tt.term, got, tt.want)
+		}
+	}
+	// Other modes don't depend on the environment.
+	if got := ColorNever.resolve(&strings.Builder{}); got != ColorNever {
+		t.Errorf("ColorNever resolved to %d", got)
+	}
+}
+
+func TestSetColorMode(t *testing.T) {
+	withColor(t, false)
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	enc.SetColorMode(ColorAlways)
+	if err := enc.Encode(true); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); !strings.Contains(got, "\x1b[") {
+		t.Errorf("got %q, want colors", got)
+	}
+}
+
+func TestSetColors(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		// The Encoder decides whatever the color package does.
+		withColor(t, !enabled)
+		f := plainCompactFormatter()
+		f.NumberColor = Style{FgHiCyan}
+		var sb strings.Builder
+		enc := NewEncoderWithFormatter(&sb, f)
+		enc.SetColors(enabled)
+		if err := enc.Encode(1); err != nil {
+			t.Fatal(err)
+		}
+		want := "1\n"
+		if enabled {
+			want = "\x1b[96m1\x1b[0m\n"
+		}
+		if got := sb.String(); got != want {
+			t.Errorf("SetColors(%v): got %q, want %q", enabled, got, want)
+		}
+	}
+}
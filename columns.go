@@ -0,0 +1,135 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ColumnsOptions configures FormatColumns.
+type ColumnsOptions struct {
+	// Width is the width of the output in columns, typically the width of the
+	// terminal. If zero or negative, 80 is used.
+	Width int
+	// Gap is the number of spaces between columns. If zero or negative, 2 is used.
+	Gap int
+}
+
+// Defaults for ColumnsOptions.
+const (
+	defaultColumnsWidth = 80
+	defaultColumnsGap   = 2
+)
+
+// FormatColumns formats the elements of a top-level array side by side, using
+// the DefaultFormatter. See Formatter.FormatColumns.
+func FormatColumns(dst io.Writer, src []byte, opts ColumnsOptions) error {
+	return DefaultFormatter.FormatColumns(dst, src, opts)
+}
+
+// FormatColumns formats the elements of the top-level array in `src` and lays
+// them out side by side in as many columns as fit within opts.Width, like `ls`
+// lists files, so that many small records can be scanned at once. Elements
+// are placed down the columns first, in order, and each column is as wide as
+// its widest line. The array's brackets and commas are left out, so the output
+// is not valid JSON. Every line, including the last, ends with a newline.
+//
+// If `src` is not an array, or an empty one, it's formatted as by Format,
+// followed by a newline.
+func (f *Formatter) FormatColumns(dst io.Writer, src []byte, opts ColumnsOptions) error {
+	width := opts.Width
+	if width <= 0 {
+		width = defaultColumnsWidth
+	}
+	gap := opts.Gap
+	if gap <= 0 {
+		gap = defaultColumnsGap
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(src, &elements); err != nil || len(elements) == 0 {
+		// Either not an array, or invalid JSON, which format reports.
+		return f.format(dst, src, true)
+	}
+
+	// Format each element as a block of lines, and measure it.
+	blocks := make([][]string, len(elements))
+	widths := make([]int, len(elements))
+	buf := &bytes.Buffer{}
+	for i, element := range elements {
+		buf.Reset()
+		if err := f.format(buf, element, false); err != nil {
+			return err
+		}
+		blocks[i] = strings.Split(buf.String(), "\n")
+		for _, line := range blocks[i] {
+			widths[i] = max(widths[i], displayWidth(line))
+		}
+	}
+
+	// Find the largest number of columns that fits, starting from a single row.
+	rows := 1
+	var columnWidths []int
+	for ; rows <= len(blocks); rows++ {
+		columnWidths = columnWidths[:0]
+		total := 0
+		for start := 0; start < len(blocks); start += rows {
+			columnWidth := 0
+			for _, w := range widths[start:min(start+rows, len(blocks))] {
+				columnWidth = max(columnWidth, w)
+			}
+			columnWidths = append(columnWidths, columnWidth)
+			total += columnWidth
+		}
+		total += gap * (len(columnWidths) - 1)
+		if total <= width || len(columnWidths) == 1 {
+			break
+		}
+	}
+
+	// Gather the lines of each column.
+	columns := make([][]string, len(columnWidths))
+	height := 0
+	for i, block := range blocks {
+		c := i / rows
+		columns[c] = append(columns[c], block...)
+		height = max(height, len(columns[c]))
+	}
+
+	out := &strings.Builder{}
+	for line := 0; line < height; line++ {
+		// Padding is only written before further content, to avoid trailing whitespace.
+		pending := 0
+		for c, column := range columns {
+			if line < len(column) {
+				out.WriteString(strings.Repeat(" ", pending))
+				out.WriteString(column[line])
+				pending = columnWidths[c] - displayWidth(column[line]) + gap
+			} else {
+				pending += columnWidths[c] + gap
+			}
+		}
+		out.WriteByte('\n')
+	}
+	_, err := io.WriteString(dst, out.String())
+	return err
+}
+
+// displayWidth returns the number of characters `s` takes up when displayed,
+// i.e. its number of runes, not counting ANSI escape sequences.
+func displayWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[':
+			// Skip to the final byte of the sequence.
+			for i += 2; i < len(s) && s[i] < 0x40; i++ {
+			}
+		case utf8.RuneStart(s[i]):
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatColumns(t *testing.T) {
+	const src = `[{"id":1},{"id":22},{"id":333},{"id":4}]`
+	tests := []struct {
+		width int
+		want  string
+	}{
+		// All four elements fit on one row.
+		{80, "{          {           {            {\n" +
+			"  \"id\": 1    \"id\": 22    \"id\": 333    \"id\": 4\n" +
+			"}          }           }            }\n"},
+		// Two columns of two elements each.
+		{30, "{           {\n" +
+			"  \"id\": 1     \"id\": 333\n" +
+			"}           }\n" +
+			"{           {\n" +
+			"  \"id\": 22    \"id\": 4\n" +
+			"}           }\n"},
+		// A single column, even if too wide.
+		{5, "{\n  \"id\": 1\n}\n{\n  \"id\": 22\n}\n{\n  \"id\": 333\n}\n{\n  \"id\": 4\n}\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := newPlainFormatter().FormatColumns(&buf, []byte(src), ColumnsOptions{Width: tt.width}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("width %d: got:\n%s\nwant:\n%s", tt.width, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestFormatColumnsNotArray(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newPlainFormatter().FormatColumns(&buf, []byte(`{"a":1}`), ColumnsOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": 1\n}\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+	if err := FormatColumns(&buf, []byte(`[1,`), ColumnsOptions{}); err == nil {
+		t.Error("invalid input: got no error")
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	if got := displayWidth("\x1b[1;34m\"é\"\x1b[0m: 1"); got != 6 {
+		t.Errorf("got %d, want 6", got)
+	}
+}
@@ -0,0 +1,118 @@
+package jsoncolor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"slices"
+	"sync"
+)
+
+// decompressor decompresses the data starting with its magic bytes.
+type decompressor struct {
+	magic []byte
+	open  func(io.Reader) (io.ReadCloser, error)
+}
+
+// decompressors holds the decompressors tried by Decompress, gzip and those
+// registered with RegisterDecompressor.
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = []decompressor{{magic: []byte{0x1f, 0x8b}, open: openGzip}}
+)
+
+// openGzip returns a reader of the gzip data read from `r`.
+func openGzip(r io.Reader) (io.ReadCloser, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return zr, nil
+}
+
+// RegisterDecompressor makes Decompress, and so FormatReader, decompress the
+// data starting with the magic bytes `magic` with `open`, which returns a
+// reader of the decompressed data read from its argument. It replaces the
+// decompressor previously registered for the same magic bytes, if any.
+//
+// Only gzip is supported out of the box. Packages adding other formats
+// register them when imported, like github.com/amterp/jsoncolor/zstd:
+//
+//	import _ "github.com/amterp/jsoncolor/zstd"
+func RegisterDecompressor(magic []byte, open func(io.Reader) (io.ReadCloser, error)) {
+	if len(magic) == 0 {
+		panic("jsoncolor: cannot register a decompressor without magic bytes")
+	}
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	// The slice is copied rather than modified, since Decompress uses it
+	// without holding the lock.
+	updated := slices.DeleteFunc(slices.Clone(decompressors), func(d decompressor) bool {
+		return bytes.Equal(d.magic, magic)
+	})
+	decompressors = append(updated, decompressor{magic: bytes.Clone(magic), open: open})
+}
+
+// FormatReader formats the JSON read from `src` to `dst` using the
+// DefaultFormatter. See Formatter.FormatReader.
+func FormatReader(dst io.Writer, src io.Reader) error {
+	return DefaultFormatter.FormatReader(dst, src)
+}
+
+// FormatReader is like Format, reading the JSON to format from `src`. Input
+// compressed with gzip, or another format registered with
+// RegisterDecompressor, such as an API dump or a log archive, is detected by
+// its magic bytes and decompressed first. See Decompress.
+func (f *Formatter) FormatReader(dst io.Writer, src io.Reader) error {
+	r, err := Decompress(src)
+	if err != nil {
+		return err
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return f.Format(dst, data)
+}
+
+// Decompress returns a reader of the data read from `r`, decompressed if it
+// starts with the magic bytes of gzip, or of a format registered with
+// RegisterDecompressor, such as zstd, or else as is. Concatenated gzip members
+// are decompressed one after the other, like gzip -d does. It returns an
+// error if the header of compressed data is invalid.
+//
+// The returned reader is an io.Closer for compressed data, which should be
+// closed to release the resources of the decompressor. It's useful to accept
+// compressed input wherever a reader of JSON is expected, as with
+// FormatLines:
+//
+//	r, err := jsoncolor.Decompress(file)
+//	if err != nil {
+//		return err
+//	}
+//	if c, ok := r.(io.Closer); ok {
+//		defer c.Close()
+//	}
+//	return jsoncolor.FormatLines(os.Stdout, r, jsoncolor.LinesOptions{})
+func Decompress(r io.Reader) (io.Reader, error) {
+	decompressorsMu.RLock()
+	registered := decompressors
+	decompressorsMu.RUnlock()
+
+	n := 0
+	for _, d := range registered {
+		n = max(n, len(d.magic))
+	}
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(n)
+	for _, d := range registered {
+		if bytes.HasPrefix(magic, d.magic) {
+			return d.open(br)
+		}
+	}
+	return br, nil
+}
@@ -0,0 +1,118 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+// gzipData compresses each of `members` as a gzip member, one after the other.
+func gzipData(t *testing.T, members ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, m := range members {
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        []byte
+		want       string
+		compressed bool
+	}{
+		{"plain", []byte(`{"a":1}`), `{"a":1}`, false},
+		{"short", []byte(`1`), `1`, false},
+		{"empty", nil, ``, false},
+		{"gzip", gzipData(t, `{"a":1}`), `{"a":1}`, true},
+		{"gzip members", gzipData(t, "1\n", "2\n"), "1\n2\n", true},
+	}
+	for _, tt := range tests {
+		r, err := Decompress(bytes.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		// Only the decompressors have resources to release.
+		closer, isCloser := r.(io.Closer)
+		if isCloser != tt.compressed {
+			t.Errorf("%s: io.Closer is %v, want %v", tt.name, isCloser, tt.compressed)
+		}
+		if isCloser {
+			closer.Close()
+		}
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	// A gzip header is checked by Decompress.
+	if _, err := Decompress(bytes.NewReader([]byte{0x1f, 0x8b, 0})); err == nil {
+		t.Error("gzip: no error for an invalid header")
+	}
+}
+
+func TestFormatReader(t *testing.T) {
+	for name, src := range map[string][]byte{
+		"plain": []byte(`{"a": [1, 2]}`),
+		"gzip":  gzipData(t, `{"a": [1, 2]}`),
+	} {
+		var buf bytes.Buffer
+		if err := newPlainFormatter().FormatReader(&buf, bytes.NewReader(src)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := "{\n  \"a\": [\n    1,\n    2\n  ]\n}"
+		if got := buf.String(); got != want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+}
+
+func TestRegisterDecompressor(t *testing.T) {
+	t.Cleanup(func() {
+		decompressorsMu.Lock()
+		decompressors = decompressors[:1]
+		decompressorsMu.Unlock()
+	})
+	// A made-up format, whose data follows the magic bytes in uppercase.
+	upper := func(r io.Reader) (io.ReadCloser, error) {
+		magic := make([]byte, 3)
+		if _, err := io.ReadFull(r, magic); err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(r)
+		return io.NopCloser(strings.NewReader(strings.ToLower(string(data)))), err
+	}
+	RegisterDecompressor([]byte("UP!"), upper)
+
+	var buf bytes.Buffer
+	if err := plainCompactFormatter().FormatReader(&buf, strings.NewReader(`UP![TRUE,NULL]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "[true,null]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Data without the magic bytes is left alone.
+	buf.Reset()
+	if err := plainCompactFormatter().FormatReader(&buf, strings.NewReader(`["UP!"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `["UP!"]`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
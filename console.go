@@ -0,0 +1,46 @@
+package jsoncolor
+
+import (
+	"io"
+	"os"
+
+	"github.com/mattn/go-colorable"
+)
+
+// EnableVirtualTerminal enables the processing of escape sequences by the
+// Windows console `f`, such as os.Stdout, so that colors are shown rather
+// than escape sequences. It returns an error if the console doesn't support
+// them, as with cmd.exe before Windows 10, and does nothing if `f` isn't a
+// Windows console. The package enables it for standard output and standard
+// error on its own when imported, so it's only needed for other consoles, or
+// to find out whether colors are supported.
+func EnableVirtualTerminal(f *os.File) error {
+	return enableVirtualTerminal(f)
+}
+
+// ConsoleWriter returns a writer to the terminal `f` showing the colors of the
+// output written to it, whichever the Windows console, so that jsoncolor works
+// out of the box on cmd.exe and older PowerShell hosts:
+//
+//	jsoncolor.DefaultFormatter.Format(jsoncolor.ConsoleWriter(os.Stdout), src)
+//
+// If the console processes escape sequences, or once enabled with
+// EnableVirtualTerminal, `f` is returned as is. On legacy consoles which
+// don't, the returned writer translates the escape sequences of colors into
+// calls to the console API, setting the attributes of the text. On other
+// systems, and if `f` isn't a console, `f` is returned as is. See StripWriter
+// to leave out colors instead.
+func ConsoleWriter(f *os.File) io.Writer {
+	if EnableVirtualTerminal(f) == nil {
+		return f
+	}
+	return colorable.NewColorable(f)
+}
+
+// StripWriter returns a writer removing the escape sequences from the output
+// written to it before writing it to `w`, for terminals and files which can
+// show neither colors nor escape sequences. Escape sequences must not be
+// split across writes, which the Formatter never does.
+func StripWriter(w io.Writer) io.Writer {
+	return colorable.NewNonColorable(w)
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package jsoncolor
+
+import "os"
+
+// enableVirtualTerminal does nothing, since only Windows consoles need escape
+// sequences to be enabled.
+func enableVirtualTerminal(*os.File) error {
+	return nil
+}
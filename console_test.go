@@ -0,0 +1,35 @@
+package jsoncolor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripWriter(t *testing.T) {
+	withColor(t, true)
+	var sb strings.Builder
+	if err := styledFormatter().Format(StripWriter(&sb), []byte(`{"a": [true, 1]}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": [\n    true,\n    1\n  ]\n}"
+	if got := sb.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestConsoleWriter(t *testing.T) {
+	// Files which aren't consoles are written to as they are.
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := EnableVirtualTerminal(f); err != nil {
+		t.Errorf("EnableVirtualTerminal: %v", err)
+	}
+	if w := ConsoleWriter(f); w != f {
+		t.Errorf("got %T, want the file", w)
+	}
+}
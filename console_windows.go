@@ -0,0 +1,33 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func init() {
+	// github.com/amterp/color only enables escape sequences for standard output.
+	enableVirtualTerminal(os.Stdout)
+	enableVirtualTerminal(os.Stderr)
+}
+
+// enableVirtualTerminal enables the processing of escape sequences by the
+// console `f`, if it is one.
+func enableVirtualTerminal(f *os.File) error {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// Not a console: escape sequences are written as they are.
+		return nil
+	}
+	const vt = windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING
+	if mode&vt == vt {
+		return nil
+	}
+	if err := windows.SetConsoleMode(handle, mode|vt); err != nil {
+		return fmt.Errorf("jsoncolor: console doesn't support escape sequences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package jsoncolor
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ControlCharPolicy controls how control characters which would be written
+// as they are within strings, keys and comments are handled, since they can
+// act on the terminal showing the output. See Formatter.ControlCharPolicy.
+type ControlCharPolicy int
+
+const (
+	// ControlCharEscape writes control characters as \u escape sequences, as
+	// JSON allows, so that the output is safe to show on a terminal however
+	// untrusted the input. This is the default.
+	ControlCharEscape ControlCharPolicy = iota
+	// ControlCharStrip leaves control characters out.
+	ControlCharStrip
+	// ControlCharKeep writes control characters as they are, for trusted input.
+	ControlCharKeep
+)
+
+// isControl reports whether `r` is a control character acting on terminals:
+// a C0 control character other than tabs and newlines, DEL, or a C1 control
+// character, such as U+009B, which some terminals take as the start of an
+// escape sequence like ESC [.
+func isControl(r rune) bool {
+	return (r < 0x20 && r != '\t' && r != '\n') || (r >= 0x7f && r <= 0x9f)
+}
+
+// sanitizeControls returns the text `s` of a string, key or comment, as
+// written in the output, with its control characters handled according to
+// `policy`. A control character escaped with a backslash, as JSON5 allows, is
+// handled along with the backslash, except for the line breaks which continue
+// JSON5 strings.
+func sanitizeControls(s string, policy ControlCharPolicy) string {
+	if policy == ControlCharKeep || !hasControl(s) {
+		return s
+	}
+	sb := &strings.Builder{}
+	sb.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == '\\' && i+1 < len(s) {
+			next, nextSize := utf8.DecodeRuneInString(s[i+1:])
+			if !isControl(next) || next == '\r' {
+				// An escape sequence, or a line continuation.
+				sb.WriteString(s[i : i+1+nextSize])
+				i += 1 + nextSize
+				continue
+			}
+			// The escaped control character replaces the escape sequence.
+			r, size = next, 1+nextSize
+		}
+		i += size
+		switch {
+		case !isControl(r):
+			sb.WriteRune(r)
+		case policy == ControlCharEscape:
+			sb.Write(appendUnicodeEscape(nil, r))
+		}
+	}
+	return sb.String()
+}
+
+// hasControl reports whether `s` contains control characters, without
+// decoding it.
+func hasControl(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < 0x20 && c != '\t' && c != '\n') || c == 0x7f || (c == 0xc2 && i+1 < len(s) && s[i+1] >= 0x80 && s[i+1] <= 0x9f) {
+			return true
+		}
+	}
+	return false
+}
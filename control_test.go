@@ -0,0 +1,62 @@
+package jsoncolor
+
+import "testing"
+
+func TestControlCharPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ControlCharPolicy
+		syntax Syntax
+		exact  bool
+		src    string
+		want   string
+	}{
+		// JSON allows C1 control characters unescaped.
+		{"escape C1", ControlCharEscape, SyntaxJSON, false, "[\"a\u009bb\"]", `["a\u009bb"]`},
+		{"strip C1", ControlCharStrip, SyntaxJSON, false, "[\"a\u009bb\"]", `["ab"]`},
+		{"keep C1", ControlCharKeep, SyntaxJSON, false, "[\"a\u009bb\"]", "[\"a\u009bb\"]"},
+		// Escaped control characters of PreserveExact input are left alone.
+		{"exact", ControlCharEscape, SyntaxJSON, true, "[\"a\u009b\\u001bb\"]", `["a\u009b\u001bb"]`},
+		{"exact strip", ControlCharStrip, SyntaxJSON, true, "[\"a\u009b\\u001bb\"]", `["a\u001bb"]`},
+		// JSON5 strings and comments may hold any character, and escape them
+		// with a backslash.
+		{"JSON5", ControlCharEscape, SyntaxJSON5, false, "['a\x1b[2Jb\\\a' /* c\x1b */]", `['a\u001b[2Jb\u0007' /* c\u001b */]`},
+		{"JSON5 strip", ControlCharStrip, SyntaxJSON5, false, "['a\x1b[2Jb\\\a' /* c\x1b */]", `['a[2Jb' /* c */]`},
+		{"JSON5 keep", ControlCharKeep, SyntaxJSON5, false, "['a\x1b[2Jb\\\a' /* c\x1b */]", "['a\x1b[2Jb\\\a' /* c\x1b */]"},
+	}
+	for _, tt := range tests {
+		f := plainCompactFormatter()
+		f.ControlCharPolicy = tt.policy
+		f.Syntax = tt.syntax
+		f.PreserveExact = tt.exact
+		if got := formatPlain(t, f, tt.src); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeControls(t *testing.T) {
+	tests := []struct {
+		src, escaped, stripped string
+	}{
+		{"plain", "plain", "plain"},
+		{"tab\tnewline\n", "tab\tnewline\n", "tab\tnewline\n"},
+		{"a\x7fb", "a\\u007fb", "ab"},
+		// Line continuations of JSON5 strings are kept.
+		{"a\\\r\nb", "a\\\r\nb", "a\\\r\nb"},
+		// So are escape sequences, including escaped backslashes.
+		{"a\\\\\x1b", "a\\\\\\u001b", "a\\\\"},
+		{"a\\\x1b", "a\\u001b", "a"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeControls(tt.src, ControlCharEscape); got != tt.escaped {
+			t.Errorf("%q: escaped: got %q, want %q", tt.src, got, tt.escaped)
+		}
+		if got := sanitizeControls(tt.src, ControlCharStrip); got != tt.stripped {
+			t.Errorf("%q: stripped: got %q, want %q", tt.src, got, tt.stripped)
+		}
+		if got := sanitizeControls(tt.src, ControlCharKeep); got != tt.src {
+			t.Errorf("%q: kept: got %q", tt.src, got)
+		}
+	}
+}
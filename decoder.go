@@ -0,0 +1,125 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Decoder works like encoding/json.Decoder, reading and decoding JSON values
+// from an input stream, but additionally writes a colorized rendering of
+// everything it decodes to a secondary trace writer. This is useful for
+// debugging API clients, providing both the decoded Go values and a readable
+// trace of the JSON they were decoded from.
+//
+// Values read with Decode are traced as a whole, and tokens read with Token
+// one by one, so the two can be mixed, e.g. to decode the elements of a large
+// array individually, and still produce a coherent trace. Each complete
+// top-level value in the trace is followed by a newline.
+type Decoder struct {
+	dec *json.Decoder
+	te  *TokenEncoder
+
+	// Options forwarded to the json.Decoder used to decode each value into its
+	// destination, which is separate from `dec`.
+	useNumber             bool
+	disallowUnknownFields bool
+}
+
+// NewDecoder returns a new Decoder that reads from `r` and writes a colorized
+// trace to `trace` using the DefaultFormatter.
+func NewDecoder(r io.Reader, trace io.Writer) *Decoder {
+	return NewDecoderWithFormatter(r, trace, DefaultFormatter)
+}
+
+// NewDecoderWithFormatter returns a new Decoder that reads from `r` and
+// writes a colorized trace to `trace` using the provided Formatter `f`.
+func NewDecoderWithFormatter(r io.Reader, trace io.Writer, f *Formatter) *Decoder {
+	if f == nil {
+		panic("jsoncolor: cannot create Decoder with a nil Formatter")
+	}
+	return &Decoder{
+		dec: json.NewDecoder(r),
+		te:  NewTokenEncoderWithFormatter(trace, f),
+	}
+}
+
+// Decode reads the next JSON value from the input, writes it to the trace and
+// stores it in the value pointed to by `v`, like encoding/json.Decoder.Decode.
+// If the value can't be stored in `v`, e.g. because of a type mismatch, it's
+// still written to the trace before the error is returned, so that the
+// offending input can be inspected.
+func (d *Decoder) Decode(v interface{}) error {
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	// The trace must never make decoding fail, so an InvalidUTF8Policy of
+	// InvalidUTF8Error is treated like InvalidUTF8Replace.
+	fs := d.te.fs
+	if escapeReplacement, err := checkUTF8(fs.utf8Policy, raw); err == nil {
+		fs.escapeReplacement = fs.escapeReplacement || escapeReplacement
+	}
+	if err := fs.writeTokens(raw); err == nil && d.te.terminate && d.te.Depth() == 0 {
+		fs.printSpace("\n", true)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if d.useNumber {
+		dec.UseNumber()
+	}
+	if d.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
+
+// Token returns the next JSON token in the input stream and writes it to the
+// trace, like encoding/json.Decoder.Token.
+func (d *Decoder) Token() (json.Token, error) {
+	t, err := d.dec.Token()
+	if err != nil {
+		return t, err
+	}
+	// Tokens returned by json.Decoder are always valid in sequence, so this
+	// can only fail for invalid UTF-8 with InvalidUTF8Error, which is ignored
+	// like in Decode.
+	_ = d.te.WriteToken(t)
+	return t, nil
+}
+
+// More reports whether there is another element in the current array or
+// object being parsed, like encoding/json.Decoder.More.
+func (d *Decoder) More() bool {
+	return d.dec.More()
+}
+
+// Buffered returns a reader of the data remaining in the Decoder's buffer,
+// like encoding/json.Decoder.Buffered.
+func (d *Decoder) Buffered() io.Reader {
+	return d.dec.Buffered()
+}
+
+// InputOffset returns the input stream byte offset of the current decoder
+// position, like encoding/json.Decoder.InputOffset.
+func (d *Decoder) InputOffset() int64 {
+	return d.dec.InputOffset()
+}
+
+// UseNumber causes the Decoder to unmarshal a number into an interface{} as a
+// json.Number instead of as a float64, like encoding/json.Decoder.UseNumber.
+// Numbers returned by Token are affected as well.
+func (d *Decoder) UseNumber() {
+	d.useNumber = true
+	d.dec.UseNumber()
+}
+
+// DisallowUnknownFields causes the Decoder to return an error when the
+// destination is a struct and the input contains object keys which do not
+// match any non-ignored, exported fields in the destination, like
+// encoding/json.Decoder.DisallowUnknownFields.
+func (d *Decoder) DisallowUnknownFields() {
+	d.disallowUnknownFields = true
+	d.dec.DisallowUnknownFields()
+}
@@ -0,0 +1,87 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var trace bytes.Buffer
+	d := NewDecoderWithFormatter(strings.NewReader(`{"a": 1} [2, "x"]`), &trace, &Formatter{})
+
+	var obj struct{ A int }
+	if err := d.Decode(&obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.A != 1 {
+		t.Errorf("got %+v", obj)
+	}
+
+	// Decode the elements of the array individually.
+	if _, err := d.Token(); err != nil {
+		t.Fatal(err)
+	}
+	var elems []interface{}
+	for d.More() {
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		elems = append(elems, v)
+	}
+	if _, err := d.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if len(elems) != 2 || elems[0] != 2.0 || elems[1] != "x" {
+		t.Errorf("got %v", elems)
+	}
+	if _, err := d.Token(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+
+	if got, want := stripANSI(trace.String()), "{\"a\":1}\n[2,\"x\"]\n"; got != want {
+		t.Errorf("got trace %q, want %q", got, want)
+	}
+}
+
+func TestDecoderOptions(t *testing.T) {
+	var trace bytes.Buffer
+	d := NewDecoderWithFormatter(strings.NewReader(`{"A": 1.50} {"A": 1, "B": 2}`), &trace, &Formatter{})
+	d.UseNumber()
+	d.DisallowUnknownFields()
+
+	var v map[string]interface{}
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v["A"] != json.Number("1.50") {
+		t.Errorf("got %#v, want a json.Number", v["A"])
+	}
+
+	var s struct{ A int }
+	if err := d.Decode(&s); err == nil {
+		t.Error("unknown field: got no error")
+	}
+	// The offending value is traced regardless.
+	if got, want := stripANSI(trace.String()), "{\"A\":1.50}\n{\"A\":1,\"B\":2}\n"; got != want {
+		t.Errorf("got trace %q, want %q", got, want)
+	}
+	if d.InputOffset() != int64(len(`{"A": 1.50} {"A": 1, "B": 2}`)) {
+		t.Errorf("got offset %d", d.InputOffset())
+	}
+}
+
+func TestDecoderInvalidUTF8(t *testing.T) {
+	var trace bytes.Buffer
+	d := NewDecoderWithFormatter(strings.NewReader("[\"\xff\"]"), &trace, &Formatter{InvalidUTF8Policy: InvalidUTF8Error})
+	var v []string
+	if err := d.Decode(&v); err != nil {
+		t.Fatalf("got %v, want the trace not to affect decoding", err)
+	}
+	if got, want := stripANSI(trace.String()), "[\"�\"]\n"; got != want {
+		t.Errorf("got trace %q, want %q", got, want)
+	}
+}
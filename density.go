@@ -0,0 +1,219 @@
+package jsoncolor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DensityOptions configures DensityMap.
+type DensityOptions struct {
+	// CellBytes is the number of input bytes summarized by each cell of the map.
+	// If zero or negative, it's chosen so that the map spans at most 8 lines.
+	CellBytes int
+	// Width is the number of cells per line. If zero or negative, 64 is used.
+	Width int
+}
+
+// Defaults for DensityOptions.
+const (
+	defaultDensityWidth = 64
+	defaultDensityLines = 8
+)
+
+// densityClass is the class of a byte of JSON input, as used by DensityMap.
+type densityClass int
+
+const (
+	densitySpace densityClass = iota // Insignificant whitespace, which is not counted.
+	densityField
+	densityString
+	densityNumber
+	densityTrue
+	densityFalse
+	densityNull
+	densityObject
+	densityArray
+	densityColon
+	densityComma
+	densityClasses // The number of classes.
+)
+
+// densityDots lists the bits of the braille dots in the order cells are
+// filled, bottom to top, so that denser cells look like taller bars.
+var densityDots = [8]rune{0x40, 0x80, 0x04, 0x20, 0x02, 0x10, 0x01, 0x08}
+
+// DensityMap writes an experimental, compact fingerprint of the valid JSON in
+// `src` to `w`, using the DefaultFormatter's colors. See Formatter.DensityMap.
+func DensityMap(w io.Writer, src []byte, opts DensityOptions) error {
+	return DefaultFormatter.DensityMap(w, src, opts)
+}
+
+// DensityMap writes an experimental, compact fingerprint of the valid JSON in
+// `src` to `w`, giving an impression of the structure of a very large document
+// at a glance before deciding how to inspect it.
+//
+// Each braille character (cell) of the map summarizes opts.CellBytes bytes of
+// input. The number of dots in a cell is proportional to the number of tokens
+// starting within it, relative to the densest cell, so that regions of many
+// small values stand out from long strings and whitespace. Each cell takes the
+// Formatter's color for the kind of token covering most of its bytes, e.g.
+// FieldColor for regions dominated by object keys.
+//
+// The format of the map is not stable and may change in future versions.
+func (f *Formatter) DensityMap(w io.Writer, src []byte, opts DensityOptions) error {
+	width := opts.Width
+	if width <= 0 {
+		width = defaultDensityWidth
+	}
+	cellBytes := opts.CellBytes
+	if cellBytes <= 0 {
+		cellBytes = max(1, (len(src)+width*defaultDensityLines-1)/(width*defaultDensityLines))
+	}
+	if len(src) == 0 {
+		return errors.New("jsoncolor: cannot map empty input")
+	}
+
+	cells := (len(src) + cellBytes - 1) / cellBytes
+	bytesPerClass := make([][densityClasses]int, cells)
+	tokens := make([]int, cells)
+	err := classifyJSON(src, func(class densityClass, start, end int) {
+		tokens[start/cellBytes]++
+		for i := start; i < end; i++ {
+			bytesPerClass[i/cellBytes][class]++
+		}
+	})
+	if err != nil {
+		return err
+	}
+	maxTokens := 0
+	for _, n := range tokens {
+		maxTokens = max(maxTokens, n)
+	}
+
+	colors := [densityClasses]func(format string, a ...interface{}) string{
+		densityField:  f.fieldColor().SprintfFunc(),
+		densityString: f.stringColor().SprintfFunc(),
+		densityNumber: f.numberColor().SprintfFunc(),
+		densityTrue:   f.trueColor().SprintfFunc(),
+		densityFalse:  f.falseColor().SprintfFunc(),
+		densityNull:   f.nullColor().SprintfFunc(),
+		densityObject: f.objectColor().SprintfFunc(),
+		densityArray:  f.arrayColor().SprintfFunc(),
+		densityColon:  f.colonColor().SprintfFunc(),
+		densityComma:  f.commaColor().SprintfFunc(),
+	}
+
+	b := &strings.Builder{}
+	for cell := 0; cell < cells; cell++ {
+		// Find the dominant class of the cell, ignoring whitespace.
+		dominant := densitySpace
+		for class := densityField; class < densityClasses; class++ {
+			if bytesPerClass[cell][class] > bytesPerClass[cell][dominant] {
+				dominant = class
+			}
+		}
+
+		// Cells without tokens of their own (e.g. within a long string) still get
+		// a dot, unless they only contain whitespace.
+		dots := 0
+		if maxTokens > 0 {
+			dots = (tokens[cell]*len(densityDots) + maxTokens - 1) / maxTokens
+		}
+		if dots == 0 && dominant != densitySpace {
+			dots = 1
+		}
+		char := rune(0x2800) // The blank braille pattern.
+		for _, dot := range densityDots[:dots] {
+			char |= dot
+		}
+
+		if dominant == densitySpace {
+			b.WriteRune(char)
+		} else {
+			b.WriteString(colors[dominant]("%c", char))
+		}
+		if (cell+1)%width == 0 || cell == cells-1 {
+			b.WriteByte('\n')
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// classifyJSON scans the JSON text `src` and calls `emit` for each token with
+// its class and its byte range [start, end). Insignificant whitespace is
+// skipped. Only the lexical structure is checked; the nesting of containers
+// is tracked to tell keys from string values but not validated.
+func classifyJSON(src []byte, emit func(class densityClass, start, end int)) error {
+	// inObject records, for each open container, whether it's an object.
+	var inObject []bool
+	expectKey := false
+
+	for i := 0; i < len(src); {
+		start := i
+		class := densitySpace
+		switch c := src[i]; {
+		case isSpace(c):
+			i++
+			continue
+		case c == '"':
+			class = densityString
+			if expectKey {
+				class = densityField
+			}
+			// Skip over the string, including any escaped quotes.
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(src) {
+				return fmt.Errorf("jsoncolor: unterminated string in input JSON at offset %d", start)
+			}
+			i++
+		case c == '{' || c == '[':
+			class = densityObject
+			if c == '[' {
+				class = densityArray
+			}
+			inObject = append(inObject, c == '{')
+			expectKey = c == '{'
+			i++
+		case c == '}' || c == ']':
+			class = densityObject
+			if c == ']' {
+				class = densityArray
+			}
+			if len(inObject) > 0 {
+				inObject = inObject[:len(inObject)-1]
+			}
+			expectKey = false
+			i++
+		case c == ':':
+			class = densityColon
+			expectKey = false
+			i++
+		case c == ',':
+			class = densityComma
+			expectKey = len(inObject) > 0 && inObject[len(inObject)-1]
+			i++
+		case c == 't' || c == 'f' || c == 'n':
+			class = map[byte]densityClass{'t': densityTrue, 'f': densityFalse, 'n': densityNull}[c]
+			for i < len(src) && src[i] >= 'a' && src[i] <= 'z' {
+				i++
+			}
+		case c == '-' || (c >= '0' && c <= '9'):
+			class = densityNumber
+			for i < len(src) && strings.IndexByte("+-.eE0123456789", src[i]) >= 0 {
+				i++
+			}
+		default:
+			return fmt.Errorf("jsoncolor: invalid character %q in input JSON at offset %d", c, i)
+		}
+		emit(class, start, i)
+	}
+	return nil
+}
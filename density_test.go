@@ -0,0 +1,79 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDensityMap(t *testing.T) {
+	src := []byte(`{"a":[1,2,3]}`)
+	tests := []struct {
+		opts DensityOptions
+		want string
+	}{
+		{DensityOptions{CellBytes: 1, Width: 13}, "⣿⣿⡀⡀⣿⣿⣿⣿⣿⣿⣿⣿⣿\n"},
+		// Cells with fewer tokens get fewer dots, filled from the bottom.
+		{DensityOptions{CellBytes: 2, Width: 4}, "⣿⡀⣿⣿\n⣿⣿⣤\n"},
+		// By default, the whole input fits on 8 lines of 64 cells.
+		{DensityOptions{}, "⣿⣿⡀⡀⣿⣿⣿⣿⣿⣿⣿⣿⣿\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := (&Formatter{}).DensityMap(&buf, src, tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := stripANSI(buf.String()); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestDensityMapColors(t *testing.T) {
+	other := tagColor("other")
+	f := &Formatter{FieldColor: tagColor("key"), StringColor: other, NumberColor: tagColor("num"), ObjectColor: other,
+		ArrayColor: other, ColonColor: other, CommaColor: other, TrueColor: other, FalseColor: other, NullColor: other}
+
+	var buf bytes.Buffer
+	if err := f.DensityMap(&buf, []byte(`{"key":1}`), DensityOptions{CellBytes: 3, Width: 3}); err != nil {
+		t.Fatal(err)
+	}
+	// The first two cells are mostly key, the third has the most tokens.
+	want := "<key>⣶</key><key>⡀</key><num>⣿</num>\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDensityMapErrors(t *testing.T) {
+	for _, src := range []string{``, `{"a`, `[1,x]`} {
+		if err := DensityMap(&bytes.Buffer{}, []byte(src), DensityOptions{}); err == nil {
+			t.Errorf("%q: got no error", src)
+		}
+	}
+}
+
+func TestClassifyJSON(t *testing.T) {
+	type token struct {
+		class      densityClass
+		start, end int
+	}
+	var got []token
+	err := classifyJSON([]byte(`{"k": ["s\"", -1.5e3, true, null], "f": false}`), func(class densityClass, start, end int) {
+		got = append(got, token{class, start, end})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []token{
+		{densityObject, 0, 1}, {densityField, 1, 4}, {densityColon, 4, 5},
+		{densityArray, 6, 7}, {densityString, 7, 12}, {densityComma, 12, 13},
+		{densityNumber, 14, 20}, {densityComma, 20, 21}, {densityTrue, 22, 26},
+		{densityComma, 26, 27}, {densityNull, 28, 32}, {densityArray, 32, 33},
+		{densityComma, 33, 34}, {densityField, 35, 38}, {densityColon, 38, 39},
+		{densityFalse, 40, 45}, {densityObject, 45, 46},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
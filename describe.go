@@ -0,0 +1,207 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// describeNode is the shape of a JSON value, as needed by Describe.
+type describeNode struct {
+	kind     string          // One of profileTypeNames.
+	keys     []string        // Keys of an object, in document order.
+	children []*describeNode // Values of an object's members, or elements of an array.
+}
+
+// Describe returns a plain-text description of the structure of the valid
+// JSON in `src`, meant for screen readers and other situations where colors
+// and indentation convey nothing. Each line is a self-contained sentence
+// describing one object, naming its keys and the type of each value, or one
+// array nested within another array, e.g.
+//
+//	The document is an object with 2 keys: name (string), items (array of 5 objects).
+//	.items[0] is an object with 2 keys: id (number), tags (array of 2 strings).
+//
+// Lines other than the first start with the jq-style path of the value they
+// describe. Arrays are summarized by the types of their elements; only the
+// first non-empty object or array within an array is described in detail,
+// on the assumption that its siblings are alike.
+func Describe(src []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	token, err := dec.Token()
+	if err != nil {
+		return "", fmt.Errorf("jsoncolor: error decoding input JSON: %w", err)
+	}
+	root, err := parseDescribeNode(dec, token)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return "", fmt.Errorf("jsoncolor: error decoding input JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return "", fmt.Errorf("jsoncolor: error decoding input JSON: unexpected data after top-level value")
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "The document is %s.\n", root.sentence())
+	root.describeChildren(b, ".")
+	return b.String(), nil
+}
+
+// parseDescribeNode reads the value starting with `token` from `dec`.
+func parseDescribeNode(dec *json.Decoder, token json.Token) (*describeNode, error) {
+	switch value := token.(type) {
+	case json.Delim:
+		n := &describeNode{kind: "object"}
+		if value == json.Delim('[') {
+			n.kind = "array"
+		}
+		for dec.More() {
+			if n.kind == "object" {
+				// Object keys are always strings.
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			childToken, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			child, err := parseDescribeNode(dec, childToken)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &describeNode{kind: "string"}, nil
+	case json.Number:
+		return &describeNode{kind: "number"}, nil
+	case bool:
+		return &describeNode{kind: "boolean"}, nil
+	default:
+		return &describeNode{kind: "null"}, nil
+	}
+}
+
+// isContainer reports whether the node is a non-empty object or array.
+func (n *describeNode) isContainer() bool {
+	return (n.kind == "object" || n.kind == "array") && len(n.children) > 0
+}
+
+// describeChildren writes a line for each container below the node at `path`
+// whose contents aren't described by the line of its parent.
+func (n *describeNode) describeChildren(b *strings.Builder, path string) {
+	if n.kind == "object" {
+		for i, child := range n.children {
+			childPath := profileChildPath(path, n.keys[i])
+			if child.kind == "object" && child.isContainer() {
+				fmt.Fprintf(b, "%s is %s.\n", childPath, child.sentence())
+			}
+			child.describeChildren(b, childPath)
+		}
+		return
+	}
+
+	// Arrays are already summarized by their parent, so only their first
+	// non-empty container is described further. Unlike arrays within objects,
+	// it gets a line even if it's an array, since its elements are yet to be
+	// described.
+	for i, child := range n.children {
+		if !child.isContainer() {
+			continue
+		}
+		childPath := path + "[" + strconv.Itoa(i) + "]"
+		fmt.Fprintf(b, "%s is %s.\n", childPath, child.sentence())
+		child.describeChildren(b, childPath)
+		return
+	}
+}
+
+// sentence returns the full description of the node, including its article
+// and, for objects, the list of its members, e.g.
+// "an object with 2 keys: id (number), name (string)".
+func (n *describeNode) sentence() string {
+	summary := n.summary()
+	if n.kind == "object" && len(n.children) > 0 {
+		members := make([]string, len(n.children))
+		for i, child := range n.children {
+			members[i] = describeKey(n.keys[i]) + " (" + child.summary() + ")"
+		}
+		summary += ": " + strings.Join(members, ", ")
+	}
+	switch {
+	case n.kind == "null":
+		return summary
+	case strings.ContainsAny(summary[:1], "aeiou"):
+		return "an " + summary
+	default:
+		return "a " + summary
+	}
+}
+
+// summary returns a short description of the node without an article, e.g.
+// "object with 2 keys" or "array of 5 strings".
+func (n *describeNode) summary() string {
+	switch n.kind {
+	case "object":
+		if len(n.children) == 0 {
+			return "empty object"
+		}
+		return "object with " + plural(len(n.children), "key", "keys")
+	case "array":
+		if len(n.children) == 0 {
+			return "empty array"
+		}
+		counts := map[string]int{}
+		for _, child := range n.children {
+			counts[child.kind]++
+		}
+		if len(counts) == 1 {
+			kind := n.children[0].kind
+			return "array of " + plural(len(n.children), kind, kind+"s")
+		}
+		// List the element types in a fixed order, e.g. "2 strings and 1 null".
+		var parts []string
+		for _, kind := range profileTypeNames {
+			if counts[kind] > 0 {
+				parts = append(parts, plural(counts[kind], kind, kind+"s"))
+			}
+		}
+		last := len(parts) - 1
+		return "array of " + plural(len(n.children), "item", "items") + ": " +
+			strings.Join(parts[:last], ", ") + " and " + parts[last]
+	default:
+		return n.kind
+	}
+}
+
+// describeKey returns `key` as written in descriptions, quoted if it's not a
+// plain identifier, so that keys containing spaces or punctuation stay unambiguous.
+func describeKey(key string) string {
+	if isIdentifier(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// plural returns `n` followed by the singular or plural form of a noun.
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return strconv.Itoa(n) + " " + pluralForm
+}
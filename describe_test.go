@@ -0,0 +1,47 @@
+package jsoncolor
+
+import "testing"
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"nested",
+			`{"name":"x","items":[{"id":1,"tags":["a","b"]},{"id":2,"tags":[]}],"meta":{"my key":null,"e":{}}}`,
+			"The document is an object with 3 keys: name (string), items (array of 2 objects), meta (object with 2 keys).\n" +
+				".items[0] is an object with 2 keys: id (number), tags (array of 2 strings).\n" +
+				".meta is an object with 2 keys: \"my key\" (null), e (empty object).\n",
+		},
+		{
+			"mixed array",
+			`[1,"a","b",null,[true]]`,
+			"The document is an array of 5 items: 1 array, 2 strings, 1 number and 1 null.\n" +
+				".[4] is an array of 1 boolean.\n",
+		},
+		{"scalar", `"s"`, "The document is a string.\n"},
+		{"null", `null`, "The document is null.\n"},
+		{"empty array", `[]`, "The document is an empty array.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Describe([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	for _, src := range []string{``, `{"a":`, `[1] 2`, `{"a" 1}`} {
+		if _, err := Describe([]byte(src)); err == nil {
+			t.Errorf("%q: got no error", src)
+		}
+	}
+}
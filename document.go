@@ -0,0 +1,216 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// DocumentOptions configures FormatDocument.
+type DocumentOptions struct {
+	// Formatter is used to format each JSON block. If nil, blocks are indented
+	// with two spaces. Only its layout settings apply, since colors are never
+	// written into the document. Its Prefix is replaced by the indentation of
+	// each block, and its Syntax by the one named in the block's language tag.
+	Formatter *Formatter
+
+	// HTML, if true, replaces each JSON block with a <pre> element in which
+	// every token is wrapped in a <span> with a CSS class naming its kind, e.g.
+	// "json-key" or "json-number" (see TokenKind.String), for documents rendered
+	// to HTML. In AsciiDoc, the element is placed in a passthrough block.
+	// Blocks rewritten this way are no longer code blocks, so they're left
+	// alone by later calls.
+	HTML bool
+}
+
+// documentLanguages maps the language tags of the code blocks rewritten by
+// FormatDocument to the Syntax their contents are parsed with.
+var documentLanguages = map[string]Syntax{
+	"json":  SyntaxJSON,
+	"jsonc": SyntaxJSONC,
+	"json5": SyntaxJSON5,
+	"hjson": SyntaxHJSON,
+}
+
+// FormatDocument finds the JSON code blocks in the Markdown or AsciiDoc
+// document `src` and returns the document with each of them formatted,
+// leaving all other content untouched. This keeps the examples in READMEs and
+// other documentation consistently formatted, e.g. from a pre-commit hook.
+//
+// In Markdown, the rewritten blocks are the fenced code blocks (``` or ~~~)
+// tagged json, jsonc, json5 or hjson. In AsciiDoc, they're the listing
+// blocks delimited by ---- (or ```) following a [source,json] attribute
+// line, or one naming another of those languages. Empty blocks are kept as is.
+//
+// If a block can't be formatted, the returned error reports its line number
+// and nothing is returned.
+func FormatDocument(src []byte, opts DocumentOptions) ([]byte, error) {
+	f := opts.Formatter
+	if f == nil {
+		f = newPlainFormatter()
+	}
+
+	out := make([]byte, 0, len(src))
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		block, ok := openDocumentBlock(lines, i)
+		if !ok {
+			out = append(out, line...)
+			continue
+		}
+
+		// Find the closing delimiter. A block left open runs to the end of the
+		// document, as in Markdown, but isn't rewritten.
+		end := i + block.bodyStart
+		for end < len(lines) && !block.closedBy(lines[end]) {
+			end++
+		}
+		if block.syntax < 0 || end == len(lines) || isBlank(bytes.Join(lines[i+block.bodyStart:end], nil)) {
+			for _, l := range lines[i:min(end+1, len(lines))] {
+				out = append(out, l...)
+			}
+			i = end
+			continue
+		}
+
+		body := bytes.Join(lines[i+block.bodyStart:end], nil)
+		formatted, err := formatDocumentBlock(f, block, body, opts.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("jsoncolor: JSON block at line %d: %w", i+block.bodyStart+1, err)
+		}
+		// Keep the line endings of the document.
+		newline := "\n"
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			newline = "\r\n"
+			formatted = strings.ReplaceAll(formatted, "\n", newline)
+		}
+
+		switch {
+		case !opts.HTML:
+			for _, l := range lines[i : i+block.bodyStart] {
+				out = append(out, l...)
+			}
+			out = append(out, formatted...)
+			out = append(out, lines[end]...)
+		case block.asciiDoc:
+			out = append(out, block.indent+"++++"+newline...)
+			out = append(out, formatted...)
+			out = append(out, block.indent+"++++"+newline...)
+		default:
+			out = append(out, formatted...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// documentBlock describes a code block found by openDocumentBlock.
+type documentBlock struct {
+	indent    string // The indentation of the opening delimiter.
+	delim     string // The opening delimiter, e.g. "```" or "----".
+	bodyStart int    // The number of lines before the block's contents.
+	asciiDoc  bool   // True if the block is an AsciiDoc listing block.
+	lang      string // The language tag, lowercased.
+	syntax    Syntax // The Syntax of the contents, or -1 if they aren't JSON.
+}
+
+// openDocumentBlock reports whether `lines[i]` opens a code block, and if so,
+// describes it. Code blocks in other languages are found too, so that their
+// contents are skipped over.
+func openDocumentBlock(lines [][]byte, i int) (documentBlock, bool) {
+	line := strings.TrimRight(string(lines[i]), "\r\n")
+	trimmed := strings.TrimLeft(line, " \t")
+	b := documentBlock{indent: line[:len(line)-len(trimmed)], bodyStart: 1, syntax: -1}
+
+	// AsciiDoc: a [source,lang,...] attribute line followed by a delimiter line.
+	if strings.HasPrefix(trimmed, "[source") && strings.HasSuffix(strings.TrimSpace(trimmed), "]") {
+		if i+1 >= len(lines) {
+			return b, false
+		}
+		delim := strings.TrimSpace(string(lines[i+1]))
+		if delim != "```" && (len(delim) < 4 || strings.Trim(delim, "-") != "") {
+			return b, false
+		}
+		attrs := strings.Split(strings.TrimSuffix(strings.TrimSpace(trimmed), "]"), ",")
+		if len(attrs) > 1 {
+			b.lang = strings.ToLower(strings.TrimSpace(attrs[1]))
+		}
+		b.delim = delim
+		b.bodyStart = 2
+		b.asciiDoc = true
+	} else {
+		// Markdown: a fence of at least three backticks or tildes, followed by an info string.
+		fence := len(trimmed) - len(strings.TrimLeft(trimmed, "`"))
+		if fence == 0 {
+			fence = len(trimmed) - len(strings.TrimLeft(trimmed, "~"))
+		}
+		if fence < 3 {
+			return b, false
+		}
+		info := trimmed[fence:]
+		if trimmed[0] == '`' && strings.Contains(info, "`") {
+			return b, false
+		}
+		if fields := strings.Fields(info); len(fields) > 0 {
+			b.lang = strings.ToLower(fields[0])
+		}
+		b.delim = trimmed[:fence]
+	}
+	if syntax, ok := documentLanguages[b.lang]; ok {
+		b.syntax = syntax
+	}
+	return b, true
+}
+
+// closedBy reports whether `line` closes the block.
+func (b documentBlock) closedBy(line []byte) bool {
+	trimmed := strings.TrimSpace(string(line))
+	if b.asciiDoc {
+		return trimmed == b.delim
+	}
+	// A Markdown fence is closed by a fence of the same character, at least as long.
+	return len(trimmed) >= len(b.delim) && strings.Trim(trimmed, b.delim[:1]) == ""
+}
+
+// formatDocumentBlock formats the contents `body` of a JSON code block,
+// returning the lines replacing them, or with `asHTML`, the whole block.
+func formatDocumentBlock(f *Formatter, b documentBlock, body []byte, asHTML bool) (string, error) {
+	f = f.clone()
+	f.Syntax = b.syntax
+	f.TrailingNewline = NewlineNever
+	if asHTML {
+		f.Prefix = ""
+	} else {
+		f.Prefix = b.indent
+	}
+
+	sb := &strings.Builder{}
+	if asHTML {
+		fmt.Fprintf(sb, `%s<pre><code class="language-%s">`, b.indent, html.EscapeString(b.lang))
+	}
+	err := f.FormatSegments(body, func(s Segment) {
+		switch {
+		case !asHTML:
+			sb.WriteString(s.Text)
+		case s.Kind == TokenWhitespace:
+			sb.WriteString(html.EscapeString(s.Text))
+		default:
+			fmt.Fprintf(sb, `<span class="json-%s">%s</span>`, s.Kind, html.EscapeString(s.Text))
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if asHTML {
+		sb.WriteString("</code></pre>")
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+// isBlank reports whether `b` consists only of whitespace.
+func isBlank(b []byte) bool {
+	return len(bytes.TrimSpace(b)) == 0
+}
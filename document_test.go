@@ -0,0 +1,97 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatDocumentMarkdown(t *testing.T) {
+	const src = "# Title\n\n```json\n{\"a\":[1,2]}\n```\n\nText `inline` and:\n\n" +
+		"  ~~~jsonc title\n  [1, // one\n  2]\n  ~~~\n\n```go\n{\"a\":1}\n```\n\n```json\n\n```\n"
+	const want = "# Title\n\n```json\n{\n  \"a\": [\n    1,\n    2\n  ]\n}\n```\n\nText `inline` and:\n\n" +
+		"  ~~~jsonc title\n  [\n    1, // one\n    2\n  ]\n  ~~~\n\n```go\n{\"a\":1}\n```\n\n```json\n\n```\n"
+	got, err := FormatDocument([]byte(src), DocumentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Formatting is idempotent.
+	again, err := FormatDocument(got, DocumentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != want {
+		t.Errorf("second pass got:\n%s", again)
+	}
+}
+
+func TestFormatDocumentAsciiDoc(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"listing", "[source,json]\n----\n{\"a\": true}\n----\n", "[source,json]\n----\n{\n  \"a\": true\n}\n----\n"},
+		// The line endings of the document are kept.
+		{"crlf", "[source,json]\r\n----\r\n{\"a\": true}\r\n----\r\n", "[source,json]\r\n----\r\n{\r\n  \"a\": true\r\n}\r\n----\r\n"},
+		{"other language", "[source,yaml]\n----\n{\"a\": true}\n----\n", "[source,yaml]\n----\n{\"a\": true}\n----\n"},
+	}
+	for _, tt := range tests {
+		got, err := FormatDocument([]byte(tt.src), DocumentOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDocumentHTML(t *testing.T) {
+	got, err := FormatDocument([]byte("Text\n```json\n{\"a\":1}\n```\n"), DocumentOptions{HTML: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Text\n<pre><code class=\"language-json\"><span class=\"json-object-delim\">{</span>\n" +
+		"  <span class=\"json-key\">&#34;a&#34;</span><span class=\"json-colon\">:</span> <span class=\"json-number\">1</span>\n" +
+		"<span class=\"json-object-delim\">}</span></code></pre>\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = FormatDocument([]byte("[source,json]\n----\n[]\n----\n"), DocumentOptions{HTML: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "++++\n<pre><code class=\"language-json\"><span class=\"json-array-delim\">[</span><span class=\"json-array-delim\">]</span></code></pre>\n++++\n"
+	if string(got) != want {
+		t.Errorf("AsciiDoc: got %q, want %q", got, want)
+	}
+}
+
+func TestFormatDocumentFormatter(t *testing.T) {
+	f := &Formatter{Indent: "\t", SortKeys: true, ObjectColor: tagColor("obj")}
+	got, err := FormatDocument([]byte("```json\n{\"b\":1,\"a\":2}\n```\n"), DocumentOptions{Formatter: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Colors are never written into the document.
+	if want := "```json\n{\n\t\"a\": 2,\n\t\"b\": 1\n}\n```\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatDocumentErrors(t *testing.T) {
+	_, err := FormatDocument([]byte("a\n```json\n{]\n```\n"), DocumentOptions{})
+	if err == nil || !strings.Contains(err.Error(), "JSON block at line 3") {
+		t.Errorf("got error %v", err)
+	}
+
+	// A block left open isn't rewritten.
+	const open = "```json\n{\"a\":1}\n"
+	got, err := FormatDocument([]byte(open), DocumentOptions{})
+	if err != nil || string(got) != open {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
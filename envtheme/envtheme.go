@@ -0,0 +1,17 @@
+// Package envtheme applies the theme selected by the environment variable
+// JSONCOLOR_THEME to jsoncolor.DefaultFormatter when it's imported, so that
+// the users of a tool can pick its colors without the tool exposing a flag:
+//
+//	import _ "github.com/amterp/jsoncolor/envtheme"
+//
+// The variable holds the name of a registered theme, such as "dracula", or
+// the path of a theme file (see jsoncolor.LoadTheme). If it names neither, the
+// DefaultFormatter is left as it is; tools which want to report the error can
+// call jsoncolor.DefaultFormatter.ApplyEnvTheme themselves instead.
+package envtheme
+
+import "github.com/amterp/jsoncolor"
+
+func init() {
+	_ = jsoncolor.DefaultFormatter.ApplyEnvTheme()
+}
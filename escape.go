@@ -0,0 +1,115 @@
+package jsoncolor
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// EscapePolicy decides which characters are written as \u escape sequences
+// inside JSON strings, beyond those JSON requires to be escaped (quotes,
+// backslashes and control characters), which always are. See
+// Formatter.EscapePolicy.
+type EscapePolicy interface {
+	// EscapeRune reports whether `r`, a valid character which JSON allows to
+	// appear literally in a string, must be written as a \u escape sequence.
+	EscapeRune(r rune) bool
+}
+
+// EscapeFunc is an adapter to use an ordinary function as an EscapePolicy.
+type EscapeFunc func(r rune) bool
+
+// EscapeRune calls fn(r).
+func (fn EscapeFunc) EscapeRune(r rune) bool {
+	return fn(r)
+}
+
+// Built-in escape policies.
+var (
+	// MinimalEscaping only escapes what JSON requires.
+	MinimalEscaping EscapePolicy = EscapeFunc(func(r rune) bool {
+		return false
+	})
+	// JSEscaping also escapes the line and paragraph separators U+2028 and
+	// U+2029, which are not valid literally in JavaScript string literals
+	// before ES2019. Like encoding/json, this is the default when EscapeHTML
+	// is false.
+	JSEscaping EscapePolicy = EscapeFunc(isJSSeparator)
+	// HTMLEscaping also escapes <, > and &, so that the JSON can be embedded
+	// in HTML <script> tags, as well as U+2028 and U+2029 like JSEscaping.
+	// Like encoding/json, this is the default when EscapeHTML is true.
+	HTMLEscaping EscapePolicy = EscapeFunc(func(r rune) bool {
+		return r == '<' || r == '>' || r == '&' || isJSSeparator(r)
+	})
+	// ASCIIEscaping escapes every character outside of printable ASCII, so
+	// that the output is pure ASCII. Characters beyond the Basic Multilingual
+	// Plane are escaped as UTF-16 surrogate pairs.
+	ASCIIEscaping EscapePolicy = EscapeFunc(func(r rune) bool {
+		return r > '~'
+	})
+)
+
+// isJSSeparator reports whether `r` is U+2028 or U+2029.
+func isJSSeparator(r rune) bool {
+	return r == '\u2028' || r == '\u2029'
+}
+
+// resolveEscapePolicy returns the EscapePolicy in effect given an explicit
+// policy `p`, which may be nil, and whether HTML escaping is enabled.
+func resolveEscapePolicy(p EscapePolicy, escapeHTML bool) EscapePolicy {
+	switch {
+	case p != nil:
+		return p
+	case escapeHTML:
+		return HTMLEscaping
+	default:
+		return JSEscaping
+	}
+}
+
+// hexDigits are used to write \u escape sequences, in lower case like encoding/json.
+const hexDigits = "0123456789abcdef"
+
+// appendEscaped appends the contents of a JSON string encoding `s` to `dst`,
+// without the surrounding quotes. Quotes, backslashes and control characters
+// are escaped as required, along with the characters selected by `policy`.
+// Invalid UTF-8 is replaced with \ufffd, like encoding/json does, and if
+// `escapeReplacement` is true, so are literal U+FFFD characters.
+func appendEscaped(dst []byte, s string, policy EscapePolicy, escapeReplacement bool) []byte {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			dst = append(dst, `\ufffd`...)
+		case r == '"' || r == '\\':
+			dst = append(dst, '\\', byte(r))
+		case r == '\n':
+			dst = append(dst, `\n`...)
+		case r == '\r':
+			dst = append(dst, `\r`...)
+		case r == '\t':
+			dst = append(dst, `\t`...)
+		case r == '\b':
+			dst = append(dst, `\b`...)
+		case r == '\f':
+			dst = append(dst, `\f`...)
+		case r < 0x20, r == utf8.RuneError && escapeReplacement, policy.EscapeRune(r):
+			if r > 0xFFFF {
+				r1, r2 := utf16.EncodeRune(r)
+				dst = appendUnicodeEscape(dst, r1)
+				dst = appendUnicodeEscape(dst, r2)
+			} else {
+				dst = appendUnicodeEscape(dst, r)
+			}
+		default:
+			dst = append(dst, s[i-size:i]...)
+		}
+	}
+	return dst
+}
+
+// appendUnicodeEscape appends the \u escape sequence of the 16-bit code unit `r` to `dst`.
+func appendUnicodeEscape(dst []byte, r rune) []byte {
+	return append(dst, '\\', 'u',
+		hexDigits[r>>12&0xF], hexDigits[r>>8&0xF], hexDigits[r>>4&0xF], hexDigits[r&0xF])
+}
@@ -0,0 +1,67 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendEscaped(t *testing.T) {
+	const s = "q\"\\\n\t\x01<&>\u2028é\U0001F600\xff\ufffd"
+	tests := []struct {
+		name              string
+		policy            EscapePolicy
+		escapeReplacement bool
+		want              string
+	}{
+		{"minimal", MinimalEscaping, false, `q\"\\\n\t\u0001<&>` + "\u2028é\U0001F600" + `\ufffd` + "\ufffd"},
+		{"js", JSEscaping, false, `q\"\\\n\t\u0001<&>\u2028` + "é\U0001F600" + `\ufffd` + "\ufffd"},
+		{"html", HTMLEscaping, false, `q\"\\\n\t\u0001\u003c\u0026\u003e\u2028` + "é\U0001F600" + `\ufffd` + "\ufffd"},
+		{"ascii", ASCIIEscaping, true, `q\"\\\n\t\u0001<&>\u2028\u00e9\ud83d\ude00\ufffd\ufffd`},
+		{"func", EscapeFunc(func(r rune) bool { return r == 'q' }), false, `\u0071\"\\\n\t\u0001<&>` + "\u2028é\U0001F600" + `\ufffd` + "\ufffd"},
+	}
+	for _, tt := range tests {
+		if got := string(appendEscaped(nil, s, tt.policy, tt.escapeReplacement)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAppendEscapedMatchesEncodingJSON(t *testing.T) {
+	const s = "a\"\\/\n\r\t\b\f\x00\x1f<>&\u2028\u2029é"
+	for _, escapeHTML := range []bool{true, false} {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(escapeHTML)
+		if err := enc.Encode(s); err != nil {
+			t.Fatal(err)
+		}
+		want := buf.String()[1 : buf.Len()-2]
+
+		got := string(appendEscaped(nil, s, resolveEscapePolicy(nil, escapeHTML), false))
+		if got != want {
+			t.Errorf("EscapeHTML %v: got %s, want %s", escapeHTML, got, want)
+		}
+	}
+}
+
+func TestEscapePolicy(t *testing.T) {
+	got, err := MarshalWithFormatter("<é>", &Formatter{EscapePolicy: ASCIIEscaping})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The policy takes precedence over the HTML escaping Marshal turns on.
+	if want := `"<\u00e9>"`; stripANSI(string(got)) != want {
+		t.Errorf("got %s, want %s", stripANSI(string(got)), want)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{})
+	enc.SetEscapePolicy(MinimalEscaping)
+	if err := enc.Encode("<\u2028>"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\"<\u2028>\"\n"; stripANSI(buf.String()) != want {
+		t.Errorf("got %q, want %q", stripANSI(buf.String()), want)
+	}
+}
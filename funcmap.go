@@ -0,0 +1,55 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// FuncMap returns template functions colorizing values with the Formatter `f`
+// (the DefaultFormatter if nil), for server-rendered debug pages and CLI
+// templates. It can be passed to the Funcs method of both text/template and
+// html/template:
+//
+//   - jsoncolor marshals its argument into indented JSON, colorized with
+//     escape sequences for terminals, e.g. {{jsoncolor .Response}}. The
+//     Formatter's Indent is used if set, and two spaces otherwise.
+//   - jsoncompact does the same without indentation.
+//   - jsonhtml marshals its argument into indented JSON rendered as HTML
+//     with inline styles, as with FormatHTML. It's returned as template.HTML,
+//     so html/template includes it without escaping it again.
+//
+// Like the Marshal* functions, HTML characters within strings are escaped,
+// which keeps the output of jsoncolor and jsoncompact safe to include in
+// HTML pages, where html/template escapes it like any other text.
+func FuncMap(f *Formatter) map[string]any {
+	if f == nil {
+		f = DefaultFormatter
+	}
+	indent := f.Indent
+	if indent == "" {
+		indent = "  "
+	}
+	return map[string]any{
+		"jsoncolor": func(v any) (string, error) {
+			b, err := MarshalIndentWithFormatter(v, "", indent, f)
+			return string(b), err
+		},
+		"jsoncompact": func(v any) (string, error) {
+			b, err := MarshalWithFormatter(v, f)
+			return string(b), err
+		},
+		"jsonhtml": func(v any) (template.HTML, error) {
+			src, err := marshalJSON(v, true)
+			if err != nil {
+				return "", err
+			}
+			g := f.clone()
+			g.setIndent("", indent)
+			buf := &bytes.Buffer{}
+			if err := g.FormatHTML(buf, src, HTMLOptions{InlineStyles: true}); err != nil {
+				return "", err
+			}
+			return template.HTML(buf.String()), nil
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package jsoncolor
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncMap(t *testing.T) {
+	withColor(t, false)
+	v := map[string]any{"a": []any{1, "<b>"}}
+	tmpl := template.Must(template.New("").Funcs(FuncMap(nil)).Parse("{{jsoncolor .}}\n{{jsoncompact .}}"))
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, v); err != nil {
+		t.Fatal(err)
+	}
+	// HTML characters are escaped within strings.
+	want := "{\n  \"a\": [\n    1,\n    \"\\u003cb\\u003e\"\n  ]\n}\n{\"a\":[1,\"\\u003cb\\u003e\"]}"
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Errors are those of the template.
+	sb.Reset()
+	if err := tmpl.Execute(&sb, func() {}); err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestFuncMapHTML(t *testing.T) {
+	withColor(t, true)
+	v := map[string]any{"a": []any{1, "<b>"}}
+	tmpl := htmltemplate.Must(htmltemplate.New("").Funcs(FuncMap(styledFormatter())).Parse("<div>{{jsonhtml .}}</div>"))
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, v); err != nil {
+		t.Fatal(err)
+	}
+	// The HTML isn't escaped again by html/template.
+	want := `<div><pre class="json">{
+  <span style="color:#0000ee;font-weight:bold">&#34;a&#34;</span>: [
+    <span style="color:#00ffff">1</span>,
+    <span style="color:#00cd00">&#34;&lt;b&gt;&#34;</span>
+  ]
+}</pre></div>`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
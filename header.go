@@ -0,0 +1,71 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+// HeaderVersion is the version of the format of the output written by Format,
+// as recorded in headers. It changes when tools reading stored output can no
+// longer rely on it, such as when the escape sequences written change.
+const HeaderVersion = 1
+
+// headerPrefix starts the line of a header. As a line comment, a header is
+// accepted by parsers of JSONC once colors are stripped.
+const headerPrefix = "// jsoncolor "
+
+// Header describes stored colorized output, so that tools reading it later can
+// recognize it, strip it or render it again. See Formatter.Header.
+//
+// A header is a single line preceding the output, made of a line comment
+// holding a JSON object, e.g.
+//
+//	// jsoncolor {"version":1,"theme":"monokai","sha256":"9f86d0…"}
+type Header struct {
+	// Version is the HeaderVersion of the output.
+	Version int `json:"version"`
+	// Theme is the name of the colors of the output, from Formatter.ThemeName.
+	// It is empty if the colors are unnamed.
+	Theme string `json:"theme,omitempty"`
+	// SourceSHA256 is the SHA-256 hash of the formatted input, hex encoded, so
+	// that the output can be matched with its source.
+	SourceSHA256 string `json:"sha256"`
+}
+
+// NewHeader returns the header of the output of formatting `src` with colors
+// named `theme`.
+func NewHeader(theme string, src []byte) Header {
+	sum := sha256.Sum256(src)
+	return Header{Version: HeaderVersion, Theme: theme, SourceSHA256: hex.EncodeToString(sum[:])}
+}
+
+// WriteHeader writes the header `h` to `dst`, as a line of its own.
+func WriteHeader(dst io.Writer, h Header) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	line := append([]byte(headerPrefix), data...)
+	if _, err := dst.Write(append(line, '\n')); err != nil {
+		return &WriteError{Err: err}
+	}
+	return nil
+}
+
+// CutHeader returns the header at the start of `b`, if any, and the rest of
+// `b`. It returns false, and `b` unchanged, if `b` doesn't start with a valid
+// header.
+func CutHeader(b []byte) (Header, []byte, bool) {
+	if !bytes.HasPrefix(b, []byte(headerPrefix)) {
+		return Header{}, b, false
+	}
+	line, rest, _ := bytes.Cut(b[len(headerPrefix):], []byte("\n"))
+	var h Header
+	if err := json.Unmarshal(bytes.TrimSuffix(line, []byte("\r")), &h); err != nil || h.Version == 0 {
+		return Header{}, b, false
+	}
+	return h, rest, true
+}
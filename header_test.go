@@ -0,0 +1,75 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// srcSHA256 is the SHA-256 hash of `{"a": 1}`.
+const srcSHA256 = "f9d86028c6e0d64e225186f96acb69338b2c59764df79162107f5c4bb34d1310"
+
+func TestFormatHeader(t *testing.T) {
+	f := plainCompactFormatter()
+	f.Header = true
+	f.ThemeName = "monokai"
+	var buf bytes.Buffer
+	if err := f.Format(&buf, []byte(`{"a": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := `// jsoncolor {"version":1,"theme":"monokai","sha256":"` + srcSHA256 + "\"}\n" + `{"a":1}`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The hash is that of the input before it's transcoded from UTF-16.
+	buf.Reset()
+	src := []byte{0xff, 0xfe, '1', 0}
+	if err := f.Format(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	h, rest, ok := CutHeader(buf.Bytes())
+	if !ok || h != NewHeader("monokai", src) || string(rest) != "1" {
+		t.Errorf("UTF-16: got %+v, %q, %v", h, rest, ok)
+	}
+}
+
+func TestCutHeader(t *testing.T) {
+	tests := []struct {
+		name, src string
+		header    Header
+		rest      string
+		ok        bool
+	}{
+		{"header", `// jsoncolor {"version":1,"sha256":"ab"}` + "\n[1]", Header{Version: 1, SourceSHA256: "ab"}, "[1]", true},
+		{"CRLF", `// jsoncolor {"version":2,"theme":"x","sha256":"ab"}` + "\r\n[1]", Header{Version: 2, Theme: "x", SourceSHA256: "ab"}, "[1]", true},
+		{"header only", `// jsoncolor {"version":1,"sha256":"ab"}`, Header{Version: 1, SourceSHA256: "ab"}, "", true},
+		{"no header", "[1]", Header{}, "[1]", false},
+		{"other comment", "// comment\n[1]", Header{}, "// comment\n[1]", false},
+		{"invalid JSON", "// jsoncolor {\n[1]", Header{}, "// jsoncolor {\n[1]", false},
+		{"no version", `// jsoncolor {"sha256":"ab"}` + "\n[1]", Header{}, `// jsoncolor {"sha256":"ab"}` + "\n[1]", false},
+	}
+	for _, tt := range tests {
+		h, rest, ok := CutHeader([]byte(tt.src))
+		if h != tt.header || string(rest) != tt.rest || ok != tt.ok {
+			t.Errorf("%s: got %+v, %q, %v, want %+v, %q, %v", tt.name, h, rest, ok, tt.header, tt.rest, tt.ok)
+		}
+	}
+}
+
+func TestWriteHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHeader(&buf, NewHeader("", []byte(`{"a": 1}`))); err != nil {
+		t.Fatal(err)
+	}
+	// The theme is left out if unnamed.
+	if want := `// jsoncolor {"version":1,"sha256":"` + srcSHA256 + "\"}\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	err := WriteHeader(&flakyWriter{fail: map[int]bool{1: true}}, Header{Version: 1})
+	var writeErr *WriteError
+	if !errors.As(err, &writeErr) || !errors.Is(err, errFlaky) {
+		t.Errorf("got %v, want a WriteError wrapping errFlaky", err)
+	}
+}
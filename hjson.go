@@ -0,0 +1,154 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// hjson reports whether the input is HJSON.
+func (p *lenientParser) hjson() bool {
+	return p.fs.syntax == SyntaxHJSON
+}
+
+// hjsonKeyLen returns the length of the unquoted HJSON key at the start of
+// `b`: any characters but whitespace and punctuation.
+func hjsonKeyLen(b []byte) int {
+	n := 0
+	for n < len(b) && !isSpace(b[n]) && strings.IndexByte(",:[]{}", b[n]) < 0 {
+		n++
+	}
+	return n
+}
+
+// rootObject reports whether the HJSON document starting at the current
+// position is an object whose braces are omitted, which starts with a key
+// followed by a colon.
+func (p *lenientParser) rootObject() bool {
+	i := p.pos
+	switch p.src[i] {
+	case '"', '\'':
+		if i = p.stringEnd(i); i < 0 {
+			return false
+		}
+	default:
+		if i += hjsonKeyLen(p.src[i:]); i == p.pos {
+			return false
+		}
+	}
+	for i < len(p.src) && isSpace(p.src[i]) {
+		i++
+	}
+	return i < len(p.src) && p.src[i] == ':'
+}
+
+// hjsonKey reads the unquoted HJSON key starting at the current position.
+func (p *lenientParser) hjsonKey() error {
+	end := p.pos + hjsonKeyLen(p.src[p.pos:])
+	if end == p.pos {
+		return p.invalid()
+	}
+	return p.write(string(p.src[p.pos:end]), end)
+}
+
+// quoteless reads the HJSON value without quotes starting at the current
+// position: a number or literal if nothing but a comma, closing delimiter or
+// comment follows it on its line, or else a string made of the rest of the
+// line, whitespace trimmed.
+func (p *lenientParser) quoteless() error {
+	if strings.IndexByte(",:]}", p.src[p.pos]) >= 0 {
+		return p.invalid()
+	}
+	lineEnd := len(p.src)
+	if i := bytes.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+		lineEnd = p.pos + i
+	}
+
+	end := p.pos
+	for end < lineEnd && !isSpace(p.src[end]) && strings.IndexByte(",]}#/", p.src[end]) < 0 {
+		end++
+	}
+	rest := bytes.TrimLeft(p.src[end:lineEnd], " \t\r")
+	if len(rest) == 0 || strings.IndexByte(",]}#", rest[0]) >= 0 || bytes.HasPrefix(rest, []byte("//")) || bytes.HasPrefix(rest, []byte("/*")) {
+		word := p.src[p.pos:end]
+		switch string(word) {
+		case "true":
+			return p.write(true, end)
+		case "false":
+			return p.write(false, end)
+		case "null":
+			return p.write(nil, end)
+		}
+		if (word[0] == '-' || (word[0] >= '0' && word[0] <= '9')) && json.Valid(word) {
+			return p.write(json.Number(word), end)
+		}
+	}
+
+	s := bytes.TrimRight(p.src[p.pos:lineEnd], " \t\r")
+	return p.write(string(s), p.pos+len(s))
+}
+
+// multilineString reads the HJSON multiline string, enclosed in triple single
+// quotes, starting at the current position. Lines are unindented by up to the column of the opening
+// quotes, and the line breaks right after the opening quotes and right before
+// the closing ones are dropped.
+func (p *lenientParser) multilineString() error {
+	lineStart := bytes.LastIndexByte(p.src[:p.pos], '\n') + 1
+	indent := utf8.RuneCount(p.src[lineStart:p.pos])
+	unindent := func(i int) int {
+		for n := 0; n < indent && i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t'); n++ {
+			i++
+		}
+		return i
+	}
+
+	i := p.pos + 3
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t' || p.src[i] == '\r') {
+		i++
+	}
+	if i < len(p.src) && p.src[i] == '\n' {
+		i = unindent(i + 1)
+	}
+	b := &strings.Builder{}
+	for !bytes.HasPrefix(p.src[i:], []byte("'''")) {
+		if i >= len(p.src) {
+			return fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+		}
+		b.WriteByte(p.src[i])
+		i++
+		if p.src[i-1] == '\n' {
+			i = unindent(i)
+		}
+	}
+	s := strings.TrimSuffix(b.String(), "\n")
+	s = strings.TrimSuffix(s, "\r")
+	return p.write(s, i+3)
+}
+
+// decodeSingleQuoted decodes the contents of a single-quoted HJSON string,
+// which uses the escape sequences of JSON, plus \' for a single quote.
+func decodeSingleQuoted(contents []byte) (string, error) {
+	literal := make([]byte, 0, len(contents)+2)
+	literal = append(literal, '"')
+	for i := 0; i < len(contents); i++ {
+		switch c := contents[i]; {
+		case c == '"':
+			literal = append(literal, '\\', '"')
+		case c == '\\' && i+1 < len(contents) && contents[i+1] == '\'':
+			literal = append(literal, '\'')
+			i++
+		case c == '\\' && i+1 < len(contents):
+			literal = append(literal, c, contents[i+1])
+			i++
+		default:
+			literal = append(literal, c)
+		}
+	}
+	literal = append(literal, '"')
+	var s string
+	err := json.Unmarshal(literal, &s)
+	return s, err
+}
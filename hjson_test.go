@@ -0,0 +1,107 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHJSON(t *testing.T) {
+	const src = `# config
+name: my app  
+port: 8080
+tags: [
+  a
+  b c, with commas]
+  3
+]
+nested: {
+  ok: true # yes
+  q: 'it\'s "quoted"'
+}
+`
+	const want = `// config
+{
+  "name": "my app",
+  "port": 8080,
+  "tags": [
+    "a",
+    "b c, with commas]",
+    3
+  ],
+  "nested": {
+    "ok": true, // yes
+    "q": "it's \"quoted\""
+  }
+}`
+	if got := formatPlain(t, &Formatter{Syntax: SyntaxHJSON, Indent: "  "}, src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	// Comments are dropped in strict output.
+	f := &Formatter{Syntax: SyntaxHJSON, StrictOutput: true}
+	if got, want := formatPlain(t, f, src), `{"name":"my app","port":8080,"tags":["a","b c, with commas]",3],"nested":{"ok":true,"q":"it's \"quoted\""}}`; got != want {
+		t.Errorf("strict: got %s, want %s", got, want)
+	}
+}
+
+func TestHJSONQuoteless(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxHJSON, StrictOutput: true}
+	tests := []struct {
+		src, want string
+	}{
+		// Numbers and literals are only read as such if nothing else follows on their line.
+		{"[\n1 # one\n-1.5e2, true, null\n3x\ntrue love\n]", `[1,-1.5e2,true,null,"3x","true love"]`},
+		{"{a: 1, b: 2}", `{"a":1,"b":2}`},
+		{"{a: x, b: 2\n}", `{"a":"x, b: 2"}`},
+		{"{a: hello // not a comment\n}", `{"a":"hello // not a comment"}`},
+		{"{\"a b\": 'c'\n'd': e\n}", `{"a b":"c","d":"e"}`},
+	}
+	for _, tt := range tests {
+		if got := formatPlain(t, f, tt.src); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestHJSONMultiline(t *testing.T) {
+	const src = "{\n  text:\n    '''\n    line one\n      line two\n    '''\n  short: '''single'''\n}"
+	const want = `{"text":"line one\n  line two","short":"single"}`
+	if got := formatPlain(t, &Formatter{Syntax: SyntaxHJSON}, src); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestHJSONBraceless(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxHJSON}
+	tests := []struct {
+		src, want string
+	}{
+		{"key: \"value\"\nother: 2", `{"key":"value","other":2}`},
+		{"'quoted key': x", `{"quoted key":"x"}`},
+		// A top-level value which isn't followed by a colon is read as is.
+		{"[1]", `[1]`},
+		{"plain text", `"plain text"`},
+	}
+	for _, tt := range tests {
+		if got := formatPlain(t, f, tt.src); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestHJSONErrors(t *testing.T) {
+	tests := []struct {
+		src, wantErr string
+	}{
+		{"{ : 1}", "invalid character ':' at offset 2"},
+		{"[\n,]", "invalid character ',' at offset 2"},
+		{"{a: '''x", "unexpected EOF"},
+		{"{a: 1", "unexpected EOF"},
+		{"{a: 'x}", "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		err := (&Formatter{Syntax: SyntaxHJSON}).Format(&strings.Builder{}, []byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: got error %v, want one containing %q", tt.src, err, tt.wantErr)
+		}
+	}
+}
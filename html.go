@@ -0,0 +1,359 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// HTMLOptions configures FormatHTML.
+type HTMLOptions struct {
+	// ClassPrefix is prepended to the name of the kind of each token to form
+	// the CSS class of its <span>, e.g. "json-key" or "json-number" (see
+	// TokenKind.String). Default is "json-".
+	ClassPrefix string
+
+	// InlineStyles, if true, writes the colors of the Formatter into a style
+	// attribute on each <span> instead of a class, e.g. `style="color:#00cd00"`,
+	// for HTML which can't come with a stylesheet, such as emails.
+	InlineStyles bool
+
+	// NoPre, if true, leaves out the <pre> element otherwise wrapping the
+	// output, for embedding it into an element of one's own.
+	NoPre bool
+}
+
+// FormatHTML formats `src` as HTML using the DefaultFormatter. See
+// Formatter.FormatHTML.
+func FormatHTML(dst io.Writer, src []byte, opts HTMLOptions) error {
+	return DefaultFormatter.FormatHTML(dst, src, opts)
+}
+
+// FormatHTML formats `src` like Format, but writes HTML markup instead of ANSI
+// escape sequences, for embedding colorized JSON in web dashboards and
+// generated reports: the output is a <pre class="json"> element in which each
+// token other than whitespace is wrapped in a <span> with a CSS class naming
+// its kind. Formatter.CSS returns a stylesheet giving these classes the colors
+// of the Formatter, so that the HTML looks like the output in a terminal.
+//
+// With HTMLOptions.InlineStyles, each <span> is given the colors of its token
+// directly. Within keys and strings, the colors of the text apply to the
+// quotes too. Nothing is written if `src` is invalid.
+func (f *Formatter) FormatHTML(dst io.Writer, src []byte, opts HTMLOptions) error {
+	prefix := opts.ClassPrefix
+	if prefix == "" {
+		prefix = "json-"
+	}
+	var styles map[string]string
+	if opts.InlineStyles {
+		styles = make(map[string]string)
+	}
+
+	sb := &strings.Builder{}
+	if !opts.NoPre {
+		fmt.Fprintf(sb, `<pre class="%s">`, html.EscapeString(strings.TrimSuffix(prefix, "-")))
+	}
+	err := f.formatMemberSegments(src, func(s memberSegment) {
+		switch {
+		case s.Kind == TokenWhitespace:
+			sb.WriteString(html.EscapeString(s.Text))
+		case opts.InlineStyles:
+			// Booleans are the only kind whose color depends on the text.
+			key := f.segmentColorKey(s)
+			style, ok := styles[key]
+			if !ok {
+				style = strings.Join(sgrCSS(f.segmentColor(s).SprintfFunc()("%s", "x")), ";")
+				styles[key] = style
+			}
+			if style == "" {
+				sb.WriteString(html.EscapeString(s.Text))
+			} else {
+				fmt.Fprintf(sb, `<span style="%s">%s</span>`, style, html.EscapeString(s.Text))
+			}
+		default:
+			fmt.Fprintf(sb, `<span class="%s%s">%s</span>`, html.EscapeString(prefix), s.Kind, html.EscapeString(s.Text))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !opts.NoPre {
+		sb.WriteString("</pre>")
+	}
+	_, err = io.WriteString(dst, sb.String())
+	return err
+}
+
+// CSS returns a stylesheet giving the classes written by FormatHTML, named
+// with the class prefix `prefix` ("json-" if empty), the colors of the
+// Formatter, e.g. `.json-key { color:#0000ee; font-weight:bold; }`.
+//
+// The colors are read from the escape sequences the Formatter's colors
+// produce, so none are found if colors are disabled, as
+// github.com/amterp/color does by default when standard output is not a
+// terminal. Booleans have the color of true.
+func (f *Formatter) CSS(prefix string) string {
+	if prefix == "" {
+		prefix = "json-"
+	}
+	colors := []struct {
+		kind  TokenKind
+		color SprintfFuncer
+	}{
+		{TokenKey, f.fieldColor()},
+		{TokenString, f.stringColor()},
+		{TokenNumber, f.numberColor()},
+		{TokenBool, f.trueColor()},
+		{TokenNull, f.nullColor()},
+		{TokenObjectDelim, f.objectColor()},
+		{TokenArrayDelim, f.arrayColor()},
+		{TokenColon, f.colonColor()},
+		{TokenComma, f.commaColor()},
+		{TokenComment, f.commentColor()},
+		{TokenAnnotation, f.annotationColor()},
+	}
+	sb := &strings.Builder{}
+	for _, c := range colors {
+		decls := sgrCSS(c.color.SprintfFunc()("%s", "x"))
+		if len(decls) == 0 {
+			continue
+		}
+		fmt.Fprintf(sb, ".%s%s { %s; }\n", prefix, c.kind, strings.Join(decls, "; "))
+	}
+	return sb.String()
+}
+
+// segmentColorKey returns a key telling apart the segments segmentColor gives
+// different colors, for renderers caching the rendering of each color.
+func (f *Formatter) segmentColorKey(s memberSegment) string {
+	key := s.Kind.String()
+	if s.Kind == TokenBool {
+		key = s.Text
+	}
+	if s.member && (f.keyColor(s.key) != nil || f.valueColor(s.key) != nil) {
+		key += "/" + s.key
+	}
+	return key
+}
+
+// segmentColor returns the color Format would write the segment `s` in.
+func (f *Formatter) segmentColor(s memberSegment) SprintfFuncer {
+	if s.member {
+		switch s.Kind {
+		case TokenKey:
+			if c := f.keyColor(s.key); c != nil {
+				return c
+			}
+		case TokenString, TokenNumber, TokenBool, TokenNull:
+			if c := f.valueColor(s.key); c != nil && f.colorizes(s.Kind) {
+				return c
+			}
+		}
+	}
+	switch s.Kind {
+	case TokenKey:
+		return f.fieldColor()
+	case TokenString:
+		return f.stringColor()
+	case TokenNumber:
+		return f.numberColor()
+	case TokenBool:
+		if s.Text == "false" {
+			return f.falseColor()
+		}
+		return f.trueColor()
+	case TokenNull:
+		return f.nullColor()
+	case TokenObjectDelim:
+		return f.objectColor()
+	case TokenArrayDelim:
+		return f.arrayColor()
+	case TokenColon:
+		return f.colonColor()
+	case TokenComma:
+		return f.commaColor()
+	case TokenComment:
+		return f.commentColor()
+	case TokenAnnotation:
+		return f.annotationColor()
+	}
+	return f.spaceColor()
+}
+
+// sgrCSS returns the CSS declarations, e.g. "color:#cd0000", equivalent to the
+// SGR escape sequences at the start of `s`.
+func sgrCSS(s string) []string {
+	return sgrStyle(s).declarations()
+}
+
+// sgrStyle returns the style set by the SGR escape sequences at the start of
+// `s`.
+func sgrStyle(s string) cssStyle {
+	var style cssStyle
+	for strings.HasPrefix(s, "\x1b[") {
+		end := strings.IndexByte(s, 'm')
+		if end < 0 {
+			break
+		}
+		style.apply(s[2:end])
+		s = s[end+1:]
+	}
+	return style
+}
+
+// cssStyle holds the text attributes set by SGR escape sequences.
+type cssStyle struct {
+	color, background                       string
+	bold, faint, italic, underline, crossed bool
+	reverse                                 bool
+}
+
+// apply applies the SGR parameters `params`, e.g. "1;31".
+func (c *cssStyle) apply(params string) {
+	var codes []int
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p) // An empty parameter means 0.
+		codes = append(codes, n)
+	}
+	for i := 0; i < len(codes); i++ {
+		switch n := codes[i]; {
+		case n == 0:
+			*c = cssStyle{}
+		case n == 1:
+			c.bold = true
+		case n == 2:
+			c.faint = true
+		case n == 3:
+			c.italic = true
+		case n == 4:
+			c.underline = true
+		case n == 7:
+			c.reverse = true
+		case n == 9:
+			c.crossed = true
+		case n == 22:
+			c.bold, c.faint = false, false
+		case n == 23:
+			c.italic = false
+		case n == 24:
+			c.underline = false
+		case n == 27:
+			c.reverse = false
+		case n == 29:
+			c.crossed = false
+		case n >= 30 && n <= 37:
+			c.color = ansiColors[n-30]
+		case n >= 90 && n <= 97:
+			c.color = ansiColors[n-90+8]
+		case n == 39:
+			c.color = ""
+		case n >= 40 && n <= 47:
+			c.background = ansiColors[n-40]
+		case n >= 100 && n <= 107:
+			c.background = ansiColors[n-100+8]
+		case n == 49:
+			c.background = ""
+		case n == 38 || n == 48:
+			color, used := extendedColor(codes[i+1:])
+			i += used
+			if n == 38 {
+				c.color = color
+			} else {
+				c.background = color
+			}
+		}
+	}
+}
+
+// declarations returns the CSS declarations of the style.
+func (c cssStyle) declarations() []string {
+	var decls []string
+	color, background := c.color, c.background
+	if c.reverse {
+		color, background = background, color
+		if color == "" {
+			color = "Canvas"
+		}
+		if background == "" {
+			background = "CanvasText"
+		}
+	}
+	if color != "" {
+		decls = append(decls, "color:"+color)
+	}
+	if background != "" {
+		decls = append(decls, "background-color:"+background)
+	}
+	if c.bold {
+		decls = append(decls, "font-weight:bold")
+	}
+	if c.faint {
+		decls = append(decls, "opacity:0.6")
+	}
+	if c.italic {
+		decls = append(decls, "font-style:italic")
+	}
+	switch {
+	case c.underline && c.crossed:
+		decls = append(decls, "text-decoration:underline line-through")
+	case c.underline:
+		decls = append(decls, "text-decoration:underline")
+	case c.crossed:
+		decls = append(decls, "text-decoration:line-through")
+	}
+	return decls
+}
+
+// fadedColor returns the color `color` as shown faint, at the opacity of the
+// CSS declarations, on a white page, for formats without opacity, such as
+// RTF. An empty color is taken to be black.
+func fadedColor(color string) string {
+	if color == "" {
+		color = "#000000"
+	}
+	r, g, b, ok := parseHexColor(color)
+	if !ok {
+		return color
+	}
+	fade := func(c uint8) uint8 {
+		return uint8(float64(c)*0.6 + 255*0.4 + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", fade(r), fade(g), fade(b))
+}
+
+// extendedColor parses the parameters following 38 or 48 in an SGR sequence:
+// 5;n for a color of the 256-color palette, or 2;r;g;b for an RGB color. It
+// returns the color and the number of parameters it consists of.
+func extendedColor(codes []int) (string, int) {
+	switch {
+	case len(codes) >= 2 && codes[0] == 5:
+		return paletteColor(codes[1]), 2
+	case len(codes) >= 4 && codes[0] == 2:
+		return fmt.Sprintf("#%02x%02x%02x", uint8(codes[1]), uint8(codes[2]), uint8(codes[3])), 4
+	}
+	return "", len(codes)
+}
+
+// ansiColors holds the 16 standard ANSI colors, as rendered by xterm.
+var ansiColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// paletteColor returns the color `n` of the xterm 256-color palette.
+func paletteColor(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	}
+	gray := 8 + 10*(n-232)
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
@@ -0,0 +1,148 @@
+package jsoncolor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// styledFormatter returns a Formatter with a Style for each kind of value,
+// and none for punctuation.
+func styledFormatter() *Formatter {
+	return &Formatter{
+		Indent:           "  ",
+		SpaceColor:       Style{},
+		FieldColor:       Style{FgBlue, Bold},
+		FieldQuoteColor:  Style{FgBlue, Bold},
+		StringColor:      Style{FgGreen},
+		StringQuoteColor: Style{FgGreen},
+		NumberColor:      Style{FgHiCyan},
+		TrueColor:        Style{Italic},
+		FalseColor:       Style{FgRed},
+		NullColor:        Style{Faint},
+		ObjectColor:      Style{},
+		ArrayColor:       Style{},
+		ColonColor:       Style{},
+		CommaColor:       Style{},
+		CommentColor:     Style{},
+	}
+}
+
+const htmlInput = `{"a<b": ["x&y", 1, true, false, null]}`
+
+func TestFormatHTML(t *testing.T) {
+	var sb strings.Builder
+	if err := styledFormatter().FormatHTML(&sb, []byte(htmlInput), HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `<pre class="json"><span class="json-object-delim">{</span>
+  <span class="json-key">&#34;a&lt;b&#34;</span><span class="json-colon">:</span> <span class="json-array-delim">[</span>
+    <span class="json-string">&#34;x&amp;y&#34;</span><span class="json-comma">,</span>
+    <span class="json-number">1</span><span class="json-comma">,</span>
+    <span class="json-bool">true</span><span class="json-comma">,</span>
+    <span class="json-bool">false</span><span class="json-comma">,</span>
+    <span class="json-null">null</span>
+  <span class="json-array-delim">]</span>
+<span class="json-object-delim">}</span></pre>`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	sb.Reset()
+	if err := plainCompactFormatter().FormatHTML(&sb, []byte(`[1]`), HTMLOptions{ClassPrefix: "j-", NoPre: true}); err != nil {
+		t.Fatal(err)
+	}
+	want = `<span class="j-array-delim">[</span><span class="j-number">1</span><span class="j-array-delim">]</span>`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatHTMLInlineStyles(t *testing.T) {
+	withColor(t, true)
+	var sb strings.Builder
+	if err := styledFormatter().FormatHTML(&sb, []byte(htmlInput), HTMLOptions{InlineStyles: true}); err != nil {
+		t.Fatal(err)
+	}
+	// Tokens without colors aren't wrapped, and true and false have their own.
+	want := `<pre class="json">{
+  <span style="color:#0000ee;font-weight:bold">&#34;a&lt;b&#34;</span>: [
+    <span style="color:#00cd00">&#34;x&amp;y&#34;</span>,
+    <span style="color:#00ffff">1</span>,
+    <span style="font-style:italic">true</span>,
+    <span style="color:#cd0000">false</span>,
+    <span style="opacity:0.6">null</span>
+  ]
+}</pre>`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatHTMLInvalid(t *testing.T) {
+	var sb strings.Builder
+	if err := styledFormatter().FormatHTML(&sb, []byte(`{"a":`), HTMLOptions{}); err == nil {
+		t.Error("got no error")
+	}
+	if sb.Len() != 0 {
+		t.Errorf("got output %q", sb.String())
+	}
+}
+
+func TestCSS(t *testing.T) {
+	withColor(t, true)
+	want := `.json-key { color:#0000ee; font-weight:bold; }
+.json-string { color:#00cd00; }
+.json-number { color:#00ffff; }
+.json-bool { font-style:italic; }
+.json-null { opacity:0.6; }
+`
+	if got := styledFormatter().CSS(""); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := styledFormatter().CSS("x-"); !strings.HasPrefix(got, ".x-key {") {
+		t.Errorf("prefix: got:\n%s", got)
+	}
+
+	withColor(t, false)
+	if got := styledFormatter().CSS(""); got != "" {
+		t.Errorf("colors disabled: got:\n%s", got)
+	}
+}
+
+func TestSGRCSS(t *testing.T) {
+	tests := []struct {
+		sgr  string
+		want []string
+	}{
+		{"\x1b[31m", []string{"color:#cd0000"}},
+		{"\x1b[38;5;196;48;2;1;2;3;4;9m", []string{"color:#ff0000", "background-color:#010203", "text-decoration:underline line-through"}},
+		{"\x1b[38;5;21m", []string{"color:#0000ff"}},
+		{"\x1b[38;5;244m", []string{"color:#808080"}},
+		{"\x1b[7m", []string{"color:Canvas", "background-color:CanvasText"}},
+		{"\x1b[7;32;104m", []string{"color:#5c5cff", "background-color:#00cd00"}},
+		// Later sequences override earlier ones.
+		{"\x1b[1;22;3m\x1b[0;93m", []string{"color:#ffff00"}},
+		{"plain", nil},
+	}
+	for _, tt := range tests {
+		if got := sgrCSS(tt.sgr + "x\x1b[0m"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.sgr, got, tt.want)
+		}
+	}
+}
+
+func TestFadedColor(t *testing.T) {
+	tests := []struct{ color, want string }{
+		{"#cd0000", "#e16666"},
+		{"#ffffff", "#ffffff"},
+		// Text without a color is black.
+		{"", "#666666"},
+		{"red", "red"},
+	}
+	for _, tt := range tests {
+		if got := fadedColor(tt.color); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.color, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes `v` as the response to the HTTP request `r`, in the form
+// best suited to its client, so that debug endpoints serve readable JSON to
+// people and plain JSON to programs:
+//
+//   - Clients preferring HTML over JSON, as browsers do, get an HTML page of
+//     the JSON indented and colorized with the Formatter `f` (the
+//     DefaultFormatter if nil), as written by FormatHTML with inline styles.
+//   - Clients preferring text/plain over JSON, or requesting ?pretty, such as
+//     `curl 'localhost:8080/debug?pretty'`, get the JSON indented and
+//     colorized with escape sequences, as text/plain.
+//   - Other clients get compact JSON without colors, as application/json.
+//
+// As with FormatHTML, the HTML only has the colors of the Formatter when
+// github.com/amterp/color enables them, whereas the text/plain response always
+// has them. Preferences are read from the Accept header, and ?pretty=0 or
+// ?pretty=false count as not requesting it. The indentation is the
+// Formatter's Indent if set, and two spaces otherwise. The response varies
+// with the Accept header, which is set in its Vary header. `v` is marshalled
+// before anything is written, so that if it can't be, the error is returned
+// and the handler can still respond otherwise.
+func WriteJSON(w http.ResponseWriter, r *http.Request, v interface{}, f *Formatter) error {
+	if f == nil {
+		f = DefaultFormatter
+	}
+	indent := f.Indent
+	if indent == "" {
+		indent = "  "
+	}
+
+	buf := &bytes.Buffer{}
+	var contentType string
+	switch negotiateJSON(r) {
+	case "text/html":
+		src, err := marshalJSON(v, false)
+		if err != nil {
+			return err
+		}
+		g := f.clone()
+		g.setIndent("", indent)
+		buf.WriteString("<!DOCTYPE html>\n<meta charset=\"utf-8\">\n")
+		if err := g.FormatHTML(buf, src, HTMLOptions{InlineStyles: true}); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+		contentType = "text/html; charset=utf-8"
+	case "text/plain":
+		enc := NewEncoderWithFormatter(buf, f, WithIndent("", indent), WithColorMode(ColorAlways))
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		contentType = "text/plain; charset=utf-8"
+	default:
+		enc := NewEncoderWithFormatter(buf, f, WithIndent("", ""), WithColorMode(ColorNever))
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		contentType = "application/json"
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Accept")
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	h.Set("X-Content-Type-Options", "nosniff")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// negotiateJSON returns the type of response WriteJSON gives to `r`:
+// "text/html", "text/plain" or "application/json".
+func negotiateJSON(r *http.Request) string {
+	html, plain, json := acceptQuality(r.Header.Values("Accept"))
+	if pretty, ok := r.URL.Query()["pretty"]; ok {
+		if len(pretty) == 0 || (pretty[0] != "0" && pretty[0] != "false") {
+			// Ties go to plain text, as for curl, which accepts everything.
+			if html > plain {
+				return "text/html"
+			}
+			return "text/plain"
+		}
+	}
+	switch {
+	case html > json && html >= plain:
+		return "text/html"
+	case plain > json:
+		return "text/plain"
+	}
+	return "application/json"
+}
+
+// acceptQuality returns the quality values the Accept headers `accept` give to
+// HTML, plain text and JSON, from 0 for unacceptable to 1. Without Accept
+// headers, everything is acceptable.
+func acceptQuality(accept []string) (html, plain, json float64) {
+	if len(accept) == 0 {
+		return 1, 1, 1
+	}
+	// The quality of each type is that of the most specific range matching it.
+	specificity := [3]int{-1, -1, -1}
+	quality := [3]float64{}
+	types := [3]string{"text/html", "text/plain", "application/json"}
+	for _, header := range accept {
+		for _, mediaRange := range strings.Split(header, ",") {
+			params := strings.Split(mediaRange, ";")
+			name := strings.ToLower(strings.TrimSpace(params[0]))
+			q := 1.0
+			for _, param := range params[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "q") {
+					if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+						q = parsed
+					}
+				}
+			}
+			for i, t := range types {
+				s := -1
+				switch {
+				case name == t:
+					s = 2
+				case name == t[:strings.IndexByte(t, '/')]+"/*":
+					s = 1
+				case name == "*/*":
+					s = 0
+				}
+				if s > specificity[i] {
+					specificity[i], quality[i] = s, q
+				}
+			}
+		}
+	}
+	return quality[0], quality[1], quality[2]
+}
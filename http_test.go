@@ -0,0 +1,82 @@
+package jsoncolor
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateJSON(t *testing.T) {
+	tests := []struct {
+		url, accept, want string
+	}{
+		{"/", "", "application/json"},
+		{"/", "application/json", "application/json"},
+		{"/", "text/html,application/xhtml+xml,*/*;q=0.8", "text/html"},
+		{"/", "text/plain", "text/plain"},
+		{"/", "text/*;q=0.5, application/json", "application/json"},
+		{"/", "text/plain, text/*;q=0", "text/plain"},
+		{"/", "*/*", "application/json"},
+		// curl accepts everything, and ?pretty asks for colors.
+		{"/?pretty", "*/*", "text/plain"},
+		{"/?pretty=1", "", "text/plain"},
+		{"/?pretty=false", "text/plain;q=0.1, application/json", "application/json"},
+		{"/?pretty=0", "text/plain", "text/plain"},
+		{"/?pretty", "text/html", "text/html"},
+		{"/?pretty", "application/json, text/html;q=0.5", "text/html"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		if got := negotiateJSON(r); got != tt.want {
+			t.Errorf("%s with Accept %q: got %s, want %s", tt.url, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	withColor(t, false)
+	f := plainCompactFormatter()
+	f.NumberColor = Style{FgHiCyan}
+	v := map[string]any{"a": []int{1}}
+	tests := []struct {
+		url, accept, contentType, body string
+	}{
+		{"/", "", "application/json", `{"a":[1]}` + "\n"},
+		// Colors are always written in plain text.
+		{"/?pretty", "", "text/plain; charset=utf-8", "{\n  \"a\": [\n    \x1b[96m1\x1b[0m\n  ]\n}\n"},
+		{"/", "text/html", "text/html; charset=utf-8", "<!DOCTYPE html>\n<meta charset=\"utf-8\">\n<pre class=\"json\">{\n  &#34;a&#34;: [\n    1\n  ]\n}</pre>\n"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		if err := WriteJSON(w, r, v, f); err != nil {
+			t.Fatal(err)
+		}
+		h := w.Result().Header
+		if got := h.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%s: Content-Type %q, want %q", tt.url, got, tt.contentType)
+		}
+		if h.Get("Vary") != "Accept" || h.Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("%s: headers %v", tt.url, h)
+		}
+		if got := w.Body.String(); got != tt.body {
+			t.Errorf("%s: got %q, want %q", tt.url, got, tt.body)
+		}
+	}
+}
+
+func TestWriteJSONError(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := WriteJSON(w, httptest.NewRequest("GET", "/", nil), func() {}, nil); err == nil {
+		t.Error("got no error")
+	}
+	// Nothing is written, so the handler can respond otherwise.
+	if w.Body.Len() != 0 || len(w.Header()) != 0 {
+		t.Errorf("got response %v %q", w.Header(), w.Body.String())
+	}
+}
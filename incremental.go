@@ -0,0 +1,389 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// IncrementalValidator validates a JSON document fed to it in chunks, such as
+// the text of an editor as it's typed or the lines entered at a prompt. Each
+// byte is only scanned once, so validating a document as it grows costs no
+// more than validating it at the end, and the validator knows at any point
+// whether the document is complete, still missing input, or invalid.
+//
+// The zero value is not usable; create one with NewIncrementalValidator.
+type IncrementalValidator struct {
+	f *Formatter // Colorizes the excerpt of the SyntaxReport.
+
+	state   scanState
+	stack   []byte // The opening delimiter of each enclosing container.
+	inKey   bool   // True if the string being scanned is an object key.
+	literal string // The literal (true, false or null) being scanned.
+	matched int    // The number of bytes of the literal scanned so far.
+	hex     int    // The number of hexadecimal digits left in a \u escape sequence.
+	started bool   // True once the value has started.
+
+	offset int64  // The number of bytes scanned.
+	line   int    // The 1-based line number of the next byte.
+	column int    // The 1-based column, in runes, of the next byte.
+	tail   []byte // The end of the current line, for the excerpt of a SyntaxReport.
+
+	report *SyntaxReport // The first error found, if any.
+}
+
+// scanState is the state of an IncrementalValidator between two bytes.
+type scanState int
+
+const (
+	scanBeginValue        scanState = iota // Expecting a value.
+	scanBeginValueOrEmpty                  // After '[': expecting a value or ']'.
+	scanBeginKey                           // After ',' in an object: expecting a key.
+	scanBeginKeyOrEmpty                    // After '{': expecting a key or '}'.
+	scanAfterKey                           // Expecting ':'.
+	scanAfterValue                         // After a value: expecting ',' or a closing delimiter, or nothing more.
+	scanString                             // In a string.
+	scanStringEscape                       // After a backslash in a string.
+	scanStringHex                          // In the hexadecimal digits of a \u escape sequence.
+	scanLiteral                            // In true, false or null.
+	scanNegative                           // After the minus sign of a number.
+	scanZero                               // After the leading zero of a number.
+	scanInteger                            // In the integer digits of a number.
+	scanDot                                // After the decimal point of a number.
+	scanFraction                           // In the fraction digits of a number.
+	scanExponent                           // After the e of a number.
+	scanExponentSign                       // After the sign of the exponent of a number.
+	scanExponentDigits                     // In the exponent digits of a number.
+)
+
+// NewIncrementalValidator creates an IncrementalValidator whose SyntaxReport
+// excerpts are colorized with the DefaultFormatter.
+func NewIncrementalValidator() *IncrementalValidator {
+	return DefaultFormatter.NewIncrementalValidator()
+}
+
+// NewIncrementalValidator creates an IncrementalValidator whose SyntaxReport
+// excerpts are colorized with this Formatter's colors.
+func (f *Formatter) NewIncrementalValidator() *IncrementalValidator {
+	v := &IncrementalValidator{f: f}
+	v.Reset()
+	return v
+}
+
+// Reset discards everything written so far, so that the IncrementalValidator
+// can validate a new document.
+func (v *IncrementalValidator) Reset() {
+	*v = IncrementalValidator{f: v.f, stack: v.stack[:0], tail: v.tail[:0], line: 1, column: 1}
+}
+
+// Write appends `p` to the document being validated. Once an error is found,
+// Write returns the SyntaxReport describing it, along with the number of bytes
+// of `p` before the offending one, and nothing more is scanned.
+func (v *IncrementalValidator) Write(p []byte) (int, error) {
+	if v.report != nil {
+		return 0, v.report
+	}
+	for i, c := range p {
+		if msg := v.step(c); msg != "" {
+			v.fail(c, msg)
+			return i, v.report
+		}
+		v.advance(c)
+	}
+	return len(p), nil
+}
+
+// Err returns the SyntaxReport describing the first error found, or nil if
+// the document written so far is valid, if possibly incomplete.
+func (v *IncrementalValidator) Err() *SyntaxReport {
+	return v.report
+}
+
+// Complete reports whether the document written so far is a complete, valid
+// JSON value. More whitespace may still follow it, but nothing else. A number
+// at the top level is complete, although more digits could extend it.
+func (v *IncrementalValidator) Complete() bool {
+	if v.report != nil || len(v.stack) > 0 {
+		return false
+	}
+	switch v.state {
+	case scanAfterValue, scanZero, scanInteger, scanFraction, scanExponentDigits:
+		return true
+	}
+	return false
+}
+
+// NeedsMoreInput reports whether the document written so far is the valid
+// beginning of a JSON value, but isn't complete yet. It's false before
+// anything but whitespace is written. See the package-level NeedsMoreInput.
+func (v *IncrementalValidator) NeedsMoreInput() bool {
+	return v.started && v.report == nil && !v.Complete()
+}
+
+// Offset returns the number of bytes scanned so far, which excludes the
+// offending byte and anything after it once an error is found.
+func (v *IncrementalValidator) Offset() int64 {
+	return v.offset
+}
+
+// advance updates the position after scanning `c`.
+func (v *IncrementalValidator) advance(c byte) {
+	v.offset++
+	if c == '\n' {
+		v.line++
+		v.column = 1
+		v.tail = v.tail[:0]
+		return
+	}
+	if utf8.RuneStart(c) {
+		v.column++
+	}
+	// Only the end of the line is kept, which is all an excerpt shows.
+	if len(v.tail) >= 4*excerptContext {
+		v.tail = append(v.tail[:0], v.tail[len(v.tail)-2*excerptContext:]...)
+	}
+	v.tail = append(v.tail, c)
+}
+
+// fail records the error `msg` for the offending byte `c`.
+func (v *IncrementalValidator) fail(c byte, msg string) {
+	// Like encoding/json, the offset of the error is just past the offending byte.
+	v.report = &SyntaxReport{
+		Message: msg,
+		Offset:  v.offset + 1,
+		Line:    v.line,
+		Column:  v.column,
+		Excerpt: v.f.excerpt(append(v.tail, c), len(v.tail)),
+	}
+	v.tail = v.tail[:0]
+}
+
+// quoteChar formats `c` the way encoding/json does in its error messages.
+func quoteChar(c byte) string {
+	switch c {
+	case '\'':
+		return `'\''`
+	case '"':
+		return `'"'`
+	}
+	s := fmt.Sprintf("%q", string(rune(c)))
+	return "'" + s[1:len(s)-1] + "'"
+}
+
+// step scans the byte `c`, returning the message of the error it causes, if any.
+func (v *IncrementalValidator) step(c byte) string {
+	switch v.state {
+	case scanBeginValue, scanBeginValueOrEmpty:
+		if isSpace(c) {
+			return ""
+		}
+		if c == ']' && v.state == scanBeginValueOrEmpty {
+			return v.close()
+		}
+		return v.beginValue(c)
+
+	case scanBeginKey, scanBeginKeyOrEmpty:
+		switch {
+		case isSpace(c):
+		case c == '}' && v.state == scanBeginKeyOrEmpty:
+			return v.close()
+		case c == '"':
+			v.state, v.inKey = scanString, true
+		default:
+			return "invalid character " + quoteChar(c) + " looking for beginning of object key string"
+		}
+		return ""
+
+	case scanAfterKey:
+		switch {
+		case isSpace(c):
+		case c == ':':
+			v.state = scanBeginValue
+		default:
+			return "invalid character " + quoteChar(c) + " after object key"
+		}
+		return ""
+
+	case scanAfterValue:
+		return v.afterValue(c)
+
+	case scanString:
+		switch {
+		case c == '"':
+			if v.inKey {
+				v.state = scanAfterKey
+			} else {
+				v.state = scanAfterValue
+			}
+		case c == '\\':
+			v.state = scanStringEscape
+		case c < 0x20:
+			return "invalid character " + quoteChar(c) + " in string literal"
+		}
+		return ""
+
+	case scanStringEscape:
+		switch c {
+		case 'b', 'f', 'n', 'r', 't', '\\', '/', '"':
+			v.state = scanString
+		case 'u':
+			v.state, v.hex = scanStringHex, 4
+		default:
+			return "invalid character " + quoteChar(c) + " in string escape code"
+		}
+		return ""
+
+	case scanStringHex:
+		if !isHexDigit(c) {
+			return "invalid character " + quoteChar(c) + ` in \u hexadecimal character escape`
+		}
+		if v.hex--; v.hex == 0 {
+			v.state = scanString
+		}
+		return ""
+
+	case scanLiteral:
+		if c != v.literal[v.matched] {
+			return "invalid character " + quoteChar(c) + " in literal " + v.literal + " (expecting " + quoteChar(v.literal[v.matched]) + ")"
+		}
+		if v.matched++; v.matched == len(v.literal) {
+			v.state = scanAfterValue
+		}
+		return ""
+	}
+	return v.number(c)
+}
+
+// beginValue scans the first byte `c` of a value.
+func (v *IncrementalValidator) beginValue(c byte) string {
+	v.started = true
+	switch {
+	case c == '{' || c == '[':
+		if len(v.stack) >= maxBinaryDepth {
+			return "exceeded max depth"
+		}
+		v.stack = append(v.stack, c)
+		v.state = scanBeginValueOrEmpty
+		if c == '{' {
+			v.state = scanBeginKeyOrEmpty
+		}
+	case c == '"':
+		v.state, v.inKey = scanString, false
+	case c == 't':
+		v.state, v.literal, v.matched = scanLiteral, "true", 1
+	case c == 'f':
+		v.state, v.literal, v.matched = scanLiteral, "false", 1
+	case c == 'n':
+		v.state, v.literal, v.matched = scanLiteral, "null", 1
+	case c == '-':
+		v.state = scanNegative
+	case c == '0':
+		v.state = scanZero
+	case c >= '1' && c <= '9':
+		v.state = scanInteger
+	default:
+		return "invalid character " + quoteChar(c) + " looking for beginning of value"
+	}
+	return ""
+}
+
+// afterValue scans the byte `c` following a value.
+func (v *IncrementalValidator) afterValue(c byte) string {
+	if isSpace(c) {
+		return ""
+	}
+	if len(v.stack) == 0 {
+		return "invalid character " + quoteChar(c) + " after top-level value"
+	}
+	if v.stack[len(v.stack)-1] == '{' {
+		switch c {
+		case ',':
+			v.state = scanBeginKey
+			return ""
+		case '}':
+			return v.close()
+		}
+		return "invalid character " + quoteChar(c) + " after object key:value pair"
+	}
+	switch c {
+	case ',':
+		v.state = scanBeginValue
+		return ""
+	case ']':
+		return v.close()
+	}
+	return "invalid character " + quoteChar(c) + " after array element"
+}
+
+// close scans the closing delimiter of the current container.
+func (v *IncrementalValidator) close() string {
+	v.stack = v.stack[:len(v.stack)-1]
+	v.state = scanAfterValue
+	return ""
+}
+
+// number scans the byte `c` within a number.
+func (v *IncrementalValidator) number(c byte) string {
+	digit := c >= '0' && c <= '9'
+	switch v.state {
+	case scanNegative:
+		switch {
+		case c == '0':
+			v.state = scanZero
+		case digit:
+			v.state = scanInteger
+		default:
+			return "invalid character " + quoteChar(c) + " in numeric literal"
+		}
+		return ""
+	case scanInteger:
+		if digit {
+			return ""
+		}
+		fallthrough
+	case scanZero:
+		switch c {
+		case '.':
+			v.state = scanDot
+			return ""
+		case 'e', 'E':
+			v.state = scanExponent
+			return ""
+		}
+	case scanDot:
+		if !digit {
+			return "invalid character " + quoteChar(c) + " after decimal point in numeric literal"
+		}
+		v.state = scanFraction
+		return ""
+	case scanFraction:
+		if digit {
+			return ""
+		}
+		if c == 'e' || c == 'E' {
+			v.state = scanExponent
+			return ""
+		}
+	case scanExponent, scanExponentSign:
+		if v.state == scanExponent && (c == '+' || c == '-') {
+			v.state = scanExponentSign
+			return ""
+		}
+		if !digit {
+			return "invalid character " + quoteChar(c) + " in exponent of numeric literal"
+		}
+		v.state = scanExponentDigits
+		return ""
+	case scanExponentDigits:
+		if digit {
+			return ""
+		}
+	}
+	// The number is complete, and `c` follows it.
+	v.state = scanAfterValue
+	return v.afterValue(c)
+}
+
+// isHexDigit reports whether `c` is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
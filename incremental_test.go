@@ -0,0 +1,161 @@
+package jsoncolor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// writeBytes writes `src` to `v` one byte at a time, as a prompt fed by a slow
+// terminal would, stopping at the first error.
+func writeBytes(v *IncrementalValidator, src string) error {
+	for i := 0; i < len(src); i++ {
+		if _, err := v.Write([]byte{src[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestIncrementalValidatorStates(t *testing.T) {
+	tests := []struct {
+		src                      string
+		complete, needsMoreInput bool
+	}{
+		{"", false, false},
+		{"  \n", false, false},
+		{"{", false, true},
+		{`{"a"`, false, true},
+		{`{"a":`, false, true},
+		{`{"a": [1, 2`, false, true},
+		{`{"a": [1, 2]}`, true, false},
+		{"{}\n", true, false},
+		{`"ab\u00`, false, true},
+		{`"ab"`, true, false},
+		{"tr", false, true},
+		{"true", true, false},
+		{"-", false, true},
+		{"0", true, false},
+		{"12", true, false},
+		{"1.", false, true},
+		{"1.5", true, false},
+		{"1e", false, true},
+		{"1e+", false, true},
+		{"1e+5", true, false},
+		{"[1 2]", false, false},
+	}
+	for _, tt := range tests {
+		v := NewIncrementalValidator()
+		writeBytes(v, tt.src)
+		if got := v.Complete(); got != tt.complete {
+			t.Errorf("%q: Complete() = %v, want %v", tt.src, got, tt.complete)
+		}
+		if got := v.NeedsMoreInput(); got != tt.needsMoreInput {
+			t.Errorf("%q: NeedsMoreInput() = %v, want %v", tt.src, got, tt.needsMoreInput)
+		}
+	}
+}
+
+// TestIncrementalValidatorErrors checks the errors found byte by byte. The
+// messages and offsets are those of encoding/json's classic scanner.
+func TestIncrementalValidatorErrors(t *testing.T) {
+	tests := []struct {
+		src, message string
+		offset       int64
+	}{
+		{"[1 2]", "invalid character '2' after array element", 4},
+		{"{\"a\" 1}", "invalid character '1' after object key", 6},
+		{"{1: 2}", "invalid character '1' looking for beginning of object key string", 2},
+		{"{\"a\": 1 \"b\": 2}", "invalid character '\"' after object key:value pair", 9},
+		{"[1, ]", "invalid character ']' looking for beginning of value", 5},
+		{"[1}", "invalid character '}' after array element", 3},
+		{"tru e", "invalid character ' ' in literal true (expecting 'e')", 4},
+		{"nulx", "invalid character 'x' in literal null (expecting 'l')", 4},
+		{"\"a\x01\"", "invalid character '\\x01' in string literal", 3},
+		{"\"\\x\"", "invalid character 'x' in string escape code", 3},
+		{"\"\\u12g4\"", "invalid character 'g' in \\u hexadecimal character escape", 6},
+		{"-x", "invalid character 'x' in numeric literal", 2},
+		{"01", "invalid character '1' after top-level value", 2},
+		{"1.e5", "invalid character 'e' after decimal point in numeric literal", 3},
+		{"1e+x", "invalid character 'x' in exponent of numeric literal", 4},
+		{"{} {}", "invalid character '{' after top-level value", 4},
+		{"]", "invalid character ']' looking for beginning of value", 1},
+	}
+	for _, tt := range tests {
+		v := NewIncrementalValidator()
+		err := writeBytes(v, tt.src)
+		var got *SyntaxReport
+		if !errors.As(err, &got) {
+			t.Errorf("%q: got %v, want a SyntaxReport", tt.src, err)
+			continue
+		}
+		if got.Message != tt.message || got.Offset != tt.offset {
+			t.Errorf("%q: got %q at %d, want %q at %d", tt.src, got.Message, got.Offset, tt.message, tt.offset)
+		}
+		if v.Err() != got {
+			t.Errorf("%q: Err() = %v, want the report returned by Write", tt.src, v.Err())
+		}
+	}
+}
+
+func TestIncrementalValidatorReport(t *testing.T) {
+	v := newPlainFormatter().NewIncrementalValidator()
+	n, err := v.Write([]byte("{\n  \"a\": [1,\n  2 3]\n}"))
+	if n != 17 {
+		t.Errorf("Write returned %d, want 17", n)
+	}
+	report, ok := err.(*SyntaxReport)
+	if !ok {
+		t.Fatalf("got %v, want a SyntaxReport", err)
+	}
+	if report.Line != 3 || report.Column != 5 || report.Offset != 18 {
+		t.Errorf("got line %d, column %d, offset %d, want 3, 5, 18", report.Line, report.Column, report.Offset)
+	}
+	if want := "  2 3\n    ^"; stripANSI(report.Excerpt) != want {
+		t.Errorf("excerpt:\n%s\nwant:\n%s", stripANSI(report.Excerpt), want)
+	}
+	if v.Offset() != 17 {
+		t.Errorf("Offset() = %d, want 17", v.Offset())
+	}
+
+	// Nothing more is scanned after an error.
+	if n, err := v.Write([]byte("]}")); n != 0 || err != report {
+		t.Errorf("Write after an error returned %d, %v", n, err)
+	}
+}
+
+func TestIncrementalValidatorLines(t *testing.T) {
+	// Feed a document line by line, as a REPL would.
+	lines := []string{`{`, `  "name": "x",`, `  "tags": [`, `    "a", "b"`, `  ]`, `}`}
+	v := NewIncrementalValidator()
+	for i, line := range lines {
+		if _, err := v.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if last := i == len(lines)-1; v.NeedsMoreInput() == last {
+			t.Errorf("line %d: NeedsMoreInput() = %v", i+1, !last)
+		}
+	}
+	if !v.Complete() {
+		t.Error("document isn't complete")
+	}
+	if want := int64(len(strings.Join(lines, "\n")) + 1); v.Offset() != want {
+		t.Errorf("Offset() = %d, want %d", v.Offset(), want)
+	}
+
+	v.Reset()
+	if v.Offset() != 0 || v.Complete() || v.NeedsMoreInput() {
+		t.Error("Reset didn't discard the document")
+	}
+	if _, err := v.Write([]byte(`[}`)); err == nil {
+		t.Error("no error after Reset")
+	}
+}
+
+func TestIncrementalValidatorMaxDepth(t *testing.T) {
+	v := NewIncrementalValidator()
+	_, err := v.Write([]byte(strings.Repeat("[", maxBinaryDepth+1)))
+	if report, ok := err.(*SyntaxReport); !ok || report.Message != "exceeded max depth" {
+		t.Errorf("got %v, want exceeded max depth", err)
+	}
+}
@@ -0,0 +1,56 @@
+package jsoncolor
+
+import "container/list"
+
+// maxInternLength is the length of the longest string cached by
+// Formatter.InternStrings. Longer strings are rarely repeated, and would make
+// the memory used by the cache unpredictable.
+const maxInternLength = 256
+
+// lruCache is a map of strings holding a bounded number of entries, evicting
+// the least recently used when full.
+type lruCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Entries from most to least recently used.
+}
+
+// lruEntry is an entry of an lruCache.
+type lruEntry struct {
+	key, value string
+}
+
+// newLRUCache returns an lruCache holding up to `capacity` entries, or nil if
+// `capacity` isn't positive.
+func newLRUCache(capacity int) *lruCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &lruCache{capacity: capacity, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// get returns the value of `key`, and whether it's cached.
+func (c *lruCache) get(key string) (string, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).value, true
+}
+
+// put caches `value` for `key`, evicting the least recently used entry if
+// the cache is full.
+func (c *lruCache) put(key, value string) {
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+}
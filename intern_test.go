@@ -0,0 +1,62 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLRUCache(t *testing.T) {
+	if newLRUCache(0) != nil {
+		t.Error("a cache without capacity isn't nil")
+	}
+	c := newLRUCache(2)
+	c.put("a", "1")
+	c.put("b", "2")
+	c.get("a") // b is now the least recently used.
+	c.put("c", "3")
+	if _, ok := c.get("b"); ok {
+		t.Error("b wasn't evicted")
+	}
+	for key, want := range map[string]string{"a": "1", "c": "3"} {
+		if got, ok := c.get(key); !ok || got != want {
+			t.Errorf("%s: got %q, %v, want %q", key, got, ok, want)
+		}
+	}
+
+	// Updating an entry doesn't evict another.
+	c.put("a", "4")
+	if got, _ := c.get("a"); got != "4" {
+		t.Errorf("a: got %q, want 4", got)
+	}
+	if _, ok := c.get("c"); !ok || c.order.Len() != 2 {
+		t.Errorf("c evicted by an update, or %d entries", c.order.Len())
+	}
+}
+
+func TestInternStrings(t *testing.T) {
+	f := &Formatter{InternStrings: 2, StringColor: tagColor("str"), StringQuoteColor: tagColor("sq")}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, []byte(`["a", "b\n", "a", "c", "b\n", "a"]`)); err != nil {
+		t.Fatal(err)
+	}
+	want := `["a","b\n","a","c","b\n","a"]`
+	want = strings.NewReplacer(`"a"`, `<sq>"</sq><str>a</str><sq>"</sq>`, `"b\n"`, `<sq>"</sq><str>b\n</str><sq>"</sq>`,
+		`"c"`, `<sq>"</sq><str>c</str><sq>"</sq>`).Replace(want)
+	if got := stripANSI(buf.String()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The output is the same as without interning, including for strings too
+	// long to be cached, and with other settings in effect.
+	long := strings.Repeat("x", maxInternLength+1)
+	src := fmt.Sprintf(`{"a": ["%s", "%s", "<", "<", "é"], "b": "é"}`, long, long)
+	for _, base := range []*Formatter{newPlainFormatter(), {EscapeHTML: true, Syntax: SyntaxJSON5}, {PreserveExact: true}} {
+		interned := *base
+		interned.InternStrings = 8
+		if got, want := formatPlain(t, &interned, src), formatPlain(t, base, src); got != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JoinArray writes an array of the colorized fragments `fragments` to `dst`
+// using the DefaultFormatter. See Formatter.JoinArray.
+func JoinArray(dst io.Writer, fragments [][]byte) error {
+	return DefaultFormatter.JoinArray(dst, fragments)
+}
+
+// JoinObject writes an object of the colorized fragments `fragments` to `dst`
+// using the DefaultFormatter. See Formatter.JoinObject.
+func JoinObject(dst io.Writer, keys []string, fragments [][]byte) error {
+	return DefaultFormatter.JoinObject(dst, keys, fragments)
+}
+
+// JoinArray writes to `dst` an array whose elements are the fragments
+// `fragments`, each a single value already colorized by the Formatter, such as
+// the output of Format or Encoder.Encode. This allows parallel workers to each
+// render a slice of a large array, and the results to be stitched together
+// into valid colorized JSON: the delimiters and commas are colorized by the
+// Formatter, and the lines of each fragment are indented at the level of the
+// element, in the same way as if the whole array had been formatted at once.
+//
+// Fragments must be rendered without a Prefix, which is added to each of their
+// lines, and a single trailing newline is dropped from each. Like Format, no
+// newline is added after the array unless TrailingNewline says otherwise. An
+// error is returned if a fragment isn't a single colorized value (see
+// ParseColored), and nothing is written then.
+func (f *Formatter) JoinArray(dst io.Writer, fragments [][]byte) error {
+	return f.join(dst, nil, fragments)
+}
+
+// JoinObject is like JoinArray, but writes an object whose members have the
+// keys `keys`, colorized by the Formatter, and the values `fragments`. It
+// returns an error if the number of keys and fragments differ.
+func (f *Formatter) JoinObject(dst io.Writer, keys []string, fragments [][]byte) error {
+	if len(keys) != len(fragments) {
+		return fmt.Errorf("jsoncolor: JoinObject given %d keys and %d fragments", len(keys), len(fragments))
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	return f.join(dst, keys, fragments)
+}
+
+// join writes an array of `fragments`, or an object if `keys` is non-nil.
+func (f *Formatter) join(dst io.Writer, keys []string, fragments [][]byte) error {
+	trimmed := make([][]byte, len(fragments))
+	for i, fragment := range fragments {
+		trimmed[i] = trimFinalNewline(fragment)
+		plain, spans, err := ParseColored(trimmed[i])
+		if err != nil {
+			return fmt.Errorf("%w in fragment %d", err, i)
+		}
+		if countTopLevelValues(plain, spans) != 1 {
+			return fmt.Errorf("jsoncolor: fragment %d is not a single value", i)
+		}
+	}
+
+	open, close := json.Delim('['), json.Delim(']')
+	if keys != nil {
+		open, close = json.Delim('{'), json.Delim('}')
+	}
+	fs := newFormatterState(f, dst)
+	if err := fs.writeToken(open, false); err != nil {
+		return err
+	}
+	for i, fragment := range trimmed {
+		if keys != nil {
+			if err := fs.writeToken(keys[i], false); err != nil {
+				return err
+			}
+		}
+		fs.writeFragment(fragment, []byte(f.Prefix))
+	}
+	if err := fs.writeToken(close, false); err != nil {
+		return err
+	}
+	if f.TrailingNewline.resolve(false) {
+		fs.printSpace("\n", true)
+	}
+	return fs.checkWrite()
+}
+
+// writeFragment writes the colorized value `fragment` as the next value, as is
+// apart from its lines after the first, whose prefix `prefix` is replaced with
+// the current indentation.
+func (fs *formatterState) writeFragment(fragment, prefix []byte) {
+	current := fs.frame()
+	if current.inArray() {
+		fs.beginMember(current)
+	}
+	current.count++
+	if current.inObject() {
+		current.field = true
+	}
+	for i, line := range bytes.Split(fragment, []byte("\n")) {
+		if i > 0 {
+			fs.out.Write([]byte("\n"))
+			fs.printIndent()
+			line = bytes.TrimPrefix(line, prefix)
+		}
+		fs.out.Write(line)
+	}
+}
+
+// trimFinalNewline drops the newline ending `fragment`, which may be followed
+// by escape sequences closing its colors.
+func trimFinalNewline(fragment []byte) []byte {
+	i := bytes.LastIndexByte(fragment, '\n')
+	if i < 0 {
+		return fragment
+	}
+	for rest := fragment[i+1:]; len(rest) > 0; {
+		if rest[0] != '\x1b' {
+			return fragment
+		}
+		_, _, n := escapeSequence(rest)
+		rest = rest[n:]
+	}
+	end := i
+	if end > 0 && fragment[end-1] == '\r' {
+		end--
+	}
+	return append(fragment[:end:end], fragment[i+1:]...)
+}
+
+// countTopLevelValues returns the number of top-level values the tokens
+// `spans` of `plain` are made of.
+func countTopLevelValues(plain []byte, spans []SpanMeta) int {
+	count, depth := 0, 0
+	for _, s := range spans {
+		switch s.Kind {
+		case TokenComment, TokenAnnotation:
+		case TokenObjectDelim, TokenArrayDelim:
+			if plain[s.Start] == '}' || plain[s.Start] == ']' {
+				depth--
+				break
+			}
+			if depth == 0 {
+				count++
+			}
+			depth++
+		default:
+			if depth == 0 {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,116 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	f := newPlainFormatter()
+	var a, b bytes.Buffer
+	if err := f.Format(&a, []byte(`{"x": [1, 2]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Format(&b, []byte(`3`)); err != nil {
+		t.Fatal(err)
+	}
+	fragments := [][]byte{a.Bytes(), b.Bytes()}
+
+	// The result is the same as if the whole document was formatted at once.
+	var sb strings.Builder
+	if err := f.JoinArray(&sb, fragments); err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n  {\n    \"x\": [\n      1,\n      2\n    ]\n  },\n  3\n]"
+	if got := sb.String(); got != want {
+		t.Errorf("array: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	sb.Reset()
+	if err := f.JoinObject(&sb, []string{"k", "l"}, fragments); err != nil {
+		t.Fatal(err)
+	}
+	want = "{\n  \"k\": {\n    \"x\": [\n      1,\n      2\n    ]\n  },\n  \"l\": 3\n}"
+	if got := sb.String(); got != want {
+		t.Errorf("object: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	sb.Reset()
+	if err := f.JoinArray(&sb, nil); err != nil || sb.String() != "[]" {
+		t.Errorf("empty array: got %q, %v", sb.String(), err)
+	}
+	sb.Reset()
+	if err := f.JoinObject(&sb, nil, nil); err != nil || sb.String() != "{}" {
+		t.Errorf("empty object: got %q, %v", sb.String(), err)
+	}
+}
+
+func TestJoinColored(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	var fragment bytes.Buffer
+	if err := f.Format(&fragment, []byte(`[true]`)); err != nil {
+		t.Fatal(err)
+	}
+	// A trailing newline, as written by Encoder.Encode, is dropped.
+	var sb strings.Builder
+	err := f.JoinArray(&sb, [][]byte{fragment.Bytes(), append(fragment.Bytes(), '\n')})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n  [\n    \x1b[3mtrue\x1b[0m\n  ],\n  [\n    \x1b[3mtrue\x1b[0m\n  ]\n]"
+	if got := sb.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	f := newPlainFormatter()
+	tests := []struct {
+		name      string
+		keys      []string
+		fragments []string
+		want      string
+	}{
+		{"two values", nil, []string{"1", "1 2"}, "fragment 1 is not a single value"},
+		{"unterminated", nil, []string{"{"}, "in fragment 0"},
+		{"key count", []string{"a"}, nil, "JoinObject given 1 keys and 0 fragments"},
+	}
+	for _, tt := range tests {
+		fragments := make([][]byte, len(tt.fragments))
+		for i, s := range tt.fragments {
+			fragments[i] = []byte(s)
+		}
+		var sb strings.Builder
+		var err error
+		if tt.keys != nil {
+			err = f.JoinObject(&sb, tt.keys, fragments)
+		} else {
+			err = f.JoinArray(&sb, fragments)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+		// Nothing is written for invalid fragments.
+		if sb.Len() != 0 {
+			t.Errorf("%s: got output %q", tt.name, sb.String())
+		}
+	}
+}
+
+func TestTrimFinalNewline(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"1", "1"},
+		{"1\n", "1"},
+		{"1\r\n", "1"},
+		// Escape sequences closing the colors are kept.
+		{"1\n\x1b[0m", "1\x1b[0m"},
+		{"1\nx", "1\nx"},
+	}
+	for _, tt := range tests {
+		if got := trimFinalNewline([]byte(tt.src)); string(got) != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// json5Space returns the length of the JSON5 whitespace character at the
+// start of `b`, or 0 if there is none. Beyond JSON whitespace, JSON5 allows
+// vertical tabs, form feeds, non-breaking spaces, line and paragraph
+// separators, byte order marks and other Unicode space separators.
+func json5Space(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+	if isSpace(b[0]) || b[0] == '\v' || b[0] == '\f' {
+		return 1
+	}
+	if b[0] < utf8.RuneSelf {
+		return 0
+	}
+	r, size := utf8.DecodeRune(b)
+	if r == '\u00a0' || r == '\u2028' || r == '\u2029' || r == '\ufeff' || unicode.Is(unicode.Zs, r) {
+		return size
+	}
+	return 0
+}
+
+// json5IdentifierLen returns the length of the ECMAScript identifier name at
+// the start of `b`, as allowed for unquoted JSON5 keys, or 0 if there is none.
+// Unicode escape sequences within identifiers are not supported.
+func json5IdentifierLen(b []byte) int {
+	n := 0
+	for n < len(b) {
+		r, size := utf8.DecodeRune(b[n:])
+		start := r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+		part := unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == '\u200c' || r == '\u200d'
+		if !start && (n == 0 || !part) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// json5NumberLen returns the length of the JSON5 number at the start of `b`,
+// or 0 if there is none: an optionally signed decimal number, which may start
+// or end with a decimal point, hexadecimal integer, Infinity or NaN.
+func json5NumberLen(b []byte) int {
+	i := 0
+	if i < len(b) && (b[i] == '+' || b[i] == '-') {
+		i++
+	}
+	rest := b[i:]
+	switch {
+	case strings.HasPrefix(string(rest), "Infinity"):
+		return i + len("Infinity")
+	case strings.HasPrefix(string(rest), "NaN"):
+		return i + len("NaN")
+	case len(rest) > 2 && rest[0] == '0' && (rest[1] == 'x' || rest[1] == 'X'):
+		j := 2
+		for j < len(rest) && strings.IndexByte("0123456789abcdefABCDEF", rest[j]) >= 0 {
+			j++
+		}
+		if j == 2 {
+			return 0
+		}
+		return i + j
+	}
+
+	digits := func() int {
+		start := i
+		for i < len(b) && b[i] >= '0' && b[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+	intDigits := digits()
+	if intDigits > 1 && b[i-intDigits] == '0' {
+		return 0 // Leading zeros are not allowed.
+	}
+	fracDigits := 0
+	if i < len(b) && b[i] == '.' {
+		i++
+		fracDigits = digits()
+	}
+	if intDigits == 0 && fracDigits == 0 {
+		return 0
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return 0
+		}
+	}
+	return i
+}
+
+// strictNumber converts the JSON5 number `n` to standard JSON notation.
+// Infinity and NaN, which JSON can't represent, are rejected.
+func strictNumber(n string) (string, error) {
+	sign := ""
+	switch {
+	case strings.HasPrefix(n, "-"):
+		sign, n = "-", n[1:]
+	case strings.HasPrefix(n, "+"):
+		n = n[1:]
+	}
+	switch {
+	case n == "Infinity" || n == "NaN":
+		return "", fmt.Errorf("jsoncolor: %s%s cannot be represented in JSON", sign, n)
+	case strings.HasPrefix(n, "0x") || strings.HasPrefix(n, "0X"):
+		i, _ := new(big.Int).SetString(n[2:], 16)
+		if i.Sign() == 0 {
+			sign = ""
+		}
+		return sign + i.String(), nil
+	}
+	// Add the digits missing around a leading or trailing decimal point.
+	if strings.HasPrefix(n, ".") {
+		n = "0" + n
+	}
+	if i := strings.IndexByte(n, '.'); i >= 0 && (i == len(n)-1 || n[i+1] < '0' || n[i+1] > '9') {
+		n = n[:i] + n[i+1:]
+	}
+	return sign + n, nil
+}
+
+// decodeJSON5String decodes the contents of a JSON5 string, without its
+// quotes. Beyond the escape sequences of JSON, JSON5 allows \', \v, \0, \xHH,
+// escaped line terminators (which are removed), and escaping any other
+// character, which stands for itself. Unescaped line feeds and carriage
+// returns are not allowed.
+func decodeJSON5String(s string) (string, error) {
+	if !strings.ContainsAny(s, "\\\n\r") {
+		return s, nil
+	}
+	b := &strings.Builder{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c == '\n' || c == '\r' {
+			return "", fmt.Errorf("unescaped line terminator")
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", fmt.Errorf("invalid escape sequence")
+		}
+		r, size := utf8.DecodeRuneInString(s[i+1:])
+		i += 1 + size
+		switch r {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			if i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				return "", fmt.Errorf("invalid escape sequence \\0%c", s[i])
+			}
+			b.WriteByte(0)
+		case '\n', '\u2028', '\u2029':
+			// Line continuation.
+		case '\r':
+			if i < len(s) && s[i] == '\n' {
+				i++
+			}
+		case 'x', 'u':
+			width := 2
+			if r == 'u' {
+				width = 4
+			}
+			code, ok := parseHex(s, i, width)
+			if !ok {
+				return "", fmt.Errorf("invalid escape sequence \\%c", r)
+			}
+			i += width
+			// Combine UTF-16 surrogate pairs written as two escape sequences.
+			if utf16.IsSurrogate(code) && strings.HasPrefix(s[i:], `\u`) {
+				if low, ok := parseHex(s, i+2, 4); ok {
+					if combined := utf16.DecodeRune(code, low); combined != utf8.RuneError {
+						code = combined
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(code)
+		default:
+			if r >= '1' && r <= '9' {
+				return "", fmt.Errorf("invalid escape sequence \\%c", r)
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+// parseHex parses the `width` hexadecimal digits of `s` at position `i`.
+func parseHex(s string, i, width int) (rune, bool) {
+	if i+width > len(s) {
+		return 0, false
+	}
+	var r rune
+	for _, c := range s[i : i+width] {
+		switch {
+		case c >= '0' && c <= '9':
+			r = r*16 + c - '0'
+		case c >= 'a' && c <= 'f':
+			r = r*16 + c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			r = r*16 + c - 'A' + 10
+		default:
+			return 0, false
+		}
+	}
+	return r, true
+}
@@ -0,0 +1,88 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSON5Preserved(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxJSON5, Indent: "  "}
+	const src = "{unquoted: 'single \\'q\\' \"d\"', $id_2: 0x1F, n: [+1, .5, 5., -0xA, 1e3], // c\n}"
+	const want = "{\n  unquoted: 'single \\'q\\' \"d\"',\n  $id_2: 0x1F,\n  n: [\n    +1,\n    .5,\n    5.,\n    -0xA,\n    1e3\n  ] // c\n}"
+	if got := formatPlain(t, f, src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJSON5Strict(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxJSON5, StrictOutput: true}
+	tests := []struct {
+		src, want string
+	}{
+		{"{unquoted: 'single \\'q\\' \"d\"', // c\n}", `{"unquoted":"single 'q' \"d\""}`},
+		// Hexadecimal numbers are converted to decimal, and missing digits added around decimal points.
+		{"[0x1F, -0xA, 0XfF, -0x0, +1, .5, 5., -.5e1, 1e3]", `[31,-10,255,0,1,0.5,5,-0.5e1,1e3]`},
+		// JSON5-only escape sequences, and escaped line terminators, which are removed.
+		{"['\\x41\\v\\0', 'a\\\nb', '\\q', \"\\'\"]", `["A\` + `u000b\` + `u0000","ab","q","'"]`},
+		{"{a: 1, b: [2,],}", `{"a":1,"b":[2]}`},
+	}
+	for _, tt := range tests {
+		if got := formatPlain(t, f, tt.src); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestJSON5Infinity(t *testing.T) {
+	const src = "[Infinity, -Infinity, +Infinity, NaN]"
+	if got, want := formatPlain(t, &Formatter{Syntax: SyntaxJSON5}, src), "[Infinity,-Infinity,+Infinity,NaN]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	// JSON can't represent them, so strict output is an error.
+	for _, src := range []string{"[Infinity]", "-Infinity", "{a: NaN}"} {
+		f := &Formatter{Syntax: SyntaxJSON5, StrictOutput: true}
+		err := f.Format(&strings.Builder{}, []byte(src))
+		if err == nil || !strings.Contains(err.Error(), "cannot be represented in JSON") {
+			t.Errorf("%q: got error %v", src, err)
+		}
+	}
+}
+
+func TestJSON5Errors(t *testing.T) {
+	tests := []struct {
+		src, wantErr string
+	}{
+		{"[01]", "invalid character '0' at offset 1"},
+		{"[0x]", "invalid character '0' at offset 1"},
+		{"[1a]", "invalid character '1' at offset 1"},
+		{"[Infinityx]", "invalid character 'I' at offset 1"},
+		{"{1a: 1}", "invalid character '1' at offset 1"},
+		{"['a\nb']", "unescaped line terminator"},
+		{"['\\1']", "invalid escape sequence \\1"},
+		{"['\\x4']", "invalid escape sequence \\x"},
+		{"['a", "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		err := (&Formatter{Syntax: SyntaxJSON5}).Format(&strings.Builder{}, []byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: got error %v, want one containing %q", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestJSON5Whitespace(t *testing.T) {
+	// JSON5 allows Unicode space separators, vertical tabs and form feeds.
+	src := "[\v1,\f\u00a02\u2028]"
+	if got, want := formatPlain(t, &Formatter{Syntax: SyntaxJSON5}, src), "[1,2]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJSON5IdentifierLen(t *testing.T) {
+	tests := map[string]int{"abc:": 3, "$_a1 ": 4, "1a": 0, "": 0, "café:": 5, "ä\u0301b": 5}
+	for s, want := range tests {
+		if got := json5IdentifierLen([]byte(s)); got != want {
+			t.Errorf("%q: got %d, want %d", s, got, want)
+		}
+	}
+}
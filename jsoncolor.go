@@ -3,8 +3,10 @@ package jsoncolor
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/amterp/color"
@@ -34,38 +36,82 @@ func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
 // for colorization rules.
 // Note: This function does not indent its output; the Prefix and Indent fields
 // within the provided Formatter `f` are ignored.
-// Note: This function always enables HTML escaping; the EscapeHTML field
-// within the provided Formatter `f` is ignored (and forced to true). To disable
-// HTML escaping, use an Encoder and call SetEscapeHTML(false) on it.
-func MarshalWithFormatter(v interface{}, f *Formatter) ([]byte, error) {
+// Note: Like encoding/json.Marshal, this function escapes HTML characters by
+// default, regardless of the EscapeHTML field within the provided Formatter
+// `f`. Pass WithFormatterEscapeHTML() to honor that field instead, or
+// WithEscapeHTML(false) to disable HTML escaping.
+func MarshalWithFormatter(v interface{}, f *Formatter, opts ...EncodeOption) ([]byte, error) {
 	// Delegates to MarshalIndentWithFormatter with no prefix and no indent string.
 	// The indentation settings in `f` are effectively ignored here.
-	return MarshalIndentWithFormatter(v, "", "", f)
+	return MarshalIndentWithFormatter(v, "", "", f, opts...)
 }
 
 // MarshalIndentWithFormatter works like MarshalIndent but uses the provided Formatter `f`
 // for colorization rules.
 // Note: The `prefix` and `indent` arguments provided to this function override
 // the Prefix and Indent fields within the Formatter `f`, which are ignored.
-// Note: This function always enables HTML escaping; the EscapeHTML field
-// within the provided Formatter `f` is ignored (and forced to true). To disable
-// HTML escaping, use an Encoder and call SetEscapeHTML(false) on it.
-func MarshalIndentWithFormatter(v interface{}, prefix, indent string, f *Formatter) ([]byte, error) {
+// Note: Like encoding/json.MarshalIndent, this function escapes HTML
+// characters by default, regardless of the EscapeHTML field within the provided
+// Formatter `f`. Pass WithFormatterEscapeHTML() to honor that field instead, or
+// WithEscapeHTML(false) to disable HTML escaping.
+func MarshalIndentWithFormatter(v interface{}, prefix, indent string, f *Formatter, opts ...EncodeOption) ([]byte, error) {
+	// The indentation given as arguments comes first, so that options can still override it.
+	return marshalIndent(v, f, append([]EncodeOption{WithIndent(prefix, indent)}, opts...)...)
+}
+
+// MarshalNoHTMLEscape works like Marshal but does not escape problematic HTML
+// characters (<, >, &) within JSON strings, so they are printed as-is.
+// This is usually what's wanted when the output is destined for a terminal
+// rather than an HTML page.
+func MarshalNoHTMLEscape(v interface{}) ([]byte, error) {
+	return marshalIndent(v, DefaultFormatter, WithIndent("", ""), WithEscapeHTML(false))
+}
+
+// MarshalIndentNoHTMLEscape works like MarshalIndent but does not escape
+// problematic HTML characters (<, >, &) within JSON strings.
+func MarshalIndentNoHTMLEscape(v interface{}, prefix, indent string) ([]byte, error) {
+	return marshalIndent(v, DefaultFormatter, WithIndent(prefix, indent), WithEscapeHTML(false))
+}
+
+// MustMarshal is like Marshal but panics if `v` cannot be marshalled.
+// It is intended for tests, examples and init-time dumps of values known to be
+// marshallable, where handling the error is pure noise.
+func MustMarshal(v interface{}) []byte {
+	return must(Marshal(v))
+}
+
+// MustMarshalIndent is like MarshalIndent but panics if `v` cannot be marshalled.
+func MustMarshalIndent(v interface{}, prefix, indent string) []byte {
+	return must(MarshalIndent(v, prefix, indent))
+}
+
+// MustMarshalWithFormatter is like MarshalWithFormatter but panics if `v` cannot be marshalled.
+func MustMarshalWithFormatter(v interface{}, f *Formatter) []byte {
+	return must(MarshalWithFormatter(v, f))
+}
+
+// MustMarshalIndentWithFormatter is like MarshalIndentWithFormatter but panics if `v` cannot be marshalled.
+func MustMarshalIndentWithFormatter(v interface{}, prefix, indent string, f *Formatter) []byte {
+	return must(MarshalIndentWithFormatter(v, prefix, indent, f))
+}
+
+// must panics with `err` if it is non-nil, and otherwise returns `b`.
+func must(b []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// marshalIndent is the shared implementation of the Marshal* functions.
+// The options `opts` apply on top of the settings of the Formatter `f`.
+func marshalIndent(v interface{}, f *Formatter, opts ...EncodeOption) ([]byte, error) {
 	// Create a buffer to hold the colorized JSON output.
 	buf := &bytes.Buffer{}
 
 	// Create an encoder specifically for this operation, associated with the buffer
-	// and the provided formatter.
-	enc := NewEncoderWithFormatter(buf, f)
-
-	// Apply the specific indentation requested for this call, overriding any
-	// defaults in the formatter `f`.
-	enc.SetIndent(prefix, indent)
-
-	// Ensure HTML characters are escaped. This is consistent with encoding/json's
-	// MarshalIndent behavior and overrides the formatter's EscapeHTML setting.
-	// Users wanting no escaping must use the Encoder directly.
-	enc.SetEscapeHTML(true) // This is forced for Marshal* functions.
+	// and the provided formatter and options.
+	enc := NewEncoderWithFormatter(buf, f, opts...)
 
 	// Perform the encoding and colorization.
 	// `false` indicates not to add a trailing newline, matching encoding/json.MarshalIndent.
@@ -78,11 +124,71 @@ func MarshalIndentWithFormatter(v interface{}, prefix, indent string, f *Formatt
 	return buf.Bytes(), nil
 }
 
+// AppendMarshal works like Marshal but appends the colorized JSON output to
+// `dst` and returns the extended slice, in the style of strconv.AppendInt.
+// Callers that manage their own buffers can reuse `dst` across calls to avoid
+// allocating a new slice for every value.
+// On error, `dst` is returned unchanged (though bytes beyond its length may
+// have been overwritten).
+func AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	// A bytes.Buffer initialized with `dst` appends into its spare capacity,
+	// only reallocating once that capacity is exhausted.
+	buf := bytes.NewBuffer(dst)
+
+	// HTML characters are escaped by default, as with the Marshal* functions.
+	enc := NewEncoderWithFormatter(buf, DefaultFormatter, WithIndent("", ""))
+
+	// `false` indicates not to add a trailing newline, matching Marshal.
+	if err := enc.encode(v, false); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+// AppendFormat works like Formatter.Format on the DefaultFormatter but appends
+// the colorized version of the valid JSON in `src` to `dst` and returns the
+// extended slice.
+// On error, `dst` is returned unchanged (though bytes beyond its length may
+// have been overwritten).
+func AppendFormat(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	if err := DefaultFormatter.Format(buf, src); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Colorize formats the valid JSON in `src` using the DefaultFormatter and
+// returns the colorized bytes. It is a one-shot alternative to calling
+// Formatter.Format with a bytes.Buffer. The DefaultFormatter's Prefix and
+// Indent fields are respected, so output is compact unless those are set.
+func Colorize(src []byte) ([]byte, error) {
+	return AppendFormat(nil, src)
+}
+
+// ColorizeIndent works like Colorize but indents the output using `prefix`
+// and `indent`, which override the Prefix and Indent fields of the DefaultFormatter.
+func ColorizeIndent(src []byte, prefix, indent string) ([]byte, error) {
+	f := DefaultFormatter.clone()
+	f.setIndent(prefix, indent)
+
+	buf := &bytes.Buffer{}
+	if err := f.Format(buf, src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // Encoder works like encoding/json.Encoder but writes colorized JSON output
 // to the underlying stream using a specified Formatter.
 type Encoder struct {
-	w io.Writer  // The output writer stream.
-	f *Formatter // The configuration for colorization and indentation.
+	w    io.Writer     // The output writer stream.
+	f    *Formatter    // The configuration for colorization and indentation, which is never modified.
+	opts encodeOptions // Settings applied on top of f, made through EncodeOptions, SetIndent, etc.
+
+	trailingNewline NewlineMode // Set by SetTrailingNewline; overrides f.TrailingNewline unless NewlineDefault.
+	normalize       bool        // Set by SetNormalize; if true, f is ignored in favor of normalized output.
+	rawPassthrough  bool        // Set by SetRawPassthrough; if true, marshalled output is formatted exactly.
 }
 
 // NewEncoder creates a new Encoder that writes colorized JSON to `w`
@@ -92,23 +198,20 @@ func NewEncoder(w io.Writer) *Encoder {
 }
 
 // NewEncoderWithFormatter creates a new Encoder that writes colorized JSON to `w`
-// using the provided Formatter `f`.
-// Note: The initial value of `f.EscapeHTML` is ignored upon creation; HTML escaping
-// is enabled by default. To disable it, call SetEscapeHTML(false) on the
-// returned Encoder instance after creation.
-func NewEncoderWithFormatter(w io.Writer, f *Formatter) *Encoder {
+// using the provided Formatter `f`, with the options `opts` applied on top of it.
+// Note: Like encoding/json.Encoder, the Encoder escapes HTML characters by
+// default, regardless of `f.EscapeHTML`. Pass WithFormatterEscapeHTML() to
+// honor that field instead, or call SetEscapeHTML on the returned Encoder.
+// The Formatter is never modified by the Encoder, but changes made to it by
+// the caller apply to subsequent calls to Encode.
+func NewEncoderWithFormatter(w io.Writer, f *Formatter, opts ...EncodeOption) *Encoder {
 	if f == nil {
 		panic("jsoncolor: cannot create Encoder with a nil Formatter")
 	}
-	// Clone the formatter to avoid modifying the original one passed by the caller,
-	// especially since we forcefully set EscapeHTML below.
-	clonedFormatter := f.clone()
-	// Default behavior for encoders is to escape HTML, consistent with encoding/json.
-	// The user can override this via SetEscapeHTML().
-	clonedFormatter.setEscapeHTML(true)
 	return &Encoder{
-		w: w,
-		f: clonedFormatter,
+		w:    w,
+		f:    f,
+		opts: defaultEncodeOptions().with(opts...),
 	}
 }
 
@@ -119,34 +222,103 @@ func (enc *Encoder) Encode(v interface{}) error {
 	return enc.encode(v, true)
 }
 
+// EncodeWithOptions works like Encode, with the options `opts` applied on
+// top of the Encoder's settings for this call only.
+func (enc *Encoder) EncodeWithOptions(v interface{}, opts ...EncodeOption) error {
+	f, o, terminateWithNewline := enc.settings(true, opts...)
+	return enc.encodeWith(f, o, v, terminateWithNewline)
+}
+
 // SetIndent configures the Encoder to indent output, similar to
 // encoding/json.Encoder.SetIndent. It sets the line prefix and the
 // indentation string for each level. Setting empty strings disables indentation.
 func (enc *Encoder) SetIndent(prefix, indent string) {
-	enc.f.setIndent(prefix, indent)
+	enc.opts = enc.opts.with(WithIndent(prefix, indent))
+}
+
+// SetEscapePolicy sets the EscapePolicy deciding which characters are escaped
+// inside JSON quoted strings, taking precedence over SetEscapeHTML. A nil
+// policy restores the default, where the Formatter's EscapePolicy, if any,
+// or SetEscapeHTML applies.
+func (enc *Encoder) SetEscapePolicy(p EscapePolicy) {
+	enc.opts = enc.opts.with(WithEscapePolicy(p))
 }
 
 // SetEscapeHTML specifies whether problematic HTML characters (<, >, &)
 // should be escaped within JSON strings. The default is true. This mimics
 // encoding/json.Encoder.SetEscapeHTML.
 func (enc *Encoder) SetEscapeHTML(on bool) {
-	enc.f.setEscapeHTML(on)
+	enc.opts = enc.opts.with(WithEscapeHTML(on))
+}
+
+// SetBaseIndentLevel makes indented output start `n` levels of indentation
+// deep, for embedding it within other indented output, such as a block of a
+// YAML document or generated code, without reindenting each line: every line
+// of the output, including the first, is indented by `n` more levels, after
+// the prefix. Compact output is not affected. The default is 0.
+func (enc *Encoder) SetBaseIndentLevel(n int) {
+	enc.opts = enc.opts.with(WithBaseIndentLevel(n))
+}
+
+// Reset discards the Encoder's current writer and makes it write to `w`
+// instead, like bufio.Writer.Reset. All settings made through SetIndent,
+// SetEscapeHTML, etc. are kept, so a configured Encoder can be reused for
+// many destinations without being recreated.
+func (enc *Encoder) Reset(w io.Writer) {
+	enc.w = w
+}
+
+// SetTrailingNewline specifies whether Encode terminates each value with a
+// newline. The default is true, mimicking encoding/json.Encoder.Encode.
+// Disabling it is useful when embedding colorized JSON within other terminal
+// output whose line endings are managed by the caller.
+// This setting takes precedence over the Formatter's TrailingNewline field.
+func (enc *Encoder) SetTrailingNewline(on bool) {
+	if on {
+		enc.trailingNewline = NewlineAlways
+	} else {
+		enc.trailingNewline = NewlineNever
+	}
 }
 
 // encode is the internal method that performs the core logic:
 // 1. Marshal the input `v` to standard JSON bytes.
 // 2. Format (colorize and indent) those bytes to the Encoder's writer.
-// The `terminateWithNewline` flag controls whether a final newline is added.
+// The `terminateWithNewline` flag controls whether a final newline is added,
+// unless overridden by SetTrailingNewline or the Formatter's TrailingNewline field.
 func (enc *Encoder) encode(v interface{}, terminateWithNewline bool) error {
+	f, o, terminateWithNewline := enc.settings(terminateWithNewline)
+	return enc.encodeWith(f, o, v, terminateWithNewline)
+}
+
+// encodeWith encodes `v` like encode, using the Formatter `f` with the options
+// `o` on top of it, and ending the output with a newline if
+// `terminateWithNewline` is true, as given.
+func (enc *Encoder) encodeWith(f *Formatter, o encodeOptions, v interface{}, terminateWithNewline bool) error {
 	// Step 1: Get the standard, non-colorized JSON representation.
-	plainJSONBytes, err := json.Marshal(v)
+	// With raw pass-through, encoding/json must not escape HTML characters, as
+	// that would alter the contents of json.RawMessage values.
+	plainJSONBytes, err := marshalJSON(v, !o.preserveExactFor(f))
 	if err != nil {
-		return fmt.Errorf("jsoncolor: failed to marshal input to standard JSON: %w", err)
+		return err
+	}
+
+	// encoding/json has silently replaced any invalid UTF-8 in `v` by now, so
+	// the Formatter's policy has to be applied to the Go value itself.
+	escapeReplacement, err := checkValueUTF8(f.InvalidUTF8Policy, v)
+	if err != nil {
+		return err
 	}
 
 	// Step 2: Format the plain JSON bytes by adding colors and indentation.
 	// This involves parsing the plain JSON and rewriting it with decorations.
-	err = enc.f.format(enc.w, plainJSONBytes, terminateWithNewline)
+	formatterState := newFormatterStateWithOptions(f, enc.w, o)
+	formatterState.escapeReplacement = escapeReplacement
+	err = formatterState.format(enc.w, plainJSONBytes, terminateWithNewline)
+	var writeErr *WriteError
+	if errors.As(err, &writeErr) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("jsoncolor: failed to format/colorize JSON: %w", err)
 	}
@@ -154,15 +326,42 @@ func (enc *Encoder) encode(v interface{}, terminateWithNewline bool) error {
 	return nil
 }
 
+// settings returns the Formatter to encode values with, the options applying
+// on top of it, and whether the output ends with a newline given the entry
+// point's default `terminateWithNewline`. The options `opts` apply on top of
+// the Encoder's own for this call only. The Formatter is not copied, so that
+// encoding doesn't cost a copy of it per call.
+func (enc *Encoder) settings(terminateWithNewline bool, opts ...EncodeOption) (*Formatter, encodeOptions, bool) {
+	if enc.normalize {
+		// Normalized output always ends with a newline, regardless of other settings.
+		return newNormalizeFormatter(), formatterOptions, true
+	}
+	o := enc.opts.with(opts...)
+	if enc.rawPassthrough {
+		o.preserveExact = true
+	}
+	// The Encoder's own setting wins over the Formatter's, which wins over the entry point's default.
+	return enc.f, o, enc.trailingNewline.resolve(enc.f.TrailingNewline.resolve(terminateWithNewline))
+}
+
 // frame represents the state within a nested JSON structure (object or array)
 // during the formatting process. It helps manage indentation and context
 // (e.g., whether the next token is an object key or value).
 type frame struct {
 	object bool // True if the current frame represents a JSON object ({...}).
-	field  bool // True if the next token expected within an object is a value (after key:). False if a key is expected.
+	field  bool // True if the next token expected within an object is a field name (key). False if a value is expected (after key:).
 	array  bool // True if the current frame represents a JSON array ([...]).
-	empty  bool // True if the object or array is empty (e.g., {} or []).
 	indent int  // The indentation level for this frame.
+
+	path  string // JSON Pointer of the container; only tracked when needed (see formatterState.trackPaths).
+	key   string // The most recent field name seen in an object.
+	count int    // The number of values (members or elements) seen so far in an object or array.
+
+	inline bool // True if the container is rendered on a single line (see Formatter.InlineMaxMembers).
+
+	// Comments within the container, which are only found with some Syntax values (see writeComment).
+	comments  bool // True if the container holds comments.
+	commaDone bool // True if the comma before the next member was already printed, ahead of a comment.
 }
 
 // inArray returns true if the current frame is a JSON array.
@@ -189,32 +388,22 @@ func (f *frame) inArrayOrObject() bool {
 	return f.object || f.array
 }
 
-// inField returns true if the formatter is inside an object and expects a value token
-// (i.e., it has just processed the field name and the colon).
+// inField returns true if the formatter is inside an object and expects a field name
+// (key) token, i.e. at the start of the object or after the previous member.
 func (f *frame) inField() bool {
 	if f == nil {
 		return false
 	}
-	// Only true if we are in an object AND expecting a value (field=true).
+	// Only true if we are in an object AND expecting a key (field=true).
 	return f.object && f.field
 }
 
-// toggleField flips the state within an object frame between expecting a field name
-// (field=false) and expecting a field value (field=true).
-func (f *frame) toggleField() {
-	if f == nil {
-		return
-	}
-	// This should only be called when f.object is true.
-	f.field = !f.field
-}
-
-// isEmpty returns true if the current frame represents an empty object or array.
-func (f *frame) isEmpty() bool {
-	if f == nil {
-		return false
+// kind returns the Kind of container the frame represents.
+func (f *frame) kind() Kind {
+	if f.array {
+		return KindArray
 	}
-	return (f.object || f.array) && f.empty
+	return KindObject
 }
 
 // SprintfFuncer is an interface wrapper around the `color` package's functionality.
@@ -222,6 +411,8 @@ func (f *frame) isEmpty() bool {
 // using fmt.Sprintf-style formatting. This allows different color settings
 // (defined by types implementing this interface, like color.Color) to be used
 // interchangeably by the Formatter.
+// Style and StyleFunc implement it without depending on a color library, and
+// adapt the styles of other libraries respectively.
 type SprintfFuncer interface {
 	// SprintfFunc returns a function that takes a format string and arguments
 	// (like fmt.Sprintf) and returns the resulting string wrapped in the
@@ -229,6 +420,14 @@ type SprintfFuncer interface {
 	SprintfFunc() func(format string, a ...interface{}) string
 }
 
+// indentGuide is the character printed at each level of indentation when
+// Formatter.IndentGuides is set.
+const indentGuide = "│"
+
+// skeletonPlaceholder is printed in place of every leaf value when
+// Formatter.Skeleton is set.
+const skeletonPlaceholder = "…"
+
 // Default color settings using the `color` package.
 // Users can override these by creating their own Formatter instance.
 var (
@@ -258,6 +457,16 @@ var (
 	DefaultNumberColor = color.New()
 	// DefaultNullColor defines the color for the 'null' value. Default is bold black (often appears gray).
 	DefaultNullColor = color.New(color.FgBlack, color.Bold)
+	// DefaultIndentGuideColor defines the color for the indentation guides printed when Formatter.IndentGuides is set. Default is faint.
+	DefaultIndentGuideColor = color.New(color.Faint)
+	// DefaultSkeletonColor defines the color for the placeholder standing in for leaf values when Formatter.Skeleton is set. Default is faint.
+	DefaultSkeletonColor = color.New(color.Faint)
+	// DefaultCommentColor defines the color for comments, in input with a Syntax allowing them. Default is faint italic.
+	DefaultCommentColor = color.New(color.Faint, color.Italic)
+	// DefaultWrapMarkerColor defines the color for the marker starting continuation lines when Formatter.WrapWidth is set. Default is faint.
+	DefaultWrapMarkerColor = color.New(color.Faint)
+	// DefaultErrorColor defines the color used to highlight the offending input in syntax error reports. Default is bold red.
+	DefaultErrorColor = color.New(color.FgRed, color.Bold)
 
 	// DefaultPrefix is the string prepended to each indented line when indentation is enabled. Default is empty.
 	DefaultPrefix = ""
@@ -269,7 +478,8 @@ var (
 // A zero value Formatter{} will use all the default colors and indentation settings.
 type Formatter struct {
 	// Color configuration for different JSON elements. If a field is nil, the
-	// corresponding Default*Color defined above will be used.
+	// corresponding Default*Color defined above will be used. Any SprintfFuncer
+	// can be used, such as a Style, a *color.Color or a StyleFunc.
 	SpaceColor       SprintfFuncer
 	CommaColor       SprintfFuncer
 	ColonColor       SprintfFuncer
@@ -283,6 +493,32 @@ type Formatter struct {
 	FalseColor       SprintfFuncer
 	NumberColor      SprintfFuncer
 	NullColor        SprintfFuncer
+	ErrorColor       SprintfFuncer // Used by Valid to highlight the position of a syntax error.
+	IndentGuideColor SprintfFuncer // Used for the guide characters printed when IndentGuides is set.
+	SkeletonColor    SprintfFuncer // Used for the placeholder standing in for leaf values when Skeleton is set.
+	CommentColor     SprintfFuncer // Used for comments, in input with a Syntax allowing them.
+	PunctuationColor SprintfFuncer // Used for commas, colons and delimiters alike, unless their own color is set, e.g. DimPunctuation.
+	RawColor         SprintfFuncer // Used for pre-escaped strings written by EncodeRawString, including quotes. If nil, they're colored like other strings.
+	WrapMarkerColor  SprintfFuncer // Used for the marker starting continuation lines when WrapWidth is set.
+
+	// KeyColors overrides FieldColor and FieldQuoteColor for specific keys,
+	// by name, e.g. map[string]SprintfFuncer{"error": Style{FgRed, Bold}}.
+	KeyColors map[string]SprintfFuncer
+
+	// ValueColors overrides the colors of the values of specific keys, by the
+	// name of the key: strings (including their quotes), numbers, booleans
+	// and null, e.g. map[string]SprintfFuncer{"status": Style{FgYellow}}.
+	// Objects and arrays under the key, and their members, keep their colors.
+	ValueColors map[string]SprintfFuncer
+
+	// Colorize, if non-nil, selects the kinds of tokens which are colorized:
+	// only those mapped to true are, and the others are written plain, e.g.
+	// map[TokenKind]bool{TokenKey: true} for low-noise logs with only the
+	// keys colored. TokenKey covers the quotes of keys, TokenString those of
+	// strings and raw strings, and TokenWhitespace the indent guides. The
+	// colors of errors (ErrorColor) and of Skeleton placeholders are not
+	// affected. If nil, all kinds are colorized.
+	Colorize map[TokenKind]bool
 
 	// Prefix is a string added before the indentation on each new line.
 	// Only used if Indent is also non-empty.
@@ -291,11 +527,269 @@ type Formatter struct {
 	// If empty, output is compact (no indentation or unnecessary whitespace).
 	Indent string
 
+	// ColonSpacing controls the whitespace around the colon separating an object
+	// key from its value. SpacingDefault prints a single space after the colon
+	// when indenting, and no whitespace in compact output. Any other value is
+	// applied in both indented and compact output.
+	ColonSpacing Spacing
+	// CommaSpacing controls the whitespace around the comma separating elements.
+	// SpacingDefault prints no whitespace (when indenting, each element already
+	// starts on a new line). Any other value is applied in both indented and
+	// compact output, except that a space is never printed after a comma that
+	// is immediately followed by a newline.
+	CommaSpacing Spacing
+
+	// TrailingNewline controls whether a newline is printed after the JSON value.
+	// NewlineDefault keeps the behavior of encoding/json: Encoder.Encode
+	// terminates each value with a newline, while the Marshal* functions and
+	// Format do not. Encoder.SetTrailingNewline takes precedence over this field.
+	TrailingNewline NewlineMode
+
+	// InlineMaxMembers, when positive, renders objects with at most this many
+	// members on a single line, e.g. `{"x": 1, "y": 2}`, while larger objects are
+	// expanded with one member per line as usual. Objects containing non-empty
+	// objects or arrays are always expanded. Has no effect on compact output.
+	InlineMaxMembers int
+	// InlineMaxMembersByDepth overrides InlineMaxMembers for objects at specific
+	// nesting depths, where depth 0 is the top-level value. A value of 0 for a
+	// depth disables inlining at that depth.
+	InlineMaxMembersByDepth map[int]int
+
+	// SortKeys prints the members of every object sorted by key, rather than in
+	// their original order. If an object contains duplicate keys, only the last
+	// is kept.
+	SortKeys bool
+
+	// PreserveExact guarantees that Format emits the input byte for byte, only
+	// adding colors and whitespace (and escaping control characters, unless
+	// ControlCharPolicy is ControlCharKeep): key order, duplicate keys, the text of
+	// numbers and the escaping within strings (e.g. `\u00e9` or `\/`) are kept
+	// exactly as written, so that the semantics-bearing bytes of a payload, such
+	// as a cryptographically signed one, are not altered. EscapeHTML,
+	// EscapePolicy and an InvalidUTF8Policy of InvalidUTF8Escape have no effect
+	// on strings in this mode, and combining it with SortKeys is an error.
+	// Without PreserveExact, strings are decoded and re-escaped minimally.
+	// When encoding Go values, this preserves the output of encoding/json.
+	PreserveExact bool
+
+	// PreserveBlankLines keeps the blank lines found between the members of
+	// objects and arrays in the input, such as those separating groups of
+	// settings in a hand-written config file, while indentation is otherwise
+	// normalized. Several consecutive blank lines are kept as one. Has no
+	// effect on compact output, nor when encoding Go values.
+	PreserveBlankLines bool
+
+	// IndentGuides renders a faint vertical guide character (│) at each level of
+	// indentation, like the indent guides of modern editors, which makes deeply
+	// nested structures easier to follow. Has no effect on compact output.
+	IndentGuides bool
+
+	// Skeleton renders only the structure of the document: object keys and
+	// container delimiters are printed as usual, but every leaf value (string,
+	// number, boolean or null) is replaced by a faint placeholder (…).
+	// Useful to outline very large documents before deciding what to inspect.
+	Skeleton bool
+
+	// Syntax selects the dialect of JSON accepted as input by Format. The
+	// default, SyntaxJSON, is standard JSON. With SyntaxJSONC, comments are
+	// accepted, colorized with CommentColor and kept in the output. See Syntax.
+	Syntax Syntax
+	// StrictOutput normalizes input in a Syntax other than SyntaxJSON to
+	// standard JSON: comments are dropped, and with SyntaxJSON5, keys and
+	// strings are double-quoted and numbers are written in JSON notation, with
+	// an error for Infinity and NaN, which JSON can't represent. By default,
+	// the syntax of the input is preserved, apart from SyntaxHJSON, which is
+	// always converted to standard JSON. StrictOutput also leaves out the
+	// comments annotating binary input converted to JSON (see FormatCBOR).
+	StrictOutput bool
+
+	// InvalidUTF8Policy controls how strings containing invalid UTF-8 are handled,
+	// both in JSON input passed to Format and in Go values passed to an Encoder
+	// or the Marshal* functions. By default (InvalidUTF8Replace), each invalid
+	// byte is replaced with U+FFFD, matching encoding/json.
+	InvalidUTF8Policy InvalidUTF8Policy
+
+	// ControlCharPolicy controls how control characters which would be
+	// written as they are within strings, keys and comments are handled, such
+	// as the escape character in strings of JSON5 input, or the C1 control
+	// character U+009B, which JSON allows unescaped. Written to a terminal,
+	// they could change its title, move the cursor or erase the screen when
+	// the input is untrusted. By default (ControlCharEscape), they're written
+	// as \u escape sequences, even in PreserveExact mode. Other control
+	// characters, such as those of decoded strings, are always escaped.
+	ControlCharPolicy ControlCharPolicy
+
+	// OnContainerOpen, if set, is called whenever an object or array is opened,
+	// right after its opening delimiter is written. `path` is the container's
+	// JSON Pointer (RFC 6901), e.g. "/items/0" ("" for the top-level value), and
+	// `count` is always 0 since the container's contents are not known yet.
+	// This allows integrations to drive external UI (tree widgets, progress,
+	// metrics) while a normal Format call runs.
+	OnContainerOpen func(path string, kind Kind, count int)
+	// OnContainerClose, if set, is called whenever an object or array is closed,
+	// right after its closing delimiter is written. `count` is the number of
+	// members of the object, or elements of the array.
+	OnContainerClose func(path string, kind Kind, count int)
+
 	// EscapeHTML specifies whether problematic HTML characters (<, >, &)
 	// should be escaped inside JSON quoted strings.
-	// Note: This setting is primarily respected by the Encoder's Encode method.
-	// The package-level Marshal* functions always enable HTML escaping, overriding this field.
+	// Note: This setting is respected by Format. Like encoding/json, Encoders
+	// and the package-level Marshal* functions escape HTML characters by
+	// default instead, unless given WithFormatterEscapeHTML().
 	EscapeHTML bool
+
+	// EscapePolicy, if set, decides which characters are escaped inside JSON
+	// quoted strings, taking precedence over EscapeHTML, including when the
+	// latter is forced by the Marshal* functions or set with
+	// Encoder.SetEscapeHTML. If nil, HTMLEscaping is used if EscapeHTML is
+	// true, and JSEscaping otherwise, matching encoding/json.
+	EscapePolicy EscapePolicy
+
+	// WriteErrorPolicy controls what happens when writing the output fails:
+	// by default (WriteErrorFailFast), formatting stops and a *WriteError is
+	// returned. With WriteErrorBestEffort, the rest of the output is still
+	// written, so that streaming displays such as FormatLines and
+	// Encoder.EncodeAll go on with the next record, and the first error is
+	// returned at the end.
+	WriteErrorPolicy WriteErrorPolicy
+
+	// InternStrings, when positive, caches the colorized rendering of up to
+	// this many distinct string values during each call, evicting the least
+	// recently used, so that repeated values such as enum-like fields of
+	// machine-generated JSON are only escaped and colorized once. Strings
+	// longer than 256 bytes are never cached. Object keys are always cached.
+	InternStrings int
+
+	// Backend, if set, renders the output in place of the colors of the
+	// Formatter: each piece of text, such as a token or indentation, is passed
+	// to it along with its kind, and the bytes it returns are written. Keys and
+	// strings are passed whole, including their quotes. This allows rendering
+	// with termenv, lipgloss or an encoder of one's own without forking the
+	// Formatter. By default, the colors are applied as escape sequences, as
+	// ANSIRenderer does.
+	Backend RendererBackend
+
+	// ColorProfile is the set of colors of the terminal the output is shown
+	// on. Colors beyond it, such as the RGB colors of a truecolor theme on a
+	// 256-color terminal, are converted to the closest colors it has, instead
+	// of being rendered wrong. By default (ProfileAuto), the profile is
+	// detected from the environment (see DetectColorProfile).
+	ColorProfile ColorProfile
+
+	// LightColors and DarkColors, if set, are variants of the colors of the
+	// Formatter for terminals with a light and a dark background, such as
+	// blue keys on dark backgrounds and dark blue ones on light backgrounds,
+	// where blue is hard to read. The variant for the Background is picked
+	// each time output is written, and its colors which are set take the place
+	// of those of the Formatter, whose other fields are ignored. With only one
+	// of them set, the Formatter's own colors apply on the other background.
+	LightColors *Formatter
+	DarkColors  *Formatter
+	// Background is the background of the terminal, which picks between
+	// LightColors and DarkColors. By default (BackgroundAuto), it's detected
+	// from the terminal (see DetectBackground).
+	Background Background
+
+	// WrapWidth, if positive, soft-wraps lines longer than this many columns,
+	// such as those of long strings, rather than leaving the terminal to wrap
+	// them at its edge, which breaks the indentation. Each continuation line
+	// starts with the leading whitespace of the line it continues, one more
+	// level of indentation and the WrapMarker, colorized with
+	// WrapMarkerColor, and colors are reset at the end of each line and
+	// restored after the marker. With WrapAuto, lines are wrapped at the width
+	// of the terminal the output is written to, and not at all if it isn't
+	// one. The wrapped output is no longer valid JSON if strings are wrapped.
+	WrapWidth int
+	// WrapMarker is the marker starting continuation lines. If empty, "↪ "
+	// is used.
+	WrapMarker string
+
+	// Header, if true, makes Format precede its output with a header line
+	// recording the HeaderVersion, the ThemeName and a hash of the input, when
+	// the output is not written to a terminal. Tools reading the output stored
+	// in a file can then recognize it, strip the header with CutHeader, and
+	// strip or reinterpret the colors reliably. See Header.
+	Header bool
+	// ThemeName is the name of the colors of the Formatter, recorded in the
+	// header written with Header.
+	ThemeName string
+}
+
+// Spacing controls the whitespace printed around a punctuation character.
+// See Formatter.ColonSpacing and Formatter.CommaSpacing.
+type Spacing int
+
+const (
+	// SpacingDefault uses the built-in spacing for the punctuation character.
+	SpacingDefault Spacing = iota
+	// SpacingNone prints no whitespace around the punctuation character, e.g. `"key":value`.
+	SpacingNone
+	// SpacingBefore prints a single space before the punctuation character, e.g. `"key" :value`.
+	SpacingBefore
+	// SpacingAfter prints a single space after the punctuation character, e.g. `"key": value`.
+	SpacingAfter
+	// SpacingBoth prints a single space on both sides of the punctuation character, e.g. `"key" : value`.
+	SpacingBoth
+)
+
+// spaces returns the whitespace to print before and after a punctuation
+// character for this Spacing. `def` is used in place of SpacingDefault.
+func (s Spacing) spaces(def Spacing) (before, after string) {
+	if s == SpacingDefault {
+		s = def
+	}
+	switch s {
+	case SpacingBefore:
+		return " ", ""
+	case SpacingAfter:
+		return "", " "
+	case SpacingBoth:
+		return " ", " "
+	}
+	return "", ""
+}
+
+// Kind identifies the kind of a JSON container. See Formatter.OnContainerOpen.
+type Kind int
+
+const (
+	// KindObject is a JSON object ({...}).
+	KindObject Kind = iota
+	// KindArray is a JSON array ([...]).
+	KindArray
+)
+
+// String returns "object" or "array".
+func (k Kind) String() string {
+	if k == KindArray {
+		return "array"
+	}
+	return "object"
+}
+
+// NewlineMode controls whether a trailing newline is printed after a JSON value.
+// See Formatter.TrailingNewline.
+type NewlineMode int
+
+const (
+	// NewlineDefault uses the default of the entry point (see Formatter.TrailingNewline).
+	NewlineDefault NewlineMode = iota
+	// NewlineAlways always prints a trailing newline.
+	NewlineAlways
+	// NewlineNever never prints a trailing newline.
+	NewlineNever
+)
+
+// resolve reports whether a trailing newline should be printed, given the
+// default `def` of the entry point.
+func (m NewlineMode) resolve(def bool) bool {
+	switch m {
+	case NewlineAlways:
+		return true
+	case NewlineNever:
+		return false
+	}
+	return def
 }
 
 // NewFormatter creates a new Formatter instance initialized with default values
@@ -306,7 +800,7 @@ func NewFormatter() *Formatter {
 
 // clone creates a shallow copy of the Formatter. This is used internally
 // to prevent modification of user-provided formatters when settings like
-// EscapeHTML are forcefully overridden (e.g., in NewEncoderWithFormatter).
+// EscapeHTML are overridden (e.g., by EncodeOptions).
 func (f *Formatter) clone() *Formatter {
 	// Create a new Formatter and copy the field values from the original.
 	g := *f
@@ -314,25 +808,54 @@ func (f *Formatter) clone() *Formatter {
 }
 
 // setIndent updates the Prefix and Indent fields of the Formatter.
-// Used internally by Encoder.SetIndent.
+// Used internally by WithIndent.
 func (f *Formatter) setIndent(prefix, indent string) {
 	f.Prefix = prefix
 	f.Indent = indent
 }
 
 // setEscapeHTML updates the EscapeHTML field of the Formatter.
-// Used internally by Encoder.SetEscapeHTML.
+// Used internally by WithEscapeHTML.
 func (f *Formatter) setEscapeHTML(on bool) {
 	f.EscapeHTML = on
 }
 
 // Format takes existing, valid JSON data in `src` and writes a colorized
 // version to `dst` according to the Formatter's settings.
-// It does not add a trailing newline.
+// It does not add a trailing newline. Several top-level values in a row, as in
+// JSON Lines, are written one per line.
+//
+// A leading UTF-8 byte order mark is skipped, and UTF-16 input, as written by
+// PowerShell redirects and other Windows tools, is decoded to UTF-8, whether it
+// starts with a byte order mark or not. The offsets in errors then refer to
+// the input decoded to UTF-8.
+//
+// If Header is set, the output is preceded by a header line, unless `dst` is a
+// terminal.
+//
+// If formatting stops early, because `src` turns out to be invalid, writing
+// fails, or a callback or Backend panics, the output written so far is
+// followed by an escape sequence resetting the colors, if it has any, so that
+// the terminal isn't left colored. The same goes for Encoder.Encode.
 func (f *Formatter) Format(dst io.Writer, src []byte) error {
+	header := f.Header && !isTerminal(dst)
+	h := Header{}
+	if header {
+		// The hash is that of the input as given, before transcoding.
+		h = NewHeader(f.ThemeName, src)
+	}
+	src, err := transcodeInput(src)
+	if err != nil {
+		return err
+	}
+	if header {
+		if err := WriteHeader(dst, h); err != nil {
+			return err
+		}
+	}
 	// Create a state machine for formatting and execute it.
-	// `false` means do not add a trailing newline.
-	return newFormatterState(f, dst).format(dst, src, false)
+	// By default, do not add a trailing newline.
+	return f.format(dst, src, f.TrailingNewline.resolve(false))
 }
 
 // format is the internal method used by both Formatter.Format and Encoder.encode.
@@ -344,240 +867,714 @@ func (f *Formatter) format(dst io.Writer, src []byte, terminateWithNewline bool)
 	return formatterState.format(dst, src, terminateWithNewline)
 }
 
-// Helper methods to get the appropriate SprintfFuncer, falling back to defaults if nil.
+// noColor is the color of the kinds of tokens left plain by Formatter.Colorize.
+var noColor = Style(nil)
+
+// colorizes reports whether tokens of the kind `kind` are colorized, as
+// decided by Formatter.Colorize.
+func (f *Formatter) colorizes(kind TokenKind) bool {
+	return f.Colorize == nil || f.Colorize[kind]
+}
+
+// Helper methods to get the appropriate SprintfFuncer: none for kinds of
+// tokens left plain by Colorize, then that of the variant
+// for the background of the terminal if set (see Formatter.LightColors),
+// falling back to the Formatter's own and to the defaults if nil.
 func (f *Formatter) spaceColor() SprintfFuncer {
+	if !f.colorizes(TokenWhitespace) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.SpaceColor != nil {
+		return v.SpaceColor
+	}
 	if f.SpaceColor != nil {
 		return f.SpaceColor
 	}
 	return DefaultSpaceColor
 }
 func (f *Formatter) commaColor() SprintfFuncer {
-	if f.CommaColor != nil {
-		return f.CommaColor
+	if !f.colorizes(TokenComma) {
+		return noColor
 	}
-	return DefaultCommaColor
+	return f.punctuationColor(func(g *Formatter) SprintfFuncer { return g.CommaColor }, DefaultCommaColor)
 }
 func (f *Formatter) colonColor() SprintfFuncer {
-	if f.ColonColor != nil {
-		return f.ColonColor
+	if !f.colorizes(TokenColon) {
+		return noColor
 	}
-	return DefaultColonColor
+	return f.punctuationColor(func(g *Formatter) SprintfFuncer { return g.ColonColor }, DefaultColonColor)
 }
 func (f *Formatter) objectColor() SprintfFuncer {
-	if f.ObjectColor != nil {
-		return f.ObjectColor
+	if !f.colorizes(TokenObjectDelim) {
+		return noColor
 	}
-	return DefaultObjectColor
+	return f.punctuationColor(func(g *Formatter) SprintfFuncer { return g.ObjectColor }, DefaultObjectColor)
 }
 func (f *Formatter) arrayColor() SprintfFuncer {
-	if f.ArrayColor != nil {
-		return f.ArrayColor
+	if !f.colorizes(TokenArrayDelim) {
+		return noColor
+	}
+	return f.punctuationColor(func(g *Formatter) SprintfFuncer { return g.ArrayColor }, DefaultArrayColor)
+}
+
+// punctuationColor returns the color of a kind of punctuation, which `own`
+// returns the field of from a Formatter, falling back to the PunctuationColor
+// of the same Formatter, and to the default `def`.
+func (f *Formatter) punctuationColor(own func(*Formatter) SprintfFuncer, def SprintfFuncer) SprintfFuncer {
+	for _, g := range [...]*Formatter{f.variant(), f} {
+		if g == nil {
+			continue
+		}
+		if c := own(g); c != nil {
+			return c
+		}
+		if g.PunctuationColor != nil {
+			return g.PunctuationColor
+		}
 	}
-	return DefaultArrayColor
+	return def
 }
 func (f *Formatter) fieldQuoteColor() SprintfFuncer {
+	if !f.colorizes(TokenKey) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.FieldQuoteColor != nil {
+		return v.FieldQuoteColor
+	}
 	if f.FieldQuoteColor != nil {
 		return f.FieldQuoteColor
 	}
 	return DefaultFieldQuoteColor
 }
 func (f *Formatter) fieldColor() SprintfFuncer {
+	if !f.colorizes(TokenKey) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.FieldColor != nil {
+		return v.FieldColor
+	}
 	if f.FieldColor != nil {
 		return f.FieldColor
 	}
 	return DefaultFieldColor
 }
 func (f *Formatter) stringQuoteColor() SprintfFuncer {
+	if !f.colorizes(TokenString) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.StringQuoteColor != nil {
+		return v.StringQuoteColor
+	}
 	if f.StringQuoteColor != nil {
 		return f.StringQuoteColor
 	}
 	return DefaultStringQuoteColor
 }
 func (f *Formatter) stringColor() SprintfFuncer {
+	if !f.colorizes(TokenString) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.StringColor != nil {
+		return v.StringColor
+	}
 	if f.StringColor != nil {
 		return f.StringColor
 	}
 	return DefaultStringColor
 }
 func (f *Formatter) trueColor() SprintfFuncer {
+	if !f.colorizes(TokenBool) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.TrueColor != nil {
+		return v.TrueColor
+	}
 	if f.TrueColor != nil {
 		return f.TrueColor
 	}
 	return DefaultTrueColor
 }
 func (f *Formatter) falseColor() SprintfFuncer {
+	if !f.colorizes(TokenBool) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.FalseColor != nil {
+		return v.FalseColor
+	}
 	if f.FalseColor != nil {
 		return f.FalseColor
 	}
 	return DefaultFalseColor
 }
 func (f *Formatter) numberColor() SprintfFuncer {
+	if !f.colorizes(TokenNumber) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.NumberColor != nil {
+		return v.NumberColor
+	}
 	if f.NumberColor != nil {
 		return f.NumberColor
 	}
 	return DefaultNumberColor
 }
 func (f *Formatter) nullColor() SprintfFuncer {
+	if !f.colorizes(TokenNull) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.NullColor != nil {
+		return v.NullColor
+	}
 	if f.NullColor != nil {
 		return f.NullColor
 	}
 	return DefaultNullColor
 }
+func (f *Formatter) indentGuideColor() SprintfFuncer {
+	if !f.colorizes(TokenWhitespace) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.IndentGuideColor != nil {
+		return v.IndentGuideColor
+	}
+	if f.IndentGuideColor != nil {
+		return f.IndentGuideColor
+	}
+	return DefaultIndentGuideColor
+}
+func (f *Formatter) commentColor() SprintfFuncer {
+	if !f.colorizes(TokenComment) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.CommentColor != nil {
+		return v.CommentColor
+	}
+	if f.CommentColor != nil {
+		return f.CommentColor
+	}
+	return DefaultCommentColor
+}
+func (f *Formatter) annotationColor() SprintfFuncer {
+	if !f.colorizes(TokenAnnotation) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.CommentColor != nil {
+		return v.CommentColor
+	}
+	if f.CommentColor != nil {
+		return f.CommentColor
+	}
+	return DefaultCommentColor
+}
+
+func (f *Formatter) skeletonColor() SprintfFuncer {
+	if v := f.variant(); v != nil && v.SkeletonColor != nil {
+		return v.SkeletonColor
+	}
+	if f.SkeletonColor != nil {
+		return f.SkeletonColor
+	}
+	return DefaultSkeletonColor
+}
+func (f *Formatter) rawColor() SprintfFuncer {
+	if !f.colorizes(TokenString) {
+		return nil
+	}
+	if v := f.variant(); v != nil && v.RawColor != nil {
+		return v.RawColor
+	}
+	return f.RawColor
+}
+func (f *Formatter) wrapMarkerColor() SprintfFuncer {
+	if !f.colorizes(TokenWhitespace) {
+		return noColor
+	}
+	if v := f.variant(); v != nil && v.WrapMarkerColor != nil {
+		return v.WrapMarkerColor
+	}
+	if f.WrapMarkerColor != nil {
+		return f.WrapMarkerColor
+	}
+	return DefaultWrapMarkerColor
+}
+func (f *Formatter) errorColor() SprintfFuncer {
+	if v := f.variant(); v != nil && v.ErrorColor != nil {
+		return v.ErrorColor
+	}
+	if f.ErrorColor != nil {
+		return f.ErrorColor
+	}
+	return DefaultErrorColor
+}
+
+// keyColor returns the color of the key `key` set by KeyColors, preferring
+// that of the variant for the background, or nil if there's none.
+func (f *Formatter) keyColor(key string) SprintfFuncer {
+	if !f.colorizes(TokenKey) {
+		return nil
+	}
+	if v := f.variant(); v != nil && v.KeyColors[key] != nil {
+		return v.KeyColors[key]
+	}
+	return f.KeyColors[key]
+}
+
+// valueColor returns the color of the values of the key `key` set by
+// ValueColors, like keyColor. Colorize is left to check for the kind of each
+// value.
+func (f *Formatter) valueColor(key string) SprintfFuncer {
+	if v := f.variant(); v != nil && v.ValueColors[key] != nil {
+		return v.ValueColors[key]
+	}
+	return f.ValueColors[key]
+}
+
+// memberColors resolves the colors of `colors`, the KeyColors or ValueColors
+// of the Formatter and of its variant, with `colorFunc`, by key.
+func memberColors(colorFunc func(SprintfFuncer) func(format string, a ...interface{}) string, color func(key string) SprintfFuncer, colors ...map[string]SprintfFuncer) map[string]func(format string, a ...interface{}) string {
+	var resolved map[string]func(format string, a ...interface{}) string
+	for _, m := range colors {
+		for key := range m {
+			if c := color(key); c != nil {
+				if resolved == nil {
+					resolved = make(map[string]func(format string, a ...interface{}) string)
+				}
+				resolved[key] = colorFunc(c)
+			}
+		}
+	}
+	return resolved
+}
+
+// palette holds the colorizing functions of a Formatter for each color role,
+// for renderers of other formats sharing them (see Formatter.FormatYAML).
+type palette struct {
+	space, field, fieldQuote, str, strQuote, number, tru, fals, null,
+	colon, comma, object, array, comment func(format string, a ...interface{}) string
+}
+
+// palette resolves the colorizing functions of the Formatter.
+func (f *Formatter) palette() palette {
+	color := formatterOptions.colorFunc(nil, f.ColorProfile.resolve())
+	return palette{
+		space:      color(f.spaceColor()),
+		field:      color(f.fieldColor()),
+		fieldQuote: color(f.fieldQuoteColor()),
+		str:        color(f.stringColor()),
+		strQuote:   color(f.stringQuoteColor()),
+		number:     color(f.numberColor()),
+		tru:        color(f.trueColor()),
+		fals:       color(f.falseColor()),
+		null:       color(f.nullColor()),
+		colon:      color(f.colonColor()),
+		comma:      color(f.commaColor()),
+		object:     color(f.objectColor()),
+		array:      color(f.arrayColor()),
+		comment:    color(f.commentColor()),
+	}
+}
 
 // formatterState holds the transient state during the process of formatting
 // (parsing and colorizing) a JSON byte slice.
 type formatterState struct {
-	compact bool     // True if indentation is disabled (Prefix and Indent are empty).
-	indent  string   // Cached indentation string (repeated f.Indent) to avoid recomputation.
-	frames  []*frame // Stack tracking nesting level and context (object/array, key/value).
+	compact  bool   // True if indentation is disabled (Prefix and Indent are empty).
+	indent   string // Cached indentation string (repeated f.Indent) to avoid recomputation.
+	guides   string // Cached colorized indentation string with guides, used when f.IndentGuides is set.
+	skeleton bool   // True if leaf values are replaced by a placeholder (f.Skeleton).
+
+	// trackPaths is true if the JSON Pointer of each container must be computed,
+	// which is only the case if a callback wants to receive it.
+	trackPaths bool
+
+	sortKeys bool // True if object keys are printed in sorted order (f.SortKeys).
+
+	syntax       Syntax // The dialect of JSON accepted as input (f.Syntax).
+	strictOutput bool   // True if input in another syntax is written as standard JSON (f.StrictOutput).
+	// quote is printed around the current key or string, which is a double
+	// quote except when preserving other syntaxes (see Formatter.Syntax).
+	quote              string
+	preserveBlankLines bool // True if blank lines between members are kept (f.PreserveBlankLines).
+	// blankLine is true if the input has a blank line before the token or comment
+	// being written, which is only tracked if preserveBlankLines is set.
+	blankLine bool
+
+	// newlinePending is true if a line comment was printed, which must be
+	// followed by a newline before anything else (see writeComment).
+	newlinePending bool
+
+	// preserveExact is true if strings are printed exactly as escaped in the input (f.PreserveExact).
+	preserveExact bool
+	// raw holds the escaped contents of the current string token in the input,
+	// which is printed instead of re-escaping the decoded string if non-nil.
+	raw []byte
+	// rawString is true while writing a pre-escaped string (see writeRawString),
+	// which is printed with RawColor.
+	rawString bool
+
+	escapePolicy      EscapePolicy      // Which characters are escaped within strings (f.EscapePolicy, f.EscapeHTML).
+	controlPolicy     ControlCharPolicy // How control characters written as they are are handled (f.ControlCharPolicy).
+	utf8Policy        InvalidUTF8Policy // How strings containing invalid UTF-8 are handled (f.InvalidUTF8Policy).
+	escapeReplacement bool              // True if U+FFFD characters are written as escape sequences (see InvalidUTF8Escape).
+
+	// Settings for rendering small objects on a single line (f.InlineMaxMembers, f.InlineMaxMembersByDepth).
+	inlineMaxMembers        int
+	inlineMaxMembersByDepth map[int]int
+	// Container callbacks, copied from the Formatter (f.OnContainerOpen, f.OnContainerClose).
+	onContainerOpen  func(path string, kind Kind, count int)
+	onContainerClose func(path string, kind Kind, count int)
+	// onValue, if set, is called with the JSON Pointer of each value right before
+	// it's written (see Formatter.FormatSourceMap).
+	onValue func(path string)
+	// onSegment, if set, receives the output as segments instead of it being
+	// written (see Formatter.FormatSegments). `path` is the JSON Pointer of the
+	// token being printed, set by writeToken and closeContainer.
+	onSegment func(memberSegment)
+	path      string
+	frames    []*frame // Stack tracking nesting level and context (object/array, key/value).
+
+	// keyMemo maps the object keys printed so far to their colorized rendering,
+	// including quotes, so that each distinct key is only escaped and colorized
+	// once per call. It holds at most maxKeyMemo keys.
+	keyMemo map[string]string
+
+	// out wraps the destination writer, recording the first error writing to it.
+	out *errWriter
+	// interned caches the colorized rendering of string values (f.InternStrings).
+	interned *lruCache
 
 	// Pre-bound printing functions that include the colorization logic
 	// based on the Formatter settings provided to newFormatterState.
-	printSpace  func(s string, force bool) // Prints whitespace (handles compact mode). `force` ignores compact mode (used for final newline).
-	printComma  func()                     // Prints a colorized comma.
-	printColon  func()                     // Prints a colorized colon.
-	printObject func(json.Delim)           // Prints a colorized object delimiter ({ or }).
-	printArray  func(json.Delim)           // Prints a colorized array delimiter ([ or ]).
-	printField  func(k string) error       // Prints a colorized object field name (key), including quotes. Handles string escaping.
-	printString func(s string) error       // Prints a colorized string value, including quotes. Handles string escaping.
-	printBool   func(b bool)               // Prints a colorized boolean value.
-	printNumber func(n json.Number)        // Prints a colorized number value.
-	printNull   func()                     // Prints a colorized null value.
-	printIndent func()                     // Prints the current indentation (prefix + indent).
+	printSpace   func(s string, force bool) // Prints whitespace (handles compact mode). `force` ignores compact mode (used for final newline).
+	printComma   func()                     // Prints a colorized comma, with its configured spacing.
+	printColon   func()                     // Prints a colorized colon, with its configured spacing.
+	printObject  func(json.Delim)           // Prints a colorized object delimiter ({ or }).
+	printArray   func(json.Delim)           // Prints a colorized array delimiter ([ or ]).
+	printField   func(k string) error       // Prints a colorized object field name (key), including quotes. Handles string escaping.
+	printString  func(s string) error       // Prints a colorized string value, including quotes. Handles string escaping.
+	printBool    func(b bool)               // Prints a colorized boolean value.
+	printNumber  func(n json.Number)        // Prints a colorized number value.
+	printNull    func()                     // Prints a colorized null value.
+	printElided  func(TokenKind)            // Prints the colorized placeholder standing in for a leaf value of the given kind in skeleton mode.
+	printIndent  func()                     // Prints the current indentation (prefix + indent).
+	printComment func(TokenKind, string)    // Prints a colorized comment or annotation, including its delimiters.
 }
 
+// maxKeyMemo is the maximum number of keys memoized by a formatterState, which
+// bounds its memory use for documents with many distinct keys, such as maps
+// keyed by IDs.
+const maxKeyMemo = 1024
+
 // newFormatterState creates and initializes a formatterState based on the
 // provided Formatter configuration `f` and output writer `dst`.
 // It captures the necessary color functions and sets up the initial state.
 func newFormatterState(f *Formatter, dst io.Writer) *formatterState {
+	return newFormatterStateWithOptions(f, dst, formatterOptions)
+}
+
+// newFormatterStateWithOptions is like newFormatterState, with the encode
+// options `o` applying on top of the settings of `f`, which is left as is.
+func newFormatterStateWithOptions(f *Formatter, dst io.Writer, o encodeOptions) *formatterState {
+	prefix, indent := o.layout(f)
 	// Retrieve the SprintfFunc for each color type, falling back to defaults.
-	sprintfSpace := f.spaceColor().SprintfFunc()
-	sprintfComma := f.commaColor().SprintfFunc()
-	sprintfColon := f.colonColor().SprintfFunc()
-	sprintfObject := f.objectColor().SprintfFunc()
-	sprintfArray := f.arrayColor().SprintfFunc()
-	sprintfFieldQuote := f.fieldQuoteColor().SprintfFunc()
-	sprintfField := f.fieldColor().SprintfFunc()
-	sprintfStringQuote := f.stringQuoteColor().SprintfFunc()
-	sprintfString := f.stringColor().SprintfFunc()
-	sprintfTrue := f.trueColor().SprintfFunc()
-	sprintfFalse := f.falseColor().SprintfFunc()
-	sprintfNumber := f.numberColor().SprintfFunc()
-	sprintfNull := f.nullColor().SprintfFunc()
+	// The colors are resolved according to the color mode (see Encoder.SetColorMode).
+	colorFunc := o.colorFunc(dst, f.ColorProfile.resolve())
+	sprintfSpace := colorFunc(f.spaceColor())
+	sprintfComma := colorFunc(f.commaColor())
+	sprintfColon := colorFunc(f.colonColor())
+	sprintfObject := colorFunc(f.objectColor())
+	sprintfArray := colorFunc(f.arrayColor())
+	sprintfFieldQuote := colorFunc(f.fieldQuoteColor())
+	sprintfField := colorFunc(f.fieldColor())
+	sprintfStringQuote := colorFunc(f.stringQuoteColor())
+	sprintfString := colorFunc(f.stringColor())
+	sprintfTrue := colorFunc(f.trueColor())
+	sprintfFalse := colorFunc(f.falseColor())
+	sprintfNumber := colorFunc(f.numberColor())
+	sprintfNull := colorFunc(f.nullColor())
+	sprintfSkeleton := colorFunc(f.skeletonColor())
+	sprintfComment := colorFunc(f.commentColor())
+	sprintfAnnotation := colorFunc(f.annotationColor())
+	sprintfRawQuote, sprintfRaw := sprintfStringQuote, sprintfString
+	if raw := f.rawColor(); raw != nil {
+		sprintfRawQuote = colorFunc(raw)
+		sprintfRaw = sprintfRawQuote
+	}
+	var variantKeyColors, variantValueColors map[string]SprintfFuncer
+	if v := f.variant(); v != nil {
+		variantKeyColors, variantValueColors = v.KeyColors, v.ValueColors
+	}
+	keyColors := memberColors(colorFunc, f.keyColor, f.KeyColors, variantKeyColors)
+	valueColors := memberColors(colorFunc, f.valueColor, f.ValueColors, variantValueColors)
 
 	// Helper function to properly encode a Go string into a JSON string payload
-	// (handling escapes like \", \n, \t, etc.) and potentially HTML escapes (<, >, &)
-	// based on the formatter's EscapeHTML setting.
-	// It uses a temporary json.Encoder to achieve this.
+	// (handling escapes like \", \n, \t, etc.), escaping further characters
+	// according to the formatter's EscapePolicy.
+	// fs is initialized below, but encodeString needs to consult it.
+	var fs *formatterState
+
 	encodeString := func(s string) (string, error) {
-		buf := bytes.NewBuffer(make([]byte, 0, len(s)+3)) // Preallocate buffer slightly larger than string
-		enc := json.NewEncoder(buf)
-
-		// Check if the standard library's json.Encoder supports SetEscapeHTML (Go 1.7+).
-		// Use an interface assertion for compatibility with older Go versions.
-		type setEscapeHTMLer interface {
-			SetEscapeHTML(bool)
-		}
-		if se, ok := interface{}(enc).(setEscapeHTMLer); ok {
-			// Apply the formatter's HTML escape setting.
-			se.SetEscapeHTML(f.EscapeHTML)
-		} else if f.EscapeHTML {
-			// If SetEscapeHTML is not available but HTML escaping is requested,
-			// we cannot fulfill the request using the standard encoder alone.
-			// Note: The original code implicitly relied on the standard Marshal which *does*
-			// escape HTML by default, so this case might indicate a slight behavioral
-			// difference if used with Go < 1.7 AND f.EscapeHTML=true AND Marshal* functions.
-			// However, the Marshal* functions *force* enc.SetEscapeHTML(true) later,
-			// mitigating this for those entry points. Direct Formatter.Format calls
-			// with Go < 1.7 and EscapeHTML=true might not escape HTML here.
-			// For safety, we won't try to manually escape here, preserving original behavior.
-		}
-
-		// Use the encoder to encode the *Go string* `s` into a *JSON string literal*.
-		// Note: We pass a pointer `&s` because json.Encoder.Encode expects an interface{},
-		// and encoding the string directly might lead to different behavior (e.g., base64).
-		err := enc.Encode(&s)
-		if err != nil {
-			return "", fmt.Errorf("internal error encoding string segment: %w", err)
+		// In PreserveExact mode, the string is printed exactly as it was escaped in the input.
+		if fs.raw != nil {
+			raw := fs.raw
+			fs.raw = nil
+			return sanitizeControls(string(raw), fs.controlPolicy), nil
+		}
+		// Only the characters which aren't escaped are left to sanitize.
+		return sanitizeControls(string(appendEscaped(make([]byte, 0, len(s)), s, fs.escapePolicy, fs.escapeReplacement)), fs.controlPolicy), nil
+	}
+
+	// All output goes through `out`, which records write errors (see WriteErrorPolicy).
+	out := &errWriter{w: dst, bestEffort: f.WriteErrorPolicy == WriteErrorBestEffort}
+	if width := f.wrapWidth(dst); width > 0 {
+		marker, markerWidth := f.wrapMarker()
+		out.w = newWrapWriter(dst, width, prefix, indent, colorFunc(f.wrapMarkerColor())("%s", marker), markerWidth)
+	}
+
+	// With a Backend, text is rendered by it rather than with the colors.
+	backend := f.Backend
+	var rendered []byte
+	render := func(kind TokenKind, text string) {
+		rendered = backend.Render(rendered[:0], kind, text)
+		out.Write(rendered)
+	}
+
+	// printText writes `text` in the color of `sprintf`, or emits it as a segment of
+	// the given kind in segment mode (see Formatter.FormatSegments).
+	printText := func(kind TokenKind, sprintf func(format string, a ...interface{}) string, text string) {
+		if fs.onSegment != nil {
+			fs.emitSegment(kind, text)
+			return
+		}
+		if backend != nil {
+			render(kind, text)
+			return
+		}
+		io.WriteString(out, sprintf("%s", text))
+	}
+	// printQuoted writes a key or string, whose quotes have a color of their own.
+	// In segment mode, it's emitted as a single segment including the quotes.
+	printQuoted := func(kind TokenKind, sprintfQuote, sprintf func(format string, a ...interface{}) string, escaped string) {
+		if fs.onSegment != nil {
+			fs.emitSegment(kind, fs.quote+escaped+fs.quote)
+			return
+		}
+		if backend != nil {
+			render(kind, fs.quote+escaped+fs.quote)
+			return
 		}
+		if fs.quote != "" {
+			io.WriteString(out, sprintfQuote(fs.quote))
+		}
+		io.WriteString(out, sprintf("%s", escaped))
+		if fs.quote != "" {
+			io.WriteString(out, sprintfQuote(fs.quote))
+		}
+	}
 
-		// enc.Encode adds quotes and a newline. We need only the content *between* the quotes.
-		sbuf := buf.Bytes()
-		// Basic sanity check: must be at least `"..."\n` or `""\n`
-		if len(sbuf) < 3 {
-			return "", fmt.Errorf("internal error encoding string segment: result too short")
+	// valueSprintf returns the colorizing function set by ValueColors for a
+	// value of the kind `kind` under the key of its member, if any.
+	valueSprintf := func(kind TokenKind) (func(format string, a ...interface{}) string, bool) {
+		if valueColors == nil || !f.colorizes(kind) {
+			return nil, false
+		}
+		if current := fs.frame(); current.inObject() {
+			sprintf, ok := valueColors[current.key]
+			return sprintf, ok
+		}
+		return nil, false
+	}
+	// printValue writes a number, boolean or null like printText, in the
+	// color set by ValueColors for its key if any.
+	printValue := func(kind TokenKind, sprintf func(format string, a ...interface{}) string, text string) {
+		if override, ok := valueSprintf(kind); ok {
+			sprintf = override
 		}
-		// Strip leading quote and trailing quote + newline.
-		return string(sbuf[1 : len(sbuf)-2]), nil
+		printText(kind, sprintf, text)
 	}
 
 	// Initialize the formatter state.
-	fs := &formatterState{
+	fs = &formatterState{
 		// Indentation is disabled if both Prefix and Indent are empty.
-		compact: len(f.Prefix) == 0 && len(f.Indent) == 0,
-		indent:  "", // Indent cache starts empty.
-		// Start with a base frame representing the top level. Indent level 0.
-		frames: []*frame{{indent: 0}},
+		compact:  len(prefix) == 0 && len(indent) == 0,
+		skeleton: f.Skeleton,
+
+		sortKeys:           f.SortKeys,
+		syntax:             f.Syntax,
+		strictOutput:       f.StrictOutput,
+		quote:              `"`,
+		preserveBlankLines: f.PreserveBlankLines,
+		preserveExact:      o.preserveExactFor(f),
+		escapePolicy:       o.escapePolicyFor(f),
+		controlPolicy:      f.ControlCharPolicy,
+		utf8Policy:         f.InvalidUTF8Policy,
+
+		interned: newLRUCache(f.InternStrings),
+		out:      out,
+
+		inlineMaxMembers:        f.InlineMaxMembers,
+		inlineMaxMembersByDepth: f.InlineMaxMembersByDepth,
+
+		trackPaths:       f.OnContainerOpen != nil || f.OnContainerClose != nil,
+		onContainerOpen:  f.OnContainerOpen,
+		onContainerClose: f.OnContainerClose,
+		indent:           "", // Indent cache starts empty.
+		// Start with a base frame representing the top level, at the base
+		// indentation level (0 unless set by Encoder.SetBaseIndentLevel).
+		frames: []*frame{{indent: o.baseIndentLevel}},
 
 		// Define the print functions, capturing the sprintf functions and the writer.
-		printComma: func() {
-			fmt.Fprint(dst, sprintfComma(","))
-		},
-		printColon: func() {
-			fmt.Fprint(dst, sprintfColon(":"))
-		},
 		printObject: func(t json.Delim) { // t is '{' or '}'
-			fmt.Fprint(dst, sprintfObject(t.String()))
+			printText(TokenObjectDelim, sprintfObject, t.String())
 		},
 		printArray: func(t json.Delim) { // t is '[' or ']'
-			fmt.Fprint(dst, sprintfArray(t.String()))
+			printText(TokenArrayDelim, sprintfArray, t.String())
 		},
 		printField: func(k string) error {
+			// Keys with a color of their own (see Formatter.KeyColors) have it
+			// for their quotes too.
+			sprintfQuote, sprintfKey := sprintfFieldQuote, sprintfField
+			if sprintf, ok := keyColors[k]; ok {
+				sprintfQuote, sprintfKey = sprintf, sprintf
+			}
+			// Keys tend to repeat, so the rendering of each is memoized, including
+			// for keys with a color of their own, which is the same every time.
+			// Keys printed exactly as escaped in the input or with other quotes
+			// are not, since their rendering doesn't only depend on the key.
+			memoize := fs.onSegment == nil && backend == nil && fs.raw == nil && fs.quote == `"`
+			if memoize {
+				if rendered, ok := fs.keyMemo[k]; ok {
+					io.WriteString(out, rendered)
+					return nil
+				}
+			}
 			// Encode the raw key string to handle escapes correctly.
 			escapedKey, err := encodeString(k)
 			if err != nil {
 				return err
 			}
-			// Print quote, key text, quote using field colors.
-			fmt.Fprint(dst, sprintfFieldQuote(`"`))
-			fmt.Fprint(dst, sprintfField("%s", escapedKey))
-			fmt.Fprint(dst, sprintfFieldQuote(`"`))
+			if memoize && len(fs.keyMemo) < maxKeyMemo {
+				if fs.keyMemo == nil {
+					fs.keyMemo = make(map[string]string)
+				}
+				rendered := sprintfQuote(fs.quote) + sprintfKey("%s", escapedKey) + sprintfQuote(fs.quote)
+				fs.keyMemo[k] = rendered
+				io.WriteString(out, rendered)
+				return nil
+			}
+			// Print quote, key text, quote using the key's colors.
+			printQuoted(TokenKey, sprintfQuote, sprintfKey, escapedKey)
 			return nil
 		},
 		printString: func(s string) error {
+			sprintfValue, overridden := valueSprintf(TokenString)
+			// Like keys, values are memoized if interned, under the same conditions.
+			intern := fs.interned != nil && fs.onSegment == nil && backend == nil && fs.raw == nil && !fs.rawString &&
+				!overridden && fs.quote == `"` && len(s) <= maxInternLength
+			if intern {
+				if rendered, ok := fs.interned.get(s); ok {
+					io.WriteString(out, rendered)
+					return nil
+				}
+			}
 			// Encode the raw value string to handle escapes correctly.
 			escapedValue, err := encodeString(s)
 			if err != nil {
 				return err
 			}
+			if intern {
+				rendered := sprintfStringQuote(fs.quote) + sprintfString("%s", escapedValue) + sprintfStringQuote(fs.quote)
+				fs.interned.put(s, rendered)
+				io.WriteString(out, rendered)
+				return nil
+			}
 			// Print quote, string text, quote using string value colors.
-			fmt.Fprint(dst, sprintfStringQuote(`"`))
-			fmt.Fprint(dst, sprintfString("%s", escapedValue))
-			fmt.Fprint(dst, sprintfStringQuote(`"`))
+			if overridden {
+				printQuoted(TokenString, sprintfValue, sprintfValue, escapedValue)
+				return nil
+			}
+			if fs.rawString {
+				printQuoted(TokenString, sprintfRawQuote, sprintfRaw, escapedValue)
+				return nil
+			}
+			printQuoted(TokenString, sprintfStringQuote, sprintfString, escapedValue)
 			return nil
 		},
 		printBool: func(b bool) {
 			if b {
-				fmt.Fprint(dst, sprintfTrue("%v", b)) // Use %v for standard "true"
+				printValue(TokenBool, sprintfTrue, "true")
 			} else {
-				fmt.Fprint(dst, sprintfFalse("%v", b)) // Use %v for standard "false"
+				printValue(TokenBool, sprintfFalse, "false")
 			}
 		},
 		printNumber: func(n json.Number) {
-			fmt.Fprint(dst, sprintfNumber("%v", n)) // Use %v for standard number format
+			printValue(TokenNumber, sprintfNumber, n.String())
 		},
 		printNull: func() {
-			fmt.Fprint(dst, sprintfNull("null"))
+			printValue(TokenNull, sprintfNull, "null")
+		},
+		printElided: func(kind TokenKind) {
+			printText(kind, sprintfSkeleton, skeletonPlaceholder)
+		},
+		printComment: func(kind TokenKind, text string) {
+			text = sanitizeControls(text, fs.controlPolicy)
+			if kind == TokenAnnotation {
+				printText(kind, sprintfAnnotation, text)
+				return
+			}
+			printText(kind, sprintfComment, text)
 		},
 	}
 
 	// printSpace needs access to the `fs.compact` field, so define it after fs init.
 	fs.printSpace = func(s string, force bool) {
 		// Only print space if not in compact mode, or if forced (e.g., final newline).
-		if fs.compact && !force {
+		if (fs.compact && !force) || s == "" {
 			return
 		}
-		fmt.Fprint(dst, sprintfSpace(s))
+		printText(TokenWhitespace, sprintfSpace, s)
+	}
+
+	// Resolve the whitespace around colons and commas. An unset ColonSpacing keeps the
+	// classic `"key": value` form, but only when indenting; compact output stays tight.
+	colonDefault := SpacingAfter
+	if fs.compact {
+		colonDefault = SpacingNone
+	}
+	colonBefore, colonAfter := f.ColonSpacing.spaces(colonDefault)
+	commaBefore, commaAfter := f.CommaSpacing.spaces(SpacingNone)
+	// Within containers rendered inline, elements are separated by a space by default, e.g. {"x": 1, "y": 2}.
+	_, inlineCommaAfter := f.CommaSpacing.spaces(SpacingAfter)
+
+	// printComma and printColon emit their surrounding whitespace through printSpace,
+	// forcing it since explicitly configured spacing applies in compact mode too.
+	fs.printComma = func() {
+		fs.printSpace(commaBefore, true)
+		printText(TokenComma, sprintfComma, ",")
+		// When indenting, a newline follows every comma, so a space would only
+		// produce trailing whitespace.
+		if fs.compact {
+			fs.printSpace(commaAfter, true)
+		} else if fs.frame().inline {
+			fs.printSpace(inlineCommaAfter, true)
+		}
+	}
+	fs.printColon = func() {
+		fs.printSpace(colonBefore, true)
+		printText(TokenColon, sprintfColon, ":")
+		fs.printSpace(colonAfter, true)
+	}
+
+	// With IndentGuides, each level of indentation starts with a guide character in
+	// place of its first space. Indents starting with anything else (e.g. a tab)
+	// keep their full width, with the guide placed in front of them.
+	guideUnit, plainGuideUnit := "", ""
+	if f.IndentGuides && len(indent) > 0 {
+		rest := indent
+		if rest[0] == ' ' {
+			rest = rest[1:]
+		}
+		guideUnit = colorFunc(f.indentGuideColor())(indentGuide) + sprintfSpace(rest)
+		plainGuideUnit = indentGuide + rest
 	}
 
 	// printIndent needs access to formatter `f` and the state `fs`, define it last.
@@ -587,22 +1584,33 @@ func newFormatterState(f *Formatter, dst io.Writer) *formatterState {
 			return
 		}
 		// Print the prefix string, if any.
-		if len(f.Prefix) > 0 {
+		if len(prefix) > 0 {
 			// Note: Prefix itself is not colorized by `sprintfSpace`.
-			fmt.Fprint(dst, f.Prefix)
+			printText(TokenWhitespace, fmt.Sprintf, prefix)
 		}
 		// Get the current indentation level from the frame stack.
 		currentIndentLevel := fs.frame().indent
-		if currentIndentLevel > 0 {
+		if currentIndentLevel > 0 && guideUnit != "" && (fs.onSegment != nil || backend != nil) {
+			// Segments carry no colors, and backends apply their own, so the
+			// guides are passed on as plain text.
+			printText(TokenWhitespace, sprintfSpace, strings.Repeat(plainGuideUnit, currentIndentLevel))
+		} else if currentIndentLevel > 0 && guideUnit != "" {
+			// The guide unit is already colorized, so it is cached and printed as-is.
+			requiredGuidesLen := len(guideUnit) * currentIndentLevel
+			if len(fs.guides) < requiredGuidesLen {
+				fs.guides = strings.Repeat(guideUnit, currentIndentLevel)
+			}
+			io.WriteString(out, fs.guides[:requiredGuidesLen])
+		} else if currentIndentLevel > 0 {
 			// Calculate required length of the indentation string (e.g., level 2 * "  " = 4 chars).
-			requiredIndentLen := len(f.Indent) * currentIndentLevel
+			requiredIndentLen := len(indent) * currentIndentLevel
 			// Cache the repeated indent string if it's not long enough.
 			// This avoids repeated string concatenation/building.
 			if len(fs.indent) < requiredIndentLen {
-				fs.indent = strings.Repeat(f.Indent, currentIndentLevel)
+				fs.indent = strings.Repeat(indent, currentIndentLevel)
 			}
 			// Print the correctly sized slice of the cached indent string, applying space color.
-			fmt.Fprint(dst, sprintfSpace(fs.indent[:requiredIndentLen]))
+			printText(TokenWhitespace, sprintfSpace, fs.indent[:requiredIndentLen])
 		}
 	}
 
@@ -616,21 +1624,108 @@ func (fs *formatterState) frame() *frame {
 
 // enterFrame pushes a new frame onto the stack when an opening delimiter
 // ('{' or '[') is encountered. It increments the indentation level.
-// `empty` indicates if the new object/array is known to be empty (e.g., {} or []).
-func (fs *formatterState) enterFrame(t json.Delim, empty bool) *frame {
+func (fs *formatterState) enterFrame(t json.Delim) *frame {
 	// New indentation level is one greater than the current frame's level.
 	newIndentLevel := fs.frames[len(fs.frames)-1].indent + 1
 	newFrame := &frame{
 		object: t == json.Delim('{'), // Set true if '{'
 		array:  t == json.Delim('['), // Set true if '['
+		field:  t == json.Delim('{'), // The first token in an object is a key.
 		indent: newIndentLevel,
-		empty:  empty, // Mark if known to be empty from the start
-		// `field` defaults to false (expecting key in object, irrelevant in array).
 	}
 	fs.frames = append(fs.frames, newFrame)
 	return newFrame
 }
 
+// inlineObject reports whether the container opened by `delim` should be
+// rendered on a single line according to Formatter.InlineMaxMembers. `offset`
+// is the position in `src` just after the opening delimiter.
+// Only objects whose members are all scalars (or empty containers) are inlined.
+func (fs *formatterState) inlineObject(delim json.Delim, src []byte, offset int) bool {
+	if fs.compact || delim != json.Delim('{') {
+		return false
+	}
+	// The new object's depth is the number of enclosing containers.
+	maxMembers := fs.inlineMaxMembers
+	if n, ok := fs.inlineMaxMembersByDepth[len(fs.frames)-1]; ok {
+		maxMembers = n
+	}
+	if maxMembers <= 0 {
+		return false
+	}
+
+	// Scan the object's contents, stopping as soon as it's clear that it can't be inlined.
+	members := 0
+	depth := 0
+	for i := offset; i < len(src); i++ {
+		switch src[i] {
+		case '"':
+			// Skip over the string, including any escaped quotes.
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		case '/':
+			// Comments (see Formatter.Syntax) are kept on lines of their own.
+			return false
+		case ':':
+			if depth == 0 {
+				members++
+				if members > maxMembers {
+					return false
+				}
+			}
+		case '{', '[':
+			// Nested containers are only acceptable if empty.
+			j := i + 1
+			for j < len(src) && isSpace(src[j]) {
+				j++
+			}
+			if j >= len(src) || (src[j] != '}' && src[j] != ']') {
+				return false
+			}
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return true
+			}
+			depth--
+		}
+	}
+	return true
+}
+
+// isSpace reports whether `c` is JSON insignificant whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// childPath returns the JSON Pointer of the next value within `parent`: the
+// current key of an object, or the next index of an array.
+// Returns "" if paths are not being tracked.
+func (fs *formatterState) childPath(parent *frame) string {
+	if !fs.trackPaths {
+		return ""
+	}
+	if parent.inObject() {
+		return parent.path + "/" + escapePointerToken(parent.key)
+	}
+	if parent.inArray() {
+		return parent.path + "/" + strconv.Itoa(parent.count)
+	}
+	// The top-level value is the root of the document.
+	return ""
+}
+
+// escapePointerToken escapes a reference token for use in a JSON Pointer (RFC 6901).
+func escapePointerToken(s string) string {
+	if !strings.ContainsAny(s, "~/") {
+		return s
+	}
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
 // leaveFrame pops the current frame from the stack when a closing delimiter
 // ('}' or ']') is encountered. It returns the frame that becomes the current one.
 func (fs *formatterState) leaveFrame() *frame {
@@ -641,6 +1736,18 @@ func (fs *formatterState) leaveFrame() *frame {
 // formatToken processes a single JSON token (delimiter, string, number, bool, null)
 // and calls the appropriate `print*` function to write the colorized output.
 func (fs *formatterState) formatToken(t json.Token) error {
+	// In skeleton mode, every leaf value (anything but a delimiter or object key)
+	// is replaced by a placeholder.
+	if fs.skeleton {
+		_, isDelim := t.(json.Delim)
+		_, isString := t.(string)
+		isKey := isString && fs.frame().inField()
+		if !isDelim && !isKey {
+			fs.printElided(valueKind(t))
+			return nil
+		}
+	}
+
 	switch value := t.(type) {
 	case json.Delim:
 		// Delimiters '{', '}', '[', ']'
@@ -654,8 +1761,8 @@ func (fs *formatterState) formatToken(t json.Token) error {
 		fs.printNumber(value)
 	case string:
 		// String literal - check context to see if it's a key or value
-		if fs.frame().inObject() && !fs.frame().inField() {
-			// Inside an object ({) and expecting a key (field=false)
+		if fs.frame().inField() {
+			// Inside an object ({) and expecting a key (field=true)
 			return fs.printField(value)
 		}
 		// Otherwise, it's a string value (in array or after colon in object)
@@ -673,138 +1780,259 @@ func (fs *formatterState) formatToken(t json.Token) error {
 	return nil
 }
 
+// writeToken writes a single JSON token, along with the punctuation and
+// whitespace preceding it. Since the output is produced as tokens arrive, a
+// separator is only printed once it's known to be needed: the comma and
+// newline after a value are deferred until the next value in the same
+// container, and the newline after an opening delimiter until its first
+// member, so that empty containers are printed as `{}` or `[]`.
+// `inline` is only consulted for opening delimiters and reports whether the
+// new container is rendered on a single line.
+// Tokens that would produce invalid JSON are rejected with an error before
+// anything is written.
+func (fs *formatterState) writeToken(t json.Token, inline bool) error {
+	// Stop at the first write error, unless writing on a best-effort basis.
+	if fs.out.err != nil && !fs.out.bestEffort {
+		return fs.out.err
+	}
+	current := fs.frame()
+	// A line comment may have just been printed, which ends its line.
+	flushed := fs.flushNewline()
+
+	delim, isDelim := t.(json.Delim)
+	if isDelim && (delim == json.Delim('}') || delim == json.Delim(']')) {
+		return fs.closeContainer(delim)
+	}
+	if isDelim && delim != json.Delim('{') && delim != json.Delim('[') {
+		return fmt.Errorf("jsoncolor: invalid delimiter %q", rune(delim))
+	}
+
+	// --- Handle Object Key ---
+	if current.inField() {
+		key, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("jsoncolor: object key must be a string, got %T", t)
+		}
+		fs.beginMember(current)
+		// Keep track of the current key for container callbacks and paths.
+		current.key = key
+		fs.path = fs.childPath(current)
+		if err := fs.formatToken(key); err != nil {
+			return err
+		}
+		fs.printColon()
+		current.field = false
+		return nil
+	}
+
+	// --- Handle Value ---
+	// Values in arrays start a new line. Values in objects follow their key's colon.
+	if current.inArray() {
+		fs.beginMember(current)
+	} else if !current.inObject() && !flushed {
+		// Top-level values are only preceded by the prefix.
+		fs.printIndent()
+	}
+
+	// The value belongs to the current container, whose path and count are updated first.
+	path := fs.childPath(current)
+	current.count++
+
+	if fs.onValue != nil {
+		fs.onValue(path)
+	}
+	fs.path = path
+
+	// Print the colorized token. `formatToken` distinguishes keys from values by
+	// the state of the current frame, so it's only updated afterwards.
+	if err := fs.formatToken(t); err != nil {
+		return err
+	}
+	if current.inObject() {
+		current.field = true
+	}
+
+	if isDelim {
+		// Descend into the new container, updating the current frame context.
+		newFrame := fs.enterFrame(delim)
+		newFrame.path = path
+		newFrame.inline = inline
+		if fs.onContainerOpen != nil {
+			fs.onContainerOpen(path, newFrame.kind(), 0)
+		}
+	}
+	return nil
+}
+
+// beginMember prints the separator before a new member of container `f`:
+// a comma if it's not the first member, and unless the container is rendered
+// on a single line, a newline followed by indentation.
+func (fs *formatterState) beginMember(f *frame) {
+	if f.count > 0 && !f.commaDone {
+		fs.printComma()
+	}
+	f.commaDone = false
+	if !f.inline {
+		fs.printSpace("\n", false)
+		fs.printBlankLine(f)
+		fs.printIndent()
+	}
+}
+
+// printBlankLine prints an empty line if one precedes the next member of
+// container `f` in the input (see Formatter.PreserveBlankLines). Blank lines
+// are only kept between members, and between comments.
+func (fs *formatterState) printBlankLine(f *frame) {
+	if fs.blankLine && (f.count > 0 || f.comments) {
+		fs.printSpace("\n", false)
+	}
+}
+
+// closeContainer handles a closing delimiter ('}' or ']') written through writeToken.
+func (fs *formatterState) closeContainer(delim json.Delim) error {
+	closing := fs.frame()
+	switch {
+	case !closing.inArrayOrObject():
+		return fmt.Errorf("jsoncolor: unexpected closing delimiter %q", rune(delim))
+	case closing.inObject() != (delim == json.Delim('}')):
+		return fmt.Errorf("jsoncolor: mismatched closing delimiter %q", rune(delim))
+	case closing.inObject() && !closing.field:
+		return fmt.Errorf("jsoncolor: missing value for object key %q", closing.key)
+	}
+
+	// Ascend back to the parent container context.
+	fs.leaveFrame()
+	fs.flushNewline()
+
+	// Non-empty containers end on their own line, unless rendered on a single
+	// line, with the closing delimiter indented at the parent's level.
+	if (closing.count > 0 || closing.comments) && !closing.inline {
+		fs.printSpace("\n", false)
+		fs.printIndent()
+	}
+	fs.path = closing.path
+	if err := fs.formatToken(delim); err != nil {
+		return err
+	}
+	if fs.onContainerClose != nil {
+		fs.onContainerClose(closing.path, closing.kind(), closing.count)
+	}
+	return nil
+}
+
 // format drives the core JSON parsing and colorized printing process.
-// It reads the input `src` using a json.Decoder, token by token,
-// and writes the formatted, colorized output to `dst`.
-// It maintains state using the `formatterState` (fs) to manage indentation,
-// context (object key vs value), and spacing (commas, newlines).
+// It reads the input `src` using a json.Decoder, token by token, and feeds
+// each token to writeToken, which writes the formatted, colorized output.
 func (fs *formatterState) format(dst io.Writer, src []byte, terminateWithNewline bool) error {
+	// Apply the InvalidUTF8Policy up front, so that no output is written for rejected input.
+	escapeReplacement, err := checkUTF8(fs.utf8Policy, src)
+	if err != nil {
+		return err
+	}
+	fs.escapeReplacement = fs.escapeReplacement || escapeReplacement
+
+	if fs.sortKeys && fs.preserveExact {
+		return errors.New("jsoncolor: SortKeys cannot be combined with PreserveExact")
+	}
+	if fs.sortKeys && fs.syntax != SyntaxJSON {
+		return fmt.Errorf("jsoncolor: SortKeys cannot be combined with %v input", fs.syntax)
+	}
+	if fs.sortKeys {
+		src, err = sortKeys(src)
+		if err != nil {
+			return fmt.Errorf("jsoncolor: error decoding input JSON: %w", err)
+		}
+	}
+
+	// If formatting is cut short by a panic, such as one of a Backend or a
+	// callback, the colors are reset before it's propagated.
+	defer func() {
+		if r := recover(); r != nil {
+			fs.resetColors()
+			panic(r)
+		}
+	}()
+
+	write := fs.writeTokens
+	if fs.syntax != SyntaxJSON {
+		write = fs.writeLenientTokens
+	}
+	if err := write(src); err != nil {
+		fs.resetColors()
+		return err
+	}
+
+	// Add a final newline if requested (e.g., by Encoder.Encode), which also
+	// ends a trailing line comment.
+	fs.newlinePending = false
+	if terminateWithNewline {
+		fs.printSpace("\n", true) // Force newline even in compact mode.
+	}
+
+	return fs.checkWrite()
+}
+
+// writeTokens writes the JSON text `src` token by token through writeToken.
+func (fs *formatterState) writeTokens(src []byte) error {
 	// Use a standard JSON decoder.
 	dec := json.NewDecoder(bytes.NewReader(src))
 	// UseNumber ensures numbers retain their original string representation.
 	dec.UseNumber()
-
-	// currentFrame represents the current nesting context (top-level, object, array, etc.).
-	currentFrame := fs.frame()
+	// The input may be written within containers opened by the caller (see StreamWriter).
+	depth := len(fs.frames)
+	// The number of top-level values written so far.
+	values := 0
 
 	// Loop through each token from the JSON input.
 	for {
+		tokenStart := dec.InputOffset()
 		token, err := dec.Token()
+		// Strings can't contain newlines, so any newlines up to the end of the
+		// token are in the whitespace preceding it.
+		fs.blankLine = fs.preserveBlankLines && err == nil &&
+			bytes.Count(src[tokenStart:dec.InputOffset()], []byte{'\n'}) >= 2
 		if err == io.EOF {
-			break // End of JSON input.
+			// End of JSON input. Decoder.Token reports the end of truncated input
+			// the same way, so check that every container was closed.
+			if len(fs.frames) > depth {
+				return fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+			}
+			break
 		}
 		if err != nil {
 			return fmt.Errorf("jsoncolor: error decoding input JSON: %w", err)
 		}
 
-		// Check if more tokens exist at the current nesting level. Important for comma placement.
-		hasMoreTokens := dec.More()
-		// Determine if a comma is needed *after* processing the current token.
-		needsCommaAfter := currentFrame.inArrayOrObject() && hasMoreTokens
-
-		// --- Process based on token type: Delimiter or Value/Key ---
-
-		// Is the token a delimiter ({, }, [, ])?
-		if delim, ok := token.(json.Delim); ok {
-			// Is it an opening delimiter?
-			if delim == json.Delim('{') || delim == json.Delim('[') {
-				// --- Handle Opening Delimiter ({ or [) ---
-				// Decide spacing/indentation *before* the delimiter.
-				// The primary case for adding space here is removed because
-				// the space after a colon is handled later. We only need to
-				// handle indentation when the delimiter starts a new line.
-				if !currentFrame.inObject() {
-					// If NOT inside an object (e.g., top-level container or inside an array),
-					// print standard indentation.
-					fs.printIndent()
-				}
-
-				// Print the colorized opening delimiter.
-				err = fs.formatToken(delim)
-				// If the container isn't empty, add a newline after the opener.
-				if hasMoreTokens {
-					fs.printSpace("\n", false)
-				}
-				// Descend into the new container, updating the current frame context.
-				// Mark if the new container is empty based on whether tokens follow immediately.
-				currentFrame = fs.enterFrame(delim, !hasMoreTokens)
-
-			} else {
-				// --- Handle Closing Delimiter (} or ]) ---
-				// Check if the container being closed was empty (e.g., {} or []).
-				isClosingEmptyContainer := currentFrame.isEmpty()
-				// Ascend back to the parent container context.
-				currentFrame = fs.leaveFrame()
-
-				// Add indentation *before* the closing delimiter, unless it was an empty container.
-				if !isClosingEmptyContainer {
-					fs.printIndent()
-				}
-				// Print the colorized closing delimiter.
-				err = fs.formatToken(delim)
-				// Add a comma *after* the closing delimiter if required by the parent context.
-				if needsCommaAfter {
-					fs.printComma()
-				}
-				// Add a newline *after* the closing delimiter if we are still nested within another container.
-				if len(fs.frames) > 1 { // > 1 means not back at the top level.
-					fs.printSpace("\n", false)
-				}
-			}
-		} else { // Token is not a delimiter, so it's a value (string, number, bool, null) or an object key.
-			// --- Handle Value or Object Key ---
-			// Determine if indentation is needed *before* this token.
-			shouldIndent := currentFrame.inArray()
-			// Special handling for strings to distinguish keys from values.
-			if _, isString := token.(string); isString {
-				// Indent string values within objects, but not object keys.
-				// Also indent strings in arrays (covered by initial `inArray` check).
-				shouldIndent = !currentFrame.inObject() || currentFrame.inField()
-			}
-
-			if shouldIndent {
-				fs.printIndent()
-			}
+		// Decide whether a new container is rendered on a single line, which
+		// requires looking ahead at its contents in the source. Empty containers
+		// are printed as `{}` or `[]` regardless.
+		inline := false
+		if delim, ok := token.(json.Delim); ok && (delim == json.Delim('{') || delim == json.Delim('[')) {
+			inline = dec.More() && fs.inlineObject(delim, src, int(dec.InputOffset()))
+		}
 
-			// Print the colorized token. `formatToken` internally distinguishes keys and values.
-			err = fs.formatToken(token)
+		// Locate the escaped contents of strings in the input, which are printed as-is
+		// in PreserveExact mode. Before the opening quote, there is only whitespace and
+		// possibly a comma or colon, and the decoder stops right after the closing quote.
+		if _, ok := token.(string); ok && fs.preserveExact {
+			start := tokenStart + int64(bytes.IndexByte(src[tokenStart:], '"'))
+			fs.raw = src[start+1 : dec.InputOffset()-1]
+		}
 
-			// --- Post-Token Formatting (Colon or Comma/Newline) ---
-			if currentFrame.inField() {
-				// If `inField` is true *now*, it means `formatToken` just processed an object *key*.
-				// Therefore, print the required colon after the key, followed by a space (respecting compact mode).
-				fs.printColon()
-				fs.printSpace(" ", false) // Add space *only* after colon: "key": value
-			} else {
-				// If `formatToken` processed an array element or an object *value*.
-				// Add a comma if needed *after* the element/value.
-				if needsCommaAfter {
-					fs.printComma()
-				}
-				// Add a newline if still nested.
-				if len(fs.frames) > 1 {
-					fs.printSpace("\n", false)
-				}
+		// Several top-level values in a row are written one per line.
+		if len(fs.frames) == depth && !fs.frame().inArrayOrObject() {
+			if values > 0 {
+				fs.printSpace("\n", true)
 			}
-		} // End handling Delimiter vs Value/Key
-
-		// If we are inside an object, toggle the state between expecting a key (`field`=false)
-		// and expecting a value (`field`=true). This runs *after* processing the token
-		// and its potential colon/comma follower for the current iteration.
-		if currentFrame.inObject() {
-			currentFrame.toggleField()
+			values++
 		}
 
-		// Check for errors from printing functions.
+		err = fs.writeToken(token, inline)
+		// The contents aren't printed if the string is elided in skeleton mode.
+		fs.raw = nil
 		if err != nil {
 			return err
 		}
-	} // End token processing loop
-
-	// Add a final newline if requested (e.g., by Encoder.Encode).
-	if terminateWithNewline {
-		fs.printSpace("\n", true) // Force newline even in compact mode.
 	}
-
 	return nil
 }
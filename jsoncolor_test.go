@@ -0,0 +1,516 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppendMarshal(t *testing.T) {
+	dst := make([]byte, 0, 64)
+	dst = append(dst, "prefix "...)
+	got, err := AppendMarshal(dst, map[string]interface{}{"a": []int{1, 2}, "b": "<"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `prefix {"a":[1,2],"b":"\u003c"}`
+	if s := stripANSI(string(got)); s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+	if &got[0] != &dst[0] {
+		t.Error("output was not appended in place despite spare capacity")
+	}
+
+	marshaled, err := Marshal(map[string]interface{}{"a": []int{1, 2}, "b": "<"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got[len("prefix "):], marshaled) {
+		t.Errorf("got %q, want the output of Marshal %q", got, marshaled)
+	}
+
+	if got, err := AppendMarshal(dst, func() {}); err == nil || string(got) != "prefix " {
+		t.Errorf("unsupported value: got %q, %v", got, err)
+	}
+}
+
+func TestAppendFormat(t *testing.T) {
+	got, err := AppendFormat([]byte("prefix "), []byte(` { "a" : [ 1 , true ] } `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `prefix {"a":[1,true]}`; stripANSI(string(got)) != want {
+		t.Errorf("got %q, want %q", stripANSI(string(got)), want)
+	}
+
+	if got, err := AppendFormat([]byte("prefix "), []byte(`{"a":`)); err == nil || string(got) != "prefix " {
+		t.Errorf("invalid input: got %q, %v", got, err)
+	}
+}
+
+// formatPlain formats `src` with `f` and returns the output without colors.
+func formatPlain(t *testing.T, f *Formatter, src string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := f.Format(&buf, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	return stripANSI(buf.String())
+}
+
+func TestSpacing(t *testing.T) {
+	const src = `{"a":[1,2],"b":{}}`
+	tests := []struct {
+		name   string
+		indent string
+		colon  Spacing
+		comma  Spacing
+		want   string
+	}{
+		{"compact default", "", SpacingDefault, SpacingDefault, `{"a":[1,2],"b":{}}`},
+		{"compact colon after", "", SpacingAfter, SpacingDefault, `{"a": [1,2],"b": {}}`},
+		{"compact both", "", SpacingBoth, SpacingAfter, `{"a" : [1, 2], "b" : {}}`},
+		{"compact comma before", "", SpacingNone, SpacingBefore, `{"a":[1 ,2] ,"b":{}}`},
+		{"indent default", "  ", SpacingDefault, SpacingDefault, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}"},
+		{"indent none", "  ", SpacingNone, SpacingAfter, "{\n  \"a\":[\n    1,\n    2\n  ],\n  \"b\":{}\n}"},
+		{"indent both", "  ", SpacingBoth, SpacingBoth, "{\n  \"a\" : [\n    1 ,\n    2\n  ] ,\n  \"b\" : {}\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Formatter{Indent: tt.indent, ColonSpacing: tt.colon, CommaSpacing: tt.comma}
+			if got := formatPlain(t, f, src); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalNoHTMLEscape(t *testing.T) {
+	v := map[string]string{"a": "<b>&</b>"}
+
+	got, err := MarshalNoHTMLEscape(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":"<b>&</b>"}`; stripANSI(string(got)) != want {
+		t.Errorf("got %q, want %q", stripANSI(string(got)), want)
+	}
+
+	got, err = MarshalIndentNoHTMLEscape(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": \"<b>&</b>\"\n}"; stripANSI(string(got)) != want {
+		t.Errorf("got %q, want %q", stripANSI(string(got)), want)
+	}
+
+	// The Marshal functions still escape.
+	got, err = Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":"\u003cb\u003e\u0026\u003c/b\u003e"}`; stripANSI(string(got)) != want {
+		t.Errorf("got %q, want %q", stripANSI(string(got)), want)
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	encode := func(f *Formatter, set func(*Encoder)) string {
+		var buf bytes.Buffer
+		enc := NewEncoderWithFormatter(&buf, f)
+		if set != nil {
+			set(enc)
+		}
+		if err := enc.Encode(1); err != nil {
+			t.Fatal(err)
+		}
+		return stripANSI(buf.String())
+	}
+	disable := func(enc *Encoder) { enc.SetTrailingNewline(false) }
+	enable := func(enc *Encoder) { enc.SetTrailingNewline(true) }
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"encode default", encode(&Formatter{}, nil), "1\n"},
+		{"encode formatter never", encode(&Formatter{TrailingNewline: NewlineNever}, nil), "1"},
+		{"encode disabled", encode(&Formatter{}, disable), "1"},
+		{"encoder wins", encode(&Formatter{TrailingNewline: NewlineNever}, enable), "1\n"},
+		{"format default", formatPlain(t, &Formatter{}, "1"), "1"},
+		{"format always", formatPlain(t, &Formatter{TrailingNewline: NewlineAlways}, "1"), "1\n"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	got, err := MarshalWithFormatter(1, &Formatter{TrailingNewline: NewlineAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "1\n" {
+		t.Errorf("marshal always: got %q, want %q", got, "1\n")
+	}
+}
+
+func TestIndentGuides(t *testing.T) {
+	const src = `{"a":[1,{"b":2}],"c":3}`
+	got := formatPlain(t, &Formatter{Indent: "  ", IndentGuides: true}, src)
+	want := "{\n│ \"a\": [\n│ │ 1,\n│ │ {\n│ │ │ \"b\": 2\n│ │ }\n│ ],\n│ \"c\": 3\n}"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = formatPlain(t, &Formatter{Indent: "\t", IndentGuides: true}, `[[1]]`)
+	if want := "[\n│\t[\n│\t│\t1\n│\t]\n]"; got != want {
+		t.Errorf("tab indent: got %q, want %q", got, want)
+	}
+
+	got = formatPlain(t, &Formatter{IndentGuides: true}, `[[1]]`)
+	if want := "[[1]]"; got != want {
+		t.Errorf("compact: got %q, want %q", got, want)
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	const src = `{"a":[1,"x",{"b":null}],"c":true,"d":{}}`
+	got := formatPlain(t, &Formatter{Skeleton: true}, src)
+	if want := `{"a":[…,…,{"b":…}],"c":…,"d":{}}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = formatPlain(t, &Formatter{Skeleton: true}, `"top"`)
+	if want := `…`; got != want {
+		t.Errorf("top-level leaf: got %q, want %q", got, want)
+	}
+}
+
+func TestEncoderReset(t *testing.T) {
+	var first, second bytes.Buffer
+	enc := NewEncoderWithFormatter(&first, &Formatter{})
+	enc.SetIndent("", "  ")
+	enc.SetTrailingNewline(false)
+	if err := enc.Encode([]int{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	enc.Reset(&second)
+	if err := enc.Encode([]int{2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := stripANSI(first.String()), "[\n  1\n]"; got != want {
+		t.Errorf("first writer: got %q, want %q", got, want)
+	}
+	// The settings carry over to the new writer.
+	if got, want := stripANSI(second.String()), "[\n  2\n]"; got != want {
+		t.Errorf("second writer: got %q, want %q", got, want)
+	}
+}
+
+func TestContainerCallbacks(t *testing.T) {
+	var events []string
+	f := &Formatter{
+		OnContainerOpen: func(path string, kind Kind, count int) {
+			events = append(events, fmt.Sprintf("open %q %v %d", path, kind, count))
+		},
+		OnContainerClose: func(path string, kind Kind, count int) {
+			events = append(events, fmt.Sprintf("close %q %v %d", path, kind, count))
+		},
+	}
+	formatPlain(t, f, `{"a":[1,{"x/y":{}},[]],"~":2}`)
+
+	want := []string{
+		`open "" object 0`,
+		`open "/a" array 0`,
+		`open "/a/1" object 0`,
+		`open "/a/1/x~1y" object 0`,
+		`close "/a/1/x~1y" object 0`,
+		`close "/a/1" object 1`,
+		`open "/a/2" array 0`,
+		`close "/a/2" array 0`,
+		`close "/a" array 3`,
+		`close "" object 2`,
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got events:\n%s\nwant:\n%s", strings.Join(events, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMustMarshal(t *testing.T) {
+	if got, want := stripANSI(string(MustMarshalIndent([]int{1}, "", " "))), "[\n 1\n]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("unsupported value: did not panic")
+		}
+	}()
+	MustMarshal(make(chan int))
+}
+
+func TestInlineMaxMembers(t *testing.T) {
+	const src = `{"p":{"x":1,"y":"}"},"q":{"x":1,"y":2,"z":3},"r":{"a":{"b":1}},"s":{"e":[]}}`
+	got := formatPlain(t, &Formatter{Indent: "  ", InlineMaxMembers: 2}, src)
+	want := `{
+  "p": {"x": 1, "y": "}"},
+  "q": {
+    "x": 1,
+    "y": 2,
+    "z": 3
+  },
+  "r": {
+    "a": {"b": 1}
+  },
+  "s": {"e": []}
+}`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Depth 0 is the top-level object, which is small enough but disabled.
+	f := &Formatter{Indent: "  ", InlineMaxMembers: 1, InlineMaxMembersByDepth: map[int]int{0: 0, 1: 2}}
+	got = formatPlain(t, f, `{"a":{"x":1,"y":2}}`)
+	if want := "{\n  \"a\": {\"x\": 1, \"y\": 2}\n}"; got != want {
+		t.Errorf("by depth: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = formatPlain(t, &Formatter{InlineMaxMembers: 5}, `{"a":{"x":1}}`)
+	if want := `{"a":{"x":1}}`; got != want {
+		t.Errorf("compact: got %q, want %q", got, want)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	got, err := Colorize([]byte(` [ 1 , 2 ] `))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[1,2]`; stripANSI(string(got)) != want {
+		t.Errorf("got %q, want %q", stripANSI(string(got)), want)
+	}
+
+	got, err = ColorizeIndent([]byte(`[1,2]`), "> ", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "> [\n> \t1,\n> \t2\n> ]"; stripANSI(string(got)) != want {
+		t.Errorf("indent: got %q, want %q", stripANSI(string(got)), want)
+	}
+	// The DefaultFormatter itself is left untouched.
+	if DefaultFormatter.Indent != "" || DefaultFormatter.Prefix != "" {
+		t.Errorf("DefaultFormatter was modified: %+v", DefaultFormatter)
+	}
+
+	if _, err := Colorize([]byte(`[1,`)); err == nil {
+		t.Error("invalid input: got no error")
+	}
+}
+
+// tagColor is a SprintfFuncer wrapping text in an XML-like tag named after
+// it, which makes the color applied to each part of the output visible in
+// tests regardless of whether the terminal supports colors.
+type tagColor string
+
+func (c tagColor) SprintfFunc() func(format string, a ...interface{}) string {
+	return func(format string, a ...interface{}) string {
+		return "<" + string(c) + ">" + fmt.Sprintf(format, a...) + "</" + string(c) + ">"
+	}
+}
+
+func TestPreserveExact(t *testing.T) {
+	const src = `{"b":"é\/<>","a":1.50e+00,"b":"x"}`
+	got := formatPlain(t, &Formatter{PreserveExact: true, EscapeHTML: true}, src)
+	if want := `{"b":"é\/<>","a":1.50e+00,"b":"x"}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Without PreserveExact, strings are re-escaped.
+	got = formatPlain(t, &Formatter{}, src)
+	if want := `{"b":"é/<>","a":1.50e+00,"b":"x"}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = formatPlain(t, &Formatter{PreserveExact: true, Skeleton: true}, `{"k\n":"v"}`)
+	if want := `{"k\n":…}`; got != want {
+		t.Errorf("skeleton: got %q, want %q", got, want)
+	}
+
+	err := (&Formatter{PreserveExact: true, SortKeys: true}).Format(&bytes.Buffer{}, []byte(`{}`))
+	if err == nil {
+		t.Error("SortKeys: got no error")
+	}
+}
+
+func TestKeyAndStringColors(t *testing.T) {
+	f := &Formatter{
+		FieldColor: tagColor("key"), FieldQuoteColor: tagColor("kq"),
+		StringColor: tagColor("str"), StringQuoteColor: tagColor("sq"),
+		ObjectColor: tagColor("obj"), ArrayColor: tagColor("arr"),
+		ColonColor: tagColor("colon"), CommaColor: tagColor("comma"),
+	}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, []byte(`{"k":"v","a":["s"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := `<obj>{</obj><kq>"</kq><key>k</key><kq>"</kq><colon>:</colon><sq>"</sq><str>v</str><sq>"</sq><comma>,</comma>` +
+		`<kq>"</kq><key>a</key><kq>"</kq><colon>:</colon><arr>[</arr><sq>"</sq><str>s</str><sq>"</sq><arr>]</arr><obj>}</obj>`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestKeyMemo(t *testing.T) {
+	f := &Formatter{FieldColor: tagColor("key"), FieldQuoteColor: tagColor("kq")}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, []byte(`[{"k":1,"a\"b":2},{"k":3,"a\"b":4}]`)); err != nil {
+		t.Fatal(err)
+	}
+	got := stripANSI(buf.String())
+	for _, key := range []string{`<key>k</key>`, `<key>a\"b</key>`} {
+		if n := strings.Count(got, `<kq>"</kq>`+key+`<kq>"</kq>`); n != 2 {
+			t.Errorf("%s rendered %d times, want 2 in:\n%s", key, n, got)
+		}
+	}
+
+	// Keys past the memo's capacity are rendered all the same.
+	var src, want strings.Builder
+	src.WriteString("[")
+	want.WriteString("[")
+	for i := 0; i < 2*maxKeyMemo; i++ {
+		if i > 0 {
+			src.WriteString(",")
+			want.WriteString(",")
+		}
+		fmt.Fprintf(&src, `{"k%d":1,"k%d":2}`, i%(maxKeyMemo+10), i)
+		fmt.Fprintf(&want, `{"k%d":1,"k%d":2}`, i%(maxKeyMemo+10), i)
+	}
+	src.WriteString("]")
+	want.WriteString("]")
+	if got := formatPlain(t, plainCompactFormatter(), src.String()); got != want.String() {
+		t.Error("keys past the memo's capacity rendered differently")
+	}
+
+	// With PreserveExact, equal keys escaped differently keep their escapes.
+	got = formatPlain(t, &Formatter{PreserveExact: true}, `[{"\u0061":1},{"a":2},{"\u0061":3}]`)
+	if want := `[{"\u0061":1},{"a":2},{"\u0061":3}]`; got != want {
+		t.Errorf("PreserveExact: got %s, want %s", got, want)
+	}
+}
+
+func TestPreserveBlankLines(t *testing.T) {
+	const src = "{\n\"a\": 1,\n\n\n\"b\": [1,\n\n2],\n\n\"c\": {}\n\n}"
+	const want = "{\n  \"a\": 1,\n\n  \"b\": [\n    1,\n\n    2\n  ],\n\n  \"c\": {}\n}"
+	for _, syntax := range []Syntax{SyntaxJSON, SyntaxJSONC, SyntaxJSON5} {
+		f := &Formatter{Indent: "  ", PreserveBlankLines: true, Syntax: syntax}
+		if got := formatPlain(t, f, src); got != want {
+			t.Errorf("%v: got %q, want %q", syntax, got, want)
+		}
+	}
+
+	tests := []struct {
+		name string
+		f    *Formatter
+		src  string
+		want string
+	}{
+		// Blank lines before the first member or the closing delimiter are dropped.
+		{"edges", &Formatter{Indent: "  ", PreserveBlankLines: true}, "\n\n[\n\n1\n\n]", "[\n  1\n]"},
+		{"comments", &Formatter{Indent: "  ", PreserveBlankLines: true, Syntax: SyntaxJSONC},
+			"{\n// group 1\n\"a\": 1,\n\n// group 2\n\"b\": 2\n}", "{\n  // group 1\n  \"a\": 1,\n\n  // group 2\n  \"b\": 2\n}"},
+		{"compact", &Formatter{PreserveBlankLines: true}, "[1,\n\n2]", "[1,2]"},
+		{"off", &Formatter{Indent: "  "}, "[1,\n\n2]", "[\n  1,\n  2\n]"},
+	}
+	for _, tt := range tests {
+		if got := formatPlain(t, tt.f, tt.src); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+// checkGolden compares `got` with the golden file testdata/golden/`name`,
+// which is rewritten instead with the -update flag.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+const goldenInput = `{
+	"name": "jsoncolor", "version": 1.5, "stable": true, "license": null,
+	"tags": ["json", "color"],
+	"nested": {"a": [1, [2, [3, {"b": {"c": [-4e10]}}]]]},
+	"empty": {"object": {}, "array": [], "in array": [{}, []]},
+	"escapes": "quote \" backslash \\ slash / controls \b\f\n\r\t \u0001 html <&> unicode é \u2028 \ud83d\ude00"
+}`
+
+func TestFormatGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		indent string
+		src    string
+	}{
+		{"indent.json", "", "  ", goldenInput},
+		{"tabs.json", "", "\t", goldenInput},
+		{"prefix.json", "// ", "  ", goldenInput},
+		{"compact.json", "", "", goldenInput},
+		{"empty.json", "", "  ", `[{}, [], {"a": {}}, [[]], ""]`},
+		{"values.json", "", "  ", `1 "a" {"b": [2]} [] null`},
+		{"values-compact.json", "", "", `1 "a" {"b": [2]} [] null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFormatter()
+			f.Prefix, f.Indent = tt.prefix, tt.indent
+			var buf bytes.Buffer
+			if err := f.Format(&buf, []byte(tt.src)); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, buf.Bytes())
+		})
+	}
+}
+
+func TestEncodeGoldenColors(t *testing.T) {
+	for _, indent := range []string{"  ", ""} {
+		name := "colors.json"
+		if indent == "" {
+			name = "colors-compact.json"
+		}
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.SetColors(true)
+			enc.SetIndent("", indent)
+			if err := enc.Encode(json.RawMessage(goldenInput)); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, name, buf.Bytes())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package jsoncolor
+
+import (
+	"encoding/json"
+	"encoding/json/jsontext"
+	"fmt"
+	"io"
+)
+
+// FormatJSONText reads all remaining JSON values from the encoding/json/v2
+// decoder `dec` and writes them to `dst`, formatted and colorized by this
+// Formatter, taking advantage of jsontext's faster tokenization. Since the
+// values are processed token by token, the same limitations as for a
+// TokenEncoder apply, and each top-level value is followed by a newline.
+//
+// This function requires Go 1.27 or later with the jsonv2 experiment, which is
+// enabled by default; it is unavailable when building with GOEXPERIMENT=nojsonv2.
+func (f *Formatter) FormatJSONText(dst io.Writer, dec *jsontext.Decoder) error {
+	te := NewTokenEncoderWithFormatter(dst, f)
+	for {
+		t, err := dec.ReadToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("jsoncolor: error decoding input JSON: %w", err)
+		}
+		if err := te.WriteJSONTextToken(t); err != nil {
+			return err
+		}
+	}
+}
+
+// WriteJSONTextToken writes a token read from an encoding/json/v2 decoder,
+// like WriteToken. Numbers are written with their original text.
+//
+// Like FormatJSONText, this method requires the jsonv2 experiment.
+func (te *TokenEncoder) WriteJSONTextToken(t jsontext.Token) error {
+	switch kind := t.Kind(); kind {
+	case '{', '}', '[', ']':
+		return te.WriteToken(json.Delim(kind))
+	case 'n':
+		return te.WriteToken(nil)
+	case 't', 'f':
+		return te.WriteToken(t.Bool())
+	case '"':
+		return te.WriteToken(t.String())
+	case '0':
+		// For numbers, String returns the raw representation.
+		return te.WriteToken(json.Number(t.String()))
+	default:
+		return fmt.Errorf("jsoncolor: invalid jsontext token %v", t)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	"strings"
+	"testing"
+)
+
+func TestFormatJSONText(t *testing.T) {
+	const src = `{"a": [1.50, true, null, "s"]} 2`
+	var buf bytes.Buffer
+	f := &Formatter{Indent: "  "}
+	if err := f.FormatJSONText(&buf, jsontext.NewDecoder(strings.NewReader(src))); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": [\n    1.50,\n    true,\n    null,\n    \"s\"\n  ]\n}\n2\n"
+	if got := stripANSI(buf.String()); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err := f.FormatJSONText(&bytes.Buffer{}, jsontext.NewDecoder(strings.NewReader(`[1,`)))
+	if err == nil {
+		t.Error("invalid input: got no error")
+	}
+}
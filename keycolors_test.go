@@ -0,0 +1,154 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// encodeColored encodes the JSON `src` with `f`, with colors forced.
+func encodeColored(t *testing.T, f *Formatter, src string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, f)
+	enc.SetColors(true)
+	if err := enc.Encode(json.RawMessage(src)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// sgr returns `text` colored with the SGR parameters `params`, as written by a Style.
+func sgr(params, text string) string {
+	return "\x1b[" + params + "m" + text + "\x1b[0m"
+}
+
+// quoted returns the string `s` and its quotes colored with the SGR parameters `params`.
+func quoted(params, s string) string {
+	return sgr(params, `"`) + sgr(params, s) + sgr(params, `"`)
+}
+
+func TestKeyColors(t *testing.T) {
+	f := NewFormatter()
+	f.KeyColors = map[string]SprintfFuncer{"a": Style{FgRed}}
+	// Repeated keys are rendered from the memo after the first time.
+	got := encodeColored(t, f, `[{"a": 1, "b": 2}, {"a": 3, "b": 4}]`)
+	if n := strings.Count(got, quoted("31", "a")); n != 2 {
+		t.Errorf("got %d keys colored with KeyColors, want 2:\n%q", n, got)
+	}
+	if strings.Contains(got, sgr("31", "b")) {
+		t.Errorf("key without a color of its own colored with KeyColors:\n%q", got)
+	}
+
+	fs := newFormatterState(f, io.Discard)
+	if err := fs.format(io.Discard, []byte(`{"a": 1}`), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fs.keyMemo["a"]; !ok {
+		t.Error("key with a color of its own not memoized")
+	}
+}
+
+func TestMemberColorsVariants(t *testing.T) {
+	tests := []struct {
+		name       string
+		background Background
+		light      *Formatter
+		key, value string // The SGR parameters of the key "a" and of its value.
+	}{
+		{
+			name:       "variant",
+			background: BackgroundLight,
+			light: &Formatter{
+				KeyColors:   map[string]SprintfFuncer{"a": Style{FgBlue}},
+				ValueColors: map[string]SprintfFuncer{"a": Style{FgMagenta}},
+			},
+			key: "34", value: "35",
+		},
+		{
+			name:       "variant for another background",
+			background: BackgroundDark,
+			light: &Formatter{
+				KeyColors:   map[string]SprintfFuncer{"a": Style{FgBlue}},
+				ValueColors: map[string]SprintfFuncer{"a": Style{FgMagenta}},
+			},
+			key: "31", value: "33",
+		},
+		{
+			name:       "variant without the key",
+			background: BackgroundLight,
+			light: &Formatter{
+				KeyColors:   map[string]SprintfFuncer{"b": Style{FgBlue}},
+				ValueColors: map[string]SprintfFuncer{"b": Style{FgMagenta}},
+			},
+			key: "31", value: "33",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFormatter()
+			f.KeyColors = map[string]SprintfFuncer{"a": Style{FgRed}}
+			f.ValueColors = map[string]SprintfFuncer{"a": Style{FgYellow}}
+			f.Background = tt.background
+			f.LightColors = tt.light
+			got := encodeColored(t, f, `{"a": "x"}`)
+			if want := quoted(tt.key, "a"); !strings.Contains(got, want) {
+				t.Errorf("key: got %q, want it to contain %q", got, want)
+			}
+			if want := quoted(tt.value, "x"); !strings.Contains(got, want) {
+				t.Errorf("value: got %q, want it to contain %q", got, want)
+			}
+		})
+	}
+}
+
+func TestValueColorsContainers(t *testing.T) {
+	f := NewFormatter()
+	f.StringColor = Style{FgGreen}
+	f.TrueColor = Style{FgCyan}
+	f.NullColor = Style{FgWhite}
+	f.ObjectColor = Style{FgBlue}
+	f.ArrayColor = Style{FgMagenta}
+	f.ValueColors = map[string]SprintfFuncer{"a": Style{FgYellow}, "n": Style{FgRed}}
+	got := encodeColored(t, f, `{"a": {"s": "x", "b": true, "n": null}, "c": {"a": [null, "y"]}}`)
+	// Objects and arrays under the key, and their members, keep their colors,
+	// while nested keys with a color of their own have their values colored.
+	for _, want := range []string{
+		sgr("34", "{"), quoted("32", "x"), sgr("36", "true"), sgr("31", "null"),
+		sgr("35", "["), sgr("37", "null"), quoted("32", "y"), sgr("35", "]"), sgr("34", "}"),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "\x1b[33m") {
+		t.Errorf("value under the key colored with ValueColors: %q", got)
+	}
+}
+
+func TestMemberColorsRenderers(t *testing.T) {
+	withColor(t, true)
+	f := plainCompactFormatter()
+	f.KeyColors = map[string]SprintfFuncer{"a": Style{FgRed}}
+	f.ValueColors = map[string]SprintfFuncer{"a": Style{Bold}}
+	src := []byte(`{"a": 1, "b": 2, "c": {"a": true}}`)
+	var sb strings.Builder
+	if err := f.FormatBBCode(&sb, src); err != nil {
+		t.Fatal(err)
+	}
+	want := `{[color=#cd0000]"a"[/color]:[b]1[/b],"b":2,"c":{[color=#cd0000]"a"[/color]:[b]true[/b]}}`
+	if got := sb.String(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Segments are the same as without member colors.
+	segments, err := f.Segments(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Segment{Text: `"a"`, Kind: TokenKey, Path: "/a"}); segments[1] != want {
+		t.Errorf("got %+v, want %+v", segments[1], want)
+	}
+}
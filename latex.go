@@ -0,0 +1,157 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LaTeXOptions configures FormatLaTeX.
+type LaTeXOptions struct {
+	// Macros, if true, wraps each token other than whitespace in a macro named
+	// after its kind instead of color commands, e.g. \jsonkey{"name"} or
+	// \jsonobjectdelim{\char123{}}, for documents styling JSON with macros of
+	// their own, such as those returned by Formatter.LaTeXMacros.
+	Macros bool
+
+	// NoEnvironment, if true, leaves out the Verbatim environment otherwise
+	// wrapping the output, for embedding it into an environment of one's own
+	// with the same commandchars.
+	NoEnvironment bool
+}
+
+// FormatLaTeX formats `src` as LaTeX using the DefaultFormatter. See
+// Formatter.FormatLaTeX.
+func FormatLaTeX(dst io.Writer, src []byte, opts LaTeXOptions) error {
+	return DefaultFormatter.FormatLaTeX(dst, src, opts)
+}
+
+// FormatLaTeX formats `src` like Format, but writes LaTeX instead of ANSI
+// escape sequences, for academic reports and books including JSON examples.
+// As with minted and Pygments, the output is a Verbatim environment of the
+// fancyvrb package with commandchars=\\\{\}, in which each token is wrapped
+// in the xcolor commands and font switches giving it the Formatter's colors
+// and attributes, e.g. \textcolor[HTML]{0000EE}{\textbf{"name"}}. The
+// document must load the fancyvrb and xcolor packages, and the ulem package
+// (with the normalem option) for colors with strikethrough text, written with
+// \sout. Faint text is written in a lighter color, as on a white page.
+//
+// Backslashes and braces of the JSON are written as \char commands, so that
+// they aren't interpreted. As with FormatHTML, the colors are read from the
+// escape sequences the Formatter's colors produce, so the text has no colors
+// if they are disabled. Nothing is written if `src` is invalid.
+func (f *Formatter) FormatLaTeX(dst io.Writer, src []byte, opts LaTeXOptions) error {
+	commands := make(map[string][2]string)
+	sb := &strings.Builder{}
+	if !opts.NoEnvironment {
+		sb.WriteString("\\begin{Verbatim}[commandchars=\\\\\\{\\}]\n")
+	}
+	err := f.formatMemberSegments(src, func(s memberSegment) {
+		if s.Kind == TokenWhitespace {
+			latexEscape(sb, s.Text)
+			return
+		}
+		if opts.Macros {
+			fmt.Fprintf(sb, `\%s{`, latexMacroName(s.Kind))
+			latexEscape(sb, s.Text)
+			sb.WriteString("}")
+			return
+		}
+		key := f.segmentColorKey(s)
+		command, ok := commands[key]
+		if !ok {
+			command = latexCommands(sgrStyle(f.segmentColor(s).SprintfFunc()("%s", "x")))
+			commands[key] = command
+		}
+		sb.WriteString(command[0])
+		latexEscape(sb, s.Text)
+		sb.WriteString(command[1])
+	})
+	if err != nil {
+		return err
+	}
+	if !opts.NoEnvironment {
+		// The environment must end on a line of its own.
+		if !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\\end{Verbatim}\n")
+	}
+	_, err = io.WriteString(dst, sb.String())
+	return err
+}
+
+// LaTeXMacros returns the definitions of the macros written by FormatLaTeX
+// with LaTeXOptions.Macros, giving each kind of token the colors of the
+// Formatter, e.g. \newcommand{\jsonkey}[1]{\textcolor[HTML]{0000EE}{\textbf{#1}}},
+// to be included in the preamble of a document. Like Formatter.CSS, the colors
+// are read from the escape sequences the Formatter's colors produce.
+// Booleans have the color of true.
+func (f *Formatter) LaTeXMacros() string {
+	sb := &strings.Builder{}
+	for _, kind := range TokenKinds() {
+		if kind == TokenWhitespace {
+			continue
+		}
+		text := ""
+		if kind == TokenBool {
+			text = "true"
+		}
+		command := latexCommands(sgrStyle(f.segmentColor(memberSegment{Segment: Segment{Kind: kind, Text: text}}).SprintfFunc()("%s", "x")))
+		fmt.Fprintf(sb, "\\newcommand{\\%s}[1]{%s#1%s}\n", latexMacroName(kind), command[0], command[1])
+	}
+	return sb.String()
+}
+
+// latexMacroName returns the name of the macro of the kind `kind`, e.g.
+// "jsonobjectdelim", since the names of LaTeX macros are made of letters.
+func latexMacroName(kind TokenKind) string {
+	return "json" + strings.ReplaceAll(kind.String(), "-", "")
+}
+
+// latexCommands returns the LaTeX wrapped around text in the style `c`.
+func latexCommands(c cssStyle) [2]string {
+	color, background := c.color, c.background
+	if c.reverse {
+		color, background = background, color
+	}
+	if c.faint {
+		color = fadedColor(color)
+	}
+	var open, close string
+	wrap := func(start, end string) {
+		open, close = open+start, end+close
+	}
+	if strings.HasPrefix(background, "#") {
+		wrap(`\colorbox[HTML]{`+strings.ToUpper(background[1:])+"}{", "}")
+	}
+	if strings.HasPrefix(color, "#") {
+		wrap(`\textcolor[HTML]{`+strings.ToUpper(color[1:])+"}{", "}")
+	}
+	if c.bold {
+		wrap(`\textbf{`, "}")
+	}
+	if c.italic {
+		wrap(`\textit{`, "}")
+	}
+	if c.underline {
+		wrap(`\underline{`, "}")
+	}
+	if c.crossed {
+		wrap(`\sout{`, "}")
+	}
+	return [2]string{open, close}
+}
+
+// latexEscape writes `s` to `sb`, with the command characters of the Verbatim
+// environment written as \char commands.
+func latexEscape(sb *strings.Builder, s string) {
+	for _, r := range s {
+		switch r {
+		case '\\', '{', '}':
+			fmt.Fprintf(sb, `\char%d{}`, r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+}
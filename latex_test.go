@@ -0,0 +1,87 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatLaTeX(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	f.NullColor = Style{ReverseVideo, FgRed, Underline}
+	var sb strings.Builder
+	if err := f.FormatLaTeX(&sb, []byte(`{"a\\{": [1, true, null]}`), LaTeXOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Reversed text has the background of its color.
+	want := `\begin{Verbatim}[commandchars=\\\{\}]
+\char123{}
+  \textcolor[HTML]{0000EE}{\textbf{"a\char92{}\char92{}\char123{}"}}: [
+    \textcolor[HTML]{00FFFF}{1},
+    \textit{true},
+    \colorbox[HTML]{CD0000}{\underline{null}}
+  ]
+\char125{}
+\end{Verbatim}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatLaTeXMacros(t *testing.T) {
+	var sb strings.Builder
+	opts := LaTeXOptions{Macros: true, NoEnvironment: true}
+	if err := plainCompactFormatter().FormatLaTeX(&sb, []byte(`{"a": [1]}`), opts); err != nil {
+		t.Fatal(err)
+	}
+	want := `\jsonobjectdelim{\char123{}}\jsonkey{"a"}\jsoncolon{:}\jsonarraydelim{[}\jsonnumber{1}\jsonarraydelim{]}\jsonobjectdelim{\char125{}}`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	withColor(t, true)
+	f := styledFormatter()
+	f.NullColor = Style{ReverseVideo, FgRed, Underline}
+	want = `\newcommand{\jsonkey}[1]{\textcolor[HTML]{0000EE}{\textbf{#1}}}
+\newcommand{\jsonstring}[1]{\textcolor[HTML]{00CD00}{#1}}
+\newcommand{\jsonnumber}[1]{\textcolor[HTML]{00FFFF}{#1}}
+\newcommand{\jsonbool}[1]{\textit{#1}}
+\newcommand{\jsonnull}[1]{\colorbox[HTML]{CD0000}{\underline{#1}}}
+\newcommand{\jsonobjectdelim}[1]{#1}
+\newcommand{\jsonarraydelim}[1]{#1}
+\newcommand{\jsoncolon}[1]{#1}
+\newcommand{\jsoncomma}[1]{#1}
+\newcommand{\jsoncomment}[1]{#1}
+\newcommand{\jsonannotation}[1]{#1}
+`
+	if got := f.LaTeXMacros(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatLaTeXInvalid(t *testing.T) {
+	var sb strings.Builder
+	if err := FormatLaTeX(&sb, []byte(`[`), LaTeXOptions{}); err == nil {
+		t.Error("got no error")
+	}
+	if sb.Len() != 0 {
+		t.Errorf("got output %q", sb.String())
+	}
+}
+
+func TestFormatLaTeXFaintAndStrikethrough(t *testing.T) {
+	withColor(t, true)
+	f := plainCompactFormatter()
+	f.NullColor = Style{Faint, FgBlue}
+	f.TrueColor = Style{CrossedOut, FgRed}
+	var sb strings.Builder
+	if err := f.FormatLaTeX(&sb, []byte(`[true, null]`), LaTeXOptions{NoEnvironment: true}); err != nil {
+		t.Fatal(err)
+	}
+	// Faint text is lighter, as on a white page.
+	want := `[\textcolor[HTML]{CD0000}{\sout{true}},\textcolor[HTML]{6666F5}{null}]`
+	if got := strings.TrimSpace(sb.String()); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
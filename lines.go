@@ -0,0 +1,168 @@
+package jsoncolor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+
+	"github.com/amterp/color"
+)
+
+// PartitionMarker selects how FormatLines marks the partition of a record.
+type PartitionMarker int
+
+const (
+	// PartitionGutter prefixes every line of a record with a tinted bar. This is the default.
+	PartitionGutter PartitionMarker = iota
+	// PartitionBrackets tints the braces and brackets of a record instead.
+	PartitionBrackets
+)
+
+// partitionGutter is printed at the start of each line of a record when
+// LinesOptions.PartitionMarker is PartitionGutter.
+const partitionGutter = "▌"
+
+// DefaultPartitionColors is the palette used to tint records by partition when
+// LinesOptions.PartitionColors is empty.
+var DefaultPartitionColors = []SprintfFuncer{
+	color.New(color.FgRed),
+	color.New(color.FgGreen),
+	color.New(color.FgYellow),
+	color.New(color.FgBlue),
+	color.New(color.FgMagenta),
+	color.New(color.FgCyan),
+	color.New(color.FgHiRed),
+	color.New(color.FgHiGreen),
+	color.New(color.FgHiYellow),
+	color.New(color.FgHiBlue),
+	color.New(color.FgHiMagenta),
+	color.New(color.FgHiCyan),
+}
+
+// LinesOptions configures FormatLines.
+type LinesOptions struct {
+	// PartitionKey, if set, is the name of a top-level field whose value
+	// determines the tint of each record, so that interleaved records from
+	// different sources (e.g. a "container" or "request_id" field) are easy to
+	// tell apart. Records with equal values get the same color, which is stable
+	// across runs. Records lacking the field are not tinted.
+	PartitionKey string
+	// PartitionMarker selects how records are tinted. See PartitionMarker.
+	PartitionMarker PartitionMarker
+	// PartitionColors is the palette records are tinted with. If empty,
+	// DefaultPartitionColors is used.
+	PartitionColors []SprintfFuncer
+}
+
+// FormatLines formats a stream of newline-delimited JSON (JSON Lines) using
+// the DefaultFormatter. See Formatter.FormatLines.
+func FormatLines(dst io.Writer, src io.Reader, opts LinesOptions) error {
+	return DefaultFormatter.FormatLines(dst, src, opts)
+}
+
+// FormatLines reads newline-delimited JSON (JSON Lines) from `src` and writes
+// each record to `dst` formatted and colorized by this Formatter, followed by
+// a newline. Records are processed one at a time as they are read, so it can
+// be used to follow a log.
+//
+// Lines which are not valid JSON, as commonly interleaved with JSON logs, are
+// copied to `dst` unchanged, and blank lines are kept. A record which fails to
+// be written stops the stream with a *WriteError, unless the WriteErrorPolicy
+// is WriteErrorBestEffort.
+func (f *Formatter) FormatLines(dst io.Writer, src io.Reader, opts LinesOptions) error {
+	palette := opts.PartitionColors
+	if len(palette) == 0 {
+		palette = DefaultPartitionColors
+	}
+	// With PartitionBrackets, a copy of the Formatter is kept for each color of the palette.
+	var tinted []*Formatter
+	if opts.PartitionKey != "" && opts.PartitionMarker == PartitionBrackets {
+		tinted = make([]*Formatter, len(palette))
+		for i, tint := range palette {
+			tinted[i] = f.clone()
+			tinted[i].ObjectColor = tint
+			tinted[i].ArrayColor = tint
+		}
+	}
+
+	r := bufio.NewReader(src)
+	buf := &bytes.Buffer{}
+	var firstWriteErr error
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if len(line) == 0 && readErr == io.EOF {
+			return firstWriteErr
+		}
+		record := bytes.TrimRight(line, "\r\n")
+
+		buf.Reset()
+		switch {
+		case len(bytes.TrimSpace(record)) == 0 || !json.Valid(record):
+			buf.Write(record)
+			buf.WriteByte('\n')
+		default:
+			// Pick the tint of the record, if any.
+			tint := -1
+			if opts.PartitionKey != "" {
+				tint = partitionOf(record, opts.PartitionKey, len(palette))
+			}
+
+			recordFormatter := f
+			if tint >= 0 && tinted != nil {
+				recordFormatter = tinted[tint]
+			}
+			if err := recordFormatter.format(buf, record, false); err != nil {
+				return err
+			}
+
+			if tint >= 0 && opts.PartitionMarker == PartitionGutter {
+				// Prefix each line of the formatted record.
+				gutter := []byte(palette[tint].SprintfFunc()("%s", partitionGutter) + " ")
+				marked := append(gutter, bytes.ReplaceAll(buf.Bytes(), []byte{'\n'}, append([]byte{'\n'}, gutter...))...)
+				buf.Reset()
+				buf.Write(marked)
+			}
+			buf.WriteByte('\n')
+		}
+
+		if _, err := dst.Write(buf.Bytes()); err != nil {
+			// With WriteErrorBestEffort, a record which failed to be written doesn't stop the stream.
+			if f.WriteErrorPolicy != WriteErrorBestEffort {
+				return &WriteError{Err: err}
+			}
+			if firstWriteErr == nil {
+				firstWriteErr = &WriteError{Err: err}
+			}
+		}
+		if readErr == io.EOF {
+			return firstWriteErr
+		}
+	}
+}
+
+// partitionOf returns the index into a palette of `n` colors for the record
+// `src`, based on the value of its top-level field `key`. It returns -1 if the
+// record is not an object or lacks the field.
+func partitionOf(src []byte, key string, n int) int {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(src, &fields); err != nil {
+		return -1
+	}
+	value, ok := fields[key]
+	if !ok {
+		return -1
+	}
+	// Hash the compacted value, so that differences in whitespace don't matter.
+	compacted := &bytes.Buffer{}
+	if err := json.Compact(compacted, value); err != nil {
+		return -1
+	}
+	h := fnv.New32a()
+	h.Write(compacted.Bytes())
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,93 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const linesInput = `{"src":"a","n":1}
+not json
+
+{"src": "b", "n":2}
+{"n":3}
+{"src":"a","n":[4]}`
+
+// plainCompactFormatter returns a Formatter producing compact output without colors.
+func plainCompactFormatter() *Formatter {
+	f := newPlainFormatter()
+	f.setIndent("", "")
+	return f
+}
+
+func TestFormatLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := plainCompactFormatter().FormatLines(&buf, strings.NewReader(linesInput), LinesOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"src":"a","n":1}
+not json
+
+{"src":"b","n":2}
+{"n":3}
+{"src":"a","n":[4]}
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatLinesPartitionGutter(t *testing.T) {
+	f := newPlainFormatter()
+	var buf bytes.Buffer
+	opts := LinesOptions{PartitionKey: "src", PartitionColors: []SprintfFuncer{tagColor("p0"), tagColor("p1")}}
+	if err := f.FormatLines(&buf, strings.NewReader(linesInput), opts); err != nil {
+		t.Fatal(err)
+	}
+	// Records with equal partitions get the same tint, and records without one none.
+	want := `<p0>▌</p0> {
+<p0>▌</p0>   "src": "a",
+<p0>▌</p0>   "n": 1
+<p0>▌</p0> }
+not json
+
+<p1>▌</p1> {
+<p1>▌</p1>   "src": "b",
+<p1>▌</p1>   "n": 2
+<p1>▌</p1> }
+{
+  "n": 3
+}
+<p0>▌</p0> {
+<p0>▌</p0>   "src": "a",
+<p0>▌</p0>   "n": [
+<p0>▌</p0>     4
+<p0>▌</p0>   ]
+<p0>▌</p0> }
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatLinesPartitionBrackets(t *testing.T) {
+	var buf bytes.Buffer
+	opts := LinesOptions{
+		PartitionKey:    "src",
+		PartitionMarker: PartitionBrackets,
+		PartitionColors: []SprintfFuncer{tagColor("p0"), tagColor("p1")},
+	}
+	if err := plainCompactFormatter().FormatLines(&buf, strings.NewReader(linesInput), opts); err != nil {
+		t.Fatal(err)
+	}
+	want := `<p0>{</p0>"src":"a","n":1<p0>}</p0>
+not json
+
+<p1>{</p1>"src":"b","n":2<p1>}</p1>
+{"n":3}
+<p0>{</p0>"src":"a","n":<p0>[</p0>4<p0>]</p0><p0>}</p0>
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,76 @@
+// Package lipglossstyle converts between lipgloss styles and jsoncolor
+// styles, so that Bubble Tea applications can keep a single theme for their
+// whole UI, JSON panes included.
+package lipglossstyle
+
+import (
+	"strings"
+
+	"github.com/amterp/jsoncolor"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// From returns a jsoncolor.SprintfFuncer styling text with the lipgloss style
+// `s`, for use as a color field of a jsoncolor.Formatter:
+//
+//	f := &jsoncolor.Formatter{
+//		FieldColor:  lipglossstyle.From(theme.Key),
+//		StringColor: lipglossstyle.From(theme.Value),
+//	}
+//
+// Only the text attributes and colors of the style apply: its layout, such as
+// its width, padding, margins and borders, is ignored, so that it can't change
+// the layout of the JSON. The style is rendered by its lipgloss renderer,
+// which decides on its own whether to apply colors.
+func From(s lipgloss.Style) jsoncolor.StyleFunc {
+	s = s.Inline(true).
+		UnsetWidth().UnsetHeight().UnsetMaxWidth().UnsetMaxHeight().
+		TabWidth(lipgloss.NoTabConversion)
+	return func(text string) string {
+		if !strings.Contains(text, "\n") {
+			return s.Render(text)
+		}
+		// An inline style removes newlines, so style each line on its own.
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = s.Render(line)
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+// To returns the lipgloss style made of the attributes of the jsoncolor style
+// `s`, with its colors as ANSI colors. Attributes lipgloss has no equivalent
+// for, such as Concealed, are left out.
+func To(s jsoncolor.Style) lipgloss.Style {
+	l := lipgloss.NewStyle()
+	for _, attr := range s {
+		if n, background, ok := attr.Color(); ok {
+			if background {
+				l = l.Background(lipgloss.ANSIColor(n))
+			} else {
+				l = l.Foreground(lipgloss.ANSIColor(n))
+			}
+			continue
+		}
+		switch attr {
+		case jsoncolor.Bold:
+			l = l.Bold(true)
+		case jsoncolor.Faint:
+			l = l.Faint(true)
+		case jsoncolor.Italic:
+			l = l.Italic(true)
+		case jsoncolor.Underline:
+			l = l.Underline(true)
+		case jsoncolor.BlinkSlow, jsoncolor.BlinkRapid:
+			l = l.Blink(true)
+		case jsoncolor.ReverseVideo:
+			l = l.Reverse(true)
+		case jsoncolor.CrossedOut:
+			l = l.Strikethrough(true)
+		}
+	}
+	return l
+}
@@ -0,0 +1,62 @@
+package lipglossstyle
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/amterp/jsoncolor"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// ansiRenderer returns a lipgloss renderer using the 16 ANSI colors,
+// regardless of the terminal running the tests.
+func ansiRenderer() *lipgloss.Renderer {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.ANSI)
+	return r
+}
+
+func TestFrom(t *testing.T) {
+	// The layout of the style is ignored.
+	s := ansiRenderer().NewStyle().Foreground(lipgloss.Color("6")).Bold(true).
+		Width(20).Padding(1).Border(lipgloss.NormalBorder())
+	fn := From(s)
+	tests := []struct {
+		text, want string
+	}{
+		{"ab", "\x1b[1;36mab\x1b[0m"},
+		{"a\tb", "\x1b[1;36ma\tb\x1b[0m"},
+		// Lines are styled on their own, keeping the newlines.
+		{"a\n\nb", "\x1b[1;36ma\x1b[0m\n\n\x1b[1;36mb\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if got := fn(tt.text); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	f := &jsoncolor.Formatter{FieldColor: fn}
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "\x1b[1;36ma\x1b[0m") {
+		t.Errorf("key not styled: %q", sb.String())
+	}
+}
+
+func TestTo(t *testing.T) {
+	l := To(jsoncolor.Style{jsoncolor.FgCyan, jsoncolor.BgHiRed, jsoncolor.Bold, jsoncolor.Concealed, jsoncolor.CrossedOut})
+	if got := l.GetForeground(); got != lipgloss.ANSIColor(6) {
+		t.Errorf("foreground: got %v, want 6", got)
+	}
+	if got := l.GetBackground(); got != lipgloss.ANSIColor(9) {
+		t.Errorf("background: got %v, want 9", got)
+	}
+	if !l.GetBold() || !l.GetStrikethrough() || l.GetItalic() || l.GetUnderline() {
+		t.Errorf("got bold %v, strikethrough %v, italic %v, underline %v",
+			l.GetBold(), l.GetStrikethrough(), l.GetItalic(), l.GetUnderline())
+	}
+}
@@ -0,0 +1,78 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// MarkdownOptions configures FormatMarkdown.
+type MarkdownOptions struct {
+	// Fenced, if true, writes a fenced code block of the JSON without colors
+	// instead of a <pre> element, for renderers which don't allow HTML, and
+	// sites coloring code blocks on their own.
+	Fenced bool
+	// InfoString is the info string of the fenced code block, which names the
+	// language of its code. Default is "json".
+	InfoString string
+	// HTML configures the <pre> element, whose styles are always inline, since
+	// Markdown documents don't come with a stylesheet of their own.
+	HTML HTMLOptions
+}
+
+// FormatMarkdown formats `src` for a Markdown document using the
+// DefaultFormatter. See Formatter.FormatMarkdown.
+func FormatMarkdown(dst io.Writer, src []byte, opts MarkdownOptions) error {
+	return DefaultFormatter.FormatMarkdown(dst, src, opts)
+}
+
+// FormatMarkdown formats `src` like Format, and writes it to `dst` in a form
+// which can be included in Markdown documents, such as those static site
+// generators build pages from, with the colors of the Formatter: a <pre>
+// element as written by FormatHTML with inline styles, which Markdown
+// renderers pass through as an HTML block, blank lines included. Renderers
+// which strip style attributes, as GitHub's does, still show the formatted
+// JSON without colors. With MarkdownOptions.Fenced, the output is a fenced
+// code block instead, whose fence is longer than any run of backticks within
+// the JSON.
+//
+// The output ends with a newline, so that the block is closed. Nothing is
+// written if `src` is invalid.
+func (f *Formatter) FormatMarkdown(dst io.Writer, src []byte, opts MarkdownOptions) error {
+	buf := &bytes.Buffer{}
+	if !opts.Fenced {
+		htmlOpts := opts.HTML
+		htmlOpts.InlineStyles = true
+		htmlOpts.NoPre = false
+		if err := f.FormatHTML(buf, src, htmlOpts); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+		_, err := dst.Write(buf.Bytes())
+		return err
+	}
+
+	err := f.FormatSegments(src, func(s Segment) {
+		buf.WriteString(s.Text)
+	})
+	if err != nil {
+		return err
+	}
+	info := opts.InfoString
+	if info == "" {
+		info = "json"
+	}
+	longest, run := 0, 0
+	for _, c := range buf.Bytes() {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	code := strings.TrimSuffix(buf.String(), "\n")
+	_, err = io.WriteString(dst, fence+info+"\n"+code+"\n"+fence+"\n")
+	return err
+}
@@ -0,0 +1,61 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	withColor(t, true)
+	var sb strings.Builder
+	if err := styledFormatter().FormatMarkdown(&sb, []byte(`{"a": [1, true]}`), MarkdownOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Styles are inline whatever the HTMLOptions, and the block is closed.
+	want := `<pre class="json">{
+  <span style="color:#0000ee;font-weight:bold">&#34;a&#34;</span>: [
+    <span style="color:#00ffff">1</span>,
+    <span style="font-style:italic">true</span>
+  ]
+}</pre>
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatMarkdownFenced(t *testing.T) {
+	withColor(t, true)
+	tests := []struct {
+		name, src string
+		opts      MarkdownOptions
+		want      string
+	}{
+		{"fenced", `[1]`, MarkdownOptions{Fenced: true}, "```json\n[\n  1\n]\n```\n"},
+		{"info string", `[1]`, MarkdownOptions{Fenced: true, InfoString: "jsonc"}, "```jsonc\n[\n  1\n]\n```\n"},
+		// The fence is longer than the backticks within the JSON.
+		{"backticks", "{\"a\": \"```x\"}", MarkdownOptions{Fenced: true}, "````json\n{\n  \"a\": \"```x\"\n}\n````\n"},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := styledFormatter().FormatMarkdown(&sb, []byte(tt.src), tt.opts); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		// Fenced code blocks have no colors.
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMarkdownInvalid(t *testing.T) {
+	for _, opts := range []MarkdownOptions{{}, {Fenced: true}} {
+		var sb strings.Builder
+		if err := styledFormatter().FormatMarkdown(&sb, []byte(`[1`), opts); err == nil {
+			t.Errorf("%+v: got no error", opts)
+		}
+		if sb.Len() != 0 {
+			t.Errorf("%+v: got output %q", opts, sb.String())
+		}
+	}
+}
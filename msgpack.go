@@ -0,0 +1,324 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// FormatMsgPack renders the MessagePack data in `src` as colorized JSON using
+// the DefaultFormatter. See Formatter.FormatMsgPack.
+func FormatMsgPack(dst io.Writer, src []byte) error {
+	return DefaultFormatter.FormatMsgPack(dst, src)
+}
+
+// FormatMsgPack renders the MessagePack data in `src` as colorized JSON
+// written to `dst`, according to the Formatter's settings, so that tools
+// debugging Redis or RPC traffic can show MessagePack blobs in place.
+//
+// Like with FormatCBOR, values without a JSON equivalent are converted and
+// annotated with comments, which StrictOutput leaves out:
+//   - Binary data is written as a base64url string, annotated with its
+//     contents in hexadecimal, or its length if long.
+//   - Timestamps (extension type -1) are written as RFC 3339 strings, annotated
+//     with "timestamp". Other extension types are written as the base64url
+//     string of their data, annotated with their type.
+//   - Map keys other than strings are written as strings, e.g. "1",
+//     annotated with their type.
+//   - NaN and infinities are written as null, annotated with what they were.
+//
+// Several values in a row, as in a stream, are written as one JSON value per
+// line. The input is decoded entirely before anything is written, so no
+// output is written for invalid MessagePack.
+func (f *Formatter) FormatMsgPack(dst io.Writer, src []byte) error {
+	buf := &bytes.Buffer{}
+	d := &msgpackDecoder{fs: newFormatterState(f, buf), src: src}
+	for n := 0; d.pos < len(src); n++ {
+		// Separate the values of a stream, unless a comment already ended the line.
+		if n > 0 && !d.fs.newlinePending {
+			d.fs.printSpace("\n", true)
+		}
+		if err := d.value(); err != nil {
+			return err
+		}
+	}
+	d.fs.newlinePending = false
+	if f.TrailingNewline.resolve(false) {
+		d.fs.printSpace("\n", true)
+	}
+	_, err := dst.Write(buf.Bytes())
+	return err
+}
+
+// msgpackTimestamp is the extension type of MessagePack timestamps.
+const msgpackTimestamp = -1
+
+// msgpackDecoder decodes MessagePack values, writing them as JSON through a formatterState.
+type msgpackDecoder struct {
+	fs  *formatterState
+	src []byte
+	pos int // Position of the next byte to read.
+
+	// remaining holds the number of elements (or members) left to read in
+	// each enclosing array (or map).
+	remaining []uint32
+}
+
+// errorf returns an error for the data at the current position.
+func (d *msgpackDecoder) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("jsoncolor: error decoding input MessagePack: %s at offset %d", fmt.Sprintf(format, a...), d.pos)
+}
+
+// read returns the next `n` bytes.
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n > len(d.src)-d.pos {
+		return nil, d.errorf("unexpected end of input")
+	}
+	b := d.src[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint reads an unsigned big-endian integer of `size` bytes.
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// more reports whether another element or member follows in the current container.
+func (d *msgpackDecoder) more() bool {
+	return len(d.remaining) > 0 && d.remaining[len(d.remaining)-1] > 0
+}
+
+// value decodes the next value and writes it as a JSON value.
+func (d *msgpackDecoder) value() error {
+	if d.pos >= len(d.src) {
+		return d.errorf("unexpected end of input")
+	}
+	switch c := d.src[d.pos]; {
+	case c >= 0x80 && c <= 0x8f:
+		d.pos++
+		return d.container(true, uint64(c&0x0f))
+	case c >= 0x90 && c <= 0x9f:
+		d.pos++
+		return d.container(false, uint64(c&0x0f))
+	case c == 0xdc || c == 0xdd || c == 0xde || c == 0xdf:
+		d.pos++
+		n, err := d.uint(2 << ((c - 0xdc) & 1))
+		if err != nil {
+			return err
+		}
+		return d.container(c >= 0xde, n)
+	}
+
+	token, annotation, err := d.scalar()
+	if err != nil {
+		return err
+	}
+	if err := d.fs.writeToken(token, false); err != nil {
+		return err
+	}
+	if annotation != "" {
+		d.fs.writeAnnotation(annotation, true, d.more())
+	}
+	return nil
+}
+
+// container decodes the `n` elements of an array, or members of a map if
+// `isMap` is true, whose header was just read.
+func (d *msgpackDecoder) container(isMap bool, n uint64) error {
+	if len(d.remaining) >= maxBinaryDepth {
+		return d.errorf("exceeded max depth")
+	}
+	openDelim, closeDelim := json.Delim('['), json.Delim(']')
+	if isMap {
+		openDelim, closeDelim = json.Delim('{'), json.Delim('}')
+	}
+	if err := d.fs.writeToken(openDelim, false); err != nil {
+		return err
+	}
+
+	d.remaining = append(d.remaining, uint32(n))
+	for ; n > 0; n-- {
+		d.remaining[len(d.remaining)-1]--
+		if isMap {
+			if err := d.key(); err != nil {
+				return err
+			}
+		}
+		if err := d.value(); err != nil {
+			return err
+		}
+	}
+	d.remaining = d.remaining[:len(d.remaining)-1]
+	return d.fs.writeToken(closeDelim, false)
+}
+
+// key decodes the key of a map member and writes it as an object key.
+func (d *msgpackDecoder) key() error {
+	start := d.pos
+	token, annotation, err := d.scalar()
+	if err != nil {
+		return err
+	}
+
+	var key string
+	switch value := token.(type) {
+	case string:
+		key = value
+		if c := d.src[start]; c == 0xc4 || c == 0xc5 || c == 0xc6 {
+			annotation = "bytes key: " + annotation
+		}
+	case json.Number:
+		key = value.String()
+		annotation = "int key"
+		if c := d.src[start]; c == 0xca || c == 0xcb {
+			annotation = "float key"
+		}
+	case bool:
+		key = strconv.FormatBool(value)
+		annotation = "bool key"
+	default:
+		key = "null"
+		if annotation == "" {
+			annotation = "nil"
+		}
+		annotation += " key"
+	}
+	if err := d.fs.writeToken(key, false); err != nil {
+		return err
+	}
+	if annotation != "" {
+		d.fs.writeAnnotation(annotation, true, true)
+	}
+	return nil
+}
+
+// scalar decodes a value other than an array or map into a JSON token and the
+// annotation to write after it, if any.
+func (d *msgpackDecoder) scalar() (json.Token, string, error) {
+	if d.pos >= len(d.src) {
+		return nil, "", d.errorf("unexpected end of input")
+	}
+	c := d.src[d.pos]
+	d.pos++
+	switch {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), "", nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), "", nil
+	case c >= 0xa0 && c <= 0xbf:
+		b, err := d.read(int(c & 0x1f))
+		return string(b), "", err
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, "", nil
+	case 0xc2:
+		return false, "", nil
+	case 0xc3:
+		return true, "", nil
+	case 0xc4, 0xc5, 0xc6, 0xd9, 0xda, 0xdb:
+		size := 1 << (c - 0xc4)
+		if c >= 0xd9 {
+			size = 1 << (c - 0xd9)
+		}
+		n, err := d.uint(size)
+		if err != nil {
+			return nil, "", err
+		}
+		if n > uint64(len(d.src)-d.pos) {
+			return nil, "", d.errorf("unexpected end of input")
+		}
+		b, _ := d.read(int(n))
+		if c >= 0xd9 {
+			return string(b), "", nil
+		}
+		return base64.RawURLEncoding.EncodeToString(b), bytesAnnotation(b), nil
+	case 0xca, 0xcb:
+		n, err := d.uint(4 << (c - 0xca))
+		if err != nil {
+			return nil, "", err
+		}
+		if c == 0xca {
+			return floatToken(float64(math.Float32frombits(uint32(n))))
+		}
+		return floatToken(math.Float64frombits(n))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (c - 0xcc))
+		return json.Number(strconv.FormatUint(n, 10)), "", err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := d.uint(size)
+		// Sign-extend the integer from its size.
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(n<<shift)>>shift, 10)), "", err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, "", err
+		}
+		if n > uint64(len(d.src)-d.pos) {
+			return nil, "", d.errorf("unexpected end of input")
+		}
+		return d.ext(int(n))
+	}
+	d.pos--
+	return nil, "", d.errorf("invalid type byte 0x%02x", c)
+}
+
+// ext decodes the type and `size` bytes of data of an extension value.
+func (d *msgpackDecoder) ext(size int) (json.Token, string, error) {
+	b, err := d.read(1 + size)
+	if err != nil {
+		return nil, "", err
+	}
+	typ, data := int8(b[0]), b[1:]
+	if typ == msgpackTimestamp {
+		if t, ok := msgpackTime(data); ok {
+			return t.Format(time.RFC3339Nano), "timestamp", nil
+		}
+	}
+	annotation := "ext " + strconv.Itoa(int(typ))
+	if len(data) <= maxBytesAnnotation {
+		annotation += ": h'" + hex.EncodeToString(data) + "'"
+	}
+	return base64.RawURLEncoding.EncodeToString(data), annotation, nil
+}
+
+// msgpackTime decodes the data of a timestamp extension value, in any of its
+// three formats, reporting whether it's valid.
+func msgpackTime(data []byte) (time.Time, bool) {
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), true
+	case 8:
+		n := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(n&(1<<34-1)), int64(n>>34)).UTC(), n>>34 < 1e9
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(nsec)).UTC(), nsec < 1e9
+	}
+	return time.Time{}, false
+}
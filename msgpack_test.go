@@ -0,0 +1,130 @@
+package jsoncolor
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// formatMsgPackHex formats the MessagePack data given in hexadecimal with `f`,
+// and strips the colors.
+func formatMsgPackHex(t *testing.T, f *Formatter, h string) (string, error) {
+	t.Helper()
+	src, err := hex.DecodeString(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	err = f.FormatMsgPack(&sb, src)
+	return stripANSI(sb.String()), err
+}
+
+func TestFormatMsgPack(t *testing.T) {
+	tests := []struct {
+		name, hex, want, strict string
+	}{
+		{"fixmap", "81a16101", `{"a":1}`, `{"a":1}`},
+		{"array 16", "dc0001c0", `[null]`, `[null]`},
+		{"negative fixint", "ff", `-1`, `-1`},
+		{"int 16", "d1ff00", `-256`, `-256`},
+		{"int 64", "d3ffffffffffffffff", `-1`, `-1`},
+		{"uint 64", "cfffffffffffffffff", `18446744073709551615`, `18446744073709551615`},
+		{"float 64", "cb3ff199999999999a", `1.1`, `1.1`},
+		{"NaN", "ca7fc00000", `null /* NaN */`, `null`},
+		{"negative infinity", "cbfff0000000000000", `null /* -Infinity */`, `null`},
+		{"str 8", "d90161", `"a"`, `"a"`},
+		{"bin 16", "c50003aabbcc", `"qrvM" /* h'aabbcc' */`, `"qrvM"`},
+		{"bytes key", "81c40161c0", `{"YQ":/* bytes key: h'61' */ null}`, `{"YQ":null}`},
+		{"float key", "81ca3f800000c0", `{"1":/* float key */ null}`, `{"1":null}`},
+		{"nil key", "81c0c0", `{"null":/* nil key */ null}`, `{"null":null}`},
+		// Several values in a row are written one per line.
+		{"stream", "0102", "1\n2", "1\n2"},
+	}
+	for _, tt := range tests {
+		got, err := formatMsgPackHex(t, &Formatter{}, tt.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		got, err = formatMsgPackHex(t, &Formatter{StrictOutput: true}, tt.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.strict {
+			t.Errorf("%s: strict: got %s, want %s", tt.name, got, tt.strict)
+		}
+	}
+}
+
+func TestFormatMsgPackExt(t *testing.T) {
+	long := "c721" + "09" + strings.Repeat("00", 33)
+	tests := []struct {
+		name, hex, want string
+	}{
+		{"fixext 1", "d40501", `"AQ" /* ext 5: h'01' */`},
+		{"fixext 2", "d5050102", `"AQI" /* ext 5: h'0102' */`},
+		{"ext 8", "c703070a0b0c", `"CgsM" /* ext 7: h'0a0b0c' */`},
+		{"ext 16", "c8000107aa", `"qg" /* ext 7: h'aa' */`},
+		{"negative type", "d4fe01", `"AQ" /* ext -2: h'01' */`},
+		// Long data is annotated with the type alone.
+		{"long", long, `"` + strings.Repeat("A", 44) + `" /* ext 9 */`},
+
+		// The three formats of timestamps.
+		{"timestamp 32", "d6ff5bc8d8c0", `"2018-10-18T19:02:24Z" /* timestamp */`},
+		{"timestamp 64", "d7ff0000000400000001", `"1970-01-01T00:00:01.000000001Z" /* timestamp */`},
+		{"timestamp 96", "c70cff000000010000000000000000", `"1970-01-01T00:00:00.000000001Z" /* timestamp */`},
+		// Timestamps with too many nanoseconds, or of another length, are
+		// written like other extension types.
+		{"invalid nanoseconds", "c70cff3b9aca000000000000000000", `"O5rKAAAAAAAAAAAA" /* ext -1: h'3b9aca000000000000000000' */`},
+		{"invalid length", "d5ff0102", `"AQI" /* ext -1: h'0102' */`},
+	}
+	for _, tt := range tests {
+		got, err := formatMsgPackHex(t, &Formatter{}, tt.hex)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// The annotations follow the commas between members.
+	got, err := formatMsgPackHex(t, newPlainFormatter(), "82a174d6ff5bc8d8c0a165d40501")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "t": "2018-10-18T19:02:24Z", /* timestamp */
+  "e": "AQ" /* ext 5: h'01' */
+}`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatMsgPackErrors(t *testing.T) {
+	tests := []struct {
+		name, hex, want string
+	}{
+		{"never used", "c1", "invalid type byte 0xc1 at offset 0"},
+		{"truncated map", "81", "unexpected end of input at offset 1"},
+		{"truncated array", "92c0", "unexpected end of input at offset 2"},
+		{"truncated bin", "82a161c4020102", "unexpected end of input at offset 7"},
+		{"truncated fixext", "d6", "unexpected end of input at offset 1"},
+		{"truncated ext", "c7ff", "unexpected end of input at offset 2"},
+		{"truncated str length", "d9", "unexpected end of input at offset 1"},
+		{"max depth", strings.Repeat("91", maxBinaryDepth+1), "exceeded max depth"},
+	}
+	for _, tt := range tests {
+		got, err := formatMsgPackHex(t, &Formatter{}, tt.hex)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+		// Nothing is written for invalid input.
+		if got != "" {
+			t.Errorf("%s: got output %q", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// plainColor is a SprintfFuncer which leaves text uncolored.
+type plainColor struct{}
+
+func (plainColor) SprintfFunc() func(format string, a ...interface{}) string {
+	return fmt.Sprintf
+}
+
+// normalizeIndent is the fixed indentation used by normalized output.
+const normalizeIndent = "  "
+
+// newPlainFormatter returns a Formatter producing uncolored output with
+// two-space indentation, suitable for writing to files.
+func newPlainFormatter() *Formatter {
+	plain := plainColor{}
+	return &Formatter{
+		SpaceColor:       plain,
+		CommaColor:       plain,
+		ColonColor:       plain,
+		ObjectColor:      plain,
+		ArrayColor:       plain,
+		FieldQuoteColor:  plain,
+		FieldColor:       plain,
+		StringQuoteColor: plain,
+		StringColor:      plain,
+		TrueColor:        plain,
+		FalseColor:       plain,
+		NumberColor:      plain,
+		NullColor:        plain,
+		IndentGuideColor: plain,
+		SkeletonColor:    plain,
+		CommentColor:     plain,
+		Indent:           normalizeIndent,
+	}
+}
+
+// newNormalizeFormatter returns a Formatter producing the canonical,
+// uncolored form of a document: keys sorted, two-space indentation, HTML
+// characters left unescaped and a trailing newline.
+func newNormalizeFormatter() *Formatter {
+	f := newPlainFormatter()
+	f.SortKeys = true
+	f.TrailingNewline = NewlineAlways
+	return f
+}
+
+// Normalize rewrites the valid JSON in `src` into a canonical, gofmt-style
+// form suitable for committing to a repository: object keys sorted, two-space
+// indentation, LF line endings, a trailing newline and no colors. Numbers and
+// the contents of strings are preserved, though strings are re-escaped
+// minimally. If an object contains duplicate keys, only the last is kept.
+// Normalizing a document which is already normalized returns it unchanged, so
+// Normalize can be used to check files in pre-commit hooks.
+func Normalize(src []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := newNormalizeFormatter().Format(buf, src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetNormalize enables or disables normalized output for the Encoder. When
+// enabled, values are written in the canonical form produced by Normalize
+// (sorted keys, two-space indentation, no colors, trailing newline), and the
+// Encoder's Formatter and other settings are ignored. This allows the same
+// code path to both display colorized JSON and write canonical JSON files,
+// guaranteeing the two agree structurally.
+func (enc *Encoder) SetNormalize(on bool) {
+	enc.normalize = on
+}
+
+// sortKeys returns `src` re-encoded compactly with the keys of every object
+// sorted. Numbers keep their original text.
+func sortKeys(src []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	// encoding/json marshals map keys in sorted order.
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	src := []byte(`{"b": [1.50, {"z": null, "a": "<&>"}], "a": "é", "b2": {}, "a": 2}`)
+	got, err := Normalize(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "a": 2,
+  "b": [
+    1.50,
+    {
+      "a": "<&>",
+      "z": null
+    }
+  ],
+  "b2": {}
+}
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	again, err := Normalize(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, got) {
+		t.Errorf("not idempotent: got:\n%s", again)
+	}
+
+	if _, err := Normalize([]byte(`{"a":`)); err == nil {
+		t.Error("invalid input: got no error")
+	}
+}
+
+func TestEncoderSetNormalize(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{Indent: "\t"})
+	enc.SetTrailingNewline(false)
+	enc.SetNormalize(true)
+	if err := enc.Encode(map[string]interface{}{"y": true, "x": []int{}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"x\": [],\n  \"y\": true\n}\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	got := formatPlain(t, &Formatter{SortKeys: true}, `{"b":1,"a":{"d":2,"c":3}}`)
+	if want := `{"a":{"c":3,"d":2},"b":1}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
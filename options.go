@@ -0,0 +1,114 @@
+package jsoncolor
+
+// EncodeOption configures how values are encoded by an Encoder or the
+// Marshal* functions. Options apply on top of the settings of the Formatter in
+// use, which is never modified.
+type EncodeOption func(*encodeOptions)
+
+// encodeOptions holds the settings made through EncodeOptions.
+type encodeOptions struct {
+	// indentSet is true if prefix and indent override the Formatter's Prefix and Indent.
+	indentSet      bool
+	prefix, indent string
+
+	// escapeHTML overrides the Formatter's EscapeHTML, unless formatterEscapeHTML is true.
+	escapeHTML          bool
+	formatterEscapeHTML bool
+	// escapePolicy, if set, overrides the Formatter's EscapePolicy.
+	escapePolicy EscapePolicy
+	// preserveExact, if true, overrides the Formatter's PreserveExact (see Encoder.SetRawPassthrough).
+	preserveExact bool
+	// colorMode decides whether colors are written (see Encoder.SetColorMode).
+	colorMode ColorMode
+	// baseIndentLevel is the indentation level of the top-level value (see Encoder.SetBaseIndentLevel).
+	baseIndentLevel int
+}
+
+// formatterOptions are the options leaving all settings of the Formatter in
+// effect, as when formatting with it directly.
+var formatterOptions = encodeOptions{formatterEscapeHTML: true}
+
+// defaultEncodeOptions returns the options in effect unless changed, which
+// escape HTML characters like encoding/json.
+func defaultEncodeOptions() encodeOptions {
+	return encodeOptions{escapeHTML: true}
+}
+
+// WithIndent indents the output using `prefix` and `indent`, overriding the
+// Formatter's Prefix and Indent fields, like Encoder.SetIndent.
+func WithIndent(prefix, indent string) EncodeOption {
+	return func(o *encodeOptions) {
+		o.indentSet = true
+		o.prefix, o.indent = prefix, indent
+	}
+}
+
+// WithEscapeHTML specifies whether problematic HTML characters (<, >, &) are
+// escaped within JSON strings, overriding the Formatter's EscapeHTML field,
+// like Encoder.SetEscapeHTML. By default, they are.
+func WithEscapeHTML(on bool) EncodeOption {
+	return func(o *encodeOptions) {
+		o.escapeHTML = on
+		o.formatterEscapeHTML = false
+	}
+}
+
+// WithFormatterEscapeHTML honors the Formatter's EscapeHTML field, rather than
+// escaping HTML characters by default like encoding/json, e.g.
+//
+//	jsoncolor.MarshalWithFormatter(v, f, jsoncolor.WithFormatterEscapeHTML())
+func WithFormatterEscapeHTML() EncodeOption {
+	return func(o *encodeOptions) {
+		o.formatterEscapeHTML = true
+	}
+}
+
+// WithEscapePolicy sets the EscapePolicy deciding which characters are escaped
+// within JSON strings, overriding the Formatter's EscapePolicy field and taking
+// precedence over HTML escaping. A nil policy leaves the Formatter's in effect.
+func WithEscapePolicy(p EscapePolicy) EncodeOption {
+	return func(o *encodeOptions) {
+		o.escapePolicy = p
+	}
+}
+
+// WithBaseIndentLevel starts the output indented `n` levels, like
+// Encoder.SetBaseIndentLevel.
+func WithBaseIndentLevel(n int) EncodeOption {
+	return func(o *encodeOptions) {
+		o.baseIndentLevel = max(n, 0)
+	}
+}
+
+// with returns a copy of the options with `opts` applied.
+func (o encodeOptions) with(opts ...EncodeOption) encodeOptions {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// layout returns the prefix and indentation to encode with on top of `f`.
+func (o encodeOptions) layout(f *Formatter) (prefix, indent string) {
+	if o.indentSet {
+		return o.prefix, o.indent
+	}
+	return f.Prefix, f.Indent
+}
+
+// escapePolicyFor returns the EscapePolicy to encode with on top of `f`.
+func (o encodeOptions) escapePolicyFor(f *Formatter) EscapePolicy {
+	escapeHTML := f.EscapeHTML
+	if !o.formatterEscapeHTML {
+		escapeHTML = o.escapeHTML
+	}
+	if o.escapePolicy != nil {
+		return o.escapePolicy
+	}
+	return resolveEscapePolicy(f.EscapePolicy, escapeHTML)
+}
+
+// preserveExactFor reports whether to preserve the exact input on top of `f`.
+func (o encodeOptions) preserveExactFor(f *Formatter) bool {
+	return o.preserveExact || f.PreserveExact
+}
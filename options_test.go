@@ -0,0 +1,179 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMarshalEncodeOptions(t *testing.T) {
+	v := map[string]string{"a": "<&>"}
+	f := &Formatter{EscapeHTML: false}
+	tests := []struct {
+		name string
+		opts []EncodeOption
+		want string
+	}{
+		{"default", nil, `{"a":"\u003c\u0026\u003e"}`},
+		{"formatter", []EncodeOption{WithFormatterEscapeHTML()}, `{"a":"<&>"}`},
+		{"no escape", []EncodeOption{WithEscapeHTML(false)}, `{"a":"<&>"}`},
+		{"policy", []EncodeOption{WithEscapePolicy(ASCIIEscaping)}, `{"a":"<&>"}`},
+		{"indent", []EncodeOption{WithIndent("", "  "), WithEscapeHTML(false)}, "{\n  \"a\": \"<&>\"\n}"},
+	}
+	for _, tt := range tests {
+		got, err := MarshalWithFormatter(v, f, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stripANSI(string(got)) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, stripANSI(string(got)), tt.want)
+		}
+	}
+
+	// The indentation arguments of MarshalIndentWithFormatter can be overridden by options.
+	got, err := MarshalIndentWithFormatter([]int{1}, "", "\t", f, WithIndent("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[1]"; stripANSI(string(got)) != want {
+		t.Errorf("got %q, want %q", stripANSI(string(got)), want)
+	}
+}
+
+func TestEncoderDoesNotModifyFormatter(t *testing.T) {
+	f := &Formatter{}
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, f)
+	enc.SetIndent(">", " ")
+	enc.SetEscapeHTML(false)
+	enc.SetEscapePolicy(ASCIIEscaping)
+	if f.Prefix != "" || f.Indent != "" || f.EscapeHTML || f.EscapePolicy != nil {
+		t.Errorf("Formatter was modified: %+v", f)
+	}
+
+	// Changes made to the Formatter by the caller apply to later calls.
+	f.SortKeys = true
+	if err := enc.Encode(map[string]int{"b": 1, "a": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if want := ">{\n> \"a\": 2,\n> \"b\": 1\n>}\n"; stripANSI(buf.String()) != want {
+		t.Errorf("got %q, want %q", stripANSI(buf.String()), want)
+	}
+}
+
+func TestEncodeWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{})
+	if err := enc.EncodeWithOptions("<", WithEscapeHTML(false)); err != nil {
+		t.Fatal(err)
+	}
+	// The options only apply to the one call.
+	if err := enc.Encode("<"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\"<\"\n\"\\u003c\"\n"; stripANSI(buf.String()) != want {
+		t.Errorf("got %q, want %q", stripANSI(buf.String()), want)
+	}
+}
+
+func TestStreamWriterEscapeHTML(t *testing.T) {
+	f := &Formatter{}
+	var buf bytes.Buffer
+	sw := NewStreamWriterWithFormatter(&buf, f)
+	if err := sw.WriteElement("<"); err != nil {
+		t.Fatal(err)
+	}
+	sw.SetEscapeHTML(false)
+	if err := sw.WriteElement("<"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stripANSI(buf.String()), "\"\\u003c\"\n\"<\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if f.EscapeHTML {
+		t.Error("Formatter was modified")
+	}
+}
+
+// escapedChars names which of a few characters the EscapePolicy `p` escapes,
+// since policies are functions and can't be compared.
+func escapedChars(p EscapePolicy) string {
+	var names []string
+	for _, c := range []struct {
+		r    rune
+		name string
+	}{{'<', "<"}, {'é', "é"}, {0x2028, "LS"}} {
+		if p.EscapeRune(c.r) {
+			names = append(names, c.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestEncodeOptionsOnTopOfFormatter(t *testing.T) {
+	f := &Formatter{Prefix: ">", Indent: " ", EscapeHTML: false, PreserveExact: false}
+	tests := []struct {
+		name           string
+		opts           encodeOptions
+		prefix, indent string
+		escaped        string // See escapedChars.
+		preserveExact  bool
+	}{
+		{"formatter", formatterOptions, ">", " ", "LS", false},
+		{"default", defaultEncodeOptions(), ">", " ", "< LS", false},
+		{"indent", formatterOptions.with(WithIndent("", "\t")), "", "\t", "LS", false},
+		{"policy", defaultEncodeOptions().with(WithEscapePolicy(ASCIIEscaping)), ">", " ", "é LS", false},
+		{"raw", encodeOptions{formatterEscapeHTML: true, preserveExact: true}, ">", " ", "LS", true},
+	}
+	for _, tt := range tests {
+		prefix, indent := tt.opts.layout(f)
+		if prefix != tt.prefix || indent != tt.indent {
+			t.Errorf("%s: got layout %q, %q, want %q, %q", tt.name, prefix, indent, tt.prefix, tt.indent)
+		}
+		if got := escapedChars(tt.opts.escapePolicyFor(f)); got != tt.escaped {
+			t.Errorf("%s: got escape policy escaping %q, want %q", tt.name, got, tt.escaped)
+		}
+		if got := tt.opts.preserveExactFor(f); got != tt.preserveExact {
+			t.Errorf("%s: got preserve exact %v, want %v", tt.name, got, tt.preserveExact)
+		}
+	}
+}
+
+func TestEncoderSetBaseIndentLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Formatter
+		opts []EncodeOption
+		want string
+	}{
+		// Every line is indented, including the first.
+		{"indented", newPlainFormatter(), []EncodeOption{WithBaseIndentLevel(2)}, "    {\n      \"a\": [\n        1\n      ]\n    }\n    1\n"},
+		{"compact", plainCompactFormatter(), []EncodeOption{WithBaseIndentLevel(2)}, "{\"a\":[1]}\n1\n"},
+		// The indentation follows the prefix.
+		{"prefix", newPlainFormatter(), []EncodeOption{WithIndent("> ", "\t"), WithBaseIndentLevel(1)}, "> \t{\n> \t\t\"a\": [\n> \t\t\t1\n> \t\t]\n> \t}\n> \t1\n"},
+		{"negative", newPlainFormatter(), []EncodeOption{WithBaseIndentLevel(-3)}, "{\n  \"a\": [\n    1\n  ]\n}\n1\n"},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		enc := NewEncoderWithFormatter(&sb, tt.f, tt.opts...)
+		for _, v := range []any{map[string]any{"a": []int{1}}, 1} {
+			if err := enc.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// The setter is the same as the option.
+	var sb strings.Builder
+	enc := NewEncoderWithFormatter(&sb, newPlainFormatter())
+	enc.SetBaseIndentLevel(1)
+	if err := enc.Encode([]int{1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "  [\n    1\n  ]\n"; got != want {
+		t.Errorf("SetBaseIndentLevel: got %q, want %q", got, want)
+	}
+}
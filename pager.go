@@ -0,0 +1,148 @@
+package jsoncolor
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// defaultPager is the pager run if the PAGER environment variable is unset.
+// -R lets the escape sequences of colors through.
+const defaultPager = "less -R"
+
+// Pager is an io.WriteCloser showing output meant for a terminal through a
+// pager when it doesn't fit on the screen, as git does: output is held back
+// until it's longer than the terminal is high, in which case it's piped
+// through the pager named by the PAGER environment variable ("less -R" if
+// unset) from then on, and otherwise written to the terminal directly on
+// Close. Output to a file or a pipe is always written directly, as is output
+// with PAGER set to an empty string, or if the pager can't be started.
+//
+// Colors are written through a Pager as to the terminal itself, e.g. by an
+// Encoder with ColorAuto, and so is the width of the terminal used by
+// Formatter.WrapWidth. Close must be called for the output to be shown, and
+// waits for the pager to exit. Writing fails once the pager has been quit,
+// and output written after Close is written directly. Create a Pager with
+// NewPager.
+type Pager struct {
+	file   *os.File
+	height int // Number of lines of the terminal, or 0 to write directly.
+	width  int // Number of columns of the terminal.
+
+	buf    []byte // Output held back, while it fits on the terminal.
+	lines  int    // Number of lines of the terminal filled by buf.
+	column int    // Column at the end of buf.
+
+	pager io.WriteCloser // Standard input of the pager, once started.
+	cmd   *exec.Cmd
+}
+
+// NewPager returns a Pager writing to `f`, typically os.Stdout.
+func NewPager(f *os.File) *Pager {
+	p := &Pager{file: f}
+	if isTerminal(f) {
+		if width, height, err := term.GetSize(f.Fd()); err == nil {
+			p.width, p.height = width, height
+		}
+	}
+	return p
+}
+
+// terminal returns the terminal the Pager writes to, if any.
+func (p *Pager) terminal() *os.File {
+	if p.height > 0 {
+		return p.file
+	}
+	return nil
+}
+
+func (p *Pager) Write(b []byte) (int, error) {
+	switch {
+	case p.pager != nil:
+		return p.pager.Write(b)
+	case p.height <= 0:
+		return p.file.Write(b)
+	}
+	p.buf = append(p.buf, b...)
+	p.count(b)
+	// The last line of the screen is kept for the prompt following the output.
+	if p.lines < p.height-1 {
+		return len(b), nil
+	}
+	if err := p.startPager(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// count counts the lines of the terminal the output `b` fills, including
+// lines wrapped by the terminal.
+func (p *Pager) count(b []byte) {
+	for i := 0; i < len(b); {
+		if b[i] == '\x1b' {
+			_, _, n := escapeSequence(b[i:])
+			i += n
+			continue
+		}
+		if b[i] == '\n' {
+			p.lines++
+			p.column = 0
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRune(b[i:])
+		width := runeColumns(r, p.column)
+		if p.width > 0 && p.column+width > p.width {
+			p.lines++
+			p.column = 0
+		}
+		p.column += width
+		i += n
+	}
+}
+
+// startPager starts the pager and writes the output held back to it, or
+// writes the output directly if there's no pager to start.
+func (p *Pager) startPager() error {
+	command, ok := os.LookupEnv("PAGER")
+	if !ok {
+		command = defaultPager
+	}
+	args := strings.Fields(command)
+	if len(args) > 0 {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdout, cmd.Stderr = p.file, os.Stderr
+		if stdin, err := cmd.StdinPipe(); err == nil {
+			if err := cmd.Start(); err == nil {
+				p.cmd, p.pager = cmd, stdin
+			}
+		}
+	}
+	buf := p.buf
+	p.buf = nil
+	if p.pager == nil {
+		p.height = 0
+		_, err := p.file.Write(buf)
+		return err
+	}
+	_, err := p.pager.Write(buf)
+	return err
+}
+
+// Close writes the output held back to the terminal, or closes the input of
+// the pager and waits for it to exit. It does not close the terminal.
+func (p *Pager) Close() error {
+	if p.pager == nil {
+		buf := p.buf
+		p.buf = nil
+		_, err := p.file.Write(buf)
+		return err
+	}
+	p.pager.Close()
+	p.pager, p.height = nil, 0
+	return p.cmd.Wait()
+}
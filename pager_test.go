@@ -0,0 +1,103 @@
+package jsoncolor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testPager returns a Pager writing to a temporary file as if it were a
+// terminal of `width` columns and `height` lines, and a function returning
+// what was written to the file.
+func testPager(t *testing.T, width, height int) (*Pager, func() string) {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "tty")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	p := NewPager(f)
+	p.width, p.height = width, height
+	return p, func() string {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+}
+
+func TestPagerCount(t *testing.T) {
+	p, _ := testPager(t, 4, 100)
+	// Escape sequences take no room, and long lines are wrapped by the
+	// terminal.
+	p.count([]byte("\x1b[31mab\x1b[0m\nabcdef\n日本語"))
+	if p.lines != 4 || p.column != 2 {
+		t.Errorf("got %d lines, column %d, want 4, 2", p.lines, p.column)
+	}
+}
+
+func TestPagerShort(t *testing.T) {
+	t.Setenv("PAGER", "false")
+	p, written := testPager(t, 80, 5)
+	if _, err := p.Write([]byte("1\n2\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Output fitting on the screen is held back until Close.
+	if got := written(); got != "" {
+		t.Errorf("before Close: got %q", got)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := written(); got != "1\n2\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPagerLong(t *testing.T) {
+	t.Setenv("PAGER", "tr a-z A-Z")
+	p, written := testPager(t, 80, 3)
+	for _, s := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := p.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// All the output goes through the pager once it fills the screen.
+	if got := written(); got != "A\nB\nC\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPagerDirect(t *testing.T) {
+	long := strings.Repeat("x\n", 10)
+	for _, pager := range []string{"", "jsoncolor-no-such-pager"} {
+		t.Setenv("PAGER", pager)
+		p, written := testPager(t, 80, 3)
+		if _, err := p.Write([]byte(long)); err != nil {
+			t.Fatal(err)
+		}
+		// Without a pager to start, the output is written directly.
+		if got := written(); got != long {
+			t.Errorf("PAGER=%q: got %q", pager, got)
+		}
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// So is output to a file, which isn't a terminal.
+	p, written := testPager(t, 0, 0)
+	if isTerminal(p) {
+		t.Error("a file is a terminal")
+	}
+	p.Write([]byte("1"))
+	if got := written(); got != "1" {
+		t.Errorf("file: got %q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer is the capacity beyond which the buffer of a PooledEncoder
+// isn't kept by its Pool, so that one huge response doesn't pin its memory.
+const maxPooledBuffer = 64 << 10
+
+// Pool keeps Encoders writing to buffers of their own for reuse, for HTTP
+// handlers and other code colorizing values on every request, where
+// allocating an Encoder and a buffer each time adds up. It's safe for
+// concurrent use.
+//
+//	pe := pool.Get()
+//	defer pool.Put(pe)
+//	if err := pe.Encode(v); err != nil {
+//		return err
+//	}
+//	_, err := pe.WriteTo(w)
+type Pool struct {
+	encoder Encoder // The Encoder whose settings every PooledEncoder starts with.
+	pool    sync.Pool
+
+	gets, misses, discarded atomic.Uint64
+}
+
+// PoolStats holds the metrics of a Pool.
+type PoolStats struct {
+	// Gets is the number of calls to Get.
+	Gets uint64
+	// Hits is the number of calls to Get reusing a PooledEncoder, and Misses
+	// the number of those allocating a new one.
+	Hits, Misses uint64
+	// Discarded is the number of PooledEncoders given to Put which weren't
+	// kept, because their buffer had grown too large.
+	Discarded uint64
+}
+
+// PooledEncoder is an Encoder writing to a buffer of its own, as returned by
+// Pool.Get.
+type PooledEncoder struct {
+	*Encoder
+	buf bytes.Buffer
+}
+
+// NewPool returns a Pool of Encoders using the Formatter `f`, with the options
+// `opts` applied on top of it, as with NewEncoderWithFormatter.
+func NewPool(f *Formatter, opts ...EncodeOption) *Pool {
+	if f == nil {
+		panic("jsoncolor: cannot create Pool with a nil Formatter")
+	}
+	p := &Pool{encoder: *NewEncoderWithFormatter(nil, f, opts...)}
+	p.pool.New = func() any {
+		p.misses.Add(1)
+		pe := &PooledEncoder{Encoder: &Encoder{}}
+		p.reset(pe)
+		return pe
+	}
+	return p
+}
+
+// Get returns a PooledEncoder with an empty buffer, and the settings of the
+// Pool, which should be given back to Put once its output is used.
+func (p *Pool) Get() *PooledEncoder {
+	p.gets.Add(1)
+	return p.pool.Get().(*PooledEncoder)
+}
+
+// Put gives `pe` back to the Pool for reuse. Settings changed on it, such as
+// with SetIndent, are reset. `pe` and the slices returned by its Bytes method
+// must not be used afterwards.
+func (p *Pool) Put(pe *PooledEncoder) {
+	if pe.buf.Cap() > maxPooledBuffer {
+		p.discarded.Add(1)
+		return
+	}
+	p.reset(pe)
+	p.pool.Put(pe)
+}
+
+// reset empties the buffer of `pe` and restores the settings of the Pool.
+func (p *Pool) reset(pe *PooledEncoder) {
+	pe.buf.Reset()
+	*pe.Encoder = p.encoder
+	pe.Encoder.w = &pe.buf
+}
+
+// Stats returns the metrics of the Pool.
+func (p *Pool) Stats() PoolStats {
+	gets, misses := p.gets.Load(), p.misses.Load()
+	return PoolStats{
+		Gets:      gets,
+		Hits:      gets - min(misses, gets),
+		Misses:    misses,
+		Discarded: p.discarded.Load(),
+	}
+}
+
+// Bytes returns the output encoded so far.
+func (pe *PooledEncoder) Bytes() []byte {
+	return pe.buf.Bytes()
+}
+
+// WriteTo writes the output encoded so far to `w`, emptying the buffer.
+func (pe *PooledEncoder) WriteTo(w io.Writer) (int64, error) {
+	return pe.buf.WriteTo(w)
+}
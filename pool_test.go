@@ -0,0 +1,95 @@
+package jsoncolor
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	p := NewPool(plainCompactFormatter())
+	pe := p.Get()
+	if err := pe.Encode(map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(pe.Bytes()); got != "{\"a\":1}\n" {
+		t.Errorf("got %q", got)
+	}
+	var sb strings.Builder
+	if n, err := pe.WriteTo(&sb); err != nil || n != 8 || sb.String() != "{\"a\":1}\n" {
+		t.Errorf("WriteTo: got %d, %v, %q", n, err, sb.String())
+	}
+	if len(pe.Bytes()) != 0 {
+		t.Error("WriteTo didn't empty the buffer")
+	}
+
+	// Settings changed on a PooledEncoder are reset by Put.
+	pe.SetIndent("", "  ")
+	pe.Encode([]int{1})
+	p.Put(pe)
+	pe = p.Get()
+	if err := pe.Encode([]int{1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(pe.Bytes()); got != "[1]\n" {
+		t.Errorf("after Put: got %q", got)
+	}
+}
+
+func TestPoolOptions(t *testing.T) {
+	p := NewPool(plainCompactFormatter(), WithIndent("", "\t"))
+	pe := p.Get()
+	if err := pe.Encode([]int{1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(pe.Bytes()); got != "[\n\t1\n]\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	p := NewPool(plainCompactFormatter())
+	pe := p.Get()
+	if got := p.Stats(); got != (PoolStats{Gets: 1, Misses: 1}) {
+		t.Errorf("after a Get: got %+v", got)
+	}
+
+	// Encoders whose buffer grew too large aren't kept.
+	pe.Encode(strings.Repeat("x", maxPooledBuffer))
+	p.Put(pe)
+	if got := p.Stats(); got.Discarded != 1 {
+		t.Errorf("after a large Put: got %+v", got)
+	}
+
+	// Hits and misses always add up to the number of Gets, whether sync.Pool
+	// keeps the PooledEncoders or not.
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				pe := p.Get()
+				pe.Encode(1)
+				p.Put(pe)
+			}
+		}()
+	}
+	wg.Wait()
+	got := p.Stats()
+	if got.Gets != 801 || got.Hits+got.Misses != got.Gets || got.Discarded != 1 {
+		t.Errorf("got %+v", got)
+	}
+	if got.Hits == 0 {
+		t.Errorf("got no hits: %+v", got)
+	}
+}
+
+func TestNewPoolNil(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	NewPool(nil)
+}
@@ -0,0 +1,50 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Sprint returns the colorized, compact JSON encoding of `v` as a string,
+// using the DefaultFormatter. It is intended for ad-hoc debugging and logging,
+// where handling an error would be noise: if `v` cannot be marshalled, the
+// returned string describes the error instead, in the style of fmt's
+// "%!verb(...)" error strings.
+func Sprint(v interface{}) string {
+	b, err := Marshal(v)
+	if err != nil {
+		return "%!(" + err.Error() + ")"
+	}
+	return string(b)
+}
+
+// Sprintf formats according to a format specifier, like fmt.Sprintf, and
+// colorizes the result as JSON using the DefaultFormatter. This allows
+// building a JSON document inline, e.g.
+//
+//	jsoncolor.Sprintf(`{"user": %q, "attempts": %d}`, name, n)
+//
+// If the formatted string is not valid JSON, it is returned uncolorized.
+func Sprintf(format string, a ...interface{}) string {
+	s := fmt.Sprintf(format, a...)
+	if !json.Valid([]byte(s)) {
+		return s
+	}
+	b, err := Colorize([]byte(s))
+	if err != nil {
+		return s
+	}
+	return string(b)
+}
+
+// Fprint writes the colorized, compact JSON encoding of `v` to `w` using the
+// DefaultFormatter, without a trailing newline. It returns the number of bytes
+// written and any marshalling or write error encountered.
+func Fprint(w io.Writer, v interface{}) (int, error) {
+	b, err := Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(b)
+}
@@ -0,0 +1,45 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSprint(t *testing.T) {
+	if got, want := stripANSI(Sprint(map[string]int{"a": 1})), `{"a":1}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := Sprint(make(chan int)); !strings.HasPrefix(got, "%!(") || !strings.Contains(got, "unsupported type") {
+		t.Errorf("unsupported value: got %q", got)
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	got := Sprintf(`{"user": %q, "attempts": %d}`, "bob", 3)
+	if want := `{"user":"bob","attempts":3}`; stripANSI(got) != want {
+		t.Errorf("got %q, want %q", stripANSI(got), want)
+	}
+	// Invalid JSON is returned as-is.
+	if got, want := Sprintf("not %s", "json"), "not json"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFprint(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Fprint(&buf, []string{"x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != buf.Len() {
+		t.Errorf("got n = %d, want %d", n, buf.Len())
+	}
+	if got, want := stripANSI(buf.String()), `["x"]`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := Fprint(&buf, make(chan int)); err == nil {
+		t.Error("unsupported value: got no error")
+	}
+}
@@ -0,0 +1,281 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSON type names used as keys in PathStats.Types, in the order they are
+// displayed by Profile.Render.
+var profileTypeNames = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// PathStats aggregates what was observed at a single path across all records
+// of a profiled NDJSON stream.
+type PathStats struct {
+	// Path identifies the location within a record using jq-style syntax,
+	// e.g. `.user.name` or `.items[].id`. Array elements are aggregated
+	// together under `[]`. The record itself is `.`.
+	Path string
+	// Records is the number of records in which the path occurred at least once.
+	Records int
+	// Count is the total number of occurrences of the path. It can exceed
+	// Records when the path lies below an array.
+	Count int
+	// Types maps JSON type names ("object", "array", "string", "number",
+	// "boolean", "null") to the number of occurrences of each.
+	Types map[string]int
+
+	lastRecord int // The most recent record counted in Records.
+}
+
+// NullRate returns the fraction of occurrences of the path whose value was null.
+func (s *PathStats) NullRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Types["null"]) / float64(s.Count)
+}
+
+// Profile is the result of scanning an NDJSON stream with ProfileNDJSON.
+type Profile struct {
+	Records int          // Number of JSON values (records) read from the stream.
+	Paths   []*PathStats // Statistics per path, in the order paths were first seen.
+
+	byPath map[string]*PathStats // Index into Paths, used while scanning.
+}
+
+// ProfileNDJSON scans a stream of JSON values (typically newline-delimited
+// JSON, one record per line) and reports, for every path seen, how often it
+// occurs, which JSON types it holds, and how often it is null.
+// Records do not need to be objects; any sequence of JSON values is accepted.
+func ProfileNDJSON(r io.Reader) (*Profile, error) {
+	p := &Profile{byPath: map[string]*PathStats{}}
+
+	dec := json.NewDecoder(r)
+	// UseNumber avoids parsing every number as float64 only to throw it away.
+	dec.UseNumber()
+
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jsoncolor: error decoding record %d: %w", p.Records+1, err)
+		}
+		p.Records++
+		if err := p.walk(dec, token, "."); err != nil {
+			return nil, fmt.Errorf("jsoncolor: error decoding record %d: %w", p.Records, err)
+		}
+	}
+
+	return p, nil
+}
+
+// ProfileNDJSONFS is like ProfileNDJSON, scanning the file `name` from the
+// file system `fsys`, such as an embed.FS holding a corpus of records.
+func ProfileNDJSONFS(fsys fs.FS, name string) (*Profile, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	p, err := ProfileNDJSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s", err, name)
+	}
+	return p, nil
+}
+
+// walk records the value starting with `token` at `path`, recursing into
+// objects and arrays by pulling further tokens from `dec`.
+func (p *Profile) walk(dec *json.Decoder, token json.Token, path string) error {
+	stats := p.stats(path)
+	stats.Count++
+
+	switch value := token.(type) {
+	case json.Delim:
+		if value == json.Delim('{') {
+			stats.Types["object"]++
+			for dec.More() {
+				// Object keys are always strings.
+				keyToken, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				valueToken, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := p.walk(dec, valueToken, profileChildPath(path, keyToken.(string))); err != nil {
+					return err
+				}
+			}
+		} else {
+			stats.Types["array"]++
+			elemPath := path + "[]"
+			for dec.More() {
+				elemToken, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := p.walk(dec, elemToken, elemPath); err != nil {
+					return err
+				}
+			}
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	case string:
+		stats.Types["string"]++
+	case json.Number:
+		stats.Types["number"]++
+	case bool:
+		stats.Types["boolean"]++
+	case nil:
+		stats.Types["null"]++
+	}
+	return nil
+}
+
+// stats returns the PathStats for `path`, creating it if this is the first
+// time it has been seen. It also counts the current record towards the path's
+// Records total the first time the path is seen within that record.
+func (p *Profile) stats(path string) *PathStats {
+	stats, ok := p.byPath[path]
+	if !ok {
+		stats = &PathStats{Path: path, Types: map[string]int{}}
+		p.byPath[path] = stats
+		p.Paths = append(p.Paths, stats)
+	}
+	if stats.lastRecord != p.Records {
+		stats.lastRecord = p.Records
+		stats.Records++
+	}
+	return stats
+}
+
+// profileChildPath appends an object key to a jq-style path, quoting keys that
+// are not plain identifiers.
+func profileChildPath(path, key string) string {
+	if isIdentifier(key) {
+		// The root path "." already ends in the separator.
+		return strings.TrimSuffix(path, ".") + "." + key
+	}
+	quoted, _ := json.Marshal(key)
+	return path + "[" + string(quoted) + "]"
+}
+
+// isIdentifier reports whether `s` can be written in a jq path without quoting.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Render writes the profile to `w` as a colorized table with one row per path,
+// using the colors of Formatter `f` (the DefaultFormatter if nil). Paths use the
+// field color, counts the number color, and each type name the color of the
+// corresponding JSON value, resolved like those of formatted output, e.g.
+// degraded to the Formatter's ColorProfile.
+func (p *Profile) Render(w io.Writer, f *Formatter) error {
+	if f == nil {
+		f = DefaultFormatter
+	}
+	colors := f.palette()
+	sprintfHeader := colors.object
+	sprintfPath := colors.field
+	sprintfNumber := colors.number
+	typeColors := map[string]func(format string, a ...interface{}) string{
+		"object":  colors.object,
+		"array":   colors.array,
+		"string":  colors.str,
+		"number":  colors.number,
+		"boolean": colors.tru,
+		"null":    colors.null,
+	}
+
+	header := []string{"PATH", "RECORDS", "COUNT", "NULL%", "TYPES"}
+
+	// Compute the plain text of each cell first, so that column widths can be
+	// measured without counting color escape sequences.
+	rows := make([][]string, 0, len(p.Paths))
+	for _, stats := range p.Paths {
+		rows = append(rows, []string{
+			stats.Path,
+			fmt.Sprintf("%d (%.1f%%)", stats.Records, percent(stats.Records, p.Records)),
+			fmt.Sprintf("%d", stats.Count),
+			fmt.Sprintf("%.1f", stats.NullRate()*100),
+		})
+	}
+	widths := make([]int, 4)
+	for i := range widths {
+		widths[i] = utf8.RuneCountInString(header[i])
+		for _, row := range rows {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+	}
+
+	// pad right-pads `s` to the width of column `i`, plus a column separator.
+	pad := func(s string, i int) string {
+		return strings.Repeat(" ", widths[i]-utf8.RuneCountInString(s)+2)
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d records\n", p.Records)
+	for i, h := range header {
+		b.WriteString(sprintfHeader("%s", h))
+		if i < len(widths) {
+			b.WriteString(pad(h, i))
+		}
+	}
+	b.WriteByte('\n')
+
+	for i, stats := range p.Paths {
+		row := rows[i]
+		b.WriteString(sprintfPath("%s", row[0]) + pad(row[0], 0))
+		b.WriteString(sprintfNumber("%s", row[1]) + pad(row[1], 1))
+		b.WriteString(sprintfNumber("%s", row[2]) + pad(row[2], 2))
+		b.WriteString(sprintfNumber("%s", row[3]) + pad(row[3], 3))
+
+		// List the observed types with their occurrence counts, e.g. "string:12 null:3".
+		first := true
+		for _, name := range profileTypeNames {
+			n := stats.Types[name]
+			if n == 0 {
+				continue
+			}
+			if !first {
+				b.WriteByte(' ')
+			}
+			first = false
+			b.WriteString(typeColors[name]("%s", name) + ":" + sprintfNumber("%d", n))
+		}
+		b.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// percent returns n as a percentage of total, or 0 if total is 0.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,134 @@
+package jsoncolor
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const profileInput = `{"id": 1, "name": "a", "tags": ["x", "y"]}
+{"id": 2, "name": null, "my key": true}
+{"id": 3, "tags": []}
+`
+
+func TestProfileNDJSON(t *testing.T) {
+	p, err := ProfileNDJSON(strings.NewReader(profileInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Records != 3 {
+		t.Errorf("got %d records, want 3", p.Records)
+	}
+
+	type stats struct {
+		Path           string
+		Records, Count int
+		Types          map[string]int
+	}
+	var got []stats
+	for _, s := range p.Paths {
+		got = append(got, stats{s.Path, s.Records, s.Count, s.Types})
+	}
+	want := []stats{
+		{".", 3, 3, map[string]int{"object": 3}},
+		{".id", 3, 3, map[string]int{"number": 3}},
+		{".name", 2, 2, map[string]int{"string": 1, "null": 1}},
+		{".tags", 2, 2, map[string]int{"array": 2}},
+		{".tags[]", 1, 2, map[string]int{"string": 2}},
+		{`.["my key"]`, 1, 1, map[string]int{"boolean": 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	if rate := p.Paths[2].NullRate(); rate != 0.5 {
+		t.Errorf("got null rate %v for .name, want 0.5", rate)
+	}
+}
+
+func TestProfileNDJSONError(t *testing.T) {
+	_, err := ProfileNDJSON(strings.NewReader("{\"a\": 1}\n{\"a\": }\n"))
+	if err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Errorf("got %v, want an error for record 2", err)
+	}
+}
+
+func TestProfileNDJSONFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"corpus/records.ndjson": {Data: []byte(profileInput)},
+		"corpus/bad.ndjson":     {Data: []byte("{\"a\": }\n")},
+	}
+	p, err := ProfileNDJSONFS(fsys, "corpus/records.ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ProfileNDJSON(strings.NewReader(profileInput))
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+
+	if _, err := ProfileNDJSONFS(fsys, "corpus/bad.ndjson"); err == nil || !strings.Contains(err.Error(), "record 1") || !strings.Contains(err.Error(), "in corpus/bad.ndjson") {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := ProfileNDJSONFS(fsys, "corpus/missing.ndjson"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v, want a missing file", err)
+	}
+}
+
+func TestProfileRender(t *testing.T) {
+	p, err := ProfileNDJSON(strings.NewReader(profileInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &strings.Builder{}
+	if err := p.Render(b, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `3 records
+PATH         RECORDS     COUNT  NULL%  TYPES
+.            3 (100.0%)  3      0.0    object:3
+.id          3 (100.0%)  3      0.0    number:3
+.name        2 (66.7%)   2      50.0   string:1 null:1
+.tags        2 (66.7%)   2      0.0    array:2
+.tags[]      1 (33.3%)   2      0.0    string:2
+.["my key"]  1 (33.3%)   1      0.0    boolean:1
+`
+	if got := stripANSI(b.String()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestProfileRenderColors(t *testing.T) {
+	p, err := ProfileNDJSON(strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &Formatter{FieldColor: Hex("#ff0000"), NumberColor: Style{FgCyan}, ObjectColor: Style{Bold}, ColorProfile: ProfileANSI}
+
+	// Without colors, the report is plain text.
+	withColor(t, false)
+	b := &strings.Builder{}
+	if err := p.Render(b, f); err != nil {
+		t.Fatal(err)
+	}
+	want := "1 records\nPATH  RECORDS     COUNT  NULL%  TYPES\n.     1 (100.0%)  1      0.0    object:1\n.a    1 (100.0%)  1      0.0    number:1\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Colors are degraded to the ColorProfile.
+	withColor(t, true)
+	b.Reset()
+	if err := p.Render(b, f); err != nil {
+		t.Fatal(err)
+	}
+	want = "1 records\n\x1b[1mPATH\x1b[0m  \x1b[1mRECORDS\x1b[0m     \x1b[1mCOUNT\x1b[0m  \x1b[1mNULL%\x1b[0m  \x1b[1mTYPES\x1b[0m\n" +
+		"\x1b[91m.\x1b[0m     \x1b[36m1 (100.0%)\x1b[0m  \x1b[36m1\x1b[0m      \x1b[36m0.0\x1b[0m    \x1b[1mobject\x1b[0m:\x1b[36m1\x1b[0m\n" +
+		"\x1b[91m.a\x1b[0m    \x1b[36m1 (100.0%)\x1b[0m  \x1b[36m1\x1b[0m      \x1b[36m0.0\x1b[0m    \x1b[36mnumber\x1b[0m:\x1b[36m1\x1b[0m\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,230 @@
+package jsoncolor
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorProfile is the set of colors a terminal can show. See
+// Formatter.ColorProfile.
+type ColorProfile int
+
+const (
+	// ProfileAuto detects the profile of the terminal from the environment.
+	// See DetectColorProfile.
+	ProfileAuto ColorProfile = iota
+	// ProfileTrueColor is 24-bit RGB colors, which are written as they are.
+	ProfileTrueColor
+	// ProfileANSI256 is the 256 colors of the xterm palette: RGB colors are
+	// converted to the closest of them.
+	ProfileANSI256
+	// ProfileANSI is the 16 standard ANSI colors: RGB colors and colors of
+	// the 256-color palette are converted to the closest of them.
+	ProfileANSI
+)
+
+// DetectColorProfile returns the color profile of the terminal, according to
+// the environment: COLORTERM=truecolor or 24bit means true colors, and
+// otherwise the profile follows TERM, e.g. "xterm-256color" for 256 colors,
+// or "xterm" or "screen" for the 16 ANSI colors. Without TERM, as in Windows
+// consoles, which don't set it, and when the output isn't meant for a
+// terminal, nothing is known to be unsupported, so ProfileTrueColor is
+// returned.
+func DetectColorProfile() ColorProfile {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ProfileTrueColor
+	}
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case term == "" || strings.Contains(term, "truecolor") || strings.Contains(term, "24bit") || strings.HasSuffix(term, "-direct"):
+		return ProfileTrueColor
+	case strings.Contains(term, "256color"):
+		return ProfileANSI256
+	}
+	return ProfileANSI
+}
+
+// resolve returns the detected profile for ProfileAuto, and the profile
+// itself otherwise.
+func (p ColorProfile) resolve() ColorProfile {
+	if p == ProfileAuto {
+		return DetectColorProfile()
+	}
+	return p
+}
+
+// degrade returns `sprintf` writing the colors of its escape sequences in the
+// profile `p`. The escape sequences are only rewritten if a sample of its
+// output uses colors beyond the profile.
+func (p ColorProfile) degrade(sprintf func(format string, a ...interface{}) string) func(format string, a ...interface{}) string {
+	if p == ProfileTrueColor {
+		return sprintf
+	}
+	sample := sprintf("%s", "x")
+	if degradeSGR(sample, p) == sample {
+		return sprintf
+	}
+	return func(format string, a ...interface{}) string {
+		return degradeSGR(sprintf(format, a...), p)
+	}
+}
+
+// degradeSGR rewrites the extended colors of the SGR escape sequences in `s`
+// to the closest colors of the profile `p`.
+func degradeSGR(s string, p ColorProfile) string {
+	if !strings.Contains(s, "8;") {
+		return s
+	}
+	sb := &strings.Builder{}
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], 'm')
+		if end < 0 {
+			break
+		}
+		end += start
+		sb.WriteString(s[:start+2])
+		sb.WriteString(degradeParams(s[start+2:end], p))
+		sb.WriteByte('m')
+		s = s[end+1:]
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+// degradeParams rewrites the extended colors in the SGR parameters `params`,
+// e.g. "1;38;2;255;0;0", to the closest colors of the profile `p`.
+func degradeParams(params string, p ColorProfile) string {
+	fields := strings.Split(params, ";")
+	out := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		if (fields[i] != "38" && fields[i] != "48") || i+1 >= len(fields) {
+			out = append(out, fields[i])
+			continue
+		}
+		background := fields[i] == "48"
+		var r, g, b, n int
+		switch {
+		case fields[i+1] == "2" && i+4 < len(fields):
+			r, _ = strconv.Atoi(fields[i+2])
+			g, _ = strconv.Atoi(fields[i+3])
+			b, _ = strconv.Atoi(fields[i+4])
+			if p == ProfileANSI256 {
+				out = append(out, fields[i], "5", strconv.Itoa(closestPaletteColor(r, g, b)))
+			} else {
+				out = append(out, ansiColorParam(closestANSIColor(r, g, b), background))
+			}
+			i += 4
+		case fields[i+1] == "5" && i+2 < len(fields):
+			n, _ = strconv.Atoi(fields[i+2])
+			switch {
+			case p == ProfileANSI256:
+				out = append(out, fields[i:i+3]...)
+			case n < 16:
+				out = append(out, ansiColorParam(n, background))
+			default:
+				r, g, b = hexRGB(paletteColor(n))
+				out = append(out, ansiColorParam(closestANSIColor(r, g, b), background))
+			}
+			i += 2
+		default:
+			out = append(out, fields[i])
+		}
+	}
+	return strings.Join(out, ";")
+}
+
+// ansiColorParam returns the SGR parameter of the ANSI color `n`, from 0 to
+// 15, as a foreground or background color.
+func ansiColorParam(n int, background bool) string {
+	base := 30
+	if n >= 8 {
+		base, n = 90, n-8
+	}
+	if background {
+		base += 10
+	}
+	return strconv.Itoa(base + n)
+}
+
+// closestANSIColor returns the ANSI color, from 0 to 15, closest to r, g, b.
+func closestANSIColor(r, g, b int) int {
+	best, bestDistance := 0, -1
+	for i, c := range ansiColors {
+		cr, cg, cb := hexRGB(c)
+		if d := colorDistance(r, g, b, cr, cg, cb); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// closestPaletteColor returns the color of the xterm 256-color palette, from
+// 16 to 255, closest to r, g, b: the closest of the 6×6×6 color cube, or of
+// the grayscale ramp.
+func closestPaletteColor(r, g, b int) int {
+	level := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	cube := 16 + 36*level(r) + 6*level(g) + level(b)
+	cr, cg, cb := hexRGB(paletteColor(cube))
+
+	gray := (r + g + b) / 3
+	grayIndex := 232 + min(max((gray-3)/10, 0), 23)
+	gr, gg, gb := hexRGB(paletteColor(grayIndex))
+
+	if colorDistance(r, g, b, gr, gg, gb) < colorDistance(r, g, b, cr, cg, cb) {
+		return grayIndex
+	}
+	return cube
+}
+
+// colorDistance returns the squared distance between two colors, weighted
+// for the sensitivity of the eye to each component.
+func colorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return 2*dr*dr + 4*dg*dg + 3*db*db
+}
+
+// hexRGB returns the components of the color `hex`, e.g. "#cd0000".
+func hexRGB(hex string) (r, g, b int) {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
+
+// ContrastRatio returns the WCAG 2 contrast ratio between the colors `fg` and
+// `bg`, e.g. "#56b4e9" and "#000000", from 1 for identical colors to 21 for
+// black on white. WCAG asks for at least 4.5 for text (level AA), and 7 for
+// enhanced contrast (level AAA).
+func ContrastRatio(fg, bg string) float64 {
+	l1, l2 := relativeLuminance(hexRGB(fg)), relativeLuminance(hexRGB(bg))
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// relativeLuminance returns the relative luminance of a color, as defined by
+// WCAG 2, from 0 for black to 1 for white.
+func relativeLuminance(r, g, b int) float64 {
+	linear := func(c int) float64 {
+		v := float64(c) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
@@ -0,0 +1,139 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDegradeSGR(t *testing.T) {
+	tests := []struct {
+		name, src, ansi256, ansi string
+	}{
+		{"RGB", "\x1b[38;2;255;0;0mx\x1b[0m", "\x1b[38;5;196mx\x1b[0m", "\x1b[91mx\x1b[0m"},
+		{"RGB background", "\x1b[1;48;2;0;0;238mx\x1b[0m", "\x1b[1;48;5;21mx\x1b[0m", "\x1b[1;44mx\x1b[0m"},
+		{"palette cube", "\x1b[38;2;95;135;175mx\x1b[0m", "\x1b[38;5;67mx\x1b[0m", "\x1b[90mx\x1b[0m"},
+		{"palette gray", "\x1b[38;2;128;128;128mx\x1b[0m", "\x1b[38;5;244mx\x1b[0m", "\x1b[90mx\x1b[0m"},
+		{"256 colors", "\x1b[38;5;196mx\x1b[0m", "\x1b[38;5;196mx\x1b[0m", "\x1b[91mx\x1b[0m"},
+		{"256 colors below 16", "\x1b[38;5;9mx\x1b[0m", "\x1b[38;5;9mx\x1b[0m", "\x1b[91mx\x1b[0m"},
+		{"ANSI", "\x1b[31mx\x1b[0m", "\x1b[31mx\x1b[0m", "\x1b[31mx\x1b[0m"},
+		{"truncated", "\x1b[38;5mx", "\x1b[38;5mx", "\x1b[38;5mx"},
+	}
+	for _, tt := range tests {
+		if got := degradeSGR(tt.src, ProfileANSI256); got != tt.ansi256 {
+			t.Errorf("%s: 256 colors: got %q, want %q", tt.name, got, tt.ansi256)
+		}
+		if got := degradeSGR(tt.src, ProfileANSI); got != tt.ansi {
+			t.Errorf("%s: ANSI: got %q, want %q", tt.name, got, tt.ansi)
+		}
+	}
+}
+
+func TestDetectColorProfile(t *testing.T) {
+	tests := []struct {
+		colorterm, term string
+		want            ColorProfile
+	}{
+		{"truecolor", "xterm", ProfileTrueColor},
+		{"24BIT", "", ProfileTrueColor},
+		{"", "", ProfileTrueColor},
+		{"", "xterm-direct", ProfileTrueColor},
+		{"", "xterm-256color", ProfileANSI256},
+		{"", "screen-256color", ProfileANSI256},
+		{"", "xterm", ProfileANSI},
+		{"", "screen", ProfileANSI},
+	}
+	for _, tt := range tests {
+		t.Setenv("COLORTERM", tt.colorterm)
+		t.Setenv("TERM", tt.term)
+		if got := DetectColorProfile(); got != tt.want {
+			t.Errorf("COLORTERM=%q TERM=%q: got %d, want %d", tt.colorterm, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestFormatterColorProfile(t *testing.T) {
+	withColor(t, true)
+	rgb := StyleFunc(func(s string) string { return "\x1b[38;2;255;0;0m" + s + "\x1b[0m" })
+	tests := []struct {
+		profile ColorProfile
+		want    string
+	}{
+		{ProfileTrueColor, "[\x1b[38;2;255;0;0m1\x1b[0m]"},
+		{ProfileANSI256, "[\x1b[38;5;196m1\x1b[0m]"},
+		{ProfileANSI, "[\x1b[91m1\x1b[0m]"},
+	}
+	for _, tt := range tests {
+		f := plainCompactFormatter()
+		f.NumberColor = rgb
+		f.ColorProfile = tt.profile
+		var sb strings.Builder
+		if err := f.Format(&sb, []byte(`[1]`)); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("profile %d: got %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+
+	// ProfileAuto follows the environment.
+	t.Setenv("COLORTERM", "")
+	t.Setenv("TERM", "xterm")
+	f := plainCompactFormatter()
+	f.NumberColor = rgb
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "[\x1b[91m1\x1b[0m]"; got != want {
+		t.Errorf("auto: got %q, want %q", got, want)
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		fg, bg string
+		want   string
+	}{
+		{"#000000", "#ffffff", "21.00"},
+		{"#ffffff", "#000000", "21.00"},
+		{"#777777", "#777777", "1.00"},
+		{"#56b4e9", "#000000", "9.10"},
+		{"#0060a0", "#ffffff", "6.60"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprintf("%.2f", ContrastRatio(tt.fg, tt.bg)); got != tt.want {
+			t.Errorf("%s on %s: got %s, want %s", tt.fg, tt.bg, got, tt.want)
+		}
+	}
+}
+
+func TestAccessibleThemes(t *testing.T) {
+	tests := []struct {
+		name, bg string
+		min      float64
+	}{
+		{"colorblind", "#000000", 4.5},
+		{"colorblind-light", "#ffffff", 4.5},
+		{"high-contrast", "#000000", 7},
+		{"high-contrast-light", "#ffffff", 7},
+	}
+	for _, tt := range tests {
+		theme, ok := LookupTheme(tt.name)
+		if !ok {
+			t.Fatalf("%s isn't registered", tt.name)
+		}
+		for _, role := range theme.roles() {
+			style, _ := (*role.color).(Style)
+			i := slices.Index(style, 38)
+			if i < 0 {
+				continue
+			}
+			fg := fmt.Sprintf("#%02x%02x%02x", int(style[i+2]), int(style[i+3]), int(style[i+4]))
+			if ratio := ContrastRatio(fg, tt.bg); ratio < tt.min {
+				t.Errorf("%s: %s %s on %s has a contrast ratio of %.2f", tt.name, role.name, fg, tt.bg, ratio)
+			}
+		}
+	}
+}
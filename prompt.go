@@ -0,0 +1,47 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"io"
+)
+
+// NeedsMoreInput reports whether `src` is the beginning of a JSON value which
+// isn't complete yet, such as `{"a": [1,` or a string missing its closing
+// quote. It's meant for prompts and REPLs reading JSON over several lines, to
+// decide whether to read a continuation line or to submit the input:
+//
+//	for jsoncolor.NeedsMoreInput(input) {
+//		input = append(input, readLine("... ")...)
+//	}
+//	jsoncolor.Echo(os.Stdout, input)
+//
+// It returns false for complete values, for input containing nothing but
+// whitespace, and for input which is already invalid, since no continuation
+// could make it valid. To avoid rescanning the whole input on every line, use
+// an IncrementalValidator instead.
+func NeedsMoreInput(src []byte) bool {
+	v := NewIncrementalValidator()
+	v.Write(src)
+	return v.NeedsMoreInput()
+}
+
+// Echo writes the JSON entered at a prompt back to `w` using the
+// DefaultFormatter. See Formatter.Echo.
+func Echo(w io.Writer, src []byte) error {
+	return DefaultFormatter.Echo(w, src)
+}
+
+// Echo validates the JSON in `src`, as entered at a prompt or in a REPL, and
+// writes it back to `w`: colorized and laid out according to the Formatter's
+// settings if it's valid, or otherwise the SyntaxReport pointing at the error,
+// which is also returned. Either way, the output ends with a newline, so that
+// the prompt can be printed again right after it.
+func (f *Formatter) Echo(w io.Writer, src []byte) error {
+	if ok, report := f.Valid(src); !ok {
+		if _, err := fmt.Fprintln(w, report.String()); err != nil {
+			return err
+		}
+		return report
+	}
+	return f.format(w, src, true)
+}
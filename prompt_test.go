@@ -0,0 +1,56 @@
+package jsoncolor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNeedsMoreInput(t *testing.T) {
+	tests := map[string]bool{
+		`{"a": [1,`: true,
+		`"abc`:      true,
+		`{"a":`:     true,
+		"[\n":       true,
+		`tr`:        true,
+		// Numbers may be continued too.
+		`-`:   true,
+		`[1.`: true,
+		`1e+`: true,
+
+		`[1]`:    false,
+		`1`:      false,
+		"  ":     false,
+		"":       false,
+		`{"a" 1`: false,
+		`[1]]`:   false,
+		`-x`:     false,
+	}
+	for src, want := range tests {
+		if got := NeedsMoreInput([]byte(src)); got != want {
+			t.Errorf("%q: got %v, want %v", src, got, want)
+		}
+	}
+}
+
+func TestEcho(t *testing.T) {
+	var sb strings.Builder
+	if err := (&Formatter{Indent: " "}).Echo(&sb, []byte("[1]")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[\n 1\n]\n"; stripANSI(sb.String()) != want {
+		t.Errorf("got %q, want %q", stripANSI(sb.String()), want)
+	}
+
+	sb.Reset()
+	err := Echo(&sb, []byte(`{"a": tru}`))
+	var report *SyntaxReport
+	if !errors.As(err, &report) {
+		t.Fatalf("got error %v, want a *SyntaxReport", err)
+	}
+	want := "jsoncolor: syntax error at line 1, column 10: invalid character '}' in literal true (expecting 'e')\n" +
+		"{\"a\": tru}\n         ^\n"
+	if got := stripANSI(sb.String()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatterPunctuationColor(t *testing.T) {
+	withColor(t, true)
+	const src = `{"a": [1]}`
+	tests := []struct {
+		name  string
+		setup func(f *Formatter)
+		want  string
+	}{
+		{"all", func(f *Formatter) {
+			f.PunctuationColor = DimPunctuation
+		}, "\x1b[2m{\x1b[0m\"a\"\x1b[2m:\x1b[0m\x1b[2m[\x1b[0m1\x1b[2m]\x1b[0m\x1b[2m}\x1b[0m"},
+		// The color of each kind of punctuation takes precedence.
+		{"own color", func(f *Formatter) {
+			f.PunctuationColor = DimPunctuation
+			f.ColonColor = Style{FgRed}
+		}, "\x1b[2m{\x1b[0m\"a\"\x1b[31m:\x1b[0m\x1b[2m[\x1b[0m1\x1b[2m]\x1b[0m\x1b[2m}\x1b[0m"},
+		// So does that of the variant for the background, whose punctuation
+		// color takes precedence over the colors of the Formatter.
+		{"variant", func(f *Formatter) {
+			f.ColonColor = Style{FgRed}
+			f.DarkColors = &Formatter{PunctuationColor: Style{Bold}, ArrayColor: Style{FgBlue}}
+			f.Background = BackgroundDark
+		}, "\x1b[1m{\x1b[0m\"a\"\x1b[1m:\x1b[0m\x1b[34m[\x1b[0m1\x1b[34m]\x1b[0m\x1b[1m}\x1b[0m"},
+	}
+	for _, tt := range tests {
+		f := &Formatter{
+			SpaceColor: Style{}, FieldQuoteColor: Style{}, FieldColor: Style{}, NumberColor: Style{},
+		}
+		tt.setup(f)
+		var sb strings.Builder
+		if err := f.Format(&sb, []byte(src)); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SetRawPassthrough enables or disables raw pass-through for the Encoder.
+// By default, the contents of json.RawMessage values (and the output of
+// json.Marshaler implementations) are re-escaped on their way through the
+// Encoder, and have their HTML characters escaped by encoding/json. When
+// enabled, their key order, number text and string escaping are preserved
+// exactly as provided, by formatting in the Formatter's PreserveExact mode.
+//
+// Since encoding/json's HTML escaping would alter the raw contents, it is
+// disabled while raw pass-through is enabled, regardless of SetEscapeHTML.
+// Raw pass-through has no effect when SetNormalize is enabled, as normalized
+// output sorts keys.
+func (enc *Encoder) SetRawPassthrough(on bool) {
+	enc.rawPassthrough = on
+}
+
+// marshalJSON returns the JSON encoding of `v`, like json.Marshal, escaping
+// HTML characters within strings only if `escapeHTML` is true.
+func marshalJSON(v interface{}, escapeHTML bool) ([]byte, error) {
+	if escapeHTML {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("jsoncolor: failed to marshal input to standard JSON: %w", err)
+		}
+		return b, nil
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("jsoncolor: failed to marshal input to standard JSON: %w", err)
+	}
+	// Unlike json.Marshal, json.Encoder terminates the value with a newline.
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
@@ -0,0 +1,43 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSetRawPassthrough(t *testing.T) {
+	v := map[string]interface{}{
+		"raw": json.RawMessage(`{"z":1.0,"a":"\u00e9<"}`),
+		"s":   "<b>",
+	}
+	encode := func(passthrough bool) string {
+		var buf bytes.Buffer
+		enc := NewEncoderWithFormatter(&buf, &Formatter{})
+		enc.SetRawPassthrough(passthrough)
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		return stripANSI(buf.String())
+	}
+
+	// encoding/json keeps the key order and numbers of raw messages, but
+	// escapes HTML characters, and the Formatter re-escapes strings.
+	if got, want := encode(false), "{\"raw\":{\"z\":1.0,\"a\":\"é\\u003c\"},\"s\":\"\\u003cb\\u003e\"}\n"; got != want {
+		t.Errorf("default: got %q, want %q", got, want)
+	}
+	if got, want := encode(true), "{\"raw\":{\"z\":1.0,\"a\":\"\\u00e9<\"},\"s\":\"<b>\"}\n"; got != want {
+		t.Errorf("pass-through: got %q, want %q", got, want)
+	}
+}
+
+func TestStreamWriterPreserveExact(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStreamWriterWithFormatter(&buf, &Formatter{PreserveExact: true})
+	if err := sw.WriteElement(json.RawMessage(`["\/<"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stripANSI(buf.String()), "[\"\\/<\"]\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
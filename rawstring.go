@@ -0,0 +1,64 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// EncodeRawString writes the pre-escaped JSON string literal `literal`,
+// including its quotes (e.g. `"café"`), as a top-level value followed by
+// a newline like Encode. The literal is written exactly as given rather than
+// being decoded and escaped again, which allows splicing strings produced by
+// another encoder into the output without double escaping. It's colorized
+// with the Formatter's RawColor, if set, and like other strings otherwise.
+// An error is returned if `literal` isn't a single valid JSON string.
+func (enc *Encoder) EncodeRawString(literal string) error {
+	f, o, terminateWithNewline := enc.settings(true)
+	fs := newFormatterStateWithOptions(f, enc.w, o)
+	if err := fs.writeRawString(literal); err != nil {
+		return err
+	}
+	if terminateWithNewline {
+		fs.printSpace("\n", true)
+	}
+	return fs.checkWrite()
+}
+
+// WriteRawString writes the pre-escaped JSON string literal `literal`,
+// including its quotes, as the next element of the current array, the value
+// of the key given to WriteKey, or a top-level value. Like
+// Encoder.EncodeRawString, the literal is written exactly as given.
+func (sw *StreamWriter) WriteRawString(literal string) error {
+	if sw.te.fs.frame().inField() {
+		return errors.New("jsoncolor: WriteRawString called where an object key is expected")
+	}
+	if err := sw.te.fs.writeRawString(literal); err != nil {
+		return err
+	}
+	if sw.te.terminate && sw.Depth() == 0 {
+		sw.te.fs.printSpace("\n", true)
+	}
+	return sw.te.fs.checkWrite()
+}
+
+// writeRawString writes the JSON string literal `literal` as the next value,
+// keeping its escaping and printing it with RawColor.
+func (fs *formatterState) writeRawString(literal string) error {
+	var s string
+	if len(literal) < 2 || literal[0] != '"' || literal[len(literal)-1] != '"' ||
+		json.Unmarshal([]byte(literal), &s) != nil {
+		return fmt.Errorf("jsoncolor: invalid raw JSON string literal %q", literal)
+	}
+	if !utf8.ValidString(literal) {
+		return fmt.Errorf("jsoncolor: raw JSON string literal %q is not valid UTF-8", literal)
+	}
+
+	fs.raw = []byte(literal[1 : len(literal)-1])
+	fs.rawString = true
+	err := fs.writeToken(s, false)
+	fs.raw = nil
+	fs.rawString = false
+	return err
+}
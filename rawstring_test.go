@@ -0,0 +1,67 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeRawString(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{RawColor: tagColor("raw"), StringColor: tagColor("str")})
+	// The escaping is kept as is, rather than normalized.
+	if err := enc.EncodeRawString(`"caf\u00e9 \/ <"`); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<raw>\"</raw><raw>caf\\u00e9 \\/ <</raw><raw>\"</raw>\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	// Without RawColor, the literal is colored like other strings.
+	buf.Reset()
+	enc = NewEncoderWithFormatter(&buf, &Formatter{StringColor: tagColor("str")})
+	enc.SetTrailingNewline(false)
+	if err := enc.EncodeRawString(`"a"`); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"<str>a</str>"`; stripANSI(buf.String()) != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncodeRawStringInvalid(t *testing.T) {
+	for _, literal := range []string{``, `"`, `a`, `"a" `, `"a\"`, `"\x"`, "\"\xff\"", `"a""b"`} {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf).EncodeRawString(literal); err == nil {
+			t.Errorf("%q: got no error", literal)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%q: wrote %q", literal, buf.String())
+		}
+	}
+}
+
+func TestStreamWriterWriteRawString(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStreamWriterWithFormatter(&buf, &Formatter{})
+	if err := sw.BeginObject(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.WriteRawString(`"x"`); err == nil {
+		t.Error("WriteRawString in key position: got no error")
+	}
+	if err := sw.WriteKey("k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.WriteRawString(`"\u0041"`); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.End(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.WriteRawString(`"top"`); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"k\":\"\\u0041\"}\n\"top\"\n"; stripANSI(buf.String()) != want {
+		t.Errorf("got %q, want %q", stripANSI(buf.String()), want)
+	}
+}
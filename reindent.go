@@ -0,0 +1,63 @@
+package jsoncolor
+
+import "bytes"
+
+// Reindent shifts the colorized fragment `colored`, such as the output of
+// Format, by `addLevels` levels of indentation, each the string `indent`, so
+// that it can be embedded within other rendered output, such as a template or
+// the view of a TUI, at a deeper level than it was rendered at. The fragment
+// isn't parsed again: each line after the first, which continues the line of
+// the enclosing output, starts with `addLevels` more indents. Lines without
+// text are left as they are.
+//
+// A negative `addLevels` removes up to as many indents from the start of each
+// line after the first, shifting the fragment back towards the left margin.
+func Reindent(colored []byte, addLevels int, indent string) []byte {
+	if addLevels == 0 || indent == "" {
+		return colored
+	}
+	added := bytes.Repeat([]byte(indent), max(addLevels, 0))
+	out := make([]byte, 0, len(colored)+bytes.Count(colored, []byte("\n"))*len(added))
+	for i, line := range bytes.Split(colored, []byte("\n")) {
+		if i == 0 {
+			out = append(out, line...)
+			continue
+		}
+		out = append(out, '\n')
+		if !lineHasText(line) {
+			out = append(out, line...)
+			continue
+		}
+		if addLevels > 0 {
+			// The indents are added in front of any escape sequences, so that
+			// they aren't styled like the first token of the line.
+			out = append(out, added...)
+			out = append(out, line...)
+			continue
+		}
+		// Escape sequences may come before and within the indentation.
+		for n := 0; n < -addLevels && len(line) > 0; {
+			if line[0] == '\x1b' {
+				_, _, size := escapeSequence(line)
+				out = append(out, line[:size]...)
+				line = line[size:]
+			} else if bytes.HasPrefix(line, []byte(indent)) {
+				line = line[len(indent):]
+				n++
+			} else {
+				break
+			}
+		}
+		out = append(out, line...)
+	}
+	return out
+}
+
+// lineHasText reports whether `line` holds anything besides escape sequences.
+func lineHasText(line []byte) bool {
+	for len(line) > 0 && line[0] == '\x1b' {
+		_, _, n := escapeSequence(line)
+		line = line[n:]
+	}
+	return len(line) > 0
+}
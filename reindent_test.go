@@ -0,0 +1,43 @@
+package jsoncolor
+
+import "testing"
+
+func TestReindent(t *testing.T) {
+	tests := []struct {
+		name, src string
+		levels    int
+		indent    string
+		want      string
+	}{
+		{"add", "{\n  \"a\": 1\n}", 1, "  ", "{\n    \"a\": 1\n  }"},
+		{"add two", "[\n\t1\n]", 2, "\t", "[\n\t\t\t1\n\t\t]"},
+		{"remove", "{\n    \"a\": 1\n  }", -1, "  ", "{\n  \"a\": 1\n}"},
+		// No more indents than the line has are removed.
+		{"remove too many", "[\n  1\n]", -3, "  ", "[\n1\n]"},
+		{"zero", "[\n  1\n]", 0, "  ", "[\n  1\n]"},
+		{"no indent", "[\n  1\n]", 1, "", "[\n  1\n]"},
+		{"single line", "1", 1, "  ", "1"},
+		// Lines without text are left alone.
+		{"blank line", "[\n  1,\n\n  2\n]", 1, "  ", "[\n    1,\n\n    2\n  ]"},
+		{"escape only", "1\n\x1b[0m", 1, "  ", "1\n\x1b[0m"},
+		// Indents are added before escape sequences, and removed from around
+		// them.
+		{"add colored", "[\n\x1b[2m  \x1b[0m\x1b[96m1\x1b[0m\n]", 1, "  ", "[\n  \x1b[2m  \x1b[0m\x1b[96m1\x1b[0m\n  ]"},
+		{"remove colored", "[\n\x1b[2m    \x1b[0m\x1b[96m1\x1b[0m\n  ]", -1, "  ", "[\n\x1b[2m  \x1b[0m\x1b[96m1\x1b[0m\n]"},
+	}
+	for _, tt := range tests {
+		if got := string(Reindent([]byte(tt.src), tt.levels, tt.indent)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReindentFormatted(t *testing.T) {
+	// A fragment formatted at the top level matches one formatted nested.
+	f := newPlainFormatter()
+	got := formatPlain(t, f, `{"a": [1]}`)
+	want := formatPlain(t, f, `[{"a": [1]}]`)
+	if nested := "[\n  " + string(Reindent([]byte(got), 1, f.Indent)) + "\n]"; nested != want {
+		t.Errorf("got:\n%s\nwant:\n%s", nested, want)
+	}
+}
@@ -0,0 +1,129 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+)
+
+// RenderedLine is a line of formatted output, as returned by FormatLinesOf.
+type RenderedLine struct {
+	// Text is the colorized text of the line, without its newline. Its
+	// colors are self-contained: those in effect at the start of the line are
+	// set again, and reset at its end, so lines can be shown apart or in any
+	// order.
+	Text string
+	// Plain is the text of the line without colors.
+	Plain string
+	// Depth is the nesting level of the line: the number of objects and
+	// arrays enclosing its first token. The closing delimiter of a container
+	// is at the depth of its opening one.
+	Depth int
+	// Path is the JSON Pointer (RFC 6901) of the first token of the line, as
+	// for Segment.Path, or of the enclosing container for lines without
+	// tokens, such as blank lines.
+	Path string
+	// Foldable is true if the line opens an object or array which is closed
+	// on a later line, so that the lines in between can be folded away.
+	Foldable bool
+	// FoldEnd is the index of the line closing the container opened by a
+	// Foldable line, and 0 otherwise.
+	FoldEnd int
+}
+
+// FormatLinesOf formats `src` with the Formatter `f` (the DefaultFormatter if
+// nil) like Format, and returns the output as lines along with their depth,
+// path and foldability, for pagers, TUIs and editors working on the output
+// line by line. The lines are never wrapped by WrapWidth, nor preceded by a
+// Header, and the output has no trailing newline, whatever the
+// TrailingNewline of the Formatter.
+func FormatLinesOf(src []byte, f *Formatter) ([]RenderedLine, error) {
+	if f == nil {
+		f = DefaultFormatter
+	}
+	g := f.clone()
+	g.WrapWidth = 0
+	g.TrailingNewline = NewlineNever
+
+	// The metadata of the lines is gathered from the segments.
+	lines := []RenderedLine{{}}
+	var plain strings.Builder
+	var opened []int // Indexes of the lines opening the containers open.
+	started := false // True once the current line has a token.
+	err := g.FormatSegments(src, func(s Segment) {
+		for i, text := range strings.Split(s.Text, "\n") {
+			if i > 0 {
+				lines[len(lines)-1].Plain = plain.String()
+				plain.Reset()
+				lines = append(lines, RenderedLine{})
+				started = false
+			}
+			plain.WriteString(text)
+			if s.Kind == TokenWhitespace || text == "" {
+				continue
+			}
+			line := &lines[len(lines)-1]
+			closing := (s.Kind == TokenObjectDelim || s.Kind == TokenArrayDelim) && (text == "}" || text == "]")
+			if !started {
+				started = true
+				line.Depth, line.Path = len(opened), s.Path
+				if closing {
+					line.Depth--
+				}
+			}
+			switch {
+			case closing:
+				start := opened[len(opened)-1]
+				opened = opened[:len(opened)-1]
+				if start != len(lines)-1 {
+					lines[start].Foldable, lines[start].FoldEnd = true, len(lines)-1
+				}
+			case s.Kind == TokenObjectDelim || s.Kind == TokenArrayDelim:
+				opened = append(opened, len(lines)-1)
+			}
+		}
+		if !started {
+			// Lines without tokens belong to the enclosing container.
+			line := &lines[len(lines)-1]
+			line.Depth = len(opened)
+			if s.Kind == TokenWhitespace {
+				line.Path = s.Path
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	lines[len(lines)-1].Plain = plain.String()
+
+	// The colorized text is split into the same lines.
+	buf := &bytes.Buffer{}
+	if err := g.format(buf, src, false); err != nil {
+		return nil, err
+	}
+	var state sgrState
+	for i, text := range strings.Split(buf.String(), "\n") {
+		if i >= len(lines) {
+			break
+		}
+		var sb strings.Builder
+		if style := state.style(); len(style) > 0 {
+			sb.WriteString(style.sequence())
+		}
+		sb.WriteString(text)
+		for j := 0; j < len(text); j++ {
+			if text[j] != '\x1b' {
+				continue
+			}
+			params, final, n := escapeSequence([]byte(text[j:]))
+			if final == 'm' {
+				state.apply(params)
+			}
+			j += n - 1
+		}
+		if len(state.style()) > 0 {
+			sb.WriteString("\x1b[0m")
+		}
+		lines[i].Text = sb.String()
+	}
+	return lines, nil
+}
@@ -0,0 +1,76 @@
+package jsoncolor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatLinesOf(t *testing.T) {
+	f := newPlainFormatter()
+	f.PreserveBlankLines = true
+	got, err := FormatLinesOf([]byte("{\"a\": [1, 2],\n\n \"b\": {}, \"c\": [\"x\"]}"), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := func(text string, depth int, path string, foldEnd int) RenderedLine {
+		return RenderedLine{Text: text, Plain: text, Depth: depth, Path: path, Foldable: foldEnd > 0, FoldEnd: foldEnd}
+	}
+	want := []RenderedLine{
+		line("{", 0, "", 10),
+		line(`  "a": [`, 1, "/a", 4),
+		line("    1,", 2, "/a/0", 0),
+		line("    2", 2, "/a/1", 0),
+		// Closing delimiters are at the depth of the opening ones.
+		line("  ],", 1, "/a", 0),
+		line("", 1, "", 0),
+		// Empty containers can't be folded.
+		line(`  "b": {},`, 1, "/b", 0),
+		line(`  "c": [`, 1, "/c", 9),
+		line(`    "x"`, 2, "/c/0", 0),
+		line("  ]", 1, "/c", 0),
+		line("}", 0, "", 0),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%#v\nwant:\n%#v", got, want)
+	}
+
+	// The trailing newline is left out.
+	f.TrailingNewline = NewlineAlways
+	got, err = FormatLinesOf([]byte(`1`), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []RenderedLine{line("1", 0, "", 0)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestFormatLinesOfColors(t *testing.T) {
+	withColor(t, true)
+	f := newPlainFormatter()
+	f.Syntax = SyntaxJSONC
+	f.CommentColor = Style{Faint}
+	got, err := FormatLinesOf([]byte("[/* a\nb */ 1]"), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The colors of a comment spanning lines are reset at the end of each,
+	// and set again at the start of the next.
+	var texts []string
+	for _, l := range got {
+		texts = append(texts, l.Text)
+	}
+	want := []string{"[ \x1b[2m/* a\x1b[0m", "\x1b[2mb */\x1b[0m", "  1", "]"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("got %q, want %q", texts, want)
+	}
+	if got[1].Plain != "b */" {
+		t.Errorf("got plain text %q", got[1].Plain)
+	}
+}
+
+func TestFormatLinesOfInvalid(t *testing.T) {
+	if lines, err := FormatLinesOf([]byte(`[1`), nil); err == nil || lines != nil {
+		t.Errorf("got %v, %v", lines, err)
+	}
+}
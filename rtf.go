@@ -0,0 +1,159 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// RTFOptions configures FormatRTF.
+type RTFOptions struct {
+	// FontName is the name of the font of the text, which should be monospace
+	// for the indentation to line up. Default is "Courier New".
+	FontName string
+	// FontSize is the size of the text in points. Default is 10.
+	FontSize float64
+}
+
+// FormatRTF formats `src` as an RTF document using the DefaultFormatter. See
+// Formatter.FormatRTF.
+func FormatRTF(dst io.Writer, src []byte, opts RTFOptions) error {
+	return DefaultFormatter.FormatRTF(dst, src, opts)
+}
+
+// FormatRTF formats `src` like Format, and writes the output to `dst` as an RTF
+// document in the Formatter's colors, which word processors and mail clients
+// keep the colors of when it's pasted, e.g. to include API payloads in
+// incident reports. Text attributes with an RTF equivalent are kept too,
+// faint text is written in a lighter color, and backgrounds are written as
+// highlights.
+//
+// As with FormatHTML, the colors are read from the escape sequences the
+// Formatter's colors produce, so the text has no colors if they are disabled.
+// Nothing is written if `src` is invalid.
+func (f *Formatter) FormatRTF(dst io.Writer, src []byte, opts RTFOptions) error {
+	if opts.FontName == "" {
+		opts.FontName = "Courier New"
+	}
+	if opts.FontSize <= 0 {
+		opts.FontSize = 10
+	}
+
+	// The color table is built as colors are found. Its entry 0 is the
+	// default color of the reader.
+	var colorTable []string
+	colorIndex := func(color string) int {
+		if !strings.HasPrefix(color, "#") || len(color) != 7 {
+			return 0
+		}
+		for i, c := range colorTable {
+			if c == color {
+				return i + 1
+			}
+		}
+		colorTable = append(colorTable, color)
+		return len(colorTable)
+	}
+	controls := make(map[string]string)
+	body := &strings.Builder{}
+	err := f.formatMemberSegments(src, func(s memberSegment) {
+		control := ""
+		if s.Kind != TokenWhitespace {
+			key := f.segmentColorKey(s)
+			var ok bool
+			if control, ok = controls[key]; !ok {
+				control = rtfControls(sgrStyle(f.segmentColor(s).SprintfFunc()("%s", "x")), colorIndex)
+				controls[key] = control
+			}
+		}
+		if control == "" {
+			rtfEscape(body, s.Text)
+			return
+		}
+		body.WriteString("{" + control + " ")
+		rtfEscape(body, s.Text)
+		body.WriteString("}")
+	})
+	if err != nil {
+		return err
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(`{\rtf1\ansi\deff0{\fonttbl{\f0\fmodern `)
+	rtfEscape(sb, opts.FontName)
+	sb.WriteString(";}}\n{\\colortbl;")
+	for _, c := range colorTable {
+		r, _ := strconv.ParseUint(c[1:3], 16, 8)
+		g, _ := strconv.ParseUint(c[3:5], 16, 8)
+		b, _ := strconv.ParseUint(c[5:7], 16, 8)
+		fmt.Fprintf(sb, `\red%d\green%d\blue%d;`, r, g, b)
+	}
+	// Font sizes are in half-points.
+	fmt.Fprintf(sb, "}\n\\f0\\fs%d ", int(opts.FontSize*2+0.5))
+	sb.WriteString(body.String())
+	sb.WriteString("}\n")
+	_, err = io.WriteString(dst, sb.String())
+	return err
+}
+
+// rtfControls returns the RTF control words setting the style `c`, with colors
+// numbered by `colorIndex`.
+func rtfControls(c cssStyle, colorIndex func(string) int) string {
+	color, background := c.color, c.background
+	if c.reverse {
+		color, background = background, color
+	}
+	if c.faint {
+		color = fadedColor(color)
+	}
+	sb := &strings.Builder{}
+	if n := colorIndex(color); n > 0 {
+		fmt.Fprintf(sb, `\cf%d`, n)
+	}
+	if n := colorIndex(background); n > 0 {
+		fmt.Fprintf(sb, `\highlight%d`, n)
+	}
+	if c.bold {
+		sb.WriteString(`\b`)
+	}
+	if c.italic {
+		sb.WriteString(`\i`)
+	}
+	if c.underline {
+		sb.WriteString(`\ul`)
+	}
+	if c.crossed {
+		sb.WriteString(`\strike`)
+	}
+	return sb.String()
+}
+
+// rtfEscape writes `s` to `sb` as RTF text: special characters are escaped,
+// newlines and tabs are written as control words, and characters other than
+// ASCII as \u control words, with a "?" for readers not supporting them.
+func rtfEscape(sb *strings.Builder, s string) {
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString("\\line\n")
+		case r == '\t':
+			sb.WriteString(`\tab `)
+		case r < 0x20:
+			fmt.Fprintf(sb, `\'%02x`, r)
+		case r < 0x80:
+			sb.WriteRune(r)
+		default:
+			// \u takes a signed 16-bit number, so characters beyond the BMP
+			// are written as surrogate pairs.
+			units := utf16.Encode([]rune{r})
+			for _, u := range units {
+				fmt.Fprintf(sb, `\u%d?`, int16(u))
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatRTF(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	f.NullColor = Style{FgRed, BgYellow, Underline}
+	var sb strings.Builder
+	if err := f.FormatRTF(&sb, []byte(`{"a{b}": ["é😀\\", 1, true, null]}`), RTFOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Colors are numbered in the order they're found, and backgrounds are highlights.
+	want := `{\rtf1\ansi\deff0{\fonttbl{\f0\fmodern Courier New;}}
+{\colortbl;\red0\green0\blue238;\red0\green205\blue0;\red0\green255\blue255;\red205\green0\blue0;\red205\green205\blue0;}
+\f0\fs20 \{\line
+  {\cf1\b "a\{b\}"}: [\line
+    {\cf2 "\u233?\u-10179?\u-8704?\\\\"},\line
+    {\cf3 1},\line
+    {\i true},\line
+    {\cf4\highlight5\ul null}\line
+  ]\line
+\}}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatRTFOptions(t *testing.T) {
+	var sb strings.Builder
+	if err := newPlainFormatter().FormatRTF(&sb, []byte(`[1]`), RTFOptions{FontName: "Fira {Code}", FontSize: 11.5}); err != nil {
+		t.Fatal(err)
+	}
+	// Without colors, the color table is empty.
+	want := `{\rtf1\ansi\deff0{\fonttbl{\f0\fmodern Fira \{Code\};}}
+{\colortbl;}
+\f0\fs23 [\line
+  1\line
+]}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	sb.Reset()
+	if err := FormatRTF(&sb, []byte(`[1,]`), RTFOptions{}); err == nil || sb.Len() != 0 {
+		t.Errorf("invalid input: got %v, %q", err, sb.String())
+	}
+}
+
+func TestRTFEscape(t *testing.T) {
+	var sb strings.Builder
+	rtfEscape(&sb, "a\tb\x01{}\\é")
+	if want := `a\tab b\'01\{\}\\\u233?`; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestFormatRTFFaint(t *testing.T) {
+	withColor(t, true)
+	f := plainCompactFormatter()
+	f.NullColor = Style{Faint, FgBlue}
+	f.TrueColor = Style{CrossedOut, FgRed}
+	var sb strings.Builder
+	if err := f.FormatRTF(&sb, []byte(`[true, null]`), RTFOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{\rtf1\ansi\deff0{\fonttbl{\f0\fmodern Courier New;}}
+{\colortbl;\red205\green0\blue0;\red102\green102\blue245;}
+\f0\fs20 [{\cf1\strike true},{\cf2 null}]}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,196 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TokenKind identifies the kind of a piece of formatted JSON output. It's the
+// vocabulary shared by everything handling output piece by piece: segments
+// (FormatSegments), spans (ParseColored), renderer backends (Formatter.Backend)
+// and the exporters to other formats.
+//
+// The kinds are stable: the value and name (see TokenKind.String) of each
+// kind never change, and new kinds are only ever added after the existing
+// ones, so values stored or exchanged by third-party code keep their meaning
+// across versions. Code switching on kinds should handle unknown ones, for
+// instance by leaving them unstyled.
+type TokenKind int
+
+const (
+	// TokenKey is an object key, including its quotes.
+	TokenKey TokenKind = iota
+	// TokenString is a string value, including its quotes.
+	TokenString
+	// TokenNumber is a number.
+	TokenNumber
+	// TokenBool is true or false.
+	TokenBool
+	// TokenNull is null.
+	TokenNull
+	// TokenObjectDelim is an object delimiter, { or }.
+	TokenObjectDelim
+	// TokenArrayDelim is an array delimiter, [ or ].
+	TokenArrayDelim
+	// TokenColon is the colon between an object key and its value.
+	TokenColon
+	// TokenComma is the comma between the members of an object or array.
+	TokenComma
+	// TokenWhitespace is whitespace: newlines, indentation (including the
+	// prefix and any indent guides), and spacing around punctuation.
+	TokenWhitespace
+	// TokenComment is a comment, including its delimiters, in input with a
+	// Syntax allowing them.
+	TokenComment
+	// TokenAnnotation is a comment added to input converted to JSON, such as
+	// the CBOR tag or the contents of a byte string, including its delimiters.
+	// It's colorized with CommentColor.
+	TokenAnnotation
+)
+
+// TokenKinds returns all the kinds of tokens, in order of value.
+func TokenKinds() []TokenKind {
+	kinds := make([]TokenKind, 0, TokenAnnotation+1)
+	for kind := TokenKey; kind <= TokenAnnotation; kind++ {
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// ParseTokenKind returns the kind of tokens named `name`, as returned by
+// TokenKind.String, e.g. "object-delim".
+func ParseTokenKind(name string) (TokenKind, error) {
+	for _, kind := range TokenKinds() {
+		if kind.String() == name {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("jsoncolor: unknown token kind %q", name)
+}
+
+// String returns the name of the kind, e.g. "key" or "object-delim".
+func (k TokenKind) String() string {
+	switch k {
+	case TokenKey:
+		return "key"
+	case TokenString:
+		return "string"
+	case TokenNumber:
+		return "number"
+	case TokenBool:
+		return "bool"
+	case TokenNull:
+		return "null"
+	case TokenObjectDelim:
+		return "object-delim"
+	case TokenArrayDelim:
+		return "array-delim"
+	case TokenColon:
+		return "colon"
+	case TokenComma:
+		return "comma"
+	case TokenWhitespace:
+		return "whitespace"
+	case TokenComment:
+		return "comment"
+	case TokenAnnotation:
+		return "annotation"
+	}
+	return "unknown"
+}
+
+// Segment is a piece of formatted output, without colors. See Formatter.FormatSegments.
+type Segment struct {
+	// Text is the text of the segment, exactly as Format would write it
+	// without colors.
+	Text string
+	// Kind is the kind of the text.
+	Kind TokenKind
+	// Path is the JSON Pointer (RFC 6901) of the value the segment belongs to,
+	// e.g. "/items/0" ("" for the top-level value). Keys belong to the value of
+	// their member, and delimiters to their container. Punctuation and
+	// whitespace belong to the enclosing container.
+	Path string
+}
+
+// memberSegment is a Segment along with the key of the object member it's the
+// key or value of, if any, which renderers need for Formatter.KeyColors and
+// ValueColors.
+type memberSegment struct {
+	Segment
+	key    string // The key of the member, if member is set.
+	member bool   // True for the key and value of an object member.
+}
+
+// Segments returns the output of formatting `src` with the DefaultFormatter as
+// segments. See Formatter.FormatSegments.
+func Segments(src []byte) ([]Segment, error) {
+	return DefaultFormatter.Segments(src)
+}
+
+// Segments returns the output of formatting `src` as segments, collected from
+// FormatSegments.
+func (f *Formatter) Segments(src []byte) ([]Segment, error) {
+	var segments []Segment
+	err := f.FormatSegments(src, func(s Segment) {
+		segments = append(segments, s)
+	})
+	return segments, err
+}
+
+// FormatSegments formats `src` like Format, but instead of writing colorized
+// text, it passes the output to `emit` as a sequence of segments, each holding
+// a piece of text along with its kind and the path of its value. This allows
+// GUIs, TUIs and web frontends to apply their own styling without parsing ANSI
+// escape sequences back out. Concatenating the text of all segments gives the
+// output of Format without colors.
+//
+// Keys and strings are emitted as single segments including their quotes.
+// In Skeleton mode, the placeholder of a value has the kind of the value.
+func (f *Formatter) FormatSegments(src []byte, emit func(Segment)) error {
+	return f.formatMemberSegments(src, func(s memberSegment) { emit(s.Segment) })
+}
+
+// formatMemberSegments is FormatSegments, with the keys of object members.
+func (f *Formatter) formatMemberSegments(src []byte, emit func(memberSegment)) error {
+	fs := newFormatterState(f, nil)
+	fs.trackPaths = true
+	fs.onSegment = emit
+	return fs.format(nil, src, f.TrailingNewline.resolve(false))
+}
+
+// emitSegment passes a segment of the given kind to onSegment.
+func (fs *formatterState) emitSegment(kind TokenKind, text string) {
+	path := fs.path
+	switch kind {
+	case TokenColon, TokenComma, TokenWhitespace:
+		path = fs.frame().path
+	}
+	s := memberSegment{Segment: Segment{Text: text, Kind: kind, Path: path}}
+	switch kind {
+	case TokenKey, TokenString, TokenNumber, TokenBool, TokenNull:
+		if current := fs.frame(); current.inObject() {
+			s.key, s.member = current.key, true
+		}
+	}
+	fs.onSegment(s)
+}
+
+// valueKind returns the TokenKind of the value token `t`.
+func valueKind(t json.Token) TokenKind {
+	switch value := t.(type) {
+	case string:
+		return TokenString
+	case json.Number, float64:
+		return TokenNumber
+	case bool:
+		return TokenBool
+	case json.Delim:
+		if value == json.Delim('[') || value == json.Delim(']') {
+			return TokenArrayDelim
+		}
+		return TokenObjectDelim
+	default:
+		return TokenNull
+	}
+}
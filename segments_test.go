@@ -0,0 +1,167 @@
+package jsoncolor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	got, err := newPlainFormatter().Segments([]byte(`[1,"x",true,null,{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := func(text, path string) Segment { return Segment{text, TokenWhitespace, path} }
+	want := []Segment{
+		{"[", TokenArrayDelim, ""}, ws("\n", ""), ws("  ", ""),
+		{"1", TokenNumber, "/0"}, {",", TokenComma, ""}, ws("\n", ""), ws("  ", ""),
+		{`"x"`, TokenString, "/1"}, {",", TokenComma, ""}, ws("\n", ""), ws("  ", ""),
+		{"true", TokenBool, "/2"}, {",", TokenComma, ""}, ws("\n", ""), ws("  ", ""),
+		{"null", TokenNull, "/3"}, {",", TokenComma, ""}, ws("\n", ""), ws("  ", ""),
+		{"{", TokenObjectDelim, "/4"}, {"}", TokenObjectDelim, "/4"}, ws("\n", ""),
+		{"]", TokenArrayDelim, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestSegmentsMatchFormat(t *testing.T) {
+	const src = `{"a":[1,"x\n",true,null],"b":{"c":-2.5e3}}`
+	for _, f := range []*Formatter{
+		{},
+		{Indent: "\t", Prefix: "> ", TrailingNewline: NewlineAlways},
+		{Indent: "  ", IndentGuides: true},
+		{ColonSpacing: SpacingBoth, CommaSpacing: SpacingAfter},
+		{Indent: "  ", Skeleton: true},
+	} {
+		segments, err := f.Segments([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var text strings.Builder
+		for _, s := range segments {
+			text.WriteString(s.Text)
+		}
+		if want := formatPlain(t, f, src); text.String() != want {
+			t.Errorf("%+v: got %q, want %q", f, text.String(), want)
+		}
+	}
+}
+
+func TestSegmentsPaths(t *testing.T) {
+	segments, err := Segments([]byte(`{"a/b":{"c~":[0]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range segments {
+		if s.Kind == TokenNumber {
+			got = append(got, s.Path)
+		}
+	}
+	if want := []string{"/a~1b/c~0/0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSegmentsSkeleton(t *testing.T) {
+	segments, err := (&Formatter{Skeleton: true}).Segments([]byte(`[1,"x",false,null]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []TokenKind
+	for _, s := range segments {
+		if s.Text == skeletonPlaceholder {
+			got = append(got, s.Kind)
+		}
+	}
+	if want := []TokenKind{TokenNumber, TokenString, TokenBool, TokenNull}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSegmentsError(t *testing.T) {
+	err := DefaultFormatter.FormatSegments([]byte(`[1,`), func(Segment) {})
+	if err == nil {
+		t.Fatal("expected an error for truncated input")
+	}
+}
+
+func TestTokenKindString(t *testing.T) {
+	if got := TokenObjectDelim.String(); got != "object-delim" {
+		t.Errorf("got %q", got)
+	}
+	if got := TokenKind(-1).String(); got != "unknown" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSegmentsKeys(t *testing.T) {
+	segments, err := Segments([]byte(`{"k":"v","o":{"n":["s"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Segment
+	for _, s := range segments {
+		if s.Kind == TokenKey || s.Kind == TokenString {
+			got = append(got, s)
+		}
+	}
+	want := []Segment{
+		{`"k"`, TokenKey, "/k"}, {`"v"`, TokenString, "/k"},
+		{`"o"`, TokenKey, "/o"}, {`"n"`, TokenKey, "/o/n"}, {`"s"`, TokenString, "/o/n/0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTokenKindsStable(t *testing.T) {
+	// The values and names of the kinds never change.
+	names := []string{"key", "string", "number", "bool", "null", "object-delim", "array-delim", "colon", "comma", "whitespace", "comment", "annotation"}
+	kinds := TokenKinds()
+	if len(kinds) != len(names) {
+		t.Fatalf("got %d kinds, want %d", len(kinds), len(names))
+	}
+	for i, kind := range kinds {
+		if int(kind) != i || kind.String() != names[i] {
+			t.Errorf("kind %d: got %d %q, want %d %q", i, kind, kind, i, names[i])
+		}
+		parsed, err := ParseTokenKind(names[i])
+		if err != nil || parsed != kind {
+			t.Errorf("ParseTokenKind(%q) = %v, %v", names[i], parsed, err)
+		}
+	}
+	if _, err := ParseTokenKind("unknown"); err == nil {
+		t.Error("ParseTokenKind(\"unknown\"): got no error")
+	}
+}
+
+func TestTokenAnnotation(t *testing.T) {
+	// Annotations of converted input are told apart from comments.
+	var got []Segment
+	f := plainCompactFormatter()
+	f.Backend = RendererFunc(func(dst []byte, kind TokenKind, text string) []byte {
+		if kind == TokenAnnotation || kind == TokenComment {
+			got = append(got, Segment{Text: text, Kind: kind})
+		}
+		return append(dst, text...)
+	})
+	var sb strings.Builder
+	if err := f.FormatMsgPack(&sb, []byte{0xd4, 0x05, 0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Segment{{Text: "/* ext 5: h'01' */", Kind: TokenAnnotation}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MessagePack: got %v, want %v", got, want)
+	}
+
+	got = nil
+	f.Syntax = SyntaxJSONC
+	if err := f.Format(&sb, []byte("[1 /* c */]")); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Segment{{Text: "/* c */", Kind: TokenComment}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONC: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package jsoncolor
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Position is a location in formatted output.
+type Position struct {
+	// Line is the 1-based line number.
+	Line int
+	// Column is the 1-based column within the line, counted in characters as
+	// displayed, i.e. in runes, not counting ANSI escape sequences.
+	Column int
+	// Offset is the 0-based byte offset in the output, including any ANSI
+	// escape sequences. It's the offset of the first byte written for the
+	// value, which may be the escape sequence setting its color.
+	Offset int
+}
+
+// SourceMap maps the JSON Pointer (RFC 6901) of each value in a document, e.g.
+// "/items/0" ("" for the top-level value), to its position in formatted output.
+type SourceMap map[string]Position
+
+// FormatSourceMap formats `src` like Format, using the DefaultFormatter, and
+// returns its source map. See Formatter.FormatSourceMap.
+func FormatSourceMap(dst io.Writer, src []byte) (SourceMap, error) {
+	return DefaultFormatter.FormatSourceMap(dst, src)
+}
+
+// FormatSourceMap formats `src` like Format, and additionally returns the
+// position in the output of every value in the document, keyed by its JSON
+// Pointer. This allows editor plugins and viewers built on this package to
+// implement jump-to-path, or to find the value under the cursor. The positions
+// of values are those of their first character, e.g. the opening quote of a
+// string or the opening brace of an object; keys are not mapped.
+//
+// If `src` holds several top-level values, the positions of the first are
+// kept. On error, the source map covers the values written so far.
+func (f *Formatter) FormatSourceMap(dst io.Writer, src []byte) (SourceMap, error) {
+	sm := SourceMap{}
+	cw := &positionWriter{w: dst, pos: Position{Line: 1, Column: 1}}
+	fs := newFormatterState(f, cw)
+	fs.trackPaths = true
+	fs.onValue = func(path string) {
+		if _, ok := sm[path]; !ok {
+			sm[path] = cw.pos
+		}
+	}
+	err := fs.format(cw, src, f.TrailingNewline.resolve(false))
+	return sm, err
+}
+
+// positionWriter is an io.Writer that keeps track of the position in the
+// output written through it.
+type positionWriter struct {
+	w   io.Writer
+	pos Position // Position of the next byte to be written.
+	// escape is true within an ANSI escape sequence (ESC '[' ... final byte),
+	// whose bytes don't take up columns.
+	escape bool
+}
+
+// Write writes `p` to the underlying writer and advances the position by the
+// number of bytes written.
+func (pw *positionWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	for _, c := range p[:n] {
+		pw.pos.Offset++
+		switch {
+		case pw.escape:
+			// Parameter and intermediate bytes lie below 0x40; the final byte ends the sequence.
+			if c >= 0x40 && c != '[' {
+				pw.escape = false
+			}
+		case c == 0x1b:
+			pw.escape = true
+		case c == '\n':
+			pw.pos.Line++
+			pw.pos.Column = 1
+		case utf8.RuneStart(c):
+			pw.pos.Column++
+		}
+	}
+	return n, err
+}
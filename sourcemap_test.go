@@ -0,0 +1,53 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFormatSourceMap(t *testing.T) {
+	f := newPlainFormatter()
+	var buf bytes.Buffer
+	sm, err := f.FormatSourceMap(&buf, []byte(`{"a":[1,{"é":"x"}],"b/c":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// {
+	//   "a": [
+	//     1,
+	//     {
+	//       "é": "x"
+	//     }
+	//   ],
+	//   "b/c": null
+	// }
+	want := SourceMap{
+		"":       {Line: 1, Column: 1, Offset: 0},
+		"/a":     {Line: 2, Column: 8, Offset: 9},
+		"/a/0":   {Line: 3, Column: 5, Offset: 15},
+		"/a/1":   {Line: 4, Column: 5, Offset: 22},
+		"/a/1/é": {Line: 5, Column: 12, Offset: 36},
+		"/b~1c":  {Line: 8, Column: 10, Offset: 60},
+	}
+	if !reflect.DeepEqual(sm, want) {
+		t.Errorf("got %v\nwant %v", sm, want)
+	}
+	// Each offset is that of the first character of the value.
+	firstChars := map[string]byte{"": '{', "/a": '[', "/a/0": '1', "/a/1": '{', "/a/1/é": '"', "/b~1c": 'n'}
+	for path, pos := range sm {
+		if c := buf.Bytes()[pos.Offset]; c != firstChars[path] {
+			t.Errorf("%q: got %q at offset %d", path, c, pos.Offset)
+		}
+	}
+}
+
+func TestPositionWriter(t *testing.T) {
+	var buf bytes.Buffer
+	pw := &positionWriter{w: &buf, pos: Position{Line: 1, Column: 1}}
+	pw.Write([]byte("ab\n\x1b[1;31mé"))
+	pw.Write([]byte("\x1b[0m"))
+	if want := (Position{Line: 2, Column: 2, Offset: 16}); pw.pos != want {
+		t.Errorf("got %+v, want %+v", pw.pos, want)
+	}
+}
@@ -0,0 +1,120 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"iter"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Split returns an iterator over the members of the top-level object in
+// `src`, or the elements of the top-level array, yielding the key of each
+// member, or the index of each element, along with its raw JSON value, a
+// subslice of `src`. A top-level value other than an object or array is
+// yielded whole, with an empty key. This allows exploding giant exports into
+// pieces which can each be colorized, or written to their own file with
+// FormatSplit.
+//
+// Iteration stops at the first syntax error, so `src` should be validated
+// first, e.g. with Valid, if it may be invalid.
+func Split(src []byte) iter.Seq2[string, []byte] {
+	return func(yield func(string, []byte) bool) {
+		dec := json.NewDecoder(bytes.NewReader(src))
+		value := func() []byte {
+			var raw json.RawMessage
+			if dec.Decode(&raw) != nil {
+				return nil
+			}
+			// Slice the input rather than keeping the copy of the decoder.
+			end := dec.InputOffset()
+			return src[end-int64(len(raw)) : end]
+		}
+
+		start := dec.InputOffset()
+		token, err := dec.Token()
+		if err != nil {
+			return
+		}
+		delim, ok := token.(json.Delim)
+		if !ok {
+			end := dec.InputOffset()
+			yield("", bytes.TrimSpace(src[start:end]))
+			return
+		}
+		for i := 0; dec.More(); i++ {
+			key := strconv.Itoa(i)
+			if delim == '{' {
+				token, err := dec.Token()
+				if err != nil {
+					return
+				}
+				key = token.(string)
+			}
+			raw := value()
+			if raw == nil || !yield(key, raw) {
+				return
+			}
+		}
+	}
+}
+
+// FormatSplit formats each value yielded by Split for `src` with the Formatter
+// and writes it to its own file in the directory `dir`, which is created if
+// needed. Each file is named after the key or index of its value with the
+// extension ".json", e.g. "users.json" or "0.json", with characters unsafe in
+// file names replaced by underscores, and a numeric suffix telling apart keys
+// which end up with the same name. Existing files are overwritten.
+//
+// As with FormatFiles, the output of each file ends with a newline unless
+// TrailingNewline is NewlineNever. `src` must be standard JSON, whatever the
+// Syntax of the Formatter, and nothing is written if it's invalid.
+func (f *Formatter) FormatSplit(dir string, src []byte) error {
+	src, err := transcodeInput(src)
+	if err != nil {
+		return err
+	}
+	if ok, report := f.Valid(src); !ok {
+		return report
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	used := make(map[string]bool)
+	buf := &bytes.Buffer{}
+	for key, value := range Split(src) {
+		name := splitFileName(key)
+		for n := 2; used[name]; n++ {
+			name = splitFileName(key) + "_" + strconv.Itoa(n)
+		}
+		used[name] = true
+
+		buf.Reset()
+		if err := f.format(buf, value, f.TrailingNewline.resolve(true)); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitFileName returns the file name, without extension, for the value of the
+// key `key`: characters other than ASCII letters, digits, '-', '_' and '.' are
+// replaced by underscores, and so is a leading dot.
+func splitFileName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, key)
+	if name == "" || name[0] == '.' {
+		name = "_" + strings.TrimPrefix(name, ".")
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+package jsoncolor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name, src string
+		want      [][2]string
+	}{
+		{"object", `{"a": [1, 2], "b" : {"c": null}}`, [][2]string{{"a", "[1, 2]"}, {"b", `{"c": null}`}}},
+		{"array", ` ["x", 1.5e3 ]`, [][2]string{{"0", `"x"`}, {"1", "1.5e3"}}},
+		{"scalar", "  42\n", [][2]string{{"", "42"}}},
+		{"empty", `{}`, nil},
+		// Iteration stops at the first syntax error.
+		{"invalid", `{"a": 1, "b": ]}`, [][2]string{{"a", "1"}}},
+	}
+	for _, tt := range tests {
+		var got [][2]string
+		for key, value := range Split([]byte(tt.src)) {
+			got = append(got, [2]string{key, string(value)})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Breaking out of the loop stops the iteration.
+	n := 0
+	for range Split([]byte(`[1, 2, 3]`)) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("got %d values after break", n)
+	}
+}
+
+func TestFormatSplit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	src := `{"users": [1, 2], "a/b": "x", "a?b": "y", ".hidden": true, "": null}`
+	if err := plainCompactFormatter().FormatSplit(dir, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"users.json":   "[1,2]\n",
+		"a_b.json":     "\"x\"\n",
+		"a_b_2.json":   "\"y\"\n",
+		"_hidden.json": "true\n",
+		"_.json":       "null\n",
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want[e.Name()] {
+			t.Errorf("%s: got %q, want %q", e.Name(), data, want[e.Name()])
+		}
+	}
+	if len(names) != len(want) {
+		sort.Strings(names)
+		t.Errorf("got files %q", names)
+	}
+}
+
+func TestFormatSplitInvalid(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := plainCompactFormatter().FormatSplit(dir, []byte(`{"a": 1,}`)); err == nil {
+		t.Fatal("got no error")
+	}
+	// Nothing is written, not even the directory.
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("directory created: %v", err)
+	}
+}
+
+func TestSplitFileName(t *testing.T) {
+	for key, want := range map[string]string{
+		"users":    "users",
+		"v1.2-x_y": "v1.2-x_y",
+		"a b/c":    "a_b_c",
+		"é":        "_",
+		"..":       "_.",
+		"":         "_",
+	} {
+		if got := splitFileName(key); got != want {
+			t.Errorf("%q: got %q, want %q", key, got, want)
+		}
+	}
+}
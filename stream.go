@@ -0,0 +1,257 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+)
+
+// StreamWriter writes a colorized JSON document incrementally, one container
+// or value at a time, so that huge arrays or objects can be emitted without
+// holding the whole structure in memory:
+//
+//	sw := jsoncolor.NewStreamWriter(os.Stdout)
+//	sw.BeginObject()
+//	sw.WriteField("count", n)
+//	sw.WriteKey("items")
+//	sw.BeginArray()
+//	for item := range items {
+//		sw.WriteElement(item)
+//	}
+//	sw.End() // Closes the array.
+//	sw.End() // Closes the object.
+//
+// Values are marshalled with encoding/json and laid out like the rest of the
+// document, so the output matches what Marshal would produce for the complete
+// structure. Like TokenEncoder, which it builds upon, a StreamWriter terminates
+// each complete top-level value with a newline, or at least separates them
+// with one (see Formatter.TrailingNewline), and writes its output as it goes;
+// wrap the destination in a bufio.Writer to reduce the number of writes.
+type StreamWriter struct {
+	te *TokenEncoder
+	f  *Formatter // The Formatter in use, which is never modified.
+}
+
+// NewStreamWriter creates a new StreamWriter that writes colorized JSON to `w`
+// using the DefaultFormatter.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return NewStreamWriterWithFormatter(w, DefaultFormatter)
+}
+
+// NewStreamWriterWithFormatter creates a new StreamWriter that writes
+// colorized JSON to `w` using the provided Formatter `f`.
+// Like an Encoder, it escapes HTML characters by default, regardless of
+// f.EscapeHTML; use SetEscapeHTML to change this.
+func NewStreamWriterWithFormatter(w io.Writer, f *Formatter) *StreamWriter {
+	if f == nil {
+		panic("jsoncolor: cannot create StreamWriter with a nil Formatter")
+	}
+	sw := &StreamWriter{
+		te: NewTokenEncoderWithFormatter(w, f),
+		f:  f,
+	}
+	sw.SetEscapeHTML(true)
+	return sw
+}
+
+// SetEscapeHTML specifies whether problematic HTML characters (<, >, &)
+// should be escaped within JSON strings. The default is true. It has no
+// effect if the Formatter has an EscapePolicy.
+func (sw *StreamWriter) SetEscapeHTML(on bool) {
+	sw.te.fs.escapePolicy = resolveEscapePolicy(sw.f.EscapePolicy, on)
+}
+
+// BeginObject starts a new object, as the next element of the current array,
+// the value of the key given to WriteKey, or a top-level value.
+func (sw *StreamWriter) BeginObject() error {
+	return sw.te.WriteToken(json.Delim('{'))
+}
+
+// BeginArray starts a new array, as the next element of the current array,
+// the value of the key given to WriteKey, or a top-level value.
+func (sw *StreamWriter) BeginArray() error {
+	return sw.te.WriteToken(json.Delim('['))
+}
+
+// End closes the innermost open object or array.
+func (sw *StreamWriter) End() error {
+	current := sw.te.fs.frame()
+	switch {
+	case current.inObject():
+		return sw.te.WriteToken(json.Delim('}'))
+	case current.inArray():
+		return sw.te.WriteToken(json.Delim(']'))
+	}
+	return errors.New("jsoncolor: End called without an open object or array")
+}
+
+// WriteKey writes the key of the next member of the current object, whose
+// value must be written next, typically by BeginObject or BeginArray.
+// For members with a value at hand, use WriteField.
+func (sw *StreamWriter) WriteKey(key string) error {
+	if !sw.te.fs.frame().inField() {
+		return errors.New("jsoncolor: WriteKey called outside of an object or before the previous key's value")
+	}
+	return sw.te.WriteToken(key)
+}
+
+// WriteField writes a member of the current object, with `v` marshalled as
+// its value.
+func (sw *StreamWriter) WriteField(key string, v interface{}) error {
+	if !sw.te.fs.frame().inField() {
+		return errors.New("jsoncolor: WriteField called outside of an object or before the previous key's value")
+	}
+	// Marshal the value first, so that nothing is written if it fails.
+	src, err := sw.marshal(v, sw.te.fs.frame().path+"/"+escapePointerToken(key))
+	if err != nil {
+		return err
+	}
+	if err := sw.te.WriteToken(key); err != nil {
+		return err
+	}
+	return sw.writeValue(src)
+}
+
+// WriteElement writes `v`, marshalled, as the next element of the current
+// array. It may also be used to write the value of the key given to WriteKey,
+// or a complete top-level value.
+func (sw *StreamWriter) WriteElement(v interface{}) error {
+	if sw.te.fs.frame().inField() {
+		return errors.New("jsoncolor: WriteElement called where an object key is expected")
+	}
+	src, err := sw.marshal(v, sw.te.fs.childPath(sw.te.fs.frame()))
+	if err != nil {
+		return err
+	}
+	return sw.writeValue(src)
+}
+
+// Depth returns the number of objects and arrays which have been opened but
+// not yet closed.
+func (sw *StreamWriter) Depth() int {
+	return sw.te.Depth()
+}
+
+// marshal returns the JSON encoding of `v`, which is to be written at the JSON
+// Pointer `path`, applying the Formatter's InvalidUTF8Policy and SortKeys.
+func (sw *StreamWriter) marshal(v interface{}, path string) ([]byte, error) {
+	fs := sw.te.fs
+	// As for an Encoder, HTML escaping by encoding/json would defeat PreserveExact.
+	src, err := marshalJSON(v, !fs.preserveExact)
+	if err != nil {
+		return nil, err
+	}
+
+	escapeReplacement, err := checkValueUTF8(fs.utf8Policy, v)
+	if err != nil {
+		// Report the position within the whole document rather than within `v`.
+		var utf8Err *UTF8Error
+		if errors.As(err, &utf8Err) {
+			utf8Err.Path = path + utf8Err.Path
+		}
+		return nil, err
+	}
+	fs.escapeReplacement = fs.escapeReplacement || escapeReplacement
+
+	if fs.sortKeys {
+		src, err = sortKeys(src)
+		if err != nil {
+			return nil, fmt.Errorf("jsoncolor: error decoding input JSON: %w", err)
+		}
+	}
+	return src, nil
+}
+
+// writeValue writes the marshalled value `src` at the current position.
+func (sw *StreamWriter) writeValue(src []byte) error {
+	sw.te.beginValue()
+	if err := sw.te.fs.writeTokens(src); err != nil {
+		sw.te.fs.resetColors()
+		return fmt.Errorf("jsoncolor: failed to format/colorize JSON: %w", err)
+	}
+	sw.te.endValue()
+	return sw.te.fs.checkWrite()
+}
+
+// EncodeStream writes a single colorized JSON array whose elements are the
+// values produced by `seq`, followed by a newline like Encode. Elements are
+// marshalled and written one at a time as `seq` produces them, so the
+// sequence may be arbitrarily long.
+//
+// The array is always closed, so that the output remains valid JSON, even if
+// an element fails to marshal (which stops the iteration and is returned) or
+// `seq` panics.
+func (enc *Encoder) EncodeStream(seq iter.Seq[interface{}]) (err error) {
+	f, o, terminateWithNewline := enc.settings(true)
+	sw := &StreamWriter{
+		te: newTokenEncoder(newFormatterStateWithOptions(f, enc.w, o), terminateWithNewline),
+		f:  f,
+	}
+
+	if err := sw.BeginArray(); err != nil {
+		return err
+	}
+	defer func() {
+		if endErr := sw.End(); err == nil {
+			err = endErr
+		}
+	}()
+
+	for v := range seq {
+		if err := sw.WriteElement(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeChan works like EncodeStream, but takes the elements of the array
+// from the channel `ch` until it's closed. If an element fails to marshal,
+// no further values are received from `ch`.
+func (enc *Encoder) EncodeChan(ch <-chan interface{}) error {
+	return enc.EncodeStream(func(yield func(interface{}) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	})
+}
+
+// EncodeAll writes each of `values` as a colorized JSON document on its own
+// line, producing JSON Lines (also known as NDJSON). Each line ends with a
+// newline and the output is compact regardless of SetIndent, since a document
+// must not span several lines. Encoding stops at the first value which fails
+// to marshal, and the error is returned. It also stops at the first value
+// which fails to be written, unless the Formatter's WriteErrorPolicy is
+// WriteErrorBestEffort, in which case the first write error is returned once
+// all values are written.
+func (enc *Encoder) EncodeAll(values []interface{}) error {
+	return enc.EncodeSeq(slices.Values(values))
+}
+
+// EncodeSeq works like EncodeAll, but takes the values from `seq`, writing
+// each line as soon as its value is produced.
+func (enc *Encoder) EncodeSeq(seq iter.Seq[interface{}]) error {
+	f, o, _ := enc.settings(true)
+	o = o.with(WithIndent("", ""))
+	var firstWriteErr error
+	for v := range seq {
+		err := enc.encodeWith(f, o, v, true)
+		// With WriteErrorBestEffort, a value which failed to be written doesn't stop the stream.
+		var writeErr *WriteError
+		if errors.As(err, &writeErr) && f.WriteErrorPolicy == WriteErrorBestEffort {
+			if firstWriteErr == nil {
+				firstWriteErr = err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return firstWriteErr
+}
@@ -0,0 +1,253 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStreamWriter(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStreamWriterWithFormatter(&buf, &Formatter{Indent: "  "})
+
+	steps := []func() error{
+		sw.BeginObject,
+		func() error { return sw.WriteField("count", 2) },
+		func() error { return sw.WriteKey("items") },
+		sw.BeginArray,
+		func() error { return sw.WriteElement(map[string]string{"name": "<a>"}) },
+		func() error { return sw.WriteElement([]int{}) },
+		sw.End,
+		sw.End,
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	if sw.Depth() != 0 {
+		t.Errorf("got depth %d, want 0", sw.Depth())
+	}
+
+	want := `{
+  "count": 2,
+  "items": [
+    {
+      "name": "\u003ca\u003e"
+    },
+    []
+  ]
+}
+`
+	if got := stripANSI(buf.String()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The output matches what Marshal produces for the complete structure.
+	marshaled := MustMarshalIndentWithFormatter(map[string]interface{}{
+		"count": 2,
+		"items": []interface{}{map[string]string{"name": "<a>"}, []int{}},
+	}, "", "  ", &Formatter{})
+	if stripANSI(string(marshaled))+"\n" != want {
+		t.Errorf("got different output from Marshal:\n%s", stripANSI(string(marshaled)))
+	}
+}
+
+func TestStreamWriterOptions(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStreamWriterWithFormatter(&buf, &Formatter{SortKeys: true})
+	sw.SetEscapeHTML(false)
+	if err := sw.WriteElement(map[string]interface{}{"b": "<", "a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stripANSI(buf.String()), "{\"a\":1,\"b\":\"<\"}\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStreamWriterErrors(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStreamWriterWithFormatter(&buf, &Formatter{InvalidUTF8Policy: InvalidUTF8Error})
+
+	if err := sw.End(); err == nil {
+		t.Error("End at the top level: got no error")
+	}
+	if err := sw.WriteKey("a"); err == nil {
+		t.Error("WriteKey outside of an object: got no error")
+	}
+
+	if err := sw.BeginObject(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.WriteElement(1); err == nil {
+		t.Error("WriteElement where a key is expected: got no error")
+	}
+	if err := sw.WriteField("f", make(chan int)); err == nil {
+		t.Error("WriteField with an unsupported value: got no error")
+	}
+
+	err := sw.WriteField("list", []string{"ok", "\xff"})
+	var utf8Err *UTF8Error
+	if !errors.As(err, &utf8Err) || utf8Err.Path != "/list/1" {
+		t.Errorf("got %v, want a *UTF8Error at /list/1", err)
+	}
+	// Nothing was written for the failed fields.
+	if got := stripANSI(buf.String()); strings.Contains(got, "f") || strings.Contains(got, "list") {
+		t.Errorf("got output %q after the errors", got)
+	}
+}
+
+func TestEncodeStream(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{})
+	enc.SetIndent("", "  ")
+	if err := enc.EncodeStream(slices.Values([]interface{}{1, "a", []int{2}})); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stripANSI(buf.String()), "[\n  1,\n  \"a\",\n  [\n    2\n  ]\n]\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := enc.EncodeStream(slices.Values([]interface{}{})); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stripANSI(buf.String()), "[]\n"; got != want {
+		t.Errorf("empty: got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeStreamError(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{})
+	err := enc.EncodeStream(slices.Values([]interface{}{1, make(chan int), 3}))
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	// The array is still closed.
+	if got, want := stripANSI(buf.String()), "[1]\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeChan(t *testing.T) {
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		for i := 0; i < 3; i++ {
+			ch <- i
+		}
+	}()
+
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{})
+	enc.SetNormalize(true)
+	if err := enc.EncodeChan(ch); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "[\n  0,\n  1,\n  2\n]\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeAll(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{})
+	enc.SetIndent("", "  ")
+	enc.SetTrailingNewline(false)
+	if err := enc.EncodeAll([]interface{}{map[string]int{"a": 1}, []int{1, 2}, "x"}); err != nil {
+		t.Fatal(err)
+	}
+	// Lines are compact and always terminated.
+	if got, want := stripANSI(buf.String()), "{\"a\":1}\n[1,2]\n\"x\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := enc.EncodeSeq(slices.Values([]interface{}{1, make(chan int), 3})); err == nil {
+		t.Error("unsupported value: got no error")
+	}
+	if got, want := stripANSI(buf.String()), "1\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeStreamColorMode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetColorMode(ColorAlways)
+	if err := enc.EncodeStream(slices.Values([]interface{}{1})); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("ColorAlways: got %q, want colored output", buf.String())
+	}
+
+	buf.Reset()
+	enc.SetColorMode(ColorNever)
+	if err := enc.EncodeStream(slices.Values([]interface{}{1, "a"})); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "[1,\"a\"]\n"; got != want {
+		t.Errorf("ColorNever: got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeStreamOptions(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, newPlainFormatter())
+	enc.SetIndent("> ", "\t")
+	enc.SetEscapeHTML(true)
+	enc.SetTrailingNewline(false)
+	if err := enc.EncodeStream(slices.Values([]interface{}{"<a>"})); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "> [\n> \t\"\\u003ca\\u003e\"\n> ]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeStreamSetColors(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetColors(!enabled)
+		enc.SetColors(enabled)
+		if err := enc.EncodeStream(slices.Values([]interface{}{"a"})); err != nil {
+			t.Fatal(err)
+		}
+		if colored := strings.Contains(buf.String(), "\x1b["); colored != enabled {
+			t.Errorf("SetColors(%v): got %q", enabled, buf.String())
+		}
+	}
+}
+
+func TestEncodeStreamBaseIndentLevel(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetBaseIndentLevel(1)
+	if err := enc.EncodeStream(slices.Values([]interface{}{1, []int{2}})); err != nil {
+		t.Fatal(err)
+	}
+	want := "  [\n    1,\n    [\n      2\n    ]\n  ]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStreamWriterTopLevelValues(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewStreamWriterWithFormatter(&buf, &Formatter{TrailingNewline: NewlineNever})
+	sw.WriteElement(map[string]int{"a": 1})
+	sw.BeginObject()
+	sw.WriteField("b", 2)
+	sw.End()
+	sw.WriteElement(3)
+	// The output is valid JSON Lines, without a newline after the last value.
+	if got, want := stripANSI(buf.String()), "{\"a\":1}\n{\"b\":2}\n3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
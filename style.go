@@ -0,0 +1,197 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/amterp/color"
+)
+
+// Attribute is an ANSI SGR (Select Graphic Rendition) parameter, such as a
+// foreground color or bold text. See Style.
+type Attribute int
+
+// Text attributes.
+const (
+	Reset Attribute = iota
+	Bold
+	Faint
+	Italic
+	Underline
+	BlinkSlow
+	BlinkRapid
+	ReverseVideo
+	Concealed
+	CrossedOut
+)
+
+// Foreground colors.
+const (
+	FgBlack Attribute = iota + 30
+	FgRed
+	FgGreen
+	FgYellow
+	FgBlue
+	FgMagenta
+	FgCyan
+	FgWhite
+)
+
+// Bright foreground colors.
+const (
+	FgHiBlack Attribute = iota + 90
+	FgHiRed
+	FgHiGreen
+	FgHiYellow
+	FgHiBlue
+	FgHiMagenta
+	FgHiCyan
+	FgHiWhite
+)
+
+// Background colors.
+const (
+	BgBlack Attribute = iota + 40
+	BgRed
+	BgGreen
+	BgYellow
+	BgBlue
+	BgMagenta
+	BgCyan
+	BgWhite
+)
+
+// Bright background colors.
+const (
+	BgHiBlack Attribute = iota + 100
+	BgHiRed
+	BgHiGreen
+	BgHiYellow
+	BgHiBlue
+	BgHiMagenta
+	BgHiCyan
+	BgHiWhite
+)
+
+// Color returns the ANSI color number, from 0 to 15, of a foreground or
+// background color attribute, and whether it's a background color. It returns
+// ok == false for the text attributes, such as Bold.
+func (a Attribute) Color() (n int, background, ok bool) {
+	switch {
+	case a >= FgBlack && a <= FgWhite:
+		return int(a - FgBlack), false, true
+	case a >= FgHiBlack && a <= FgHiWhite:
+		return int(a-FgHiBlack) + 8, false, true
+	case a >= BgBlack && a <= BgWhite:
+		return int(a - BgBlack), true, true
+	case a >= BgHiBlack && a <= BgHiWhite:
+		return int(a-BgHiBlack) + 8, true, true
+	}
+	return 0, false, false
+}
+
+// Style is a set of ANSI text attributes, such as Style{FgBlue, Bold}. It
+// implements SprintfFuncer, so it can be used for any color field of a
+// Formatter without importing a color library:
+//
+//	f := &jsoncolor.Formatter{
+//		FieldColor: jsoncolor.Style{jsoncolor.FgCyan},
+//		NullColor:  jsoncolor.Style{jsoncolor.Faint, jsoncolor.Italic},
+//	}
+//
+// 24-bit colors are made with Hex and RGB, e.g. jsoncolor.Hex("#61afef").
+// An empty Style leaves text unstyled. Like the default colors, styles are
+// not applied when github.com/amterp/color disables colors, which it does by
+// default when standard output is not a terminal.
+type Style []Attribute
+
+// DimPunctuation is a preset for Formatter.PunctuationColor, dimming commas,
+// colons and delimiters so that keys and values stand out.
+var DimPunctuation = Style{Faint}
+
+// NewStyle returns a Style made of the attributes `attrs`.
+func NewStyle(attrs ...Attribute) Style {
+	return Style(attrs)
+}
+
+// RGB returns the Style of the 24-bit foreground color with the components
+// `r`, `g` and `b`, preceded by the attributes `attrs`, e.g.
+// RGB(0x61, 0xaf, 0xef, Bold). Terminals with fewer colors are given the
+// closest color they can show (see Formatter.ColorProfile).
+func RGB(r, g, b uint8, attrs ...Attribute) Style {
+	return append(Style(attrs), 38, 2, Attribute(r), Attribute(g), Attribute(b))
+}
+
+// Hex returns the Style of the 24-bit foreground color `hex`, e.g. "#61afef"
+// or "#fa0", preceded by the attributes `attrs`, like RGB. It panics if `hex`
+// isn't a color in hexadecimal; use ParseStyle for colors from user input.
+func Hex(hex string, attrs ...Attribute) Style {
+	r, g, b, ok := parseHexColor(hex)
+	if !ok {
+		panic("jsoncolor: invalid hex color " + strconv.Quote(hex))
+	}
+	return RGB(r, g, b, attrs...)
+}
+
+// parseHexColor returns the components of the color `hex`, e.g. "#61afef" or
+// "#fa0", with or without the #.
+func parseHexColor(hex string) (r, g, b uint8, ok bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
+
+// Sprint returns `text` wrapped in the escape sequences of the Style.
+func (s Style) Sprint(text string) string {
+	if len(s) == 0 || color.NoColor {
+		return text
+	}
+	return s.sequence() + text + "\x1b[0m"
+}
+
+// SprintfFunc returns a function formatting its arguments like fmt.Sprintf
+// and wrapping the result in the escape sequences of the Style.
+func (s Style) SprintfFunc() func(format string, a ...interface{}) string {
+	if len(s) == 0 {
+		return fmt.Sprintf
+	}
+	return func(format string, a ...interface{}) string {
+		return s.Sprint(fmt.Sprintf(format, a...))
+	}
+}
+
+// sequence returns the escape sequence enabling the attributes of the Style.
+func (s Style) sequence() string {
+	params := make([]string, len(s))
+	for i, attr := range s {
+		params[i] = strconv.Itoa(int(attr))
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// StyleFunc adapts a function styling a string to a SprintfFuncer, so that
+// the styles of other libraries can be used for the color fields of a
+// Formatter without an adapter type, e.g. StyleFunc(termenvStyle.Styled) or
+//
+//	jsoncolor.StyleFunc(func(s string) string { return lipglossStyle.Render(s) })
+//
+// The function decides on its own whether to apply styles.
+type StyleFunc func(s string) string
+
+// SprintfFunc returns a function formatting its arguments like fmt.Sprintf
+// and passing the result to fn.
+func (fn StyleFunc) SprintfFunc() func(format string, a ...interface{}) string {
+	return func(format string, a ...interface{}) string {
+		return fn(fmt.Sprintf(format, a...))
+	}
+}
@@ -0,0 +1,128 @@
+package jsoncolor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amterp/color"
+)
+
+// withColor forces github.com/amterp/color to enable colors, or not, for the
+// duration of the test.
+func withColor(t *testing.T, enabled bool) {
+	t.Helper()
+	noColor := color.NoColor
+	color.NoColor = !enabled
+	t.Cleanup(func() { color.NoColor = noColor })
+}
+
+func TestStyle(t *testing.T) {
+	withColor(t, true)
+	tests := []struct {
+		name  string
+		style Style
+		want  string
+	}{
+		{"empty", Style{}, "x 1"},
+		{"foreground", Style{FgCyan}, "\x1b[36mx 1\x1b[0m"},
+		{"several", NewStyle(Bold, FgHiRed, BgBlack), "\x1b[1;91;40mx 1\x1b[0m"},
+		{"bright background", Style{Faint, BgHiWhite}, "\x1b[2;107mx 1\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if got := tt.style.SprintfFunc()("%s %d", "x", 1); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStyleNoColor(t *testing.T) {
+	withColor(t, false)
+	if got := (Style{Bold, FgRed}).SprintfFunc()("%d", 1); got != "1" {
+		t.Errorf("got %q, want no escape sequences", got)
+	}
+}
+
+func TestStyleFunc(t *testing.T) {
+	upper := StyleFunc(strings.ToUpper)
+	if got := upper.SprintfFunc()("%s=%d", "a", 1); got != "A=1" {
+		t.Errorf("got %q, want %q", got, "A=1")
+	}
+}
+
+func TestFormatterStyles(t *testing.T) {
+	withColor(t, true)
+	// Styles and StyleFuncs mix with the other color fields.
+	f := plainCompactFormatter()
+	f.FieldColor = Style{FgCyan}
+	f.StringColor = StyleFunc(func(s string) string { return "<" + s + ">" })
+	f.NumberColor = Style{Bold}
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`{"a": "b", "c": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"\x1b[36ma\x1b[0m\":\"<b>\",\"\x1b[36mc\x1b[0m\":\x1b[1m1\x1b[0m}"
+	if got := sb.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAttributeColor(t *testing.T) {
+	tests := []struct {
+		attr           Attribute
+		n              int
+		background, ok bool
+	}{
+		{FgBlack, 0, false, true},
+		{FgWhite, 7, false, true},
+		{FgHiBlack, 8, false, true},
+		{FgHiCyan, 14, false, true},
+		{BgRed, 1, true, true},
+		{BgHiWhite, 15, true, true},
+		{Bold, 0, false, false},
+		{CrossedOut, 0, false, false},
+	}
+	for _, tt := range tests {
+		n, background, ok := tt.attr.Color()
+		if n != tt.n || background != tt.background || ok != tt.ok {
+			t.Errorf("%d: got %d, %v, %v, want %d, %v, %v", tt.attr, n, background, ok, tt.n, tt.background, tt.ok)
+		}
+	}
+}
+
+func TestRGBAndHex(t *testing.T) {
+	tests := []struct {
+		name  string
+		style Style
+		want  Style
+	}{
+		{"rgb", RGB(0x61, 0xaf, 0xef), Style{38, 2, 97, 175, 239}},
+		{"rgb with attributes", RGB(255, 0, 0, Bold, Underline), Style{Bold, Underline, 38, 2, 255, 0, 0}},
+		{"hex", Hex("#61afef"), Style{38, 2, 97, 175, 239}},
+		{"short hex", Hex("#fa0", Italic), Style{Italic, 38, 2, 255, 170, 0}},
+		{"hex without #", Hex("00FF80"), Style{38, 2, 0, 255, 128}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.style, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.style, tt.want)
+		}
+	}
+
+	withColor(t, true)
+	if got, want := Hex("#fa0").Sprint("x"), "\x1b[38;2;255;170;0mx\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHexInvalid(t *testing.T) {
+	for _, hex := range []string{"", "#12345", "#ggg", "#+12345"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: didn't panic", hex)
+				}
+			}()
+			Hex(hex)
+		}()
+	}
+}
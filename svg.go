@@ -0,0 +1,173 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SVGOptions configures FormatSVG.
+type SVGOptions struct {
+	// FontSize is the size of the text in pixels. Default is 14.
+	FontSize float64
+	// FontFamily is the CSS font family of the text, which must be monospace
+	// for the text to line up. Default is "ui-monospace, SFMono-Regular,
+	// Menlo, Consolas, monospace".
+	FontFamily string
+	// Background is the CSS color of the background, and Foreground that of
+	// text without a color of its own. Defaults are "#ffffff" and "#000000",
+	// which suit the default colors.
+	Background, Foreground string
+	// LineNumbers, if true, numbers the lines in a gutter on the left.
+	LineNumbers bool
+}
+
+// svgTabWidth is the number of columns a tab is expanded to.
+const svgTabWidth = 4
+
+// FormatSVG renders `src` as an SVG image using the DefaultFormatter. See
+// Formatter.FormatSVG.
+func FormatSVG(dst io.Writer, src []byte, opts SVGOptions) error {
+	return DefaultFormatter.FormatSVG(dst, src, opts)
+}
+
+// FormatSVG formats `src` like Format, and writes the output to `dst` as an
+// SVG image of its text in the Formatter's colors, for embedding colorized
+// JSON in READMEs and other documentation which can't show ANSI escape
+// sequences or HTML. The image is sized to fit the text, assuming the width
+// of each character is 0.6 times the font size, as with most monospace fonts.
+//
+// Like Formatter.CSS, the colors are read from the escape sequences the
+// Formatter's colors produce, so the text has no colors if they are disabled.
+// Nothing is written if `src` is invalid.
+func (f *Formatter) FormatSVG(dst io.Writer, src []byte, opts SVGOptions) error {
+	if opts.FontSize <= 0 {
+		opts.FontSize = 14
+	}
+	if opts.FontFamily == "" {
+		opts.FontFamily = "ui-monospace, SFMono-Regular, Menlo, Consolas, monospace"
+	}
+	if opts.Background == "" {
+		opts.Background = "#ffffff"
+	}
+	if opts.Foreground == "" {
+		opts.Foreground = "#000000"
+	}
+
+	// Split the output into lines of styled runs of text.
+	type run struct{ text, style string }
+	lines := [][]run{nil}
+	columns := []int{0}
+	styles := make(map[string]string)
+	err := f.formatMemberSegments(src, func(s memberSegment) {
+		style := ""
+		if s.Kind != TokenWhitespace {
+			key := f.segmentColorKey(s)
+			var ok bool
+			if style, ok = styles[key]; !ok {
+				style = svgStyle(sgrCSS(f.segmentColor(s).SprintfFunc()("%s", "x")))
+				styles[key] = style
+			}
+		}
+		for i, text := range strings.Split(s.Text, "\n") {
+			if i > 0 {
+				lines, columns = append(lines, nil), append(columns, 0)
+			}
+			if text == "" {
+				continue
+			}
+			// Expand tabs, whose width SVG doesn't define.
+			if strings.Contains(text, "\t") {
+				sb := &strings.Builder{}
+				column := columns[len(columns)-1]
+				for _, r := range text {
+					if r == '\t' {
+						n := svgTabWidth - column%svgTabWidth
+						sb.WriteString(strings.Repeat(" ", n))
+						column += n
+					} else {
+						sb.WriteRune(r)
+						column++
+					}
+				}
+				text = sb.String()
+			}
+			lines[len(lines)-1] = append(lines[len(lines)-1], run{text, style})
+			columns[len(columns)-1] += utf8.RuneCountInString(text)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	maxColumns := 0
+	for _, n := range columns {
+		maxColumns = max(maxColumns, n)
+	}
+	charWidth := 0.6 * opts.FontSize
+	lineHeight := 1.4 * opts.FontSize
+	padding := opts.FontSize
+	gutter := 0.0
+	if opts.LineNumbers {
+		gutter = float64(len(strconv.Itoa(len(lines)))+2) * charWidth
+	}
+	width := 2*padding + gutter + float64(maxColumns)*charWidth
+	height := 2*padding + float64(len(lines))*lineHeight
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s">`+"\n",
+		svgNumber(width), svgNumber(height))
+	fmt.Fprintf(sb, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", html.EscapeString(opts.Background))
+	fmt.Fprintf(sb, `<g font-family="%s" font-size="%s" fill="%s" xml:space="preserve">`+"\n",
+		html.EscapeString(opts.FontFamily), svgNumber(opts.FontSize), html.EscapeString(opts.Foreground))
+	for i, line := range lines {
+		// The baseline sits a little above the bottom of the line.
+		y := svgNumber(padding + float64(i)*lineHeight + opts.FontSize)
+		if opts.LineNumbers {
+			fmt.Fprintf(sb, `<text x="%s" y="%s" text-anchor="end" opacity="0.5">%d</text>`,
+				svgNumber(padding+gutter-2*charWidth), y, i+1)
+		}
+		if len(line) == 0 {
+			if opts.LineNumbers {
+				sb.WriteString("\n")
+			}
+			continue
+		}
+		fmt.Fprintf(sb, `<text x="%s" y="%s">`, svgNumber(padding+gutter), y)
+		for _, r := range line {
+			if r.style == "" {
+				sb.WriteString(html.EscapeString(r.text))
+			} else {
+				fmt.Fprintf(sb, `<tspan style="%s">%s</tspan>`, r.style, html.EscapeString(r.text))
+			}
+		}
+		sb.WriteString("</text>\n")
+	}
+	sb.WriteString("</g>\n</svg>\n")
+	_, err = io.WriteString(dst, sb.String())
+	return err
+}
+
+// svgStyle converts the CSS declarations `decls` of text to their SVG
+// equivalent, where the color of text is its fill. Backgrounds are left out.
+func svgStyle(decls []string) string {
+	var converted []string
+	for _, decl := range decls {
+		switch {
+		case strings.HasPrefix(decl, "color:"):
+			converted = append(converted, "fill:"+strings.TrimPrefix(decl, "color:"))
+		case strings.HasPrefix(decl, "background-color:"):
+		default:
+			converted = append(converted, decl)
+		}
+	}
+	return strings.Join(converted, ";")
+}
+
+// svgNumber formats the coordinate `x` with at most two decimals.
+func svgNumber(x float64) string {
+	return strconv.FormatFloat(float64(int64(x*100+0.5))/100, 'f', -1, 64)
+}
@@ -0,0 +1,76 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatSVG(t *testing.T) {
+	withColor(t, true)
+	var sb strings.Builder
+	if err := styledFormatter().FormatSVG(&sb, []byte(`{"a<b": [true, null]}`), SVGOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="112" height="145.6" viewBox="0 0 112 145.6">
+<rect width="100%" height="100%" fill="#ffffff"/>
+<g font-family="ui-monospace, SFMono-Regular, Menlo, Consolas, monospace" font-size="14" fill="#000000" xml:space="preserve">
+<text x="14" y="28">{</text>
+<text x="14" y="47.6">  <tspan style="fill:#0000ee;font-weight:bold">&#34;a&lt;b&#34;</tspan>: [</text>
+<text x="14" y="67.2">    <tspan style="font-style:italic">true</tspan>,</text>
+<text x="14" y="86.8">    <tspan style="opacity:0.6">null</tspan></text>
+<text x="14" y="106.4">  ]</text>
+<text x="14" y="126">}</text>
+</g>
+</svg>
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatSVGOptions(t *testing.T) {
+	// Tabs are expanded, and the width counts characters rather than bytes.
+	f := newPlainFormatter()
+	f.Indent = "\t"
+	var sb strings.Builder
+	opts := SVGOptions{FontSize: 10, LineNumbers: true, Background: "#000", Foreground: "#fff"}
+	if err := f.FormatSVG(&sb, []byte(`{"é": [1]}`), opts); err != nil {
+		t.Fatal(err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="98" height="90" viewBox="0 0 98 90">
+<rect width="100%" height="100%" fill="#000"/>
+<g font-family="ui-monospace, SFMono-Regular, Menlo, Consolas, monospace" font-size="10" fill="#fff" xml:space="preserve">
+<text x="16" y="20" text-anchor="end" opacity="0.5">1</text><text x="28" y="20">{</text>
+<text x="16" y="34" text-anchor="end" opacity="0.5">2</text><text x="28" y="34">    &#34;é&#34;: [</text>
+<text x="16" y="48" text-anchor="end" opacity="0.5">3</text><text x="28" y="48">        1</text>
+<text x="16" y="62" text-anchor="end" opacity="0.5">4</text><text x="28" y="62">    ]</text>
+<text x="16" y="76" text-anchor="end" opacity="0.5">5</text><text x="28" y="76">}</text>
+</g>
+</svg>
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatSVGInvalid(t *testing.T) {
+	var sb strings.Builder
+	if err := FormatSVG(&sb, []byte(`[1,`), SVGOptions{}); err == nil {
+		t.Error("got no error")
+	}
+	if sb.Len() != 0 {
+		t.Errorf("got output %q", sb.String())
+	}
+}
+
+func TestSVGStyle(t *testing.T) {
+	got := svgStyle([]string{"color:#cd0000", "background-color:#000000", "font-weight:bold"})
+	if want := "fill:#cd0000;font-weight:bold"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for x, want := range map[float64]string{14: "14", 145.6: "145.6", 1.236: "1.24", 1.0 / 3: "0.33"} {
+		if got := svgNumber(x); got != want {
+			t.Errorf("%v: got %s, want %s", x, got, want)
+		}
+	}
+}
@@ -0,0 +1,503 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Syntax selects the dialect of JSON accepted as input. See Formatter.Syntax.
+type Syntax int
+
+const (
+	// SyntaxJSON is standard JSON (RFC 8259). This is the default.
+	SyntaxJSON Syntax = iota
+	// SyntaxJSONC is JSON with comments, as used by VS Code settings and
+	// tsconfig.json: line (// ...) and block (/* ... */) comments are allowed
+	// wherever whitespace is, as are trailing commas in objects and arrays.
+	// Comments are kept in the output, while trailing commas are dropped.
+	SyntaxJSONC
+	// SyntaxJSON5 is JSON5 (https://json5.org), which extends JSONC with
+	// unquoted keys, single-quoted strings, additional escape sequences,
+	// hexadecimal numbers, numbers with a leading plus sign or a leading or
+	// trailing decimal point, Infinity and NaN. Its syntax is kept in the
+	// output unless Formatter.StrictOutput is set.
+	SyntaxJSON5
+	// SyntaxHJSON is HJSON (https://hjson.github.io), a syntax for config
+	// files which extends JSONC with # comments, unquoted keys, single-quoted
+	// strings, strings without quotes running to the end of their line,
+	// multiline strings between triple single quotes, newlines separating members in
+	// place of commas, and braces around a top-level object being optional.
+	// It's converted to standard JSON in the output, apart from comments,
+	// which are kept as with SyntaxJSONC unless Formatter.StrictOutput is set.
+	SyntaxHJSON
+)
+
+// String returns the name of the syntax, e.g. "JSONC".
+func (s Syntax) String() string {
+	switch s {
+	case SyntaxJSON:
+		return "JSON"
+	case SyntaxJSONC:
+		return "JSONC"
+	case SyntaxJSON5:
+		return "JSON5"
+	case SyntaxHJSON:
+		return "HJSON"
+	}
+	return fmt.Sprintf("Syntax(%d)", int(s))
+}
+
+// lenientParser reads JSON in a Syntax other than SyntaxJSON, which
+// encoding/json can't, and writes it through a formatterState.
+type lenientParser struct {
+	fs  *formatterState
+	src []byte
+	pos int // Position of the next byte to read.
+	// lastEnd is the end of the last token read, or -1 before the first one.
+	// A comment on the same line is a trailing comment (see writeComment).
+	lastEnd int
+	// gapStart is the end of the last token or comment, from which blank lines
+	// preceding the next one are looked for (see Formatter.PreserveBlankLines).
+	gapStart int
+}
+
+// writeLenientTokens writes the JSON text `src`, in the formatter's Syntax,
+// like writeTokens, including any comments.
+func (fs *formatterState) writeLenientTokens(src []byte) error {
+	p := &lenientParser{fs: fs, src: src, lastEnd: -1}
+	for n := 0; ; n++ {
+		if err := p.skip(); err != nil {
+			return err
+		}
+		if p.pos >= len(src) {
+			return nil
+		}
+		// Several top-level values in a row are written one per line, unless a
+		// comment already ended the line.
+		if n > 0 && !fs.newlinePending {
+			fs.printSpace("\n", true)
+		}
+		if p.hjson() && p.lastEnd < 0 && p.rootObject() {
+			return p.container(json.Delim('{'), true)
+		}
+		if err := p.value(); err != nil {
+			return err
+		}
+	}
+}
+
+// value reads the value starting at the current position.
+func (p *lenientParser) value() error {
+	switch c := p.src[p.pos]; {
+	case c == '{' || c == '[':
+		return p.container(json.Delim(c), false)
+	case p.hjson() && bytes.HasPrefix(p.src[p.pos:], []byte("'''")):
+		return p.multilineString()
+	case c == '"' || (c == '\'' && (p.json5() || p.hjson())):
+		return p.string()
+	case p.hjson():
+		return p.quoteless()
+	case p.json5() && strings.IndexByte("+-.0123456789IN", c) >= 0:
+		end := p.pos + json5NumberLen(p.src[p.pos:])
+		if end == p.pos || p.identifierAt(end) {
+			return p.invalid()
+		}
+		number := string(p.src[p.pos:end])
+		if p.fs.strictOutput {
+			var err error
+			if number, err = strictNumber(number); err != nil {
+				return err
+			}
+		}
+		return p.write(json.Number(number), end)
+	case c == 't' || c == 'f' || c == 'n':
+		for _, literal := range []string{"true", "false", "null"} {
+			if bytes.HasPrefix(p.src[p.pos:], []byte(literal)) && !p.identifierAt(p.pos+len(literal)) {
+				token := map[string]json.Token{"true": true, "false": false, "null": nil}[literal]
+				return p.write(token, p.pos+len(literal))
+			}
+		}
+		return p.invalid()
+	case c == '-' || (c >= '0' && c <= '9'):
+		end := p.pos
+		for end < len(p.src) && strings.IndexByte("+-.eE0123456789", p.src[end]) >= 0 {
+			end++
+		}
+		number := p.src[p.pos:end]
+		if !json.Valid(number) {
+			return fmt.Errorf("jsoncolor: error decoding input JSON: invalid number %q at offset %d", number, p.pos)
+		}
+		return p.write(json.Number(number), end)
+	default:
+		return p.invalid()
+	}
+}
+
+// container reads the object or array opened by `delim` at the current
+// position. A `braceless` object, found at the top level of HJSON, has no
+// braces and ends with the input.
+func (p *lenientParser) container(delim json.Delim, braceless bool) error {
+	closing := byte('}')
+	if delim == json.Delim('[') {
+		closing = ']'
+	}
+	// The contents of HJSON strings without quotes can't be told apart by the
+	// scan of inlineObject, so HJSON objects are never inlined.
+	inline := !p.hjson() && p.fs.inlineObject(delim, p.src, p.pos+1)
+	p.blankLine(p.pos)
+	if err := p.fs.writeToken(delim, inline); err != nil {
+		return err
+	}
+	if !braceless {
+		p.pos++
+		p.lastEnd = p.pos
+		p.gapStart = p.pos
+	}
+
+	for {
+		if err := p.skip(); err != nil {
+			return err
+		}
+		switch {
+		case p.pos >= len(p.src) && braceless:
+			return p.write(json.Delim(closing), p.pos)
+		case p.pos >= len(p.src):
+			return fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+		case p.src[p.pos] == closing && !braceless:
+			return p.write(json.Delim(closing), p.pos+1)
+		}
+
+		if delim == json.Delim('{') {
+			var err error
+			switch c := p.src[p.pos]; {
+			case c == '"' || (c == '\'' && (p.json5() || p.hjson())):
+				err = p.string()
+			case p.hjson():
+				err = p.hjsonKey()
+			case p.json5() && json5IdentifierLen(p.src[p.pos:]) > 0:
+				err = p.identifier()
+			default:
+				err = p.invalid()
+			}
+			if err != nil {
+				return err
+			}
+			if err := p.skip(); err != nil {
+				return err
+			}
+			if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+				return p.invalid()
+			}
+			p.pos++
+			p.lastEnd = p.pos
+			if err := p.skip(); err != nil {
+				return err
+			}
+			if p.pos >= len(p.src) {
+				return fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+			}
+		}
+		if err := p.value(); err != nil {
+			return err
+		}
+
+		// Members are followed by a comma, which may be trailing, or the end of the container.
+		if err := p.skip(); err != nil {
+			return err
+		}
+		switch {
+		case p.pos >= len(p.src) && braceless:
+		case p.pos >= len(p.src):
+			return fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+		case p.src[p.pos] == ',':
+			p.pos++
+			p.lastEnd = p.pos
+		case p.src[p.pos] == closing:
+		case p.hjson() && bytes.IndexByte(p.src[p.lastEnd:p.pos], '\n') >= 0:
+			// HJSON members may be separated by newlines rather than commas.
+		default:
+			return p.invalid()
+		}
+	}
+}
+
+// string reads the string starting at the current position, which is a key
+// or a value depending on the state of the formatter.
+func (p *lenientParser) string() error {
+	quote := p.src[p.pos]
+	end := p.stringEnd(p.pos)
+	if end < 0 {
+		return fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+	}
+
+	literal := p.src[p.pos:end]
+	contents := literal[1 : len(literal)-1]
+	var s string
+	var err error
+	switch {
+	case p.json5():
+		s, err = decodeJSON5String(string(contents))
+	case quote == '\'':
+		s, err = decodeSingleQuoted(contents)
+	default:
+		err = json.Unmarshal(literal, &s)
+	}
+	if err != nil {
+		return fmt.Errorf("jsoncolor: error decoding input JSON: invalid string at offset %d: %w", p.pos, err)
+	}
+
+	switch {
+	case p.json5() && !p.fs.strictOutput:
+		// The string is printed as written, in its own quotes.
+		p.fs.raw = contents
+		p.fs.quote = string(quote)
+	case p.fs.preserveExact && (quote == '"' && (!p.json5() || json.Valid(literal))):
+		// Only escaping valid in JSON is preserved in strict output.
+		p.fs.raw = contents
+	}
+	return p.write(s, end)
+}
+
+// stringEnd returns the end of the string starting at position `i`, after
+// its closing quote, or -1 if it's unterminated.
+func (p *lenientParser) stringEnd(i int) int {
+	quote := p.src[i]
+	for i++; i < len(p.src) && p.src[i] != quote; i++ {
+		if p.src[i] == '\\' {
+			i++
+		}
+	}
+	if i >= len(p.src) {
+		return -1
+	}
+	return i + 1
+}
+
+// identifier reads the unquoted JSON5 key starting at the current position.
+func (p *lenientParser) identifier() error {
+	end := p.pos + json5IdentifierLen(p.src[p.pos:])
+	key := string(p.src[p.pos:end])
+	if !p.fs.strictOutput {
+		p.fs.raw = p.src[p.pos:end]
+		p.fs.quote = ""
+	}
+	return p.write(key, end)
+}
+
+// write writes `token`, which ends at position `end`, and moves past it.
+func (p *lenientParser) write(token json.Token, end int) error {
+	p.blankLine(p.pos)
+	err := p.fs.writeToken(token, false)
+	// The raw contents of a string aren't printed if it's elided in skeleton mode.
+	p.fs.raw = nil
+	p.fs.quote = `"`
+	if err != nil {
+		return err
+	}
+	p.pos = end
+	p.lastEnd = end
+	p.gapStart = end
+	return nil
+}
+
+// blankLine records whether a blank line precedes the token or comment at
+// position `start` in the input.
+func (p *lenientParser) blankLine(start int) {
+	p.fs.blankLine = p.fs.preserveBlankLines && bytes.Count(p.src[p.gapStart:start], []byte{'\n'}) >= 2
+}
+
+// skip moves past whitespace and comments, writing the comments.
+func (p *lenientParser) skip() error {
+	for {
+		for p.pos < len(p.src) && p.spaceLen(p.pos) > 0 {
+			p.pos += p.spaceLen(p.pos)
+		}
+		start := p.pos
+		end, err := p.commentEnd(start)
+		if err != nil || end == start {
+			return err
+		}
+		trailing := p.lastEnd >= 0 && bytes.IndexByte(p.src[p.lastEnd:start], '\n') < 0
+		// Line comments end before the newline, which may be preceded by a carriage return.
+		text := strings.TrimRight(string(p.src[start:end]), "\r")
+		if strings.HasPrefix(text, "#") {
+			// HJSON # comments are written as line comments of JSONC.
+			text = "//" + text[1:]
+		}
+		if !p.fs.strictOutput {
+			p.blankLine(start)
+			p.fs.writeComment(TokenComment, text, trailing, p.more(end))
+			p.gapStart = end
+		}
+		p.pos = end
+	}
+}
+
+// commentEnd returns the end of the comment at position `i`, or `i` if there
+// is none.
+func (p *lenientParser) commentEnd(i int) (int, error) {
+	lineEnd := func() int {
+		if end := bytes.IndexByte(p.src[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(p.src)
+	}
+	if p.hjson() && i < len(p.src) && p.src[i] == '#' {
+		return lineEnd(), nil
+	}
+	if i+1 >= len(p.src) || p.src[i] != '/' {
+		return i, nil
+	}
+	switch p.src[i+1] {
+	case '/':
+		return lineEnd(), nil
+	case '*':
+		end := bytes.Index(p.src[i+2:], []byte("*/"))
+		if end < 0 {
+			return 0, fmt.Errorf("jsoncolor: error decoding input JSON: unterminated comment at offset %d", i)
+		}
+		return i + 2 + end + 2, nil
+	}
+	return i, nil
+}
+
+// more reports whether another member of the current container follows
+// position `i`, skipping whitespace, comments and a comma.
+func (p *lenientParser) more(i int) bool {
+	skip := func() {
+		for i < len(p.src) {
+			if n := p.spaceLen(i); n > 0 {
+				i += n
+				continue
+			}
+			end, err := p.commentEnd(i)
+			if err != nil || end == i {
+				return
+			}
+			i = end
+		}
+	}
+	skip()
+	if i < len(p.src) && p.src[i] == ',' {
+		i++
+		skip()
+	}
+	return i < len(p.src) && p.src[i] != '}' && p.src[i] != ']'
+}
+
+// json5 reports whether the input is JSON5.
+func (p *lenientParser) json5() bool {
+	return p.fs.syntax == SyntaxJSON5
+}
+
+// spaceLen returns the length of the whitespace character at position `i`,
+// or 0 if there is none.
+func (p *lenientParser) spaceLen(i int) int {
+	if p.json5() {
+		return json5Space(p.src[i:])
+	}
+	if isSpace(p.src[i]) {
+		return 1
+	}
+	return 0
+}
+
+// identifierAt reports whether position `i` holds a character which may be
+// part of an identifier, so that e.g. "nullx" isn't read as null.
+func (p *lenientParser) identifierAt(i int) bool {
+	if i >= len(p.src) {
+		return false
+	}
+	c := p.src[i]
+	if c >= 0x80 {
+		// Non-ASCII characters are part of identifiers, except JSON5 whitespace.
+		return p.spaceLen(i) == 0
+	}
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// invalid returns an error for the unexpected character at the current
+// position, or the unexpected end of the input.
+func (p *lenientParser) invalid() error {
+	if p.pos >= len(p.src) {
+		return fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+	}
+	return fmt.Errorf("jsoncolor: error decoding input JSON: invalid character %q at offset %d", p.src[p.pos], p.pos)
+}
+
+// writeComment writes a comment of the kind `kind`, TokenComment or
+// TokenAnnotation, including its delimiters. A trailing
+// comment follows the previous token on the same line; others are leading
+// comments, which start a line of their own when indenting. `more` reports
+// whether another member of the current container follows the comment, in
+// which case the comma separating it from the previous member is printed
+// ahead of the comment, rather than after it.
+func (fs *formatterState) writeComment(kind TokenKind, text string, trailing, more bool) {
+	current := fs.frame()
+	flushed := fs.flushNewline()
+	switch {
+	case !current.inArrayOrObject():
+		// Top-level comments are on lines of their own, apart from trailing ones.
+		switch {
+		case flushed:
+		case trailing:
+			fs.printSpace(" ", true)
+		case current.count > 0 || current.comments:
+			fs.printSpace("\n", true)
+			fs.printIndent()
+		default:
+			fs.printIndent()
+		}
+		fs.printComment(kind, text)
+		fs.newlinePending = true
+
+	case current.inObject() && !current.field:
+		// Between a key and its value, which follows on the same line if
+		// possible. The colon is already followed by its configured spacing.
+		fs.printComment(kind, text)
+		if strings.HasPrefix(text, "//") {
+			fs.newlinePending = true
+		} else {
+			fs.printSpace(" ", true)
+		}
+
+	default:
+		afterMember := current.count > 0 && (current.inArray() || current.field)
+		if more && afterMember && !current.commaDone {
+			fs.printComma()
+			current.commaDone = true
+		}
+		switch {
+		case flushed:
+		case trailing || fs.compact:
+			// In compact output, only a comment following something else is spaced out.
+			if !fs.compact || current.count > 0 || current.comments || current.commaDone {
+				fs.printSpace(" ", true)
+			}
+		default:
+			fs.printSpace("\n", false)
+			fs.printBlankLine(current)
+			fs.printIndent()
+		}
+		fs.printComment(kind, text)
+		current.comments = true
+		if fs.compact && strings.HasPrefix(text, "//") {
+			fs.newlinePending = true
+		}
+	}
+}
+
+// flushNewline prints the newline and indentation due after a line comment,
+// if any, and reports whether it did.
+func (fs *formatterState) flushNewline() bool {
+	if !fs.newlinePending {
+		return false
+	}
+	fs.newlinePending = false
+	fs.printSpace("\n", true)
+	fs.printIndent()
+	return true
+}
@@ -0,0 +1,118 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestJSONCComments(t *testing.T) {
+	const src = "// head\n{\"a\": 1, // one\n \"b\": [1, 2], /* block */ \"c\": {}}"
+	tests := []struct {
+		indent string
+		want   string
+	}{
+		{"  ", "// head\n{\n  \"a\": 1, // one\n  \"b\": [\n    1,\n    2\n  ], /* block */\n  \"c\": {}\n}"},
+		// In compact output, line comments still end their line.
+		{"", "// head\n{\"a\":1, // one\n\"b\":[1,2], /* block */\"c\":{}}"},
+	}
+	for _, tt := range tests {
+		f := &Formatter{Syntax: SyntaxJSONC, Indent: tt.indent}
+		if got := formatPlain(t, f, src); got != tt.want {
+			t.Errorf("indent %q: got %q, want %q", tt.indent, got, tt.want)
+		}
+	}
+}
+
+func TestJSONCCommentPlacement(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxJSONC, Indent: "  "}
+	tests := []struct {
+		src, want string
+	}{
+		// The comma separating two elements goes ahead of a trailing comment.
+		{"[1 // x\n, 2]", "[\n  1, // x\n  2\n]"},
+		// A comment between a key and its value stays on the line.
+		{`{"a": /* v */ 1}`, "{\n  \"a\": /* v */ 1\n}"},
+		// Leading comments start lines of their own.
+		{"[\n// first\n1]", "[\n  // first\n  1\n]"},
+		{"1 // end", "1 // end"},
+	}
+	for _, tt := range tests {
+		if got := formatPlain(t, f, tt.src); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestJSONCTopLevelValues(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxJSONC}
+	tests := []struct {
+		src, want string
+	}{
+		{`1 [2] {"a": 3}`, "1\n[2]\n{\"a\":3}"},
+		// A line comment already ends the line.
+		{"1 // one\n2", "1 // one\n2"},
+		{"1 /* c */ 2", "1 /* c */\n2"},
+	}
+	for _, tt := range tests {
+		if got := formatPlain(t, f, tt.src); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestJSONCTrailingCommas(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxJSONC}
+	if got, want := formatPlain(t, f, `{"a": [1, 2,], "b": {"c": true,},}`), `{"a":[1,2],"b":{"c":true}}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestJSONCCommentColor(t *testing.T) {
+	f := &Formatter{Syntax: SyntaxJSONC, CommentColor: tagColor("c"), NumberColor: tagColor("n")}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, []byte("[1 /* x */]")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[<n>1</n> <c>/* x */</c>]"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestJSONCErrors(t *testing.T) {
+	tests := []struct {
+		src, wantErr string
+	}{
+		{`{a: 1}`, "invalid character 'a' at offset 1"},
+		{`[1,,]`, "invalid character ',' at offset 3"},
+		{`[1 2]`, "invalid character '2' at offset 3"},
+		{`nullx`, "invalid character 'n' at offset 0"},
+		{`'a'`, "invalid character '\\'' at offset 0"},
+		{`/* x`, "unterminated comment at offset 0"},
+		{`[01]`, `invalid number "01"`},
+	}
+	for _, tt := range tests {
+		err := (&Formatter{Syntax: SyntaxJSONC}).Format(io.Discard, []byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: got error %v, want one containing %q", tt.src, err, tt.wantErr)
+		}
+	}
+
+	// Input cut short is reported as such, including right after a key.
+	for _, src := range []string{`{"a"`, `{"a":`, `[1`, `{"a":1 // c`, `"a`} {
+		err := (&Formatter{Syntax: SyntaxJSONC}).Format(io.Discard, []byte(src))
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%q: got %v, want %v", src, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestSyntaxString(t *testing.T) {
+	for s, want := range map[Syntax]string{SyntaxJSON: "JSON", SyntaxJSONC: "JSONC", Syntax(99): "Syntax(99)"} {
+		if got := s.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
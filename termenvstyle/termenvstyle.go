@@ -0,0 +1,58 @@
+// Package termenvstyle converts between termenv styles and jsoncolor styles,
+// so that applications styling their output with github.com/muesli/termenv
+// can colorize JSON with the same theme.
+package termenvstyle
+
+import (
+	"strconv"
+
+	"github.com/amterp/jsoncolor"
+	"github.com/muesli/termenv"
+)
+
+// From returns a jsoncolor.SprintfFuncer styling text with the termenv style
+// `s`, for use as a color field of a jsoncolor.Formatter:
+//
+//	f := &jsoncolor.Formatter{
+//		FieldColor: termenvstyle.From(output.String().Foreground(output.Color("6"))),
+//	}
+//
+// The style is rendered for the termenv profile it was created with,
+// regardless of whether github.com/amterp/color disables colors.
+func From(s termenv.Style) jsoncolor.StyleFunc {
+	return jsoncolor.StyleFunc(s.Styled)
+}
+
+// To returns the termenv style made of the attributes of the jsoncolor style
+// `s`, with its colors converted to the termenv profile `p`. Attributes
+// termenv has no equivalent for, such as Concealed, are left out.
+func To(s jsoncolor.Style, p termenv.Profile) termenv.Style {
+	t := p.String()
+	for _, attr := range s {
+		if n, background, ok := attr.Color(); ok {
+			if background {
+				t = t.Background(p.Color(strconv.Itoa(n)))
+			} else {
+				t = t.Foreground(p.Color(strconv.Itoa(n)))
+			}
+			continue
+		}
+		switch attr {
+		case jsoncolor.Bold:
+			t = t.Bold()
+		case jsoncolor.Faint:
+			t = t.Faint()
+		case jsoncolor.Italic:
+			t = t.Italic()
+		case jsoncolor.Underline:
+			t = t.Underline()
+		case jsoncolor.BlinkSlow, jsoncolor.BlinkRapid:
+			t = t.Blink()
+		case jsoncolor.ReverseVideo:
+			t = t.Reverse()
+		case jsoncolor.CrossedOut:
+			t = t.CrossOut()
+		}
+	}
+	return t
+}
@@ -0,0 +1,34 @@
+package termenvstyle
+
+import (
+	"testing"
+
+	"github.com/amterp/jsoncolor"
+	"github.com/muesli/termenv"
+)
+
+func TestTo(t *testing.T) {
+	s := jsoncolor.Style{jsoncolor.FgCyan, jsoncolor.BgHiRed, jsoncolor.Bold, jsoncolor.Concealed, jsoncolor.Underline}
+	tests := []struct {
+		profile termenv.Profile
+		want    string
+	}{
+		// Concealed has no termenv equivalent, and is left out.
+		{termenv.ANSI, "\x1b[36;101;1;4mx\x1b[0m"},
+		// ANSI colors are kept as they are by richer profiles.
+		{termenv.TrueColor, "\x1b[36;101;1;4mx\x1b[0m"},
+		{termenv.Ascii, "x"},
+	}
+	for _, tt := range tests {
+		if got := To(s, tt.profile).Styled("x"); got != tt.want {
+			t.Errorf("profile %v: got %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestFrom(t *testing.T) {
+	s := termenv.ANSI.String().Foreground(termenv.ANSI.Color("2")).Italic()
+	if got, want := From(s).SprintfFunc()("%s%d", "a", 1), "\x1b[32;3ma1\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package jsoncolor
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// TextConv writes the JSON values read from `r` to `w` in a form suited to
+// git's textconv filters, which convert files to text before they are diffed:
+// each value is written in the canonical form produced by Normalize, so that
+// differences in key order or whitespace don't show up in diffs. If `w` is a
+// terminal, the output is colorized with the DefaultFormatter (with sorted
+// keys and two-space indentation) instead, for direct use from a shell.
+//
+// Values are written as soon as they are read, so large files and streams of
+// several values, such as JSON Lines, start showing output immediately. If the
+// input turns out not to be valid JSON, the rest of it is copied to `w`
+// unchanged, from the end of the last valid value, rather than failing the
+// diff; the returned error is then nil unless reading or writing failed.
+//
+// To diff JSON files through a program calling TextConvFile with its argument:
+//
+//	# .gitattributes
+//	*.json diff=json
+//
+//	# .git/config
+//	[diff "json"]
+//		textconv = my-json-textconv
+//		cachetextconv = true
+func TextConv(w io.Writer, r io.Reader) error {
+	f := newNormalizeFormatter()
+	if isTerminal(w) {
+		f = DefaultFormatter.clone()
+		f.SortKeys = true
+		f.setIndent("", normalizeIndent)
+		f.TrailingNewline = NewlineAlways
+	}
+
+	bw := bufio.NewWriter(w)
+	rr := &recordingReader{r: r}
+	dec := json.NewDecoder(rr)
+	for {
+		var value json.RawMessage
+		err := dec.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Fall back to copying the input as is, starting with what was read
+			// since the end of the last value.
+			if _, err := bw.Write(rr.buf); err != nil {
+				return err
+			}
+			if _, err := io.Copy(bw, r); err != nil {
+				return err
+			}
+			break
+		}
+		if err := f.format(bw, value, true); err != nil {
+			return err
+		}
+		// The value is complete, so the input it was read from is no longer needed.
+		rr.discard(dec.InputOffset())
+		// Flush after each value, so that output keeps up with a slow input stream.
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// TextConvFile is like TextConv, reading the file at `path`. It's meant to be
+// called with the argument git passes to a textconv program.
+func TextConvFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return TextConv(w, file)
+}
+
+// recordingReader keeps a copy of the bytes read from `r` since the position
+// last passed to discard, so that they can be written out as is.
+type recordingReader struct {
+	r    io.Reader
+	buf  []byte // The bytes read since `base`.
+	base int64  // The offset in the input of the first byte of buf.
+}
+
+func (rr *recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[:n]...)
+	return n, err
+}
+
+// discard forgets the bytes read before `offset`.
+func (rr *recordingReader) discard(offset int64) {
+	rr.buf = append(rr.buf[:0], rr.buf[offset-rr.base:]...)
+	rr.base = offset
+}
+
+// terminalWriter is implemented by writers standing for a terminal, such as
+// Pager.
+type terminalWriter interface {
+	// terminal returns the terminal, or nil if the writer doesn't stand for one.
+	terminal() *os.File
+}
+
+// isTerminal reports whether `w` is a terminal.
+func isTerminal(w io.Writer) bool {
+	if t, ok := w.(terminalWriter); ok {
+		return t.terminal() != nil
+	}
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,55 @@
+package jsoncolor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTextConv(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"values", "{\"b\":1,\"a\":[1,2]}\n{\"z\":null}", "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": 1\n}\n{\n  \"z\": null\n}\n"},
+		// Invalid input is copied as is, from the end of the last valid value.
+		{"invalid", "[1] {\"a\": oops, \"b\": 2}\ntrailing", "[\n  1\n]\n {\"a\": oops, \"b\": 2}\ntrailing"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		// Reading a byte at a time checks that nothing is lost between reads.
+		var sb strings.Builder
+		if err := TextConv(&sb, iotest.OneByteReader(strings.NewReader(tt.src))); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sb.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, sb.String(), tt.want)
+		}
+	}
+
+	errRead := errors.New("read failed")
+	if err := TextConv(&strings.Builder{}, iotest.ErrReader(errRead)); !errors.Is(err, errRead) {
+		t.Errorf("got %v, want %v", err, errRead)
+	}
+}
+
+func TestTextConvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.json")
+	if err := os.WriteFile(path, []byte(`{"b":true,"a":"x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := TextConvFile(&sb, path); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": \"x\",\n  \"b\": true\n}\n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+
+	if err := TextConvFile(&sb, path+".missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, want %v", err, fs.ErrNotExist)
+	}
+}
@@ -0,0 +1,277 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Theme is a named set of colors for all the kinds of tokens, applied to a
+// Formatter with Formatter.ApplyTheme. Its fields are those of the Formatter,
+// and a nil field leaves the default color in effect. Themes can be looked up
+// by name among the built-in ones and those registered with RegisterTheme,
+// see LookupTheme.
+//
+// Colors can carry text attributes besides colors, e.g. faint italic nulls
+// and underlined keys with Style{Faint, Italic} and Hex("#61afef", Underline),
+// or "faint italic" and "underline #61afef" in theme files. Bold, faint,
+// italic, underlined and strikethrough text are kept by FormatHTML and the
+// other formats, as far as they can express them.
+type Theme struct {
+	// Name is the name of the theme, e.g. "dracula", under which it's
+	// registered and recorded in headers (see Formatter.ThemeName).
+	Name string
+
+	SpaceColor       SprintfFuncer
+	CommaColor       SprintfFuncer
+	ColonColor       SprintfFuncer
+	ObjectColor      SprintfFuncer
+	ArrayColor       SprintfFuncer
+	FieldQuoteColor  SprintfFuncer
+	FieldColor       SprintfFuncer
+	StringQuoteColor SprintfFuncer
+	StringColor      SprintfFuncer
+	TrueColor        SprintfFuncer
+	FalseColor       SprintfFuncer
+	NumberColor      SprintfFuncer
+	NullColor        SprintfFuncer
+	ErrorColor       SprintfFuncer
+	IndentGuideColor SprintfFuncer
+	SkeletonColor    SprintfFuncer
+	CommentColor     SprintfFuncer
+	PunctuationColor SprintfFuncer
+	RawColor         SprintfFuncer
+	WrapMarkerColor  SprintfFuncer
+}
+
+// ApplyTheme sets all the color fields of the Formatter to those of the theme
+// `t`, including the nil ones, so that no color of the Formatter's previous
+// look is left, and its ThemeName to the name of the theme. Other fields,
+// including LightColors and DarkColors, are left as they are.
+func (f *Formatter) ApplyTheme(t Theme) {
+	f.ThemeName = t.Name
+	f.SpaceColor = t.SpaceColor
+	f.CommaColor = t.CommaColor
+	f.ColonColor = t.ColonColor
+	f.ObjectColor = t.ObjectColor
+	f.ArrayColor = t.ArrayColor
+	f.FieldQuoteColor = t.FieldQuoteColor
+	f.FieldColor = t.FieldColor
+	f.StringQuoteColor = t.StringQuoteColor
+	f.StringColor = t.StringColor
+	f.TrueColor = t.TrueColor
+	f.FalseColor = t.FalseColor
+	f.NumberColor = t.NumberColor
+	f.NullColor = t.NullColor
+	f.ErrorColor = t.ErrorColor
+	f.IndentGuideColor = t.IndentGuideColor
+	f.SkeletonColor = t.SkeletonColor
+	f.CommentColor = t.CommentColor
+	f.PunctuationColor = t.PunctuationColor
+	f.RawColor = t.RawColor
+	f.WrapMarkerColor = t.WrapMarkerColor
+}
+
+// Theme returns the theme in effect for the Formatter, named after its
+// ThemeName: its colors as resolved when formatting, including the default
+// colors, the variant for the Background (see LightColors) and the colors
+// disabled by Colorize, which are empty Styles. PunctuationColor is left nil,
+// since it's resolved into the colors of commas, colons and delimiters.
+func (f *Formatter) Theme() Theme {
+	return Theme{
+		Name:             f.ThemeName,
+		SpaceColor:       f.spaceColor(),
+		CommaColor:       f.commaColor(),
+		ColonColor:       f.colonColor(),
+		ObjectColor:      f.objectColor(),
+		ArrayColor:       f.arrayColor(),
+		FieldQuoteColor:  f.fieldQuoteColor(),
+		FieldColor:       f.fieldColor(),
+		StringQuoteColor: f.stringQuoteColor(),
+		StringColor:      f.stringColor(),
+		TrueColor:        f.trueColor(),
+		FalseColor:       f.falseColor(),
+		NumberColor:      f.numberColor(),
+		NullColor:        f.nullColor(),
+		ErrorColor:       f.errorColor(),
+		IndentGuideColor: f.indentGuideColor(),
+		SkeletonColor:    f.skeletonColor(),
+		CommentColor:     f.commentColor(),
+		RawColor:         f.rawColor(),
+		WrapMarkerColor:  f.wrapMarkerColor(),
+	}
+}
+
+// With returns the theme derived from `t` by overriding its colors with the
+// non-nil colors of `overrides`, named after `overrides` if it has a name,
+// e.g. Dracula with dim nulls:
+//
+//	dracula, _ := jsoncolor.LookupTheme("dracula")
+//	f.ApplyTheme(dracula.With(jsoncolor.Theme{NullColor: jsoncolor.Style{jsoncolor.Faint}}))
+//
+// Themes derived in turn from the result inherit from both, the latest
+// overrides winning.
+func (t Theme) With(overrides Theme) Theme {
+	if overrides.Name != "" {
+		t.Name = overrides.Name
+	}
+	roles := t.roles()
+	for i, role := range overrides.roles() {
+		if *role.color != nil {
+			*roles[i].color = *role.color
+		}
+	}
+	return t
+}
+
+// themes holds the registered themes by lowercase name.
+var (
+	themesMu sync.RWMutex
+	themes   = map[string]Theme{}
+)
+
+// RegisterTheme registers the theme `t` under its name, case-insensitively,
+// replacing any theme registered under the same name, including a built-in
+// one. It panics if the name is empty.
+func RegisterTheme(t Theme) {
+	if t.Name == "" {
+		panic("jsoncolor: cannot register a Theme without a name")
+	}
+	themesMu.Lock()
+	defer themesMu.Unlock()
+	themes[strings.ToLower(t.Name)] = t
+}
+
+// LookupTheme returns the theme registered under `name`, case-insensitively,
+// such as one of the built-in themes "monokai", "solarized", "dracula" and
+// "nord", or the accessible "colorblind" and "high-contrast", which have
+// variants for light backgrounds named with the suffix "-light". It returns
+// false if no theme is registered under the name.
+func LookupTheme(name string) (Theme, bool) {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	t, ok := themes[strings.ToLower(name)]
+	return t, ok
+}
+
+// ThemeNames returns the names of the registered themes, sorted.
+func ThemeNames() []string {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	names := make([]string, 0, len(themes))
+	for _, t := range themes {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeEnv is the environment variable selecting a theme for
+// Formatter.ApplyEnvTheme, so that the users of a tool can pick its colors
+// without the tool exposing a flag.
+const ThemeEnv = "JSONCOLOR_THEME"
+
+// EnvTheme returns the theme selected by the environment variable
+// JSONCOLOR_THEME: a registered theme, by name (see LookupTheme), or else a
+// theme file, by path (see LoadTheme). It returns false if the variable is
+// unset or empty, and an error if it names neither a theme nor a file.
+func EnvTheme() (Theme, bool, error) {
+	name := os.Getenv(ThemeEnv)
+	if name == "" {
+		return Theme{}, false, nil
+	}
+	if t, ok := LookupTheme(name); ok {
+		return t, true, nil
+	}
+	if _, err := os.Stat(name); err != nil {
+		return Theme{}, false, fmt.Errorf("jsoncolor: %s=%s is neither a theme nor a theme file", ThemeEnv, name)
+	}
+	t, err := LoadTheme(name)
+	if err != nil {
+		return Theme{}, false, err
+	}
+	return t, true, nil
+}
+
+// ApplyEnvTheme applies the theme selected by the environment variable
+// JSONCOLOR_THEME (see EnvTheme) to the Formatter, and leaves it as it is if
+// the variable is unset or the theme can't be loaded, in which case it
+// returns the error. Importing the package
+// github.com/amterp/jsoncolor/envtheme applies the theme to the
+// DefaultFormatter on startup.
+func (f *Formatter) ApplyEnvTheme() error {
+	t, ok, err := EnvTheme()
+	if ok {
+		f.ApplyTheme(t)
+	}
+	return err
+}
+
+// newTheme returns a theme named `name` in which punctuation, keys, strings,
+// numbers, booleans, null, comments and errors have the given colors. Faint
+// elements, such as indent guides, have the color of comments.
+func newTheme(name string, punctuation, key, str, number, boolean, null, comment, err Style) Theme {
+	return Theme{
+		Name:             name,
+		PunctuationColor: punctuation,
+		FieldQuoteColor:  key,
+		FieldColor:       key,
+		StringQuoteColor: str,
+		StringColor:      str,
+		NumberColor:      number,
+		TrueColor:        boolean,
+		FalseColor:       boolean,
+		NullColor:        null,
+		CommentColor:     append(comment[:len(comment):len(comment)], Italic),
+		IndentGuideColor: comment,
+		SkeletonColor:    comment,
+		WrapMarkerColor:  comment,
+		ErrorColor:       append(err[:len(err):len(err)], Bold),
+	}
+}
+
+func init() {
+	for _, t := range []Theme{
+		// https://monokai.pro
+		newTheme("monokai",
+			Hex("#f8f8f2"), Hex("#f92672"), Hex("#e6db74"), Hex("#ae81ff"),
+			Hex("#ae81ff"), Hex("#ae81ff"), Hex("#75715e"), Hex("#f92672")),
+		// https://ethanschoonover.com/solarized. Punctuation is left in the
+		// color of the terminal, so that the theme suits dark and light
+		// backgrounds alike.
+		newTheme("solarized",
+			nil, Hex("#268bd2"), Hex("#2aa198"), Hex("#d33682"),
+			Hex("#cb4b16"), Hex("#6c71c4"), Hex("#93a1a1"), Hex("#dc322f")),
+		// https://draculatheme.com
+		newTheme("dracula",
+			Hex("#f8f8f2"), Hex("#8be9fd"), Hex("#f1fa8c"), Hex("#bd93f9"),
+			Hex("#bd93f9"), Hex("#ff79c6"), Hex("#6272a4"), Hex("#ff5555")),
+		// https://www.nordtheme.com
+		newTheme("nord",
+			Hex("#d8dee9"), Hex("#8fbcbb"), Hex("#a3be8c"), Hex("#b48ead"),
+			Hex("#81a1c1"), Hex("#81a1c1"), Hex("#616e88"), Hex("#bf616a")),
+		// Accessible themes, for dark and light backgrounds. The colorblind
+		// ones tell apart keys, strings, numbers and booleans by blue,
+		// orange, yellow and purple, from the palette of Okabe and Ito, which
+		// are distinct with deuteranopia and protanopia alike, and have a
+		// contrast ratio of at least 4.5 with black or white (see
+		// ContrastRatio). The high-contrast ones have a contrast ratio of at
+		// least 7, and bold keys.
+		newTheme("colorblind",
+			nil, Hex("#56b4e9"), Hex("#e69f00"), Hex("#f0e442"),
+			Hex("#cc79a7"), Hex("#bbbbbb"), Hex("#999999"), Hex("#ef6f2e")),
+		newTheme("colorblind-light",
+			nil, Hex("#0060a0"), Hex("#9a5800"), Hex("#6b5f00"),
+			Hex("#a0407a"), Hex("#555555"), Hex("#6a6a6a"), Hex("#b33c00")),
+		newTheme("high-contrast",
+			Hex("#ffffff"), Hex("#87d7ff", Bold), Hex("#afff87"), Hex("#ffd75f"),
+			Hex("#ff87ff"), Hex("#e4e4e4"), Hex("#c6c6c6"), Hex("#ff8787")),
+		newTheme("high-contrast-light",
+			Hex("#000000"), Hex("#00336e", Bold), Hex("#005000"), Hex("#6b3000"),
+			Hex("#6a0dad"), Hex("#3a3a3a"), Hex("#4e4e4e"), Hex("#a00000")),
+	} {
+		RegisterTheme(t)
+	}
+}
@@ -0,0 +1,134 @@
+package jsoncolor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestApplyTheme(t *testing.T) {
+	withColor(t, true)
+	theme, ok := LookupTheme("Solarized")
+	if !ok {
+		t.Fatal("solarized isn't registered")
+	}
+	f := &Formatter{ColorProfile: ProfileTrueColor, NullColor: Style{FgRed}, SortKeys: true}
+	f.ApplyTheme(theme)
+	if f.ThemeName != "solarized" || !f.SortKeys {
+		t.Errorf("got ThemeName %q, SortKeys %v", f.ThemeName, f.SortKeys)
+	}
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`{"a": [1, null]}`)); err != nil {
+		t.Fatal(err)
+	}
+	// The colors of the previous look are all replaced, and solarized leaves
+	// punctuation in the color of the terminal.
+	want := "{\x1b[38;2;38;139;210m\"\x1b[0m\x1b[38;2;38;139;210ma\x1b[0m\x1b[38;2;38;139;210m\"\x1b[0m:[\x1b[38;2;211;54;130m1\x1b[0m,\x1b[38;2;108;113;196mnull\x1b[0m]}"
+	if got := sb.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuiltinThemes(t *testing.T) {
+	for _, name := range []string{"monokai", "solarized", "dracula", "nord"} {
+		theme, ok := LookupTheme(name)
+		if !ok || theme.Name != name {
+			t.Errorf("%s: got %q, %v", name, theme.Name, ok)
+			continue
+		}
+		if theme.FieldColor == nil || theme.StringColor == nil || theme.NumberColor == nil || theme.ErrorColor == nil {
+			t.Errorf("%s: colors missing", name)
+		}
+	}
+	if !slices.IsSorted(ThemeNames()) {
+		t.Errorf("names not sorted: %v", ThemeNames())
+	}
+}
+
+func TestRegisterTheme(t *testing.T) {
+	RegisterTheme(Theme{Name: "Test-Theme", NumberColor: Style{Bold}})
+	t.Cleanup(func() {
+		themesMu.Lock()
+		delete(themes, "test-theme")
+		themesMu.Unlock()
+	})
+	theme, ok := LookupTheme("test-theme")
+	if !ok || theme.Name != "Test-Theme" {
+		t.Errorf("got %q, %v", theme.Name, ok)
+	}
+	if !slices.Contains(ThemeNames(), "Test-Theme") {
+		t.Errorf("names: %v", ThemeNames())
+	}
+	if _, ok := LookupTheme("no-such-theme"); ok {
+		t.Error("found an unregistered theme")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a theme without a name")
+		}
+	}()
+	RegisterTheme(Theme{})
+}
+
+func TestEnvTheme(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ocean.json")
+	if err := os.WriteFile(path, []byte(`{"string": "cyan"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		env, name string
+		ok        bool
+		err       string
+	}{
+		{"", "", false, ""},
+		{"Dracula", "dracula", true, ""},
+		{path, "ocean", true, ""},
+		{"no-such-theme", "", false, "JSONCOLOR_THEME=no-such-theme is neither a theme nor a theme file"},
+	}
+	for _, tt := range tests {
+		t.Setenv(ThemeEnv, tt.env)
+		theme, ok, err := EnvTheme()
+		if theme.Name != tt.name || ok != tt.ok || (err == nil) != (tt.err == "") || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%q: got %q, %v, %v", tt.env, theme.Name, ok, err)
+		}
+	}
+}
+
+func TestApplyEnvTheme(t *testing.T) {
+	f := &Formatter{NumberColor: Style{FgRed}}
+	t.Setenv(ThemeEnv, "no-such-theme")
+	// The Formatter is left as it is if the theme can't be found.
+	if err := f.ApplyEnvTheme(); err == nil || f.ThemeName != "" || f.NumberColor == nil {
+		t.Errorf("got %v, %+v", err, f)
+	}
+	t.Setenv(ThemeEnv, "nord")
+	if err := f.ApplyEnvTheme(); err != nil || f.ThemeName != "nord" {
+		t.Errorf("got %v, theme %q", err, f.ThemeName)
+	}
+}
+
+func TestThemeWith(t *testing.T) {
+	dracula, _ := LookupTheme("dracula")
+	theme := dracula.With(Theme{NullColor: Style{Faint}})
+	// Without a name, the derived theme keeps that of its base.
+	if theme.Name != "dracula" || !reflect.DeepEqual(theme.NullColor, Style{Faint}) {
+		t.Errorf("got %q, null %v", theme.Name, theme.NullColor)
+	}
+	if !reflect.DeepEqual(theme.StringColor, dracula.StringColor) {
+		t.Errorf("got string %v, want %v", theme.StringColor, dracula.StringColor)
+	}
+	// The base is left alone.
+	if reflect.DeepEqual(dracula.NullColor, Style{Faint}) {
+		t.Error("dracula was modified")
+	}
+
+	// The latest overrides win.
+	theme = theme.With(Theme{Name: "mine", NullColor: Style{FgRed}})
+	if theme.Name != "mine" || !reflect.DeepEqual(theme.NullColor, Style{FgRed}) {
+		t.Errorf("got %q, null %v", theme.Name, theme.NullColor)
+	}
+}
@@ -0,0 +1,496 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// attributeNames holds the names of the text attributes in the specs of
+// ParseStyle and Style.String, indexed by attribute.
+var attributeNames = [CrossedOut + 1]string{
+	Bold:         "bold",
+	Faint:        "faint",
+	Italic:       "italic",
+	Underline:    "underline",
+	BlinkSlow:    "blink",
+	BlinkRapid:   "rapid-blink",
+	ReverseVideo: "reverse",
+	Concealed:    "concealed",
+	CrossedOut:   "strikethrough",
+}
+
+// attributeAliases holds other names accepted by ParseStyle.
+var attributeAliases = map[string]Attribute{
+	"dim":         Faint,
+	"ul":          Underline,
+	"hidden":      Concealed,
+	"strike":      CrossedOut,
+	"crossed-out": CrossedOut,
+}
+
+// colorNames holds the names of the 16 ANSI colors, by color number.
+var colorNames = [16]string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"bright-black", "bright-red", "bright-green", "bright-yellow",
+	"bright-blue", "bright-magenta", "bright-cyan", "bright-white",
+}
+
+// ParseStyle returns the Style described by `spec`, a list of words
+// separated by spaces, such as "bold #61afef" or "white on red":
+//
+//   - the text attributes bold, faint (or dim), italic, underline, blink,
+//     rapid-blink, reverse, concealed and strikethrough;
+//   - the colors black, red, green, yellow, blue, magenta, cyan and white, and
+//     their bright variants, e.g. bright-red (or hi-red), with gray for
+//     bright-black;
+//   - colors of the 256-color palette by number, e.g. 208;
+//   - 24-bit colors in hexadecimal, e.g. #61afef or #fa0.
+//
+// A color is a foreground color unless it follows the word "on". The spec
+// "none", like an empty one, leaves text unstyled. Words are
+// case-insensitive.
+func ParseStyle(spec string) (Style, error) {
+	style := Style{}
+	words := strings.Fields(strings.ToLower(spec))
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if word == "none" && len(words) == 1 {
+			break
+		}
+		if attr, ok := parseAttribute(word); ok {
+			style = append(style, attr)
+			continue
+		}
+		background := word == "on"
+		if background {
+			if i++; i == len(words) {
+				return nil, fmt.Errorf("jsoncolor: missing color after %q in style %q", word, spec)
+			}
+			word = words[i]
+		}
+		color, ok := parseColor(word, background)
+		if !ok {
+			return nil, fmt.Errorf("jsoncolor: unknown color or attribute %q in style %q", word, spec)
+		}
+		style = append(style, color...)
+	}
+	return style, nil
+}
+
+// parseAttribute returns the text attribute named `name`.
+func parseAttribute(name string) (Attribute, bool) {
+	for attr, attrName := range attributeNames {
+		if attrName != "" && attrName == name {
+			return Attribute(attr), true
+		}
+	}
+	attr, ok := attributeAliases[name]
+	return attr, ok
+}
+
+// parseColor returns the attributes setting the foreground or background
+// color named `name`.
+func parseColor(name string, background bool) (Style, bool) {
+	fg, bg := Attribute(38), Attribute(48)
+	if background {
+		fg = bg
+	}
+	if strings.HasPrefix(name, "#") {
+		r, g, b, ok := parseHexColor(name)
+		if !ok {
+			return nil, false
+		}
+		return Style{fg, 2, Attribute(r), Attribute(g), Attribute(b)}, true
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 0 || n > 255 {
+			return nil, false
+		}
+		return Style{fg, 5, Attribute(n)}, true
+	}
+	switch name {
+	case "gray", "grey":
+		name = "bright-black"
+	default:
+		if strings.HasPrefix(name, "hi-") {
+			name = "bright-" + name[len("hi-"):]
+		}
+	}
+	for n, colorName := range colorNames {
+		if colorName != name {
+			continue
+		}
+		base := FgBlack
+		if background {
+			base = BgBlack
+		}
+		if n >= 8 {
+			base += FgHiBlack - FgBlack
+			n -= 8
+		}
+		return Style{base + Attribute(n)}, true
+	}
+	return nil, false
+}
+
+// String returns the spec of the Style in the form read by ParseStyle, e.g.
+// "bold #61afef" or "white on red". Attributes which can't be expressed in
+// a spec, such as Reset, are left out.
+func (s Style) String() string {
+	var words []string
+	for i := 0; i < len(s); i++ {
+		attr := s[i]
+		if attr >= Bold && attr <= CrossedOut {
+			words = append(words, attributeNames[attr])
+			continue
+		}
+		var color string
+		background := false
+		if n, bg, ok := attr.Color(); ok {
+			color, background = colorNames[n], bg
+		} else if attr == 38 || attr == 48 {
+			codes := make([]int, 0, 4)
+			for _, code := range s[i+1:] {
+				codes = append(codes, int(code))
+			}
+			used := 0
+			switch _, used = extendedColor(codes); {
+			case used == 2:
+				color = strconv.Itoa(codes[1])
+			case used == 4:
+				color = fmt.Sprintf("#%02x%02x%02x", uint8(codes[1]), uint8(codes[2]), uint8(codes[3]))
+			}
+			background = attr == 48
+			i += used
+		}
+		if color == "" {
+			continue
+		}
+		if background {
+			words = append(words, "on")
+		}
+		words = append(words, color)
+	}
+	return strings.Join(words, " ")
+}
+
+// sampleStyle returns the Style applied by the color `c`, sampled from the
+// escape sequences it writes when enabled.
+func sampleStyle(c SprintfFuncer) Style {
+	sample := []byte(forceColor(c).SprintfFunc()("%s", "x"))
+	var state sgrState
+	for len(sample) > 0 && sample[0] == '\x1b' {
+		params, final, n := escapeSequence(sample)
+		if final == 'm' {
+			state.apply(params)
+		}
+		sample = sample[n:]
+	}
+	style := state.style()
+	if style == nil {
+		style = Style{}
+	}
+	return style
+}
+
+// themeRole is a color of a Theme, under its name in theme files.
+type themeRole struct {
+	name  string
+	color *SprintfFuncer
+}
+
+// roles returns the colors of the theme, in the order of its fields.
+func (t *Theme) roles() []themeRole {
+	return []themeRole{
+		{"space", &t.SpaceColor},
+		{"comma", &t.CommaColor},
+		{"colon", &t.ColonColor},
+		{"object", &t.ObjectColor},
+		{"array", &t.ArrayColor},
+		{"field-quote", &t.FieldQuoteColor},
+		{"field", &t.FieldColor},
+		{"string-quote", &t.StringQuoteColor},
+		{"string", &t.StringColor},
+		{"true", &t.TrueColor},
+		{"false", &t.FalseColor},
+		{"number", &t.NumberColor},
+		{"null", &t.NullColor},
+		{"error", &t.ErrorColor},
+		{"indent-guide", &t.IndentGuideColor},
+		{"skeleton", &t.SkeletonColor},
+		{"comment", &t.CommentColor},
+		{"punctuation", &t.PunctuationColor},
+		{"raw", &t.RawColor},
+		{"wrap-marker", &t.WrapMarkerColor},
+	}
+}
+
+// setColors sets the name and colors of the theme from the specs `specs`, by
+// name in theme files. A nil spec leaves the color nil, or that of the theme
+// named by the spec "inherit", as returned by `lookup`.
+func (t *Theme) setColors(specs map[string]*string, lookup func(name string) (Theme, error)) error {
+	*t = Theme{}
+	roles := t.roles()
+	var inherit string
+	for name, spec := range specs {
+		if name == "name" || name == "inherit" {
+			if spec == nil {
+				continue
+			}
+			if name == "name" {
+				t.Name = *spec
+			} else {
+				inherit = *spec
+			}
+			continue
+		}
+		i := 0
+		for i < len(roles) && roles[i].name != name {
+			i++
+		}
+		if i == len(roles) {
+			return fmt.Errorf("jsoncolor: unknown theme color %q", name)
+		}
+		if spec == nil {
+			continue
+		}
+		style, err := ParseStyle(*spec)
+		if err != nil {
+			return fmt.Errorf("%w for theme color %q", err, name)
+		}
+		*roles[i].color = style
+	}
+	if inherit != "" {
+		base, err := lookup(inherit)
+		if err != nil {
+			return err
+		}
+		name := t.Name
+		*t = base.With(*t)
+		t.Name = name
+	}
+	return nil
+}
+
+// lookupInherited returns the registered theme named `name`, for a theme
+// inheriting from it.
+func lookupInherited(name string) (Theme, error) {
+	t, ok := LookupTheme(name)
+	if !ok {
+		return Theme{}, fmt.Errorf("jsoncolor: unknown theme %q to inherit from", name)
+	}
+	return t, nil
+}
+
+// MarshalJSON encodes the theme as an object holding its name and the specs
+// of its non-nil colors, as read by ParseTheme, e.g.
+//
+//	{"name":"mine","field":"bold #61afef","null":"faint italic"}
+//
+// Colors other than Styles are sampled from the escape sequences they write.
+func (t Theme) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if t.Name != "" {
+		name, _ := json.Marshal(t.Name)
+		buf.WriteString(`"name":`)
+		buf.Write(name)
+	}
+	for _, role := range t.roles() {
+		if *role.color == nil {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		spec, _ := json.Marshal(sampleStyle(*role.color).String())
+		fmt.Fprintf(&buf, "%q:%s", role.name, spec)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a theme encoded by MarshalJSON. Colors missing from
+// the object, or null, are left nil.
+func (t *Theme) UnmarshalJSON(data []byte) error {
+	var specs map[string]*string
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return err
+	}
+	return t.setColors(specs, lookupInherited)
+}
+
+// ParseTheme parses a theme defined in JSON, as an object mapping the names of
+// its colors to style specs (see ParseStyle), along with its name, e.g.
+//
+//	{
+//	  "name": "mine",
+//	  "field": "bold #61afef",
+//	  "string": "green",
+//	  "null": "faint italic",
+//	  "punctuation": "none"
+//	}
+//
+// The colors are named after the fields of Theme, in lowercase and separated
+// by dashes: space, comma, colon, object, array, field-quote, field,
+// string-quote, string, true, false, number, null, error, indent-guide,
+// skeleton, comment, punctuation, raw and wrap-marker. Missing colors are
+// left nil, so that the defaults apply, unless the theme inherits the colors
+// of a registered theme, named by "inherit" (see Theme.With):
+//
+//	{"name": "dracula-dim-nulls", "inherit": "dracula", "null": "faint"}
+//
+// Theme files read with LoadTheme or LoadThemeFS can also inherit from the
+// theme files next to them.
+//
+// Themes in other formats are parsed by the packages registering them, like
+// github.com/amterp/jsoncolor/themeyaml for YAML (see RegisterThemeFormat).
+func ParseTheme(data []byte) (Theme, error) {
+	var t Theme
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Theme{}, err
+	}
+	return t, nil
+}
+
+// themeFormats holds the converters registered with RegisterThemeFormat, by
+// lowercase file extension.
+var (
+	themeFormatsMu sync.RWMutex
+	themeFormats   = map[string]func([]byte) ([]byte, error){}
+)
+
+// RegisterThemeFormat makes LoadTheme and LoadThemeFS read the theme files
+// with the extension `ext`, such as ".yaml", by converting them with `toJSON`
+// to the JSON read by ParseTheme. It replaces the converter previously
+// registered for the same extension, if any.
+//
+// Only JSON is supported out of the box. Packages adding other formats
+// register them when imported, like github.com/amterp/jsoncolor/themeyaml:
+//
+//	import _ "github.com/amterp/jsoncolor/themeyaml"
+func RegisterThemeFormat(ext string, toJSON func([]byte) ([]byte, error)) {
+	if ext == "" {
+		panic("jsoncolor: cannot register a theme format without an extension")
+	}
+	themeFormatsMu.Lock()
+	defer themeFormatsMu.Unlock()
+	themeFormats[strings.ToLower(ext)] = toJSON
+}
+
+// LoadTheme reads a theme from the file `path` with ParseTheme, or the
+// converter registered for its extension with RegisterThemeFormat. A theme
+// without a name is named after the file, without its extension.
+//
+// A theme file can also inherit from a theme file in the same directory, by
+// file name, e.g. "inherit": "base.yaml", which takes precedence over a
+// registered theme of the same name.
+func LoadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	l := &themeLoader{fsys: os.DirFS(filepath.Dir(path))}
+	return l.parse(filepath.Base(path), path, data)
+}
+
+// LoadThemeFS is like LoadTheme, reading the theme file `name` from the file
+// system `fsys`, such as an embed.FS. Inherited theme files are read from
+// `fsys` too, next to the theme file.
+func LoadThemeFS(fsys fs.FS, name string) (Theme, error) {
+	l := &themeLoader{fsys: fsys}
+	return l.load(name)
+}
+
+// themeLoader reads theme files from a file system, along with the theme files
+// they inherit from.
+type themeLoader struct {
+	fsys    fs.FS
+	loading []string // The theme files being parsed, to detect cycles.
+}
+
+// load reads and parses the theme file `name`.
+func (l *themeLoader) load(name string) (Theme, error) {
+	data, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return Theme{}, err
+	}
+	return l.parse(name, name, data)
+}
+
+// parse parses the theme file `name` holding `data`, referring to it as `file`
+// in errors.
+func (l *themeLoader) parse(name, file string, data []byte) (Theme, error) {
+	if slices.Contains(l.loading, name) {
+		return Theme{}, fmt.Errorf("jsoncolor: theme file %s inherits from itself", file)
+	}
+	l.loading = append(l.loading, name)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	t, err := l.decode(name, data)
+	if err != nil {
+		return Theme{}, fmt.Errorf("%w in theme file %s", err, file)
+	}
+	if t.Name == "" {
+		base := path.Base(name)
+		t.Name = strings.TrimSuffix(base, path.Ext(base))
+	}
+	return t, nil
+}
+
+// decode decodes the theme file `name` holding `data`, resolving the theme it
+// inherits from among the files next to it, and then the registered themes.
+func (l *themeLoader) decode(name string, data []byte) (Theme, error) {
+	themeFormatsMu.RLock()
+	toJSON := themeFormats[strings.ToLower(path.Ext(name))]
+	themeFormatsMu.RUnlock()
+	if toJSON != nil {
+		var err error
+		if data, err = toJSON(data); err != nil {
+			return Theme{}, err
+		}
+	}
+	var specs map[string]*string
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return Theme{}, err
+	}
+	var t Theme
+	err := t.setColors(specs, func(inherit string) (Theme, error) {
+		sibling := path.Join(path.Dir(name), inherit)
+		if fs.ValidPath(sibling) {
+			if info, err := fs.Stat(l.fsys, sibling); err == nil && !info.IsDir() {
+				return l.load(sibling)
+			}
+		}
+		return lookupInherited(inherit)
+	})
+	return t, err
+}
+
+// DumpTheme writes the theme in effect for the DefaultFormatter to `w`. See
+// Formatter.DumpTheme.
+func DumpTheme(w io.Writer) error {
+	return DefaultFormatter.DumpTheme(w)
+}
+
+// DumpTheme writes the theme in effect for the Formatter (see Formatter.Theme)
+// to `w` as indented JSON, with all of its colors including the defaults, so
+// that it can be tweaked and loaded back with LoadTheme.
+func (f *Formatter) DumpTheme(w io.Writer) error {
+	data, err := json.MarshalIndent(f.Theme(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
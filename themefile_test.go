@@ -0,0 +1,298 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		spec  string
+		style Style
+		str   string
+	}{
+		{"bold #61afef", Style{Bold, 38, 2, 97, 175, 239}, "bold #61afef"},
+		{"white on red", Style{FgWhite, BgRed}, "white on red"},
+		{"hi-red on 208", Style{FgHiRed, 48, 5, 208}, "bright-red on 208"},
+		{"GRAY dim", Style{FgHiBlack, Faint}, "bright-black faint"},
+		{"#fa0", Style{38, 2, 255, 170, 0}, "#ffaa00"},
+		{"strike ul", Style{CrossedOut, Underline}, "strikethrough underline"},
+		{"none", Style{}, ""},
+		{"", Style{}, ""},
+	}
+	for _, tt := range tests {
+		style, err := ParseStyle(tt.spec)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(style, tt.style) {
+			t.Errorf("%q: got %v, want %v", tt.spec, style, tt.style)
+		}
+		if got := style.String(); got != tt.str {
+			t.Errorf("%q: String() = %q, want %q", tt.spec, got, tt.str)
+		}
+	}
+	// Attributes without a spec are left out.
+	if got := (Style{Reset, FgRed}).String(); got != "red" {
+		t.Errorf("got %q, want %q", got, "red")
+	}
+}
+
+func TestParseStyleErrors(t *testing.T) {
+	tests := []struct{ spec, want string }{
+		{"on", `missing color after "on"`},
+		{"red on", `missing color after "on"`},
+		{"purple", `unknown color or attribute "purple"`},
+		{"#12345", `unknown color or attribute "#12345"`},
+		{"256", `unknown color or attribute "256"`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseStyle(tt.spec); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got error %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	want := Theme{
+		Name:             "mine",
+		FieldColor:       Style{Bold, 38, 2, 97, 175, 239},
+		TrueColor:        Style{FgGreen},
+		PunctuationColor: Style{},
+	}
+	got, err := ParseTheme([]byte(`{"name": "mine", "field": "bold #61afef", "true": "green", "null": null, "punctuation": "none"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseThemeErrors(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{`{"bogus": "red"}`, `unknown theme color "bogus"`},
+		{`{"field": "red on"}`, `missing color after "on" in style "red on" for theme color "field"`},
+		{`["a"]`, "cannot unmarshal array"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseTheme([]byte(tt.src)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestThemeRoundTrip(t *testing.T) {
+	for _, name := range ThemeNames() {
+		theme, _ := LookupTheme(name)
+		data, err := json.Marshal(theme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseTheme(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		again, _ := json.Marshal(parsed)
+		if string(again) != string(data) {
+			t.Errorf("%s: got %s, want %s", name, again, data)
+		}
+	}
+
+	theme, _ := LookupTheme("monokai")
+	data, _ := json.Marshal(theme)
+	want := `{"name":"monokai","field-quote":"#f92672","field":"#f92672","string-quote":"#e6db74","string":"#e6db74","true":"#ae81ff","false":"#ae81ff","number":"#ae81ff","null":"#ae81ff","error":"bold #f92672","indent-guide":"#75715e","skeleton":"#75715e","comment":"italic #75715e","punctuation":"#f8f8f2","wrap-marker":"#75715e"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ocean.json")
+	if err := os.WriteFile(path, []byte(`{"string": "cyan"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A theme without a name is named after its file.
+	theme, err := LoadTheme(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Name != "ocean" || !reflect.DeepEqual(theme.StringColor, Style{FgCyan}) {
+		t.Errorf("got %+v", theme)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"field": "purple"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTheme(bad); err == nil || !strings.Contains(err.Error(), "in theme file "+bad) {
+		t.Errorf("got error %v", err)
+	}
+	if _, err := LoadTheme(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("got error %v, want a missing file", err)
+	}
+}
+
+func TestLoadThemeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"themes/base.json":   {Data: []byte(`{"inherit": "dracula", "string": "cyan"}`)},
+		"themes/mine.json":   {Data: []byte(`{"inherit": "base.json", "null": "faint"}`)},
+		"themes/dracula":     {Mode: fs.ModeDir},
+		"themes/loop.json":   {Data: []byte(`{"inherit": "loop.json"}`)},
+		"themes/parent.json": {Data: []byte(`{"inherit": "../base.json"}`)},
+		"themes/bad.json":    {Data: []byte(`{"inherit": "broken.json"}`)},
+		"themes/broken.json": {Data: []byte(`{"field": "purple"}`)},
+	}
+	// Themes inherit from the files next to them, and then from the
+	// registered themes, such as dracula, rather than a directory.
+	theme, err := LoadThemeFS(fsys, "themes/mine.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dracula, _ := LookupTheme("dracula")
+	want := dracula.With(Theme{StringColor: Style{FgCyan}, NullColor: Style{Faint}})
+	want.Name = "mine"
+	if !reflect.DeepEqual(theme, want) {
+		t.Errorf("got %+v, want %+v", theme, want)
+	}
+
+	tests := []struct{ name, want string }{
+		{"themes/loop.json", "theme file themes/loop.json inherits from itself"},
+		{"themes/parent.json", `unknown theme "../base.json" to inherit from in theme file themes/parent.json`},
+		{"themes/bad.json", `for theme color "field" in theme file themes/broken.json in theme file themes/bad.json`},
+	}
+	for _, tt := range tests {
+		if _, err := LoadThemeFS(fsys, tt.name); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := LoadThemeFS(fsys, "themes/missing.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v, want a missing file", err)
+	}
+
+	// LoadTheme resolves inherited theme files in the same way.
+	dir := t.TempDir()
+	for _, name := range []string{"base.json", "mine.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), fsys["themes/"+name].Data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	theme, err = LoadTheme(filepath.Join(dir, "mine.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(theme, want) {
+		t.Errorf("got %+v, want %+v", theme, want)
+	}
+}
+
+func TestRegisterThemeFormat(t *testing.T) {
+	// A format parsing "role=spec" lines.
+	RegisterThemeFormat(".Roles", func(data []byte) ([]byte, error) {
+		specs := map[string]string{}
+		for _, line := range strings.Fields(string(data)) {
+			role, spec, _ := strings.Cut(line, "=")
+			specs[role] = spec
+		}
+		return json.Marshal(specs)
+	})
+	defer func() {
+		themeFormatsMu.Lock()
+		delete(themeFormats, ".roles")
+		themeFormatsMu.Unlock()
+	}()
+
+	path := filepath.Join(t.TempDir(), "mine.roles")
+	if err := os.WriteFile(path, []byte("string=cyan\nnull=faint\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	theme, err := LoadTheme(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Theme{Name: "mine", StringColor: Style{FgCyan}, NullColor: Style{Faint}}
+	if !reflect.DeepEqual(theme, want) {
+		t.Errorf("got %+v, want %+v", theme, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a format without an extension")
+		}
+	}()
+	RegisterThemeFormat("", func(data []byte) ([]byte, error) { return data, nil })
+}
+
+func TestDumpTheme(t *testing.T) {
+	f := &Formatter{ThemeName: "mine", StringColor: Style{FgCyan}, NullColor: Style{}}
+	var sb strings.Builder
+	if err := f.DumpTheme(&sb); err != nil {
+		t.Fatal(err)
+	}
+	// The default colors are written along with those set on the Formatter.
+	want := `{
+  "name": "mine",
+  "space": "",
+  "comma": "bold",
+  "colon": "bold",
+  "object": "bold",
+  "array": "bold",
+  "field-quote": "bold blue",
+  "field": "bold blue",
+  "string-quote": "green",
+  "string": "cyan",
+  "true": "",
+  "false": "",
+  "number": "",
+  "null": "",
+  "error": "bold red",
+  "indent-guide": "faint",
+  "skeleton": "faint",
+  "comment": "faint italic",
+  "wrap-marker": "faint"
+}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// The dump loads back into the same theme.
+	theme, err := ParseTheme([]byte(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Formatter{}
+	g.ApplyTheme(theme)
+	var again strings.Builder
+	if err := g.DumpTheme(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != want {
+		t.Errorf("got %s, want %s", again.String(), want)
+	}
+}
+
+func TestParseThemeInherit(t *testing.T) {
+	theme, err := ParseTheme([]byte(`{"name": "dim", "inherit": "dracula", "null": "faint"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(theme)
+	want := `{"name":"dim","field-quote":"#8be9fd","field":"#8be9fd","string-quote":"#f1fa8c","string":"#f1fa8c","true":"#bd93f9","false":"#bd93f9","number":"#bd93f9","null":"faint","error":"bold #ff5555","indent-guide":"#6272a4","skeleton":"#6272a4","comment":"italic #6272a4","punctuation":"#f8f8f2","wrap-marker":"#6272a4"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	if _, err := ParseTheme([]byte(`{"inherit": "nope"}`)); err == nil || !strings.Contains(err.Error(), `unknown theme "nope" to inherit from`) {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Package themeyaml reads and writes jsoncolor themes in YAML, and makes
+// jsoncolor.LoadTheme and jsoncolor.LoadThemeFS read the theme files with the
+// extension .yaml or .yml when it's imported, so that only the programs
+// reading YAML themes depend on a YAML parser:
+//
+//	import _ "github.com/amterp/jsoncolor/themeyaml"
+package themeyaml
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/amterp/jsoncolor"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	jsoncolor.RegisterThemeFormat(".yaml", ToJSON)
+	jsoncolor.RegisterThemeFormat(".yml", ToJSON)
+}
+
+// Parse parses a theme defined in YAML, as a mapping like the object read by
+// jsoncolor.ParseTheme, e.g.
+//
+//	name: mine
+//	inherit: dracula
+//	field: "bold #61afef"
+//	null: faint italic
+//
+// Keys are taken as they're written, so that the colors true, false and null
+// can be left unquoted. Specs with hexadecimal colors must be quoted, since #
+// starts a comment.
+func Parse(data []byte) (jsoncolor.Theme, error) {
+	object, err := ToJSON(data)
+	if err != nil {
+		return jsoncolor.Theme{}, err
+	}
+	return jsoncolor.ParseTheme(object)
+}
+
+// ToJSON converts a theme defined in YAML, as read by Parse, to the JSON read
+// by jsoncolor.ParseTheme.
+func ToJSON(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	specs := map[string]*string{}
+	if len(doc.Content) > 0 {
+		value := doc.Content[0]
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("themeyaml: line %d: a theme must be a mapping", value.Line)
+		}
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, spec := value.Content[i], value.Content[i+1]
+			if spec.Tag == "!!null" {
+				specs[key.Value] = nil
+				continue
+			}
+			var s string
+			if err := spec.Decode(&s); err != nil {
+				return nil, err
+			}
+			specs[key.Value] = &s
+		}
+	}
+	return json.Marshal(specs)
+}
+
+// Marshal encodes the theme `t` as a YAML mapping, with the name and colors of
+// the object of Theme.MarshalJSON in the same order.
+func Marshal(t jsoncolor.Theme) ([]byte, error) {
+	object, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is YAML: the object decodes to a flow mapping of quoted strings,
+	// which is written back in block style, quoting only where needed.
+	var node yaml.Node
+	if err := yaml.Unmarshal(object, &node); err != nil {
+		return nil, err
+	}
+	mapping := node.Content[0]
+	mapping.Style = 0
+	for _, n := range mapping.Content {
+		n.Style = 0
+	}
+	return yaml.Marshal(mapping)
+}
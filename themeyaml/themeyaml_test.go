@@ -0,0 +1,147 @@
+package themeyaml
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/amterp/jsoncolor"
+)
+
+func TestParse(t *testing.T) {
+	want := jsoncolor.Theme{
+		Name:             "mine",
+		FieldColor:       jsoncolor.Style{jsoncolor.Bold, 38, 2, 97, 175, 239},
+		TrueColor:        jsoncolor.Style{jsoncolor.FgGreen},
+		PunctuationColor: jsoncolor.Style{},
+	}
+	got, err := Parse([]byte("name: mine\nfield: \"bold #61afef\"\ntrue: green\nnull: ~\npunctuation: none\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// JSON is YAML too.
+	got, err = Parse([]byte(`{"name": "mine", "field": "bold #61afef", "true": "green", "null": null, "punctuation": "none"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got, err := Parse(nil); err != nil || !reflect.DeepEqual(got, jsoncolor.Theme{}) {
+		t.Errorf("got %+v, %v for an empty theme", got, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"- a", "line 1: a theme must be a mapping"},
+		{"bogus: red", `unknown theme color "bogus"`},
+		{"field: red on", `missing color after "on" in style "red on" for theme color "field"`},
+		{"inherit: nope", `unknown theme "nope" to inherit from`},
+		{"field: [red]", "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.src)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestParseInherit(t *testing.T) {
+	theme, err := Parse([]byte("name: dim\ninherit: dracula\nnull: faint\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dracula, _ := jsoncolor.LookupTheme("dracula")
+	want := dracula.With(jsoncolor.Theme{NullColor: jsoncolor.Style{jsoncolor.Faint}})
+	want.Name = "dim"
+	if !reflect.DeepEqual(theme, want) {
+		t.Errorf("got %+v, want %+v", theme, want)
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"ocean.yaml", "ocean.YML"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("string: cyan\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		theme, err := jsoncolor.LoadTheme(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if theme.Name != "ocean" || !reflect.DeepEqual(theme.StringColor, jsoncolor.Style{jsoncolor.FgCyan}) {
+			t.Errorf("%s: got %+v", name, theme)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("field: purple\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jsoncolor.LoadTheme(bad); err == nil || !strings.Contains(err.Error(), "in theme file "+bad) {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestLoadThemeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.yml":   {Data: []byte("inherit: dracula\nstring: cyan\n")},
+		"ocean.yaml": {Data: []byte("inherit: base.yml\nnull: faint\n")},
+	}
+	theme, err := jsoncolor.LoadThemeFS(fsys, "ocean.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dracula, _ := jsoncolor.LookupTheme("dracula")
+	want := dracula.With(jsoncolor.Theme{StringColor: jsoncolor.Style{jsoncolor.FgCyan}, NullColor: jsoncolor.Style{jsoncolor.Faint}})
+	want.Name = "ocean"
+	if !reflect.DeepEqual(theme, want) {
+		t.Errorf("got %+v, want %+v", theme, want)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	theme := jsoncolor.Theme{
+		Name:        "mine",
+		FieldColor:  jsoncolor.Style{jsoncolor.Bold, 38, 2, 97, 175, 239},
+		TrueColor:   jsoncolor.Style{jsoncolor.FgGreen},
+		NullColor:   jsoncolor.Style{jsoncolor.Faint},
+		NumberColor: jsoncolor.Style{},
+	}
+	data, err := Marshal(theme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "name: mine\nfield: 'bold #61afef'\n\"true\": green\nnumber: \"\"\n\"null\": faint\n"
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Every theme reads back as it was written.
+	for _, name := range jsoncolor.ThemeNames() {
+		theme, _ := jsoncolor.LookupTheme(name)
+		data, err := Marshal(theme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want, _ := json.Marshal(theme)
+		if got, _ := json.Marshal(parsed); string(got) != string(want) {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
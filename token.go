@@ -0,0 +1,166 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// TokenEncoder writes colorized JSON one token at a time, for programs which
+// already process a stream of tokens, such as proxies and transformers built
+// on encoding/json.Decoder.Token. The tokens written through WriteToken are
+// laid out exactly as the Formatter would lay out the equivalent JSON text,
+// except that objects are never rendered on a single line (see
+// Formatter.InlineMaxMembers), since that requires looking ahead, and keys
+// are written in the order they are given regardless of Formatter.SortKeys.
+//
+// Each complete top-level value is terminated by a newline, like
+// Encoder.Encode, unless disabled through Formatter.TrailingNewline, in which
+// case top-level values are still separated by newlines, as in JSON Lines,
+// and only the last one is left unterminated.
+type TokenEncoder struct {
+	fs           *formatterState
+	terminate    bool // True if each top-level value is followed by a newline.
+	unterminated bool // True if the last top-level value wasn't followed by a newline.
+}
+
+// NewTokenEncoder creates a new TokenEncoder that writes colorized JSON to `w`
+// using the DefaultFormatter.
+func NewTokenEncoder(w io.Writer) *TokenEncoder {
+	return NewTokenEncoderWithFormatter(w, DefaultFormatter)
+}
+
+// NewTokenEncoderWithFormatter creates a new TokenEncoder that writes colorized
+// JSON to `w` using the provided Formatter `f`. Unlike an Encoder, it uses
+// f.EscapeHTML as is, like Formatter.Format.
+// The Formatter must not be modified while the TokenEncoder is in use.
+func NewTokenEncoderWithFormatter(w io.Writer, f *Formatter) *TokenEncoder {
+	if f == nil {
+		panic("jsoncolor: cannot create TokenEncoder with a nil Formatter")
+	}
+	return newTokenEncoder(newFormatterState(f, w), f.TrailingNewline.resolve(true))
+}
+
+// newTokenEncoder creates a TokenEncoder writing through the formatter state
+// `fs`, terminating top-level values with a newline if `terminate` is true.
+func newTokenEncoder(fs *formatterState, terminate bool) *TokenEncoder {
+	// Paths are needed to report the location of invalid UTF-8 (see InvalidUTF8Error).
+	fs.trackPaths = true
+	return &TokenEncoder{fs: fs, terminate: terminate}
+}
+
+// WriteToken writes the next token of the JSON stream. The token must be one
+// of the types returned by encoding/json.Decoder.Token: a json.Delim for the
+// four JSON delimiters, a bool, a float64 or json.Number for numbers, a
+// string for strings and object keys, or nil for null. For convenience, any
+// other Go integer or floating-point type is accepted as a number as well.
+//
+// Commas, colons and whitespace are inserted automatically. WriteToken returns
+// an error, without writing anything, if the token would make the output
+// invalid JSON, e.g. a non-string object key or a mismatched closing delimiter.
+//
+// Strings are subject to the Formatter's InvalidUTF8Policy. With
+// InvalidUTF8Escape, replacement characters are escaped from the first string
+// containing invalid UTF-8 onwards.
+func (te *TokenEncoder) WriteToken(t json.Token) error {
+	t, err := te.normalizeToken(t)
+	if err != nil {
+		return err
+	}
+	// Closing delimiters can't start a top-level value, and are rejected below.
+	if t != json.Delim('}') && t != json.Delim(']') {
+		te.beginValue()
+	}
+	if err := te.fs.writeToken(t, false); err != nil {
+		return err
+	}
+	te.endValue()
+	return te.fs.checkWrite()
+}
+
+// beginValue separates a new top-level value from the previous one with a
+// newline, if the previous one wasn't terminated.
+func (te *TokenEncoder) beginValue() {
+	if te.unterminated && te.Depth() == 0 {
+		te.fs.printSpace("\n", true)
+		te.unterminated = false
+	}
+}
+
+// endValue terminates the top-level value just written, if it's complete,
+// which is the case once no container remains open, after any token other
+// than an opening delimiter or an object key.
+func (te *TokenEncoder) endValue() {
+	if te.Depth() != 0 {
+		return
+	}
+	if te.terminate {
+		te.fs.printSpace("\n", true)
+	} else {
+		te.unterminated = true
+	}
+}
+
+// Depth returns the number of objects and arrays which have been opened but
+// not yet closed.
+func (te *TokenEncoder) Depth() int {
+	return len(te.fs.frames) - 1
+}
+
+// normalizeToken converts `t` to one of the token types understood by
+// formatterState.writeToken, and applies the InvalidUTF8Policy to strings.
+func (te *TokenEncoder) normalizeToken(t json.Token) (json.Token, error) {
+	switch value := t.(type) {
+	case nil, bool, json.Delim:
+		return t, nil
+	case string:
+		return t, te.checkString(value)
+	case json.Number:
+		// Numbers are written verbatim, so make sure they are valid JSON numbers.
+		// Only numbers start with a digit or minus sign.
+		if value == "" || (value[0] != '-' && (value[0] < '0' || value[0] > '9')) || !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("jsoncolor: invalid number %q", string(value))
+		}
+		return t, nil
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("jsoncolor: unsupported number %v", value)
+		}
+	case float32:
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return nil, fmt.Errorf("jsoncolor: unsupported number %v", value)
+		}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+	default:
+		return nil, fmt.Errorf("jsoncolor: unsupported token type %T", t)
+	}
+
+	// Let encoding/json format the number, so that it matches the output of Marshal.
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("jsoncolor: %w", err)
+	}
+	return json.Number(b), nil
+}
+
+// checkString applies the InvalidUTF8Policy to the string token `s`, which is
+// either an object key or a string value.
+func (te *TokenEncoder) checkString(s string) error {
+	fs := te.fs
+	if fs.utf8Policy == InvalidUTF8Replace || utf8.ValidString(s) {
+		return nil
+	}
+	if fs.utf8Policy == InvalidUTF8Escape {
+		fs.escapeReplacement = true
+		return nil
+	}
+
+	current := fs.frame()
+	path := fs.childPath(current)
+	if current.inField() {
+		path = current.path + "/" + escapePointerToken(s)
+	}
+	return &UTF8Error{Path: path, Offset: -1}
+}
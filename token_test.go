@@ -0,0 +1,132 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// writeTokens writes `tokens` through `te`, failing the test on error.
+func writeTokens(t *testing.T, te *TokenEncoder, tokens ...json.Token) {
+	t.Helper()
+	for _, token := range tokens {
+		if err := te.WriteToken(token); err != nil {
+			t.Fatalf("WriteToken(%#v): %v", token, err)
+		}
+	}
+}
+
+func TestTokenEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	te := NewTokenEncoderWithFormatter(&buf, &Formatter{Indent: "  "})
+	writeTokens(t, te,
+		json.Delim('{'), "a", json.Delim('['), 1, 2.5, json.Number("3e2"), true, nil, json.Delim(']'),
+		"b", json.Delim('{'))
+	if te.Depth() != 2 {
+		t.Errorf("got depth %d, want 2", te.Depth())
+	}
+	writeTokens(t, te, json.Delim('}'), "c", "x", json.Delim('}'), uint8(7))
+
+	want := `{
+  "a": [
+    1,
+    2.5,
+    3e2,
+    true,
+    null
+  ],
+  "b": {},
+  "c": "x"
+}
+7
+`
+	if got := stripANSI(buf.String()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTokenEncoderMatchesFormat(t *testing.T) {
+	const src = `{"a":[1,{"b":null}],"c":"d"}`
+	f := &Formatter{Indent: "\t", TrailingNewline: NewlineNever}
+
+	var buf bytes.Buffer
+	te := NewTokenEncoderWithFormatter(&buf, f)
+	dec := json.NewDecoder(strings.NewReader(src))
+	dec.UseNumber()
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			break
+		}
+		writeTokens(t, te, token)
+	}
+
+	if want := formatPlain(t, f, src); stripANSI(buf.String()) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stripANSI(buf.String()), want)
+	}
+}
+
+func TestTokenEncoderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []json.Token
+		bad    json.Token
+		errMsg string
+	}{
+		{"non-string key", []json.Token{json.Delim('{')}, 1, "object key must be a string"},
+		{"unexpected close", nil, json.Delim(']'), "unexpected closing delimiter"},
+		{"mismatched close", []json.Token{json.Delim('[')}, json.Delim('}'), "mismatched closing delimiter"},
+		{"missing value", []json.Token{json.Delim('{'), "k"}, json.Delim('}'), `missing value for object key "k"`},
+		{"invalid delimiter", nil, json.Delim(','), "invalid delimiter"},
+		{"invalid number", nil, json.Number("1x"), "invalid number"},
+		{"NaN", nil, math.NaN(), "unsupported number"},
+		{"unsupported type", nil, struct{}{}, "unsupported token type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			te := NewTokenEncoderWithFormatter(&buf, &Formatter{})
+			writeTokens(t, te, tt.tokens...)
+			before := buf.Len()
+			err := te.WriteToken(tt.bad)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("got %v, want an error containing %q", err, tt.errMsg)
+			}
+			if buf.Len() != before {
+				t.Errorf("got output %q after the error", buf.String()[before:])
+			}
+		})
+	}
+}
+
+func TestTokenEncoderInvalidUTF8(t *testing.T) {
+	var buf bytes.Buffer
+	te := NewTokenEncoderWithFormatter(&buf, &Formatter{InvalidUTF8Policy: InvalidUTF8Error})
+	writeTokens(t, te, json.Delim('{'), "a", json.Delim('['), "ok")
+	err := te.WriteToken("b\xff")
+	var utf8Err *UTF8Error
+	if !errors.As(err, &utf8Err) || utf8Err.Path != "/a/1" {
+		t.Errorf("got %v, want a *UTF8Error at /a/1", err)
+	}
+}
+
+func TestTokenEncoderTopLevelValues(t *testing.T) {
+	for _, tt := range []struct {
+		newline NewlineMode
+		want    string
+	}{
+		{NewlineDefault, "{\"a\":1}\n[]\n2\n"},
+		// Values are still separated, and only the last one is left unterminated.
+		{NewlineNever, "{\"a\":1}\n[]\n2"},
+	} {
+		var buf bytes.Buffer
+		te := NewTokenEncoderWithFormatter(&buf, &Formatter{TrailingNewline: tt.newline})
+		writeTokens(t, te, json.Delim('{'), "a", 1, json.Delim('}'), json.Delim('['), json.Delim(']'), 2)
+		if got := stripANSI(buf.String()); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.newline, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatTOML colorizes the TOML document in `src` using the DefaultFormatter.
+// See Formatter.FormatTOML.
+func FormatTOML(dst io.Writer, src []byte) error {
+	return DefaultFormatter.FormatTOML(dst, src)
+}
+
+// FormatTOML writes the TOML document in `src` to `dst`, colorized with the
+// same color roles as JSON, so that CLI tools can show TOML configuration
+// consistently with JSON: keys use FieldColor and FieldQuoteColor, strings
+// StringColor and StringQuoteColor, numbers and dates NumberColor, booleans
+// TrueColor and FalseColor, the equals sign ColonColor, commas CommaColor and
+// comments CommentColor. The brackets of [table] headers and inline tables use
+// ObjectColor, and those of [[array]] headers and arrays ArrayColor.
+//
+// Unlike Format, FormatTOML only highlights its input: the layout, including
+// whitespace and comments, is kept as is, and the Formatter's other settings
+// have no effect. The input is checked as it's tokenized, so that malformed
+// documents, such as ones with an unterminated string or an unclosed array,
+// produce an error and no output, but the semantics of the document, such as
+// duplicate keys, aren't checked.
+func (f *Formatter) FormatTOML(dst io.Writer, src []byte) error {
+	l := &tomlLexer{src: src, palette: f.palette(), expectKey: true}
+	if err := l.run(); err != nil {
+		return err
+	}
+	_, err := dst.Write(l.buf.Bytes())
+	return err
+}
+
+// tomlLexer tokenizes a TOML document, writing each token colorized to buf.
+type tomlLexer struct {
+	src []byte
+	pos int // Position of the next byte to read.
+	buf bytes.Buffer
+
+	// stack holds the opening bracket of each enclosing array ('[') or inline table ('{').
+	stack []byte
+	// expectKey is true if the next token is a key, at the start of a line
+	// (outside of arrays) or of a member of an inline table.
+	expectKey bool
+
+	palette
+}
+
+// run tokenizes the whole document.
+func (l *tomlLexer) run() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t':
+			end := l.pos
+			for end < len(l.src) && (l.src[end] == ' ' || l.src[end] == '\t') {
+				end++
+			}
+			l.emit(l.space, end)
+		case c == '\n' || c == '\r':
+			l.emit(l.space, l.pos+1)
+			if len(l.stack) == 0 {
+				l.expectKey = true
+			}
+		case c == '#':
+			end := l.pos
+			for end < len(l.src) && l.src[end] != '\n' && l.src[end] != '\r' {
+				end++
+			}
+			l.emit(l.comment, end)
+		case l.expectKey && len(l.stack) == 0 && c == '[':
+			if err := l.header(); err != nil {
+				return err
+			}
+		case l.expectKey:
+			if err := l.key(); err != nil {
+				return err
+			}
+		default:
+			if err := l.value(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(l.stack) > 0 {
+		return l.errorf("unclosed %q", l.stack[len(l.stack)-1])
+	}
+	return nil
+}
+
+// emit writes the input from the current position up to `end` colorized with
+// `sprintf`, line by line, and advances past it.
+func (l *tomlLexer) emit(sprintf func(format string, a ...interface{}) string, end int) {
+	lines := strings.SplitAfter(string(l.src[l.pos:end]), "\n")
+	for _, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		if text != "" {
+			l.buf.WriteString(sprintf("%s", text))
+		}
+		l.buf.WriteString(line[len(text):])
+	}
+	l.pos = end
+}
+
+// errorf returns an error for the current position.
+func (l *tomlLexer) errorf(format string, a ...interface{}) error {
+	line := bytes.Count(l.src[:l.pos], []byte{'\n'}) + 1
+	return fmt.Errorf("jsoncolor: error decoding input TOML: line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+// header tokenizes a [table] or [[array of tables]] header.
+func (l *tomlLexer) header() error {
+	sprintf, delim, closing := l.object, "[", "]"
+	if bytes.HasPrefix(l.src[l.pos:], []byte("[[")) {
+		sprintf, delim, closing = l.array, "[[", "]]"
+	}
+	l.emit(sprintf, l.pos+len(delim))
+	for {
+		if err := l.keyPart(); err != nil {
+			return err
+		}
+		if bytes.HasPrefix(l.src[l.pos:], []byte(closing)) {
+			l.emit(sprintf, l.pos+len(closing))
+			l.expectKey = false
+			return nil
+		}
+		if l.pos >= len(l.src) || l.src[l.pos] != '.' {
+			return l.errorf("expected %q at end of table header", closing)
+		}
+		l.emit(l.space, l.pos+1)
+	}
+}
+
+// key tokenizes a possibly dotted key, up to and including the equals sign.
+func (l *tomlLexer) key() error {
+	for {
+		if err := l.keyPart(); err != nil {
+			return err
+		}
+		if l.pos < len(l.src) && l.src[l.pos] == '=' {
+			l.emit(l.colon, l.pos+1)
+			l.expectKey = false
+			return nil
+		}
+		if l.pos >= len(l.src) || l.src[l.pos] != '.' {
+			return l.errorf("expected '=' after key")
+		}
+		l.emit(l.space, l.pos+1)
+	}
+}
+
+// keyPart tokenizes a bare or quoted key, along with the surrounding whitespace.
+func (l *tomlLexer) keyPart() error {
+	l.skipSpaces()
+	if l.pos >= len(l.src) {
+		return l.errorf("expected key")
+	}
+	switch l.src[l.pos] {
+	case '"', '\'':
+		if err := l.quoted(l.field, l.fieldQuote, false); err != nil {
+			return err
+		}
+	default:
+		end := l.pos
+		for end < len(l.src) && isBareKeyChar(l.src[end]) {
+			end++
+		}
+		if end == l.pos {
+			return l.errorf("unexpected %q in key", l.src[l.pos])
+		}
+		l.emit(l.field, end)
+	}
+	l.skipSpaces()
+	return nil
+}
+
+// skipSpaces writes the spaces and tabs at the current position.
+func (l *tomlLexer) skipSpaces() {
+	end := l.pos
+	for end < len(l.src) && (l.src[end] == ' ' || l.src[end] == '\t') {
+		end++
+	}
+	if end > l.pos {
+		l.emit(l.space, end)
+	}
+}
+
+// isBareKeyChar reports whether `c` is allowed in a bare key.
+func isBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value tokenizes a value or the punctuation of an array or inline table.
+func (l *tomlLexer) value() error {
+	c := l.src[l.pos]
+	switch c {
+	case '"', '\'':
+		return l.quoted(l.str, l.strQuote, true)
+	case '[':
+		l.stack = append(l.stack, '[')
+		l.emit(l.array, l.pos+1)
+		return nil
+	case '{':
+		l.stack = append(l.stack, '{')
+		l.emit(l.object, l.pos+1)
+		l.expectKey = true
+		return nil
+	case ']', '}':
+		opening := byte('[')
+		sprintf := l.array
+		if c == '}' {
+			opening, sprintf = '{', l.object
+		}
+		if len(l.stack) == 0 || l.stack[len(l.stack)-1] != opening {
+			return l.errorf("unexpected %q", c)
+		}
+		l.stack = l.stack[:len(l.stack)-1]
+		l.emit(sprintf, l.pos+1)
+		l.expectKey = false
+		return nil
+	case ',':
+		if len(l.stack) == 0 {
+			return l.errorf("unexpected ','")
+		}
+		l.emit(l.comma, l.pos+1)
+		l.expectKey = l.stack[len(l.stack)-1] == '{'
+		return nil
+	}
+
+	// Anything else is a boolean, number or date, which runs up to the next delimiter.
+	end := l.pos
+	for end < len(l.src) && !strings.ContainsRune(" \t\r\n,]}#", rune(l.src[end])) {
+		end++
+	}
+	// A date may be separated from its time by a space, e.g. 1979-05-27 07:32:00.
+	if isTOMLDate(l.src[l.pos:end]) && end+3 < len(l.src) && l.src[end] == ' ' &&
+		isDigit(l.src[end+1]) && isDigit(l.src[end+2]) && l.src[end+3] == ':' {
+		for end++; end < len(l.src) && !strings.ContainsRune(" \t\r\n,]}#", rune(l.src[end])); end++ {
+		}
+	}
+	word := string(l.src[l.pos:end])
+	switch {
+	case word == "true":
+		l.emit(l.tru, end)
+	case word == "false":
+		l.emit(l.fals, end)
+	case word == "inf" || word == "nan" || word != "" && strings.ContainsRune("0123456789+-", rune(word[0])):
+		l.emit(l.number, end)
+	default:
+		return l.errorf("invalid value %q", word)
+	}
+	return nil
+}
+
+// isTOMLDate reports whether `b` is a date, e.g. 1979-05-27.
+func isTOMLDate(b []byte) bool {
+	if len(b) != 10 || b[4] != '-' || b[7] != '-' {
+		return false
+	}
+	for i, c := range b {
+		if i != 4 && i != 7 && !isDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigit reports whether `c` is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// quoted tokenizes a basic ("...") or literal ('...') string, which may be a
+// multi-line string if `multiline` is true. Its contents are colorized with
+// `sprintf`, and its quotes with `sprintfQuote`.
+func (l *tomlLexer) quoted(sprintf, sprintfQuote func(format string, a ...interface{}) string, multiline bool) error {
+	quote := l.src[l.pos : l.pos+1]
+	if triple := bytes.Repeat(quote, 3); multiline && bytes.HasPrefix(l.src[l.pos:], triple) {
+		l.emit(sprintfQuote, l.pos+3)
+		end := l.pos
+		for ; end < len(l.src); end++ {
+			if quote[0] == '"' && l.src[end] == '\\' {
+				end++
+			} else if bytes.HasPrefix(l.src[end:], triple) {
+				break
+			}
+		}
+		if end >= len(l.src) {
+			return l.errorf("unterminated multi-line string")
+		}
+		// Up to two quotes right before the closing delimiter belong to the string.
+		for n := 0; n < 2 && end+3 < len(l.src) && l.src[end+3] == quote[0]; n++ {
+			end++
+		}
+		l.emit(sprintf, end)
+		l.emit(sprintfQuote, end+3)
+		return nil
+	}
+
+	l.emit(sprintfQuote, l.pos+1)
+	end := l.pos
+	for ; end < len(l.src) && l.src[end] != quote[0]; end++ {
+		if l.src[end] == '\n' {
+			break
+		}
+		if quote[0] == '"' && l.src[end] == '\\' {
+			end++
+		}
+	}
+	if end >= len(l.src) || l.src[end] != quote[0] {
+		return l.errorf("unterminated string")
+	}
+	l.emit(sprintf, end)
+	l.emit(sprintfQuote, end+1)
+	return nil
+}
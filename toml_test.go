@@ -0,0 +1,80 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatTOML(t *testing.T) {
+	f := &Formatter{
+		FieldColor: tagColor("k"), FieldQuoteColor: tagColor("kq"), StringColor: tagColor("s"), StringQuoteColor: tagColor("q"),
+		NumberColor: tagColor("n"), TrueColor: tagColor("t"), ColonColor: tagColor("eq"), CommaColor: tagColor("c"),
+		CommentColor: tagColor("#"), ObjectColor: tagColor("o"), ArrayColor: tagColor("a"),
+	}
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			"tables",
+			"# top\n[server]\nhost = \"local\" # c\nports = [80, 443]\n\n[[items]]\n\"a.b\".c = {x = true, y = 'lit'}\n",
+			"<#># top</#>\n<o>[</o><k>server</k><o>]</o>\n<k>host</k> <eq>=</eq> <q>\"</q><s>local</s><q>\"</q> <#># c</#>\n" +
+				"<k>ports</k> <eq>=</eq> <a>[</a><n>80</n><c>,</c> <n>443</n><a>]</a>\n\n" +
+				"<a>[[</a><k>items</k><a>]]</a>\n<kq>\"</kq><k>a.b</k><kq>\"</kq>.<k>c</k> <eq>=</eq> " +
+				"<o>{</o><k>x</k> <eq>=</eq> <t>true</t><c>,</c> <k>y</k> <eq>=</eq> <q>'</q><s>lit</s><q>'</q><o>}</o>\n",
+		},
+		{
+			// A date and time separated by a space are a single value.
+			"date",
+			"when = 1979-05-27 07:32:00\n",
+			"<k>when</k> <eq>=</eq> <n>1979-05-27 07:32:00</n>\n",
+		},
+		{
+			// Up to two quotes before the closing delimiter belong to the string.
+			"multiline",
+			"text = \"\"\"\nmulti \"\"quoted\"\"\"\"\"\n",
+			"<k>text</k> <eq>=</eq> <q>\"\"\"</q>\n<s>multi \"\"quoted\"\"</s><q>\"\"\"</q>\n",
+		},
+		{
+			// The layout is kept, including arrays spanning several lines.
+			"array",
+			"a = [1,\r\n  2,\r\n]\r\n",
+			"<k>a</k> <eq>=</eq> <a>[</a><n>1</n><c>,</c>\r\n  <n>2</n><c>,</c>\r\n<a>]</a>\r\n",
+		},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := f.FormatTOML(&sb, []byte(tt.src)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sb.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, sb.String(), tt.want)
+		}
+	}
+}
+
+func TestFormatTOMLErrors(t *testing.T) {
+	tests := []struct {
+		src, wantErr string
+	}{
+		{"a = \"x", "line 1: unterminated string"},
+		{"a = 'x\n'", "line 1: unterminated string"},
+		{"a = \"\"\"x", "line 1: unterminated multi-line string"},
+		{"a = 1\nb = [1", "line 2: unclosed '['"},
+		{"a = ]", "unexpected ']'"},
+		{"a = 1, 2", "unexpected ','"},
+		{"a = nope", `invalid value "nope"`},
+		{"[t\n", `expected "]" at end of table header`},
+		{"a b = 1", "expected '=' after key"},
+		{"= 1", "unexpected '=' in key"},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		err := FormatTOML(&sb, []byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: got error %v, want one containing %q", tt.src, err, tt.wantErr)
+		}
+		if sb.Len() != 0 {
+			t.Errorf("%q: wrote %q", tt.src, sb.String())
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// InvalidUTF8Policy controls how strings containing invalid UTF-8 are handled.
+// See Formatter.InvalidUTF8Policy.
+type InvalidUTF8Policy int
+
+const (
+	// InvalidUTF8Replace replaces each invalid byte with the Unicode replacement
+	// character U+FFFD, matching encoding/json. This is the default.
+	InvalidUTF8Replace InvalidUTF8Policy = iota
+	// InvalidUTF8Error aborts formatting with a *UTF8Error describing the
+	// position of the first invalid string. No output is written.
+	InvalidUTF8Error
+	// InvalidUTF8Escape replaces each invalid byte like InvalidUTF8Replace, but
+	// writes the replacement as the escape sequence `\ufffd` rather than as a raw
+	// character, so that substitutions stand out in the output. Note that once
+	// a document is found to contain invalid UTF-8, every U+FFFD character in
+	// it is escaped, including any that were present in the input.
+	InvalidUTF8Escape
+)
+
+// UTF8Error is returned when a string contains invalid UTF-8 and the
+// Formatter's InvalidUTF8Policy is InvalidUTF8Error.
+type UTF8Error struct {
+	// Path is the JSON Pointer (RFC 6901) of the offending string value, or of
+	// the member whose key is the offending string.
+	Path string
+	// Offset is the byte offset of the first invalid byte within the JSON input
+	// being formatted. It is -1 when the invalid string was found in a Go value
+	// passed to an Encoder or a Marshal function, which has no JSON input.
+	Offset int64
+}
+
+func (e *UTF8Error) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("jsoncolor: invalid UTF-8 in string at %q", e.Path)
+	}
+	return fmt.Sprintf("jsoncolor: invalid UTF-8 in string at %q (offset %d)", e.Path, e.Offset)
+}
+
+// utf8BOM is the UTF-8 encoding of the byte order mark U+FEFF.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// transcodeInput returns the JSON text `src` as UTF-8 without a byte order
+// mark, as written by many Windows tools, decoding it from UTF-16 if needed.
+// UTF-16 is detected by its byte order mark or, lacking one, by a NUL byte
+// next to the first character, which is always ASCII in JSON (RFC 8259).
+// It returns `src` itself if it's already UTF-8 without a byte order mark.
+func transcodeInput(src []byte) ([]byte, error) {
+	if bytes.HasPrefix(src, utf8BOM) {
+		return src[len(utf8BOM):], nil
+	}
+	if len(src) < 2 {
+		return src, nil
+	}
+	var order binary.ByteOrder
+	switch {
+	case src[0] == 0xfe && src[1] == 0xff:
+		order, src = binary.BigEndian, src[2:]
+	case src[0] == 0xff && src[1] == 0xfe:
+		order, src = binary.LittleEndian, src[2:]
+	case src[0] == 0 && src[1] != 0:
+		order = binary.BigEndian
+	case src[0] != 0 && src[1] == 0:
+		order = binary.LittleEndian
+	default:
+		return src, nil
+	}
+	if len(src)%2 != 0 {
+		return nil, fmt.Errorf("jsoncolor: error decoding input JSON: UTF-16 input has an odd length of %d bytes", len(src))
+	}
+	units := make([]uint16, len(src)/2)
+	for i := range units {
+		units[i] = order.Uint16(src[2*i:])
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// checkUTF8 applies the InvalidUTF8Policy `policy` to the JSON text `src`.
+// It returns a *UTF8Error if the policy rejects the input, and otherwise
+// reports whether replacement characters must be escaped.
+func checkUTF8(policy InvalidUTF8Policy, src []byte) (escape bool, err error) {
+	if policy == InvalidUTF8Replace || utf8.Valid(src) {
+		return false, nil
+	}
+	if policy == InvalidUTF8Escape {
+		return true, nil
+	}
+
+	// Find the first invalid byte. Outside of strings, any non-ASCII byte is a
+	// syntax error which the decoder reports on its own, so the offset found
+	// here always lies within a string.
+	offset := 0
+	for offset < len(src) {
+		r, size := utf8.DecodeRune(src[offset:])
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		offset += size
+	}
+	return false, &UTF8Error{Path: pointerAt(src, int64(offset)), Offset: int64(offset)}
+}
+
+// checkValueUTF8 applies the InvalidUTF8Policy `policy` to the strings of the
+// Go value `v`, before encoding/json has had a chance to replace their invalid
+// bytes. It must only be called once `v` is known to marshal successfully,
+// which rules out cyclic values.
+// Values implementing json.Marshaler or encoding.TextMarshaler are not
+// inspected; the JSON produced by a json.Marshaler is checked by the Formatter
+// like any other JSON input.
+func checkValueUTF8(policy InvalidUTF8Policy, v interface{}) (escape bool, err error) {
+	if policy == InvalidUTF8Replace {
+		return false, nil
+	}
+	path, found := findInvalidUTF8(reflect.ValueOf(v), "")
+	if !found {
+		return false, nil
+	}
+	if policy == InvalidUTF8Escape {
+		return true, nil
+	}
+	return false, &UTF8Error{Path: path, Offset: -1}
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// findInvalidUTF8 walks `v` the way encoding/json would marshal it and returns
+// the JSON Pointer of the first string containing invalid UTF-8.
+func findInvalidUTF8(v reflect.Value, path string) (string, bool) {
+	if !v.IsValid() {
+		return "", false
+	}
+	if v.Type().Implements(marshalerType) || v.Type().Implements(textMarshalerType) {
+		return "", false
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return "", false
+		}
+		return findInvalidUTF8(v.Elem(), path)
+	case reflect.String:
+		if !utf8.ValidString(v.String()) {
+			return path, true
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			keyPath := path + "/" + fmt.Sprint(key.Interface())
+			if key.Kind() == reflect.String {
+				keyPath = path + "/" + escapePointerToken(key.String())
+				if !utf8.ValidString(key.String()) {
+					return keyPath, true
+				}
+			}
+			if p, found := findInvalidUTF8(iter.Value(), keyPath); found {
+				return p, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 and cannot contain invalid UTF-8.
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return "", false
+		}
+		for i := 0; i < v.Len(); i++ {
+			if p, found := findInvalidUTF8(v.Index(i), path+"/"+strconv.Itoa(i)); found {
+				return p, true
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonFieldName(field)
+			if !ok {
+				continue
+			}
+			fieldPath := path + "/" + escapePointerToken(name)
+			// Untagged embedded structs have their fields promoted into the parent object.
+			if field.Anonymous && name == field.Name && field.Type.Kind() != reflect.Interface {
+				fieldPath = path
+			}
+			if p, found := findInvalidUTF8(v.Field(i), fieldPath); found {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonFieldName returns the name under which encoding/json marshals a struct
+// field, and false if the field is not marshalled at all.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+// pointerLevel tracks the position within one enclosing object or array
+// while searching for a JSON Pointer with pointerAt.
+type pointerLevel struct {
+	pointer string // JSON Pointer of the container itself.
+	object  bool   // True for objects, false for arrays.
+	atKey   bool   // True if the next token in an object is a key.
+	key     string // The current key in an object.
+	index   int    // The current index in an array.
+}
+
+// advance moves past a completed value in the container.
+func (l *pointerLevel) advance() {
+	if l.object {
+		l.atKey = true
+	} else {
+		l.index++
+	}
+}
+
+// pointerAt returns the JSON Pointer of the value (or member key) in the valid
+// JSON text `src` which contains the byte at `offset`.
+func pointerAt(src []byte, offset int64) string {
+	var stack []*pointerLevel
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			// Either the offset is past the end of the input or the input is invalid.
+			return ""
+		}
+		end := dec.InputOffset()
+
+		var top *pointerLevel
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		// Closing delimiters complete the value of the enclosing container.
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].advance()
+			}
+			continue
+		}
+
+		// Compute the pointer of the current token.
+		pointer := ""
+		if top != nil {
+			if top.object && top.atKey {
+				top.key = token.(string)
+			}
+			if top.object {
+				pointer = top.pointer + "/" + escapePointerToken(top.key)
+			} else {
+				pointer = top.pointer + "/" + strconv.Itoa(top.index)
+			}
+		}
+		if end > offset {
+			return pointer
+		}
+
+		switch {
+		case top != nil && top.object && top.atKey:
+			top.atKey = false
+		case token == json.Delim('{') || token == json.Delim('['):
+			stack = append(stack, &pointerLevel{pointer: pointer, object: token == json.Delim('{'), atKey: true})
+		case top != nil:
+			top.advance()
+		}
+	}
+}
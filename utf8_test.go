@@ -0,0 +1,136 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestInvalidUTF8Format(t *testing.T) {
+	src := []byte("{\"a\":[\"ok\",\"b\xffd\"]}")
+
+	if got := formatPlain(t, &Formatter{}, string(src)); got != "{\"a\":[\"ok\",\"b�d\"]}" {
+		t.Errorf("replace: got %q", got)
+	}
+	if got := formatPlain(t, &Formatter{InvalidUTF8Policy: InvalidUTF8Escape}, string(src)); got != `{"a":["ok","b\ufffdd"]}` {
+		t.Errorf("escape: got %q", got)
+	}
+
+	var buf bytes.Buffer
+	err := (&Formatter{InvalidUTF8Policy: InvalidUTF8Error}).Format(&buf, src)
+	var utf8Err *UTF8Error
+	if !errors.As(err, &utf8Err) {
+		t.Fatalf("error: got %v, want a *UTF8Error", err)
+	}
+	if utf8Err.Path != "/a/1" || utf8Err.Offset != 13 {
+		t.Errorf("error: got path %q, offset %d, want %q, 13", utf8Err.Path, utf8Err.Offset, "/a/1")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("error: got output %q, want none", buf.String())
+	}
+
+	// Keys are reported at the path of their member, as decoded.
+	err = (&Formatter{InvalidUTF8Policy: InvalidUTF8Error}).Format(&buf, []byte("{\"x\":1,\"k\xff\":2}"))
+	if !errors.As(err, &utf8Err) || utf8Err.Path != "/k\uFFFD" {
+		t.Errorf("key: got %v", err)
+	}
+}
+
+func TestInvalidUTF8Encode(t *testing.T) {
+	type inner struct {
+		Name string `json:"name"`
+	}
+	type embedded struct{ Note string }
+	type value struct {
+		embedded
+		Items []inner
+		skip  string
+	}
+	v := value{embedded{"fine"}, []inner{{"ok"}, {"b\xffd"}}, "\xff"}
+
+	encode := func(policy InvalidUTF8Policy) (string, error) {
+		var buf bytes.Buffer
+		enc := NewEncoderWithFormatter(&buf, &Formatter{InvalidUTF8Policy: policy})
+		err := enc.Encode(v)
+		return stripANSI(buf.String()), err
+	}
+
+	got, err := encode(InvalidUTF8Escape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Note":"fine","Items":[{"name":"ok"},{"name":"b\ufffdd"}]}` + "\n"; got != want {
+		t.Errorf("escape: got %q, want %q", got, want)
+	}
+
+	_, err = encode(InvalidUTF8Error)
+	var utf8Err *UTF8Error
+	if !errors.As(err, &utf8Err) || utf8Err.Path != "/Items/1/name" || utf8Err.Offset != -1 {
+		t.Errorf("error: got %v, want a *UTF8Error at /Items/1/name", err)
+	}
+
+	v.embedded.Note = "\xff"
+	if _, err = encode(InvalidUTF8Error); !errors.As(err, &utf8Err) || utf8Err.Path != "/Note" {
+		t.Errorf("embedded: got %v, want a *UTF8Error at /Note", err)
+	}
+}
+
+// utf16Data encodes `s` as UTF-16 in the byte order `order`, after the byte
+// order mark if `bom` is true.
+func utf16Data(s string, order binary.AppendByteOrder, bom bool) []byte {
+	var data []byte
+	if bom {
+		data = order.AppendUint16(data, 0xfeff)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		data = order.AppendUint16(data, u)
+	}
+	return data
+}
+
+func TestTranscodeInput(t *testing.T) {
+	const text = `{"é": "😀"}`
+	tests := []struct {
+		name string
+		src  []byte
+	}{
+		{"UTF-8", []byte(text)},
+		{"UTF-8 BOM", append([]byte{0xef, 0xbb, 0xbf}, text...)},
+		{"UTF-16BE BOM", utf16Data(text, binary.BigEndian, true)},
+		{"UTF-16LE BOM", utf16Data(text, binary.LittleEndian, true)},
+		{"UTF-16BE", utf16Data(text, binary.BigEndian, false)},
+		{"UTF-16LE", utf16Data(text, binary.LittleEndian, false)},
+	}
+	for _, tt := range tests {
+		got, err := transcodeInput(tt.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != text {
+			t.Errorf("%s: got %q, want %q", tt.name, got, text)
+		}
+	}
+
+	if _, err := transcodeInput([]byte{0xff, 0xfe, '1', 0, '2'}); err == nil || !strings.Contains(err.Error(), "odd length of 3 bytes") {
+		t.Errorf("odd length: got %v", err)
+	}
+	for _, src := range []string{"", "1", "\x00\x00"} {
+		if got, err := transcodeInput([]byte(src)); err != nil || string(got) != src {
+			t.Errorf("%q: got %q, %v", src, got, err)
+		}
+	}
+}
+
+func TestFormatTranscodes(t *testing.T) {
+	var buf bytes.Buffer
+	src := utf16Data("[1, \"é\"]", binary.LittleEndian, true)
+	if err := plainCompactFormatter().Format(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if want := `[1,"é"]`; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,161 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// excerptContext is the maximum number of bytes shown on either side of the
+// error position in a SyntaxReport excerpt. Longer lines are truncated with an
+// ellipsis so that minified, single-line documents still produce a readable excerpt.
+const excerptContext = 40
+
+// SyntaxReport describes why a JSON document failed validation.
+// It carries the position of the offending input along with a colorized
+// excerpt of the surrounding text, making it suitable for printing directly
+// to a terminal.
+type SyntaxReport struct {
+	Message string // The underlying error description, as reported by encoding/json.
+	Offset  int64  // Byte offset into the input where the error was detected.
+	Line    int    // 1-based line number of the error position.
+	Column  int    // 1-based column (in runes) of the error position within its line.
+
+	// Excerpt is the line containing the error (truncated around the error
+	// position if long), followed by a second line with a caret pointing at the
+	// offending character. The offending character and caret are colorized
+	// using the Formatter's ErrorColor.
+	Excerpt string
+}
+
+// Error returns a single-line description of the syntax error, including its
+// line and column. This allows a SyntaxReport to be used as an error value.
+func (r *SyntaxReport) Error() string {
+	return fmt.Sprintf("jsoncolor: syntax error at line %d, column %d: %s", r.Line, r.Column, r.Message)
+}
+
+// String returns the error description followed by the colorized excerpt.
+func (r *SyntaxReport) String() string {
+	return r.Error() + "\n" + r.Excerpt
+}
+
+// Valid reports whether `src` is a valid JSON encoding, like encoding/json.Valid.
+// When `src` is invalid, the returned SyntaxReport describes where and why,
+// using the DefaultFormatter to colorize the excerpt. The report is nil when
+// `src` is valid.
+func Valid(src []byte) (bool, *SyntaxReport) {
+	return DefaultFormatter.Valid(src)
+}
+
+// Valid works like the package-level Valid but colorizes the report's excerpt
+// using this Formatter's colors.
+func (f *Formatter) Valid(src []byte) (bool, *SyntaxReport) {
+	// Fast path: the vast majority of inputs are valid, and json.Valid does not allocate.
+	if json.Valid(src) {
+		return true, nil
+	}
+
+	// json.Compact runs the same scanner as json.Valid but reports a *json.SyntaxError
+	// with an offset, without building a value tree like json.Unmarshal would.
+	err := json.Compact(&bytes.Buffer{}, src)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		// Should not happen since json.Valid rejected the input, but degrade gracefully.
+		return false, f.newSyntaxReport(src, "invalid JSON", int64(len(src)))
+	}
+	return false, f.newSyntaxReport(src, syntaxErr.Error(), syntaxErr.Offset)
+}
+
+// newSyntaxReport builds a SyntaxReport for an error reported by encoding/json
+// at `offset`. The scanner's offset points just *past* the offending byte, except
+// for premature end of input, where it equals len(src).
+func (f *Formatter) newSyntaxReport(src []byte, message string, offset int64) *SyntaxReport {
+	// Determine the byte position of the offending character.
+	pos := int(offset)
+	if pos > len(src) {
+		pos = len(src)
+	}
+	if pos > 0 && !(pos == len(src) && strings.HasPrefix(message, "unexpected end")) {
+		pos--
+	}
+	// Step back to the start of a multi-byte rune, should the offset land inside one.
+	for pos > 0 && pos < len(src) && !utf8.RuneStart(src[pos]) {
+		pos--
+	}
+
+	// Locate the line containing the error position.
+	lineStart := bytes.LastIndexByte(src[:pos], '\n') + 1
+	lineEnd := len(src)
+	if i := bytes.IndexByte(src[pos:], '\n'); i >= 0 {
+		lineEnd = pos + i
+	}
+
+	return &SyntaxReport{
+		Message: message,
+		Offset:  offset,
+		Line:    bytes.Count(src[:lineStart], []byte{'\n'}) + 1,
+		Column:  utf8.RuneCount(src[lineStart:pos]) + 1,
+		Excerpt: f.excerpt(src[lineStart:lineEnd], pos-lineStart),
+	}
+}
+
+// excerpt renders `line` with the character at byte position `pos` highlighted,
+// followed by a caret line pointing at it. Text far from `pos` is elided, and
+// control characters are handled according to the ControlCharPolicy, as in
+// formatted output, since the line comes from the invalid input.
+func (f *Formatter) excerpt(line []byte, pos int) string {
+	sprintfError := formatterOptions.colorFunc(nil, f.ColorProfile.resolve())(f.errorColor())
+
+	// Trim the line to a window around the error position, keeping rune boundaries intact.
+	start, end := pos-excerptContext, pos+excerptContext
+	leading, trailing := "…", "…"
+	if start <= 0 {
+		start, leading = 0, ""
+	}
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start++
+	}
+	if end >= len(line) {
+		end, trailing = len(line), ""
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+
+	before := sanitizeControls(string(line[start:pos]), f.ControlCharPolicy)
+	// The offending character itself. At end of input there is none, so the
+	// caret simply points just past the last character.
+	offending := ""
+	after := ""
+	if pos < end {
+		_, size := utf8.DecodeRune(line[pos:])
+		offending = sanitizeControls(string(line[pos:pos+size]), f.ControlCharPolicy)
+		after = sanitizeControls(string(line[pos+size:end]), f.ControlCharPolicy)
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(leading)
+	b.WriteString(before)
+	if offending != "" {
+		b.WriteString(sprintfError("%s", offending))
+	}
+	b.WriteString(after)
+	b.WriteString(trailing)
+	b.WriteByte('\n')
+
+	// Build the caret line. Tabs are copied from the excerpt so that the caret
+	// stays aligned regardless of the terminal's tab width.
+	b.WriteString(strings.Repeat(" ", utf8.RuneCountInString(leading)))
+	for _, r := range before {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteString(sprintfError("^"))
+	return b.String()
+}
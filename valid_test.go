@@ -0,0 +1,98 @@
+package jsoncolor
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// ansiEscape matches the escape sequences setting colors.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// stripANSI removes the escape sequences setting colors from `s`, so that tests
+// don't depend on whether colors are enabled.
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+func TestValid(t *testing.T) {
+	if ok, report := Valid([]byte(`{"a": [1, 2]}`)); !ok || report != nil {
+		t.Errorf("valid input: got %v, %v", ok, report)
+	}
+
+	tests := []struct {
+		name         string
+		src          string
+		line, column int
+		offset       int64
+		excerpt      string
+	}{
+		{"invalid character", "{\n  \"a\": tru\n}", 2, 11, 13, "  \"a\": tru\n          ^"},
+		{"missing comma", `[1 2]`, 1, 4, 4, "[1 2]\n   ^"},
+		{"end of input", "{\"a\": 1", 1, 8, 7, "{\"a\": 1\n       ^"},
+		{"tab", "\t[x]", 1, 3, 3, "\t[x]\n\t ^"},
+		{"multibyte", `["é" x]`, 1, 6, 7, "[\"é\" x]\n     ^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, report := Valid([]byte(tt.src))
+			if ok || report == nil {
+				t.Fatalf("got %v, %v, want a report", ok, report)
+			}
+			if report.Line != tt.line || report.Column != tt.column || report.Offset != tt.offset {
+				t.Errorf("got line %d, column %d, offset %d, want %d, %d, %d",
+					report.Line, report.Column, report.Offset, tt.line, tt.column, tt.offset)
+			}
+			if got := stripANSI(report.Excerpt); got != tt.excerpt {
+				t.Errorf("got excerpt:\n%s\nwant:\n%s", got, tt.excerpt)
+			}
+		})
+	}
+}
+
+func TestValidLongLine(t *testing.T) {
+	src := "[\"" + strings.Repeat("a", 100) + `", x, "` + strings.Repeat("b", 100) + `"]`
+	_, report := Valid([]byte(src))
+	if report == nil {
+		t.Fatal("got no report")
+	}
+	want := "…" + strings.Repeat("a", 37) + `", x, "` + strings.Repeat("b", 36) + "…\n" + strings.Repeat(" ", 41) + "^"
+	if got := stripANSI(report.Excerpt); got != want {
+		t.Errorf("got excerpt:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestValidExcerptControls(t *testing.T) {
+	withColor(t, true)
+	// Control characters from the input are escaped, rather than acting on the
+	// terminal, and the caret stays aligned.
+	_, report := Valid([]byte("[\"a\x1bb\" x]"))
+	want := "[\"a\x1b[31;1m\\u001b\x1b[0;22mb\" x]\n   \x1b[31;1m^\x1b[0;22m"
+	if report.Excerpt != want {
+		t.Errorf("got %q, want %q", report.Excerpt, want)
+	}
+
+	// The caret points at where the offending character was, once stripped.
+	f := &Formatter{ControlCharPolicy: ControlCharStrip}
+	_, report = f.Valid([]byte("[\"\x1b[2J\", x]"))
+	if got, want := stripANSI(report.Excerpt), "[\"[2J\", x]\n  ^"; got != want {
+		t.Errorf("strip: got %q, want %q", got, want)
+	}
+}
+
+func TestValidExcerptColors(t *testing.T) {
+	withColor(t, true)
+	// Colors are degraded to the ColorProfile.
+	f := &Formatter{ErrorColor: Hex("#ff0000"), ColorProfile: ProfileANSI}
+	_, report := f.Valid([]byte("[x]"))
+	if got, want := report.Excerpt, "[\x1b[91mx\x1b[0m]\n \x1b[91m^\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Colors disabled by the color package are left out.
+	withColor(t, false)
+	_, report = f.Valid([]byte("[x]"))
+	if got, want := report.Excerpt, "[x]\n ^"; got != want {
+		t.Errorf("colors disabled: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build js && wasm
+
+package jsoncolor
+
+import "syscall/js"
+
+// ConsoleLog formats `src` with the Formatter and logs it to the console of
+// the browser or JavaScript runtime the WebAssembly module runs in, with the
+// colors of the Formatter. See FormatBrowserConsole.
+func (f *Formatter) ConsoleLog(src []byte) error {
+	format, args, err := f.FormatBrowserConsole(src)
+	if err != nil {
+		return err
+	}
+	values := make([]any, 0, len(args)+1)
+	values = append(values, format)
+	for _, arg := range args {
+		values = append(values, arg)
+	}
+	js.Global().Get("console").Call("log", values...)
+	return nil
+}
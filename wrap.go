@@ -0,0 +1,187 @@
+package jsoncolor
+
+import (
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-runewidth"
+)
+
+// WrapAuto, as Formatter.WrapWidth, wraps lines at the width of the terminal
+// the output is written to.
+const WrapAuto = -1
+
+// defaultWrapMarker is the marker starting continuation lines if
+// Formatter.WrapMarker is empty.
+const defaultWrapMarker = "↪ "
+
+// wrapTabWidth is the number of columns between tab stops.
+const wrapTabWidth = 8
+
+// wrapWidth returns the width to wrap the output written to `dst` at, or 0
+// not to wrap it.
+func (f *Formatter) wrapWidth(dst io.Writer) int {
+	switch {
+	case dst == nil:
+		return 0
+	case f.WrapWidth > 0:
+		return f.WrapWidth
+	case f.WrapWidth == WrapAuto:
+		return terminalWidth(dst)
+	}
+	return 0
+}
+
+// terminalWidth returns the width in columns of the terminal `w`, or 0 if it
+// isn't one.
+func terminalWidth(w io.Writer) int {
+	if t, ok := w.(terminalWriter); ok {
+		w = t.terminal()
+	}
+	file, ok := w.(*os.File)
+	if !ok || file == nil || !isTerminal(file) {
+		return 0
+	}
+	width, _, err := term.GetSize(file.Fd())
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// wrapWriter soft-wraps the lines written through it at a width, counted in
+// columns of the terminal, not counting escape sequences. Each continuation
+// line starts with the leading whitespace of the line it continues (the
+// prefix, indentation and indent guides), a further indentation and a marker,
+// and the colors in effect at the break are reset before it and restored
+// after. Writes must not split escape sequences or UTF-8 characters, as the
+// writes of a formatterState don't.
+type wrapWriter struct {
+	w      io.Writer
+	width  int
+	prefix int    // Width of the prefix, which leads indented lines.
+	indent string // Indentation added to the leading whitespace of continuation lines.
+	marker string // Colorized marker starting continuation lines.
+	// markerWidth is the width of the marker.
+	markerWidth int
+
+	column    int      // Column of the next character on the current line.
+	leading   []byte   // Leading whitespace of the current line, without escape sequences.
+	hang      int      // Width of the leading whitespace.
+	inLeading bool     // True until the end of the leading whitespace.
+	start     int      // Column of the first character after the leading whitespace or marker.
+	sgr       sgrState // Colors in effect.
+	buf       []byte
+}
+
+// newWrapWriter returns a wrapWriter writing to `w`, wrapping lines at
+// `width`, with the colorized marker `marker` of width `markerWidth`.
+func newWrapWriter(w io.Writer, width int, prefix, indent, marker string, markerWidth int) *wrapWriter {
+	if indent == "" {
+		indent = "  "
+	}
+	return &wrapWriter{
+		w:           w,
+		width:       width,
+		prefix:      runewidth.StringWidth(prefix),
+		indent:      indent,
+		marker:      marker,
+		markerWidth: markerWidth,
+		inLeading:   true,
+	}
+}
+
+func (ww *wrapWriter) Write(p []byte) (int, error) {
+	ww.buf = ww.buf[:0]
+	for i := 0; i < len(p); {
+		switch p[i] {
+		case '\x1b':
+			params, final, n := escapeSequence(p[i:])
+			if final == 'm' {
+				ww.sgr.apply(params)
+			}
+			ww.buf = append(ww.buf, p[i:i+n]...)
+			i += n
+			continue
+		case '\n':
+			ww.column, ww.start, ww.hang = 0, 0, 0
+			ww.leading, ww.inLeading = ww.leading[:0], true
+			ww.buf = append(ww.buf, '\n')
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRune(p[i:])
+		width := runeColumns(r, ww.column)
+		if ww.inLeading && (ww.column < ww.prefix || r == ' ' || r == '\t' || string(r) == indentGuide) {
+			ww.leading = append(ww.leading, p[i:i+n]...)
+		} else {
+			if ww.inLeading {
+				ww.inLeading, ww.start, ww.hang = false, ww.column, ww.column
+			}
+			// Break before the character, unless the line holds nothing else,
+			// which would leave it as long on the next line.
+			if ww.column+width > ww.width && ww.column > ww.start {
+				ww.breakLine()
+			}
+		}
+		ww.buf = append(ww.buf, p[i:i+n]...)
+		ww.column += width
+		i += n
+	}
+	if _, err := ww.w.Write(ww.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// breakLine appends a line break to the buffer, followed by the start of the
+// continuation line.
+func (ww *wrapWriter) breakLine() {
+	style := ww.sgr.style()
+	if len(style) > 0 {
+		ww.buf = append(ww.buf, "\x1b[0m"...)
+	}
+	ww.buf = append(ww.buf, '\n')
+	// The leading whitespace is left out if it would leave too little room.
+	column := advance(ww.hang, ww.indent) + ww.markerWidth
+	if column <= ww.width/2 {
+		ww.buf = append(ww.buf, ww.leading...)
+	} else {
+		column = advance(0, ww.indent) + ww.markerWidth
+	}
+	ww.buf = append(ww.buf, ww.indent...)
+	ww.buf = append(ww.buf, ww.marker...)
+	if len(style) > 0 {
+		ww.buf = append(ww.buf, style.sequence()...)
+	}
+	ww.column, ww.start = column, column
+}
+
+// runeColumns returns the number of columns the rune `r` takes up at the
+// column `column`.
+func runeColumns(r rune, column int) int {
+	if r == '\t' {
+		return wrapTabWidth - column%wrapTabWidth
+	}
+	return runewidth.RuneWidth(r)
+}
+
+// advance returns the column following the text `s`, without escape
+// sequences, written at the column `column`.
+func advance(column int, s string) int {
+	for _, r := range s {
+		column += runeColumns(r, column)
+	}
+	return column
+}
+
+// wrapMarker returns the marker starting continuation lines, and its width.
+func (f *Formatter) wrapMarker() (string, int) {
+	marker := f.WrapMarker
+	if marker == "" {
+		marker = defaultWrapMarker
+	}
+	return marker, runewidth.StringWidth(marker)
+}
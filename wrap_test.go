@@ -0,0 +1,78 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatterWrapWidth(t *testing.T) {
+	withColor(t, false)
+	tests := []struct {
+		name, src string
+		width     int
+		want      string
+	}{
+		{"string", `{"key": "a long string value here", "n": [1]}`, 20, `{
+  "key": "a long str
+    ↪ ing value here
+    ↪ ",
+  "n": [
+    1
+  ]
+}`},
+		// Characters are counted in columns.
+		{"wide", `["日本語日本語日本語"]`, 12, `[
+  "日本語日
+    ↪ 本語日
+    ↪ 本語"
+]`},
+		{"short", `["a"]`, 5, "[\n  \"a\"\n]"},
+		// Output which isn't written to a terminal isn't wrapped.
+		{"auto", `["` + strings.Repeat("a", 100) + `"]`, WrapAuto, "[\n  \"" + strings.Repeat("a", 100) + "\"\n]"},
+	}
+	for _, tt := range tests {
+		f := newPlainFormatter()
+		f.WrapWidth = tt.width
+		if got := formatPlain(t, f, tt.src); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatterWrapPrefix(t *testing.T) {
+	withColor(t, false)
+	f := newPlainFormatter()
+	f.Prefix = "| "
+	f.WrapWidth = 10
+	// The leading whitespace is left out of continuation lines when it would
+	// leave too little room.
+	want := `| {
+|   "a": {
+|     "b":
+  ↪  "xxxx
+  ↪ xxxxxx
+  ↪ xxxxxx
+  ↪ "
+|   }
+| }`
+	if got := formatPlain(t, f, `{"a": {"b": "xxxxxxxxxxxxxxxx"}}`); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatterWrapColors(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	f.WrapWidth = 16
+	f.WrapMarker = "> "
+	f.WrapMarkerColor = Style{Faint}
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`["abcdefghijklmnopqrstuvwxyz"]`)); err != nil {
+		t.Fatal(err)
+	}
+	// Colors are reset before each break and restored after the marker.
+	want := "[\n  \x1b[32m\"\x1b[0m\x1b[32mabcdefghijklm\x1b[0m\n    \x1b[2m> \x1b[0m\x1b[32mnopqrstuvw\x1b[0m\n    \x1b[2m> \x1b[0m\x1b[32mxyz\x1b[0m\x1b[32m\"\x1b[0m\n]"
+	if got := sb.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"io"
+)
+
+// WriteErrorPolicy controls what happens when writing the output fails. See
+// Formatter.WriteErrorPolicy.
+type WriteErrorPolicy int
+
+const (
+	// WriteErrorFailFast stops at the first write error, which is returned
+	// right away. This is the default.
+	WriteErrorFailFast WriteErrorPolicy = iota
+	// WriteErrorBestEffort keeps writing after a write error, such as a
+	// transient failure of a pseudo-terminal: the rest of the value is still
+	// written, and streams of values or records go on with the next one. The
+	// first write error is returned once the value, or the whole stream, is
+	// written.
+	WriteErrorBestEffort
+)
+
+// WriteError is returned when writing the output fails, wrapping the error of
+// the destination writer.
+type WriteError struct {
+	Err error
+}
+
+func (e *WriteError) Error() string {
+	return "jsoncolor: error writing output: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the destination writer.
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// errWriter records the first error writing to `w`. Unless `bestEffort` is
+// set, nothing more is written after it.
+type errWriter struct {
+	w          io.Writer
+	bestEffort bool
+	err        *WriteError
+	// colored is true once an escape sequence has been written, or attempted to be.
+	colored bool
+}
+
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil && !ew.bestEffort {
+		return 0, ew.err
+	}
+	ew.colored = ew.colored || bytes.IndexByte(p, '\x1b') >= 0
+	n, err := ew.w.Write(p)
+	if err != nil && ew.err == nil {
+		ew.err = &WriteError{Err: err}
+	}
+	return n, err
+}
+
+// resetColors writes the escape sequence resetting colors, if any were
+// written, so that output cut short by an error or a panic, possibly in the
+// middle of a colorized token, doesn't leave the terminal colored. It's
+// written even after an error writing the output, whose cause may be gone.
+func (fs *formatterState) resetColors() {
+	if fs.out.colored {
+		io.WriteString(fs.out.w, "\x1b[0m")
+	}
+}
+
+// writeError returns the error writing the output, if any. With
+// WriteErrorBestEffort, the error is only returned once, so that writing
+// further values can succeed.
+func (fs *formatterState) writeError() error {
+	if fs.out.err == nil {
+		return nil
+	}
+	err := fs.out.err
+	if fs.out.bestEffort {
+		fs.out.err = nil
+	}
+	return err
+}
+
+// checkWrite returns the error writing the output like writeError, after
+// resetting the colors if there's one, for the entry points writing values
+// piecemeal, such as StreamWriter.
+func (fs *formatterState) checkWrite() error {
+	err := fs.writeError()
+	if err != nil {
+		fs.resetColors()
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errFlaky = errors.New("flaky")
+
+// flakyWriter fails the writes numbered in `fail`, counting from 1, and
+// records the others.
+type flakyWriter struct {
+	buf    bytes.Buffer
+	writes int
+	fail   map[int]bool
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.fail[w.writes] {
+		return 0, errFlaky
+	}
+	return w.buf.Write(p)
+}
+
+func TestWriteErrorFailFast(t *testing.T) {
+	w := &flakyWriter{fail: map[int]bool{2: true}}
+	err := plainCompactFormatter().Format(w, []byte(`{"a":[1,2]}`))
+	var writeErr *WriteError
+	if !errors.As(err, &writeErr) || !errors.Is(err, errFlaky) {
+		t.Fatalf("got %v, want a WriteError wrapping errFlaky", err)
+	}
+	if got := w.buf.String(); got != "{" || w.writes != 2 {
+		t.Errorf("got %q in %d writes, want nothing written after the error", got, w.writes)
+	}
+}
+
+func TestWriteErrorBestEffort(t *testing.T) {
+	f := plainCompactFormatter()
+	f.WriteErrorPolicy = WriteErrorBestEffort
+	w := &flakyWriter{fail: map[int]bool{2: true}}
+	err := f.Format(w, []byte(`{"a":[1,2]}`))
+	if !errors.Is(err, errFlaky) {
+		t.Fatalf("got %v, want errFlaky", err)
+	}
+	// Only the key failed to be written.
+	if got, want := w.buf.String(), `{:[1,2]}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteErrorFormatLines(t *testing.T) {
+	for _, policy := range []WriteErrorPolicy{WriteErrorFailFast, WriteErrorBestEffort} {
+		f := plainCompactFormatter()
+		f.WriteErrorPolicy = policy
+		w := &flakyWriter{fail: map[int]bool{1: true}}
+		err := f.FormatLines(w, strings.NewReader("{\"a\": 1}\n[2]\n"), LinesOptions{})
+		var writeErr *WriteError
+		if !errors.As(err, &writeErr) || !errors.Is(err, errFlaky) {
+			t.Errorf("policy %d: got %v, want a WriteError wrapping errFlaky", policy, err)
+		}
+		want := ""
+		if policy == WriteErrorBestEffort {
+			want = "[2]\n"
+		}
+		if got := w.buf.String(); got != want {
+			t.Errorf("policy %d: got %q, want %q", policy, got, want)
+		}
+	}
+}
+
+func TestWriteErrorEncodeAll(t *testing.T) {
+	for _, policy := range []WriteErrorPolicy{WriteErrorFailFast, WriteErrorBestEffort} {
+		f := newPlainFormatter()
+		f.WriteErrorPolicy = policy
+		w := &flakyWriter{fail: map[int]bool{1: true}}
+		err := NewEncoderWithFormatter(w, f).EncodeAll([]interface{}{1, 2, 3})
+		var writeErr *WriteError
+		if !errors.As(err, &writeErr) || !errors.Is(err, errFlaky) {
+			t.Errorf("policy %d: got %v, want a WriteError wrapping errFlaky", policy, err)
+		}
+		want := ""
+		if policy == WriteErrorBestEffort {
+			want = "\n2\n3\n"
+		}
+		if got := w.buf.String(); got != want {
+			t.Errorf("policy %d: got %q, want %q", policy, got, want)
+		}
+	}
+}
+
+func TestResetColorsOnError(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	f.Indent = ""
+	var sb strings.Builder
+	if err := f.Format(&sb, []byte(`[1, 2, }`)); err == nil {
+		t.Fatal("got no error")
+	}
+	// The output written before the error is followed by a reset.
+	if got, want := sb.String(), "[\x1b[96m1\x1b[0m,\x1b[96m2\x1b[0m\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Output without colors isn't followed by one.
+	sb.Reset()
+	if err := plainCompactFormatter().Format(&sb, []byte(`[1, }`)); err == nil {
+		t.Fatal("got no error")
+	}
+	if got := sb.String(); got != "[1" {
+		t.Errorf("got %q, want %q", got, "[1")
+	}
+
+	// The reset is written even after a write error.
+	w := &flakyWriter{fail: map[int]bool{3: true}}
+	if err := f.Format(w, []byte(`[1, 2]`)); !errors.Is(err, errFlaky) {
+		t.Fatalf("got %v, want errFlaky", err)
+	}
+	if got := w.buf.String(); !strings.HasSuffix(got, "\x1b[0m") || strings.Count(got, "\x1b[96m") != 1 {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestResetColorsOnPanic(t *testing.T) {
+	withColor(t, true)
+	f := styledFormatter()
+	f.Indent = ""
+	f.Backend = RendererFunc(func(dst []byte, kind TokenKind, text string) []byte {
+		if text == "2" {
+			panic("backend")
+		}
+		return f.ANSIRenderer().Render(dst, kind, text)
+	})
+	var sb strings.Builder
+	defer func() {
+		// The panic is propagated once the colors are reset.
+		if r := recover(); r != "backend" {
+			t.Errorf("recovered %v", r)
+		}
+		if got, want := sb.String(), "[\x1b[96m1\x1b[0m,\x1b[0m"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}()
+	f.Format(&sb, []byte(`[1, 2]`))
+}
+
+func TestStreamWriterWriteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(sw *StreamWriter) error
+	}{
+		{"WriteElement", func(sw *StreamWriter) error { return sw.WriteElement(1) }},
+		{"WriteField", func(sw *StreamWriter) error {
+			sw.BeginObject()
+			return sw.WriteField("a", 1)
+		}},
+		{"WriteRawString", func(sw *StreamWriter) error { return sw.WriteRawString(`"x"`) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := NewStreamWriter(&flakyWriter{fail: map[int]bool{1: true, 2: true, 3: true}})
+			var writeErr *WriteError
+			if err := tt.write(sw); !errors.As(err, &writeErr) || !errors.Is(err, errFlaky) {
+				t.Errorf("got error %v, want a WriteError", err)
+			}
+		})
+	}
+}
+
+func TestEncodeRawStringWriteError(t *testing.T) {
+	enc := NewEncoder(&flakyWriter{fail: map[int]bool{1: true}})
+	if err := enc.EncodeRawString(`"x"`); !errors.Is(err, errFlaky) {
+		t.Errorf("got error %v, want %v", err, errFlaky)
+	}
+}
+
+func TestWriteErrorResetsColors(t *testing.T) {
+	w := &flakyWriter{fail: map[int]bool{2: true}}
+	enc := NewEncoder(w)
+	enc.SetColors(true)
+	if err := enc.EncodeRawString(`"a long string value"`); !errors.Is(err, errFlaky) {
+		t.Fatalf("got error %v, want %v", err, errFlaky)
+	}
+	if out := w.buf.String(); !strings.HasSuffix(out, "\x1b[0m") {
+		t.Errorf("got %q, want the colors reset after the failure", out)
+	}
+}
@@ -0,0 +1,137 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Writer returns an io.WriteCloser which colorizes the JSON written into it:
+// each complete top-level value is formatted with the Encoder's settings and
+// written to the Encoder's writer as soon as its last byte arrives, followed
+// by a newline like Encode. Values may be split across any number of writes,
+// so a stream can be colorized as it's copied, e.g.
+//
+//	io.Copy(enc.Writer(), resp.Body)
+//
+// Whitespace between values is dropped. If a value is invalid JSON, Write
+// returns an error, as do all subsequent calls. Close formats a top-level
+// number or literal left at the end of the input, whose end can't be known
+// before, and reports an error if a value is incomplete; it does not close the
+// Encoder's writer.
+func (enc *Encoder) Writer() io.WriteCloser {
+	return &encoderWriter{enc: enc, start: -1}
+}
+
+// encoderWriter is the io.WriteCloser returned by Encoder.Writer. It scans the
+// input incrementally to find the end of each top-level value.
+type encoderWriter struct {
+	enc *Encoder
+	err error // The first error encountered, which is returned by subsequent calls.
+
+	buf     []byte // Input not written yet, starting with the current value if any.
+	scanned int    // Number of bytes of buf already scanned.
+
+	// State of the current value.
+	start    int  // Position of the value in buf, or -1 if between values.
+	depth    int  // Number of containers open.
+	scalar   bool // True if the value is a top-level number or literal.
+	inString bool // True within a string.
+	escaped  bool // True after a backslash within a string.
+}
+
+// Write buffers `p` and writes every top-level value it completes.
+func (w *encoderWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.buf = append(w.buf, p...)
+
+	// consumed is the number of bytes of buf which have been written.
+	consumed := 0
+	for i := w.scanned; i < len(w.buf) && w.err == nil; i++ {
+		c := w.buf[i]
+		if w.inString {
+			switch {
+			case w.escaped:
+				w.escaped = false
+			case c == '\\':
+				w.escaped = true
+			case c == '"':
+				w.inString = false
+				if w.depth == 0 {
+					consumed = w.emit(i + 1)
+				}
+			}
+			continue
+		}
+		if w.start < 0 {
+			if isSpace(c) {
+				consumed = i + 1
+				continue
+			}
+			w.start = i
+			w.scalar = !strings.ContainsRune(`"{[]}`, rune(c))
+		}
+
+		switch {
+		case w.scalar:
+			// A top-level number or literal ends at the first byte which can't be part of it.
+			if isSpace(c) || strings.ContainsRune(`"{[]},:`, rune(c)) {
+				consumed = w.emit(i)
+				i-- // The byte is scanned again, as the start of what follows.
+			}
+		case c == '"':
+			w.inString = true
+		case c == '{' || c == '[':
+			w.depth++
+		case c == '}' || c == ']':
+			// A closing delimiter without a container ends the value too, which
+			// is then rejected as invalid.
+			w.depth--
+			if w.depth <= 0 {
+				consumed = w.emit(i + 1)
+			}
+		}
+	}
+
+	// Drop the written bytes, keeping the start of the current value.
+	w.buf = w.buf[:copy(w.buf, w.buf[consumed:])]
+	w.scanned = len(w.buf)
+	if w.start >= 0 {
+		w.start -= consumed
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+// emit writes the current value, which ends at position `end` of buf, and
+// returns `end`. On failure, it records the error.
+func (w *encoderWriter) emit(end int) int {
+	f, o, terminateWithNewline := w.enc.settings(true)
+	fs := newFormatterStateWithOptions(f, w.enc.w, o)
+	if err := fs.format(w.enc.w, w.buf[w.start:end], terminateWithNewline); err != nil {
+		w.err = err
+	}
+	w.start, w.depth, w.scalar = -1, 0, false
+	return end
+}
+
+// Close writes a top-level number or literal left at the end of the input,
+// and reports an error if the input ends within a value.
+func (w *encoderWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	switch {
+	case w.start < 0:
+	case w.scalar:
+		w.emit(len(w.buf))
+		w.buf = w.buf[:0]
+	default:
+		w.err = fmt.Errorf("jsoncolor: error decoding input JSON: %w", io.ErrUnexpectedEOF)
+	}
+	return w.err
+}
@@ -0,0 +1,76 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEncoderWriter(t *testing.T) {
+	const src = ` {"a":"}\"]","b":[1,{}]} [ ] "s" 12 true -3.5e2`
+	const want = "{\"a\":\"}\\\"]\",\"b\":[1,{}]}\n[]\n\"s\"\n12\ntrue\n-3.5e2\n"
+
+	// Write the input in chunks of every size, down to single bytes.
+	for size := 1; size <= len(src); size++ {
+		var buf bytes.Buffer
+		w := NewEncoderWithFormatter(&buf, &Formatter{}).Writer()
+		for i := 0; i < len(src); i += size {
+			end := min(i+size, len(src))
+			if n, err := w.Write([]byte(src[i:end])); err != nil || n != end-i {
+				t.Fatalf("size %d: Write returned %d, %v", size, n, err)
+			}
+		}
+		// The trailing number can only be written once the end of the input is known.
+		if got := stripANSI(buf.String()); got != want[:len(want)-len("-3.5e2\n")] {
+			t.Errorf("size %d: before Close got %q", size, got)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := stripANSI(buf.String()); got != want {
+			t.Errorf("size %d: got %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestEncoderWriterSettings(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderWithFormatter(&buf, &Formatter{})
+	enc.SetIndent("", "  ")
+	enc.SetTrailingNewline(false)
+	w := enc.Writer()
+	if _, err := io.WriteString(w, `{"a":[1]}`); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": [\n    1\n  ]\n}"; stripANSI(buf.String()) != want {
+		t.Errorf("got %q, want %q", stripANSI(buf.String()), want)
+	}
+}
+
+func TestEncoderWriterErrors(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewEncoder(&buf).Writer()
+	if _, err := io.WriteString(w, `[1,}`); err == nil {
+		t.Fatal("invalid value: got no error")
+	}
+	// The error is returned by all subsequent calls.
+	if n, err := io.WriteString(w, `[]`); n != 0 || err == nil {
+		t.Errorf("got %d, %v after an error", n, err)
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Close: got no error")
+	}
+
+	buf.Reset()
+	w = NewEncoder(&buf).Writer()
+	if _, err := io.WriteString(w, `[1] {"a":`); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("incomplete value: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if want := "[1]\n"; stripANSI(buf.String()) != want {
+		t.Errorf("got %q, want %q", stripANSI(buf.String()), want)
+	}
+}
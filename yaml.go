@@ -0,0 +1,337 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatYAML formats the YAML documents in `src` using the DefaultFormatter.
+// See Formatter.FormatYAML.
+func FormatYAML(dst io.Writer, src []byte) error {
+	return DefaultFormatter.FormatYAML(dst, src)
+}
+
+// FormatYAML pretty-prints the YAML documents in `src` to `dst`, colorized
+// with the same color roles as JSON, so that tools handling both formats
+// present a consistent look: keys use FieldColor, strings StringColor and
+// StringQuoteColor, numbers NumberColor, booleans TrueColor and FalseColor,
+// null NullColor, colons ColonColor, the dashes of sequence items ArrayColor,
+// and comments CommentColor, while the delimiters of flow collections use
+// ObjectColor and ArrayColor as in JSON.
+//
+// Since JSON is a subset of YAML, FormatYAML also converts JSON to YAML.
+//
+// Block collections are re-indented with the Formatter's Indent, or two
+// spaces if it's empty or not made of spaces only, which YAML requires. The
+// Prefix is written at the start of every line. Flow collections containing
+// other collections are laid out as block collections. Comments, anchors,
+// aliases, explicit tags and the quoting style of string values are kept, but
+// quotes are dropped from keys which don't need them, and folded strings are
+// written as literal ones, which have the same value.
+//
+// The input is parsed entirely before anything is written, so no output is
+// written for invalid YAML.
+func (f *Formatter) FormatYAML(dst io.Writer, src []byte) error {
+	p := newYAMLPrinter(f)
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for n := 0; ; n++ {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("jsoncolor: error decoding input YAML: %w", err)
+		}
+		if n > 0 {
+			p.line(p.prefix, p.space("---"))
+		}
+		p.document(&doc)
+	}
+	_, err := dst.Write(p.buf.Bytes())
+	return err
+}
+
+// yamlPrinter renders YAML nodes into a buffer.
+type yamlPrinter struct {
+	buf    bytes.Buffer
+	prefix string // Written at the start of every line (Formatter.Prefix).
+	indent string // One level of indentation of block mappings.
+
+	palette
+}
+
+// newYAMLPrinter creates a yamlPrinter using the settings of `f`.
+func newYAMLPrinter(f *Formatter) *yamlPrinter {
+	indent := f.Indent
+	if indent == "" || strings.Trim(indent, " ") != "" {
+		indent = "  "
+	}
+	return &yamlPrinter{
+		prefix:  f.Prefix,
+		indent:  indent,
+		palette: f.palette(),
+	}
+}
+
+// line writes the already colorized `text`, preceded by `indent`, followed by a newline.
+func (p *yamlPrinter) line(indent, text string) {
+	p.buf.WriteString(p.begin(indent, ""))
+	p.buf.WriteString(text)
+	p.buf.WriteString("\n")
+}
+
+// begin returns the start of a line: `lead` if non-empty, which is already
+// written on the line, such as the dash of a sequence item, and otherwise the
+// colorized `indent`.
+func (p *yamlPrinter) begin(indent, lead string) string {
+	if lead != "" || indent == "" {
+		return lead
+	}
+	return p.space("%s", indent)
+}
+
+// comments writes the lines of a head or foot comment at `indent`.
+func (p *yamlPrinter) comments(indent, text string) {
+	if text == "" {
+		return
+	}
+	for _, l := range strings.Split(text, "\n") {
+		if l == "" {
+			p.line("", "")
+			continue
+		}
+		p.line(indent, p.comment("%s", l))
+	}
+}
+
+// lineComment returns the colorized comments in `texts` to be written at the
+// end of a line, preceded by a space.
+func (p *yamlPrinter) lineComment(texts ...string) string {
+	s := ""
+	for _, text := range texts {
+		if text != "" {
+			s += " " + p.comment("%s", text)
+		}
+	}
+	return s
+}
+
+// document writes a document node.
+func (p *yamlPrinter) document(doc *yaml.Node) {
+	p.comments(p.prefix, doc.HeadComment)
+	for _, n := range doc.Content {
+		p.comments(p.prefix, n.HeadComment)
+		p.block(n, p.prefix, "")
+		p.comments(p.prefix, n.FootComment)
+	}
+	p.comments(p.prefix, doc.FootComment)
+}
+
+// isBlock reports whether `n` is written as a block collection, over several
+// lines. Flow collections are too if they contain non-empty collections, which
+// notably lays out JSON input as YAML.
+func isBlock(n *yaml.Node) bool {
+	if (n.Kind != yaml.MappingNode && n.Kind != yaml.SequenceNode) || len(n.Content) == 0 {
+		return false
+	}
+	if n.Style&yaml.FlowStyle == 0 {
+		return true
+	}
+	for _, child := range n.Content {
+		if (child.Kind == yaml.MappingNode || child.Kind == yaml.SequenceNode) && len(child.Content) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// block writes the value `n` starting a line at `indent`. `lead`, if
+// non-empty, is already written on that line, such as the dash of a sequence
+// item, and `indent` includes its width.
+func (p *yamlPrinter) block(n *yaml.Node, indent, lead string) {
+	if !isBlock(n) {
+		p.line("", p.begin(indent, lead)+p.inline(n, indent)+p.lineComment(n.LineComment))
+		return
+	}
+	if props := p.properties(n); props != "" {
+		// Anchors and tags of block collections stand on the line before their contents.
+		p.line("", p.begin(indent, lead)+props)
+		lead = ""
+	}
+
+	if n.Kind == yaml.SequenceNode {
+		for i, item := range n.Content {
+			if i > 0 {
+				lead = ""
+			}
+			if lead == "" {
+				p.comments(indent, item.HeadComment)
+			}
+			p.block(item, indent+"  ", p.begin(indent, lead)+p.array("-")+" ")
+			p.comments(indent, item.FootComment)
+		}
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if i > 0 {
+			lead = ""
+		}
+		if lead == "" {
+			p.comments(indent, k.HeadComment)
+		}
+		key := p.begin(indent, lead) + p.key(k) + p.colon(":")
+		if isBlock(v) {
+			p.line("", key+p.lineComment(k.LineComment, v.LineComment))
+			childIndent := indent + p.indent
+			if v.Kind == yaml.SequenceNode {
+				// Sequences are conventionally not indented within mappings.
+				childIndent = indent
+			}
+			p.comments(childIndent, v.HeadComment)
+			p.block(v, childIndent, "")
+		} else {
+			p.line("", key+" "+p.inline(v, indent+p.indent)+p.lineComment(k.LineComment, v.LineComment))
+		}
+		p.comments(indent, k.FootComment)
+		p.comments(indent, v.FootComment)
+	}
+}
+
+// properties returns the colorized anchor and explicit tag of `n`, if any.
+func (p *yamlPrinter) properties(n *yaml.Node) string {
+	var props []string
+	if n.Style&yaml.TaggedStyle != 0 && n.Tag != "" {
+		props = append(props, p.space("%s", n.Tag))
+	}
+	if n.Anchor != "" {
+		props = append(props, p.space("&%s", n.Anchor))
+	}
+	return strings.Join(props, " ")
+}
+
+// key returns the colorized mapping key `k`.
+func (p *yamlPrinter) key(k *yaml.Node) string {
+	if k.Kind != yaml.ScalarNode {
+		// Collections used as keys are written in flow style.
+		return p.inline(k, "")
+	}
+	s := ""
+	if props := p.properties(k); props != "" {
+		s = props + " "
+	}
+	if k.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 && plainSafe(k.Value) {
+		// Quotes are dropped from keys which don't need them, such as those of JSON objects.
+		return s + p.field("%s", k.Value)
+	}
+	return s + p.scalar(k, "", p.field, p.fieldQuote)
+}
+
+// inline returns the colorized value `n`, written on the current line, or
+// for literal strings, starting on it. `indent` is the indentation of the
+// lines of literal strings.
+func (p *yamlPrinter) inline(n *yaml.Node, indent string) string {
+	s := ""
+	if props := p.properties(n); props != "" {
+		s = props + " "
+	}
+	switch n.Kind {
+	case yaml.AliasNode:
+		return s + p.space("*%s", n.Value)
+	case yaml.MappingNode:
+		parts := make([]string, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			parts = append(parts, p.key(n.Content[i])+p.colon(":")+" "+p.inline(n.Content[i+1], indent))
+		}
+		return s + p.object("{") + strings.Join(parts, p.comma(",")+" ") + p.object("}")
+	case yaml.SequenceNode:
+		parts := make([]string, 0, len(n.Content))
+		for _, item := range n.Content {
+			parts = append(parts, p.inline(item, indent))
+		}
+		return s + p.array("[") + strings.Join(parts, p.comma(",")+" ") + p.array("]")
+	}
+
+	var sprintf func(format string, a ...interface{}) string
+	switch n.ShortTag() {
+	case "!!int", "!!float":
+		sprintf = p.number
+	case "!!null":
+		sprintf = p.null
+	case "!!bool":
+		var b bool
+		if n.Decode(&b) == nil && b {
+			sprintf = p.tru
+		} else {
+			sprintf = p.fals
+		}
+	default:
+		return s + p.scalar(n, indent, p.str, p.strQuote)
+	}
+	return s + sprintf("%s", n.Value)
+}
+
+// scalar returns the scalar `n` in its quoting style, colorized with
+// `sprintf`, and its quotes with `sprintfQuote`.
+func (p *yamlPrinter) scalar(n *yaml.Node, indent string, sprintf, sprintfQuote func(format string, a ...interface{}) string) string {
+	value := n.Value
+	style := n.Style &^ (yaml.TaggedStyle | yaml.FlowStyle)
+	if style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 && indent != "" && literalSafe(value) {
+		return p.literal(value, indent, sprintf)
+	}
+	switch {
+	case style&yaml.SingleQuotedStyle != 0 && !strings.Contains(value, "\n"):
+		return sprintfQuote("'") + sprintf("%s", strings.ReplaceAll(value, "'", "''")) + sprintfQuote("'")
+	case style != 0 || strings.Contains(value, "\n"):
+		return sprintfQuote(`"`) + sprintf("%s", appendEscaped(nil, value, JSEscaping, false)) + sprintfQuote(`"`)
+	}
+	return sprintf("%s", value)
+}
+
+// plainSafe reports whether the string `s` can be written without quotes.
+func plainSafe(s string) bool {
+	// The encoder only omits quotes if the string reads back as the same string.
+	out, err := yaml.Marshal(s)
+	return err == nil && string(out) == s+"\n"
+}
+
+// literalSafe reports whether `s` can be written as a literal block scalar
+// without an explicit indentation indicator.
+func literalSafe(s string) bool {
+	return s != "" && s[0] != ' ' && s[0] != '\n' && !strings.Contains(s, "\r")
+}
+
+// literal returns the string `s` as a literal block scalar, whose lines are
+// indented with `indent`.
+func (p *yamlPrinter) literal(s, indent string, sprintf func(format string, a ...interface{}) string) string {
+	// The chomping indicator keeps the trailing newlines of the value.
+	header := "|"
+	body := strings.TrimRight(s, "\n")
+	switch trailing := len(s) - len(body); {
+	case trailing == 0:
+		header = "|-"
+	case trailing > 1:
+		header = "|+"
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(sprintf("%s", header))
+	for _, l := range strings.Split(body, "\n") {
+		sb.WriteString("\n")
+		if l != "" {
+			sb.WriteString(p.space("%s", indent))
+			sb.WriteString(sprintf("%s", l))
+		}
+	}
+	for i := 1; i < len(s)-len(body); i++ {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
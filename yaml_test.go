@@ -0,0 +1,95 @@
+package jsoncolor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// formatYAMLPlain formats `src` with FormatYAML and strips the colors.
+func formatYAMLPlain(t *testing.T, f *Formatter, src string) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := f.FormatYAML(&sb, []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	return stripANSI(sb.String())
+}
+
+func TestFormatYAML(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			"block",
+			"# head\nname: app # trailing\nlist:\n    - a\n    - {x: 1, y: [true, null]}\nquoted: 'single'\n\"k\": \"v\"\ntext: >\n  folded\n  words\n",
+			"# head\nname: app # trailing\nlist:\n- a\n- x: 1\n  y: [true, null]\nquoted: 'single'\nk: \"v\"\ntext: |\n    folded words\n",
+		},
+		{
+			// JSON is laid out as YAML, keeping quotes where needed, e.g. for "n", a boolean in YAML 1.1.
+			"json",
+			`{"a": [1, {"b": "c"}], "d": "e f", "n": 1.5}`,
+			"a:\n- 1\n- {b: \"c\"}\nd: \"e f\"\n\"n\": 1.5\n",
+		},
+		{
+			"anchors and documents",
+			"base: &b {x: 1}\nref: *b\ntagged: !!str 123\n---\n- 1\n",
+			"base: &b {x: 1}\nref: *b\ntagged: !!str 123\n---\n- 1\n",
+		},
+	}
+	for _, tt := range tests {
+		got := formatYAMLPlain(t, &Formatter{Indent: "    "}, tt.src)
+		if got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+
+		// The output holds the same data as the input.
+		var in, out interface{}
+		if err := yaml.Unmarshal([]byte(tt.src), &in); err != nil {
+			t.Fatal(err)
+		}
+		if err := yaml.Unmarshal([]byte(got), &out); err != nil {
+			t.Fatalf("%s: invalid output: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Errorf("%s: got data %v, want %v", tt.name, out, in)
+		}
+	}
+}
+
+func TestFormatYAMLColors(t *testing.T) {
+	f := &Formatter{
+		FieldColor: tagColor("k"), NumberColor: tagColor("n"), ColonColor: tagColor("c"),
+		ArrayColor: tagColor("a"), StringColor: tagColor("s"), StringQuoteColor: tagColor("q"),
+	}
+	var sb strings.Builder
+	if err := f.FormatYAML(&sb, []byte("a: 1\nb:\n- x\n- 'y'\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "<k>a</k><c>:</c> <n>1</n>\n<k>b</k><c>:</c>\n<a>-</a> <s>x</s>\n<a>-</a> <q>'</q><s>y</s><q>'</q>\n"
+	if sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestFormatYAMLPrefix(t *testing.T) {
+	// Indents which YAML doesn't allow fall back to two spaces.
+	got := formatYAMLPlain(t, &Formatter{Prefix: "> ", Indent: "\t"}, "a:\n  b: 1\n")
+	if want := "> a:\n>   b: 1\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatYAMLInvalid(t *testing.T) {
+	var sb strings.Builder
+	err := FormatYAML(&sb, []byte("ok: 1\n---\na: [1, 2"))
+	if err == nil || !strings.Contains(err.Error(), "error decoding input YAML") {
+		t.Errorf("got error %v", err)
+	}
+	// Nothing is written for invalid input, even after valid documents.
+	if sb.Len() != 0 {
+		t.Errorf("wrote %q", sb.String())
+	}
+}
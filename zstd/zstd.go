@@ -0,0 +1,31 @@
+// Package zstd adds zstd to the compression formats jsoncolor.Decompress and
+// jsoncolor.Formatter.FormatReader detect and decompress, when it's imported,
+// so that only the programs reading zstd input depend on a zstd decoder:
+//
+//	import _ "github.com/amterp/jsoncolor/zstd"
+package zstd
+
+import (
+	"io"
+
+	"github.com/amterp/jsoncolor"
+	"github.com/klauspost/compress/zstd"
+)
+
+// magic starts every zstd frame.
+var magic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+func init() {
+	jsoncolor.RegisterDecompressor(magic, NewReader)
+}
+
+// NewReader returns a reader of the zstd data read from `r`, which should be
+// closed to release the resources of the decoder. Concatenated frames are
+// decompressed one after the other, like zstd -d does.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
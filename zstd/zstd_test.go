@@ -0,0 +1,89 @@
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/amterp/jsoncolor"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdData compresses each of `frames` as a zstd frame, one after the other.
+func zstdData(t *testing.T, frames ...string) []byte {
+	t.Helper()
+	e, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	var data []byte
+	for _, f := range frames {
+		data = e.EncodeAll([]byte(f), data)
+	}
+	return data
+}
+
+func TestDecompress(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want string
+	}{
+		{"frame", zstdData(t, `{"a":1}`), `{"a":1}`},
+		{"frames", zstdData(t, "1\n", "2\n"), "1\n2\n"},
+	}
+	for _, tt := range tests {
+		r, err := jsoncolor.Decompress(bytes.NewReader(tt.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		closer, ok := r.(io.Closer)
+		if !ok {
+			t.Fatalf("%s: got a reader which isn't an io.Closer", tt.name)
+		}
+		closer.Close()
+	}
+}
+
+func TestDecompressTruncated(t *testing.T) {
+	// A truncated frame fails when it's read.
+	data := zstdData(t, strings.Repeat(`{"a":1}`, 100))
+	r, err := jsoncolor.Decompress(bytes.NewReader(data[:len(data)/2]))
+	if err == nil {
+		_, err = io.ReadAll(r)
+		r.(io.Closer).Close()
+	}
+	if err == nil {
+		t.Error("got no error for truncated data")
+	}
+}
+
+func TestFormatReader(t *testing.T) {
+	f := &jsoncolor.Formatter{Indent: "  "}
+	var buf bytes.Buffer
+	if err := f.FormatReader(&buf, bytes.NewReader(zstdData(t, `{"a": [1, 2]}`))); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": [\n    1,\n    2\n  ]\n}"
+	if got := stripANSI(buf.String()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// ansiEscape matches the escape sequences setting colors.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// stripANSI removes the escape sequences setting colors from `s`.
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}